@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/CMS-Enterprise/ztmf/backend/cmd/api/internal/spreadsheet"
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/gorilla/mux"
+)
+
+// ImportDataCallAnswers reads back the workbook GetDatacallExport writes, as
+// edited offline. Every system a change would write is authorized as an
+// answer save on it, and each accepted change is saved as one; see
+// model.PreviewAnswerImport and model.ApplyAnswerImport.
+//
+//	@Summary		Import answers from an edited export workbook
+//	@Description	Upload the data call's xlsx export (multipart field "file") after editing Answer, Maturity Tier, Score or ADO Answer Details. With preview=true, returns each row as a change (create or update, with the stored answer beside the new one), unchanged, or a finding, and writes nothing. Otherwise saves the rows named by accept, each as an ordinary answer save attributed to the caller. Systems are those the caller sees in the export; every system with a change must be one the caller may answer for, and the data call's state and the system's deadline apply as in the questionnaire.
+//	@Tags			datacalls
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			datacallid	path		int		true	"Data call ID"
+//	@Param			file		formData	file	true	"The data call's xlsx export, edited"
+//	@Param			preview		query		bool	false	"Preview only; write nothing"
+//	@Param			accept		query		[]int	false	"Workbook rows to apply; required unless preview"
+//	@Success		200			{object}	apiResponse[model.AnswerImportPreview]
+//	@Success		201			{object}	apiResponse[model.AnswerImportResult]
+//	@Failure		400			{object}	apiResponse[any]	"data.rows lists accepted rows with findings; nothing is written"
+//	@Failure		403			{object}	apiResponse[any]
+//	@Failure		404			{object}	apiResponse[any]
+//	@Failure		500			{object}	apiResponse[any]
+//	@Router			/datacalls/{datacallid}/import [post]
+func ImportDataCallAnswers(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+
+	if user.IsReadOnlyAdmin() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	input := model.AnswerImportInput{}
+	if err := decoder.Decode(&input, r.URL.Query()); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	fmt.Sscan(mux.Vars(r)["datacallid"], &input.DataCallID)
+
+	// The export's scope, set after decode: a row naming a system outside it
+	// matches nothing.
+	if input.ApplyTier(user) {
+		input.UserID = user.UserIDPtr()
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxScoreImportBytes)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		log.Println(err)
+		respond(w, r, nil, ErrMalformed)
+		return
+	}
+	defer file.Close()
+
+	if !strings.EqualFold(filepath.Ext(header.Filename), "."+spreadsheet.FormatXLSX) {
+		respond(w, r, nil, ErrMalformed)
+		return
+	}
+
+	input.Rows, input.ParseErrors, err = spreadsheet.ParseAnswerWorkbook(file)
+	if err != nil {
+		if errors.Is(err, spreadsheet.ErrUnreadableImport) {
+			log.Println(err)
+			err = ErrMalformed
+		}
+		respond(w, r, nil, err)
+		return
+	}
+
+	preview, err := model.PreviewAnswerImport(r.Context(), input)
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	guarded := map[int32]bool{}
+	for _, c := range preview.Changes {
+		if guarded[c.FismaSystemID] {
+			continue
+		}
+		if err := guardScoreWrite(r.Context(), user, c.FismaSystemID); err != nil {
+			respond(w, r, nil, err)
+			return
+		}
+		guarded[c.FismaSystemID] = true
+	}
+
+	if input.Preview {
+		respondOK(w, preview)
+		return
+	}
+
+	result, err := model.ApplyAnswerImport(r.Context(), preview, input.Accept)
+	respond(w, r, result, err)
+}
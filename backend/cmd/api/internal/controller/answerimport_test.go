@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// answerImportRequest uploads content as the workbook for data call 1.
+func answerImportRequest(t *testing.T, user *model.User, query, filename, content string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = fw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	r := httptest.NewRequest("POST", "/api/v1/datacalls/1/import"+query, &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	r = mux.SetURLVars(r, map[string]string{"datacallid": "1"})
+	return withUser(r, user)
+}
+
+func TestImportDataCallAnswers_ReadOnlyAdminsForbidden(t *testing.T) {
+	for _, user := range []*model.User{readonlyAdmin, opdivReadonly} {
+		t.Run(user.Role, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ImportDataCallAnswers(w, answerImportRequest(t, user, "?preview=true", "answers.xlsx", "x"))
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
+// TestImportDataCallAnswers_RejectsBeforeDB covers the requests refused before
+// any database access: a scope key in the query, a file that is not a
+// workbook by name, and one that is not by content.
+func TestImportDataCallAnswers_RejectsBeforeDB(t *testing.T) {
+	tests := []struct {
+		name, query, filename string
+	}{
+		{"scope in query", "?preview=true&UserID=someone", "answers.xlsx"},
+		{"csv upload", "?preview=true", "answers.csv"},
+		{"unreadable workbook", "?preview=true", "answers.xlsx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ImportDataCallAnswers(w, answerImportRequest(t, issoUser, tt.query, tt.filename, "Fisma Acronym,Pillar\n"))
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
@@ -274,6 +274,38 @@ func TestGuardScoreWrite_NonAdminPaths(t *testing.T) {
 	})
 }
 
+// guardScoreReview gates approve and return. As with guardScoreWrite, only
+// the non-admin branches are pure.
+func TestGuardScoreReview_NonAdminPaths(t *testing.T) {
+	ctx := context.Background()
+	assignedTo7 := func(role string) *model.User {
+		return &model.User{
+			UserID:               "55555555-5555-5555-5555-555555555555",
+			Role:                 role,
+			AssignedFismaSystems: []*int32{int32Ptr(7)},
+		}
+	}
+
+	assert.ErrorIs(t, guardScoreReview(ctx, readonlyAdmin, 7), ErrForbidden, "read-only admins never review")
+	assert.ErrorIs(t, guardScoreReview(ctx, assignedTo7("ISSO"), 7), ErrForbidden, "an ISSO answers but does not review")
+	assert.ErrorIs(t, guardScoreReview(ctx, assignedTo7("SYSTEM_DELEGATE"), 7), ErrForbidden, "a delegate answers but does not review")
+	assert.ErrorIs(t, guardScoreReview(ctx, assignedTo7("ISSM"), 8), ErrForbidden, "an ISSM reviews only assigned systems")
+	assert.NoError(t, guardScoreReview(ctx, assignedTo7("ISSM"), 7))
+}
+
+func TestReviewScore_ReadonlyForbidden(t *testing.T) {
+	handlers := map[string]http.HandlerFunc{"submit": SubmitScore, "approve": ApproveScore, "return": ReturnScore}
+	for action, handler := range handlers {
+		t.Run(action, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/api/v1/scores/123/"+action, nil)
+			r = mux.SetURLVars(r, map[string]string{"scoreid": "123"})
+			w := httptest.NewRecorder()
+			handler(w, withUser(r, readonlyAdmin))
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
 // --- SaveFismaSystemTargetMaturity (#398) ---
 
 func TestSaveFismaSystemTargetMaturity_ReadonlyAdminForbidden(t *testing.T) {
@@ -448,6 +480,34 @@ func TestSaveDataCallFismaSystem_ReadonlyAdminForbiddenEvenIfAssigned(t *testing
 	assert.Equal(t, http.StatusForbidden, w.Code)
 }
 
+func TestSaveDataCallFismaSystem_ForceAdminOnly(t *testing.T) {
+	assigned := &model.User{
+		UserID:               "33333333-3333-3333-3333-333333333333",
+		Email:                "isso@test.com",
+		Role:                 "ISSO",
+		AssignedFismaSystems: []*int32{int32Ptr(1)},
+	}
+	r := httptest.NewRequest("PUT", "/api/v1/datacalls/1/fismasystems/1?force=true", nil)
+	r = mux.SetURLVars(r, map[string]string{"datacallid": "1", "fismasystemid": "1"})
+	w := httptest.NewRecorder()
+
+	SaveDataCallFismaSystem(w, withUser(r, assigned))
+	assert.Equal(t, http.StatusForbidden, w.Code, "only an admin overrides the completeness check")
+}
+
+func TestDeleteDataCallFismaSystem_Forbidden(t *testing.T) {
+	for _, user := range []*model.User{readonlyAdmin, issoUser} {
+		t.Run(user.Role, func(t *testing.T) {
+			r := httptest.NewRequest("DELETE", "/api/v1/datacalls/1/fismasystems/1", nil)
+			r = mux.SetURLVars(r, map[string]string{"datacallid": "1", "fismasystemid": "1"})
+			w := httptest.NewRecorder()
+
+			DeleteDataCallFismaSystem(w, withUser(r, user))
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
 // --- SaveFismaSystem ---
 
 func TestSaveFismaSystem_ReadonlyAdminForbidden(t *testing.T) {
@@ -560,6 +620,18 @@ func TestSaveDataCall_ReadonlyAdminForbidden(t *testing.T) {
 	assert.Equal(t, http.StatusForbidden, w.Code)
 }
 
+func TestUnscheduleDataCall_NonAdminsForbidden(t *testing.T) {
+	for _, user := range []*model.User{readonlyAdmin, opdivReadonly, issoUser} {
+		t.Run(user.Role, func(t *testing.T) {
+			r := httptest.NewRequest("DELETE", "/api/v1/datacalls/1/opens_at", nil)
+			r = mux.SetURLVars(r, map[string]string{"datacallid": "1"})
+			w := httptest.NewRecorder()
+			UnscheduleDataCall(w, withUser(r, user))
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
 // --- SaveQuestion ---
 
 func TestSaveQuestion_ReadonlyAdminForbidden(t *testing.T) {
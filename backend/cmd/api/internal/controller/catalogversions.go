@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+)
+
+//	@Summary		List questionnaire catalog versions
+//	@Description	Newest first. Every question or function save publishes a version; each data call reads the version in its catalogversionid.
+//	@Tags			questions
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Success		200	{object}	apiResponse[[]model.CatalogVersion]
+//	@Failure		403	{object}	apiResponse[any]
+//	@Failure		500	{object}	apiResponse[any]
+//	@Router			/catalogversions [get]
+func ListCatalogVersions(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	if !user.HasAdminRead() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	versions, err := model.FindCatalogVersions(r.Context())
+	respond(w, r, versions, err)
+}
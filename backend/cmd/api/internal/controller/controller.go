@@ -128,7 +128,8 @@ func sanitizeErr(err error) (int, string, error) {
 		status = 403
 		code = auth.CodeSelfDeleteForbidden
 	case errors.Is(err, ErrForbidden),
-		errors.Is(err, model.ErrPastDeadline):
+		errors.Is(err, model.ErrPastDeadline),
+		errors.Is(err, model.ErrDataCallNotOpen):
 		status = 403
 	case errors.Is(err, model.ErrDelegatesNotEnabled):
 		// Capability-off for the OpDiv. 403 with a code so the FE can render an
@@ -55,6 +55,7 @@ func TestSanitizeErrMapping(t *testing.T) {
 		{"not found -> 404", ErrNotFound, 404},
 		{"forbidden -> 403", ErrForbidden, 403},
 		{"past deadline -> 403", model.ErrPastDeadline, 403},
+		{"data call not open -> 403", model.ErrDataCallNotOpen, 403},
 		{"not unique -> 400", model.ErrNotUnique, 400},
 		{"db connection -> 503", model.ErrDbConnection, 503},
 		{"unknown -> 500", errors.New("boom"), 500},
@@ -9,17 +9,33 @@ import (
 	"github.com/CMS-Enterprise/ztmf/backend/cmd/api/internal/spreadsheet"
 	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
 	"github.com/gorilla/mux"
+	"github.com/xuri/excelize/v2"
 )
 
-//	@Summary	List all data calls
-//	@Tags		datacalls
-//	@Produce	json
-//	@Security	bearerAuth
-//	@Success	200	{object}	apiResponse[[]model.DataCall]
-//	@Failure	500	{object}	apiResponse[any]
-//	@Router		/datacalls [get]
+//	@Summary		List data calls
+//	@Description	Archived calls are omitted unless include_archived is true. Draft calls are listed for admin tiers only.
+//	@Tags			datacalls
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			include_archived	query		bool	false	"Include archived data calls"
+//	@Success		200					{object}	apiResponse[[]model.DataCall]
+//	@Failure		400					{object}	apiResponse[any]
+//	@Failure		500					{object}	apiResponse[any]
+//	@Router			/datacalls [get]
 func ListDataCalls(w http.ResponseWriter, r *http.Request) {
-	datacalls, err := model.FindDataCalls(r.Context())
+	user := model.UserFromContext(r.Context())
+	input := model.FindDataCallsInput{}
+
+	if err := decoder.Decode(&input, r.URL.Query()); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	// A draft is a cycle an admin is still staging; set after decode so it is
+	// never client-controlled.
+	input.IncludeDrafts = user.HasAdminRead()
+
+	datacalls, err := model.FindDataCalls(r.Context(), input)
 	respond(w, r, datacalls, err)
 }
 
@@ -47,15 +63,18 @@ func GetDataCallByID(w http.ResponseWriter, r *http.Request) {
 	respond(w, r, dc, err)
 }
 
-//	@Summary	Export a data call's answers as an xlsx spreadsheet
-//	@Tags		datacalls
-//	@Produce	application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
-//	@Security	bearerAuth
-//	@Param		datacallid	path	int		true	"Data call ID"
-//	@Param		fsids		query	[]int	false	"FISMA system IDs to filter by"
-//	@Success	200	{string}	binary	"xlsx spreadsheet of the data call's answers"
-//	@Failure	500	{object}	apiResponse[any]
-//	@Router		/datacalls/{datacallid}/export [get]
+//	@Summary		Export a data call's answers as an xlsx spreadsheet
+//	@Description	workbook=executive adds, ahead of the answers sheet, a summary of system scores and tiers, a pillar-by-system matrix, OpDiv rollups and the pillars short of each system's target, with charts. Its figures are those of the scores endpoints, over the same systems as the answers; the HHS average appears only in an unscoped, unfiltered export.
+//	@Tags			datacalls
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Security		bearerAuth
+//	@Param			datacallid	path	int		true	"Data call ID"
+//	@Param			fsids		query	[]int	false	"FISMA system IDs to filter by"
+//	@Param			workbook	query	string	false	"answers (default) or executive"
+//	@Success		200	{string}	binary	"xlsx spreadsheet of the data call's answers"
+//	@Failure		400	{object}	apiResponse[any]
+//	@Failure		500	{object}	apiResponse[any]
+//	@Router			/datacalls/{datacallid}/export [get]
 func GetDatacallExport(w http.ResponseWriter, r *http.Request) {
 	user := model.UserFromContext(r.Context())
 	findAnswersInput := model.FindAnswersInput{}
@@ -79,6 +98,11 @@ func GetDatacallExport(w http.ResponseWriter, r *http.Request) {
 		fmt.Sscan(v, &findAnswersInput.DataCallID)
 	}
 
+	if err := model.ValidateExportWorkbook(findAnswersInput.Workbook); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
 	scopeFindAnswersInput(user, &findAnswersInput)
 
 	answers, err := model.FindAnswers(r.Context(), findAnswersInput)
@@ -87,7 +111,18 @@ func GetDatacallExport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	file, err := spreadsheet.Excel(answers)
+	executive := findAnswersInput.Workbook != nil && *findAnswersInput.Workbook == model.ExportWorkbookExecutive
+
+	var file *excelize.File
+	if executive {
+		var summary *model.ExportSummary
+		summary, err = model.FindExportSummary(r.Context(), findAnswersInput)
+		if err == nil {
+			file, err = spreadsheet.ExecutiveExcel(answers, summary)
+		}
+	} else {
+		file, err = spreadsheet.Excel(answers)
+	}
 	if err != nil {
 		respond(w, r, nil, err)
 		return
@@ -100,6 +135,9 @@ func GetDatacallExport(w http.ResponseWriter, r *http.Request) {
 	if len(answers) > 0 {
 		filename = strings.ReplaceAll(answers[0].DataCall, " ", "")
 	}
+	if executive {
+		filename += "-executive"
+	}
 	// Filename is left unquoted because the frontend (FismaTable.saveSystemAnswers)
 	// parses the header by splitting on `filename=` and uses the resulting value
 	// directly as the anchor's download attribute. Chrome sanitizes filesystem-
@@ -173,14 +211,173 @@ func SaveDataCall(w http.ResponseWriter, r *http.Request) {
 	respond(w, r, d, nil)
 }
 
-//	@Summary	Get the latest data call
-//	@Tags		datacalls
-//	@Produce	json
-//	@Security	bearerAuth
-//	@Success	200	{object}	apiResponse[model.DataCall]
-//	@Failure	500	{object}	apiResponse[any]
-//	@Router		/datacalls/latest [get]
+//	@Summary		Clear a draft data call's scheduled opening
+//	@Description	Removes opens_at, so the draft stays a draft until its status is set to open. An update that leaves opens_at out keeps it; this is the way to clear it. Only a draft has a schedule to clear (400 otherwise).
+//	@Tags			datacalls
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			datacallid	path		int	true	"Data call ID"
+//	@Success		200			{object}	apiResponse[model.DataCall]
+//	@Failure		400			{object}	apiResponse[any]
+//	@Failure		403			{object}	apiResponse[any]
+//	@Failure		404			{object}	apiResponse[any]
+//	@Failure		500			{object}	apiResponse[any]
+//	@Router			/datacalls/{datacallid}/opens_at [delete]
+func UnscheduleDataCall(w http.ResponseWriter, r *http.Request) {
+	// The same field SaveDataCall writes, so the same gate.
+	if !model.UserFromContext(r.Context()).IsAdmin() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	var dataCallID int32
+	fmt.Sscan(mux.Vars(r)["datacallid"], &dataCallID)
+
+	dc, err := model.UnscheduleDataCall(r.Context(), dataCallID)
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+	respondOK(w, dc)
+}
+
+// dataCallStatusInput is the body of PUT /datacalls/{datacallid}/status.
+type dataCallStatusInput struct {
+	Status string `json:"status"`
+}
+
+//	@Summary		Change a data call's lifecycle status
+//	@Description	Allowed transitions: draft to open or archived, open to closed, closed to open or archived, archived to closed. The first transition into open rolls the previous cycle's answers forward, or the sources its rollover rules name, and pins the newest catalog version; a reopen does neither. A call flagged requires_rollover_rules cannot open until it has rollover rules (400). Closing freezes the call's scores and export in a snapshot; reopening discards it.
+//	@Tags			datacalls
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			datacallid	path		int					true	"Data call ID"
+//	@Param			body		body		dataCallStatusInput	true	"Target status"
+//	@Success		200			{object}	apiResponse[model.DataCall]
+//	@Failure		400			{object}	apiResponse[any]
+//	@Failure		403			{object}	apiResponse[any]
+//	@Failure		404			{object}	apiResponse[any]
+//	@Failure		500			{object}	apiResponse[any]
+//	@Router			/datacalls/{datacallid}/status [put]
+func SetDataCallStatus(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	// Opening a cycle rolls every system's answers forward and closing one
+	// locks every OpDiv out, so this is an HHS-wide write: an OPDIV_ADMIN may
+	// edit a call's details but not its lifecycle.
+	if !user.CanWriteHHSWide() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	input := dataCallStatusInput{}
+	if err := getJSON(r.Body, &input); err != nil {
+		log.Println(err)
+		respond(w, r, nil, ErrMalformed)
+		return
+	}
+
+	var dataCallID int32
+	fmt.Sscan(mux.Vars(r)["datacallid"], &dataCallID)
+
+	dc, err := model.SetDataCallStatus(r.Context(), dataCallID, input.Status)
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+	respondOK(w, dc)
+}
+
+//	@Summary		Get a data call's snapshot
+//	@Description	A closed data call is frozen: its scores and export are read from the snapshot taken when it closed, not recomputed. 404 when the call is not frozen.
+//	@Tags			datacalls
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			datacallid	path		int	true	"Data call ID"
+//	@Success		200			{object}	apiResponse[model.DataCallSnapshot]
+//	@Failure		403			{object}	apiResponse[any]
+//	@Failure		404			{object}	apiResponse[any]
+//	@Failure		500			{object}	apiResponse[any]
+//	@Router			/datacalls/{datacallid}/snapshot [get]
+func GetDataCallSnapshot(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	if !user.HasAdminRead() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	var dataCallID int32
+	fmt.Sscan(mux.Vars(r)["datacallid"], &dataCallID)
+
+	snapshot, err := model.FindDataCallSnapshot(r.Context(), dataCallID)
+	respond(w, r, snapshot, err)
+}
+
+//	@Summary		Freeze a closed data call
+//	@Description	Takes the snapshot of a closed or archived data call that has none, such as one closed before snapshots existed. Closing a call takes its snapshot itself; a snapshot is never replaced, and reopening the call discards it.
+//	@Tags			datacalls
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			datacallid	path		int	true	"Data call ID"
+//	@Success		201			{object}	apiResponse[model.DataCallSnapshot]
+//	@Failure		400			{object}	apiResponse[any]
+//	@Failure		403			{object}	apiResponse[any]
+//	@Failure		404			{object}	apiResponse[any]
+//	@Failure		500			{object}	apiResponse[any]
+//	@Router			/datacalls/{datacallid}/snapshot [post]
+func SaveDataCallSnapshot(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	// What a cycle reported is fixed HHS-wide, like its lifecycle.
+	if !user.CanWriteHHSWide() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	var dataCallID int32
+	fmt.Sscan(mux.Vars(r)["datacallid"], &dataCallID)
+
+	snapshot, err := model.SnapshotDataCall(r.Context(), dataCallID)
+	respond(w, r, snapshot, err)
+}
+
+// latestDataCallInput is the query of GET /datacalls/latest.
+type latestDataCallInput struct {
+	FismaSystemID *int32 `schema:"fismasystemid"`
+}
+
+//	@Summary		Get the latest data call
+//	@Description	With fismasystemid, the response also carries effectivedeadline - the deadline that system is held to after any OpDiv or system extension - and the extension that set it.
+//	@Tags			datacalls
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			fismasystemid	query		int	false	"Resolve the effective deadline for this FISMA system"
+//	@Success		200				{object}	apiResponse[model.DataCall]
+//	@Failure		400				{object}	apiResponse[any]
+//	@Failure		403				{object}	apiResponse[any]
+//	@Failure		404				{object}	apiResponse[any]
+//	@Failure		500				{object}	apiResponse[any]
+//	@Router			/datacalls/latest [get]
 func GetLatestDataCall(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	input := latestDataCallInput{}
+
+	if err := decoder.Decode(&input, r.URL.Query()); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	// An extension's reason and grantor are only shown to someone who can see
+	// the system it was granted for.
+	if input.FismaSystemID != nil {
+		if err := guardViewFismaSystem(r.Context(), user, *input.FismaSystemID); err != nil {
+			respond(w, r, nil, err)
+			return
+		}
+	}
+
 	dc, err := model.FindLatestDataCall(r.Context())
+	if err == nil && input.FismaSystemID != nil {
+		err = dc.ApplyDeadlineExtension(r.Context(), *input.FismaSystemID)
+	}
 	respond(w, r, dc, err)
 }
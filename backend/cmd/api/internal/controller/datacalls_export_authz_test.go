@@ -112,7 +112,7 @@ func int32StrPtr(s string) *string { return &s }
 // TestGetDatacallExport_ScopeFieldsRejectedFromQuery pins that the export's
 // server-owned scope fields cannot be set from the query string: a request that
 // tries returns 400 (unknown key) rather than 200 with redirected scope. fsids
-// remains the one legitimate query parameter.
+// and workbook remain the legitimate query parameters.
 func TestGetDatacallExport_ScopeFieldsRejectedFromQuery(t *testing.T) {
 	for _, q := range []string{"UserID=victim-uuid", "userid=victim-uuid", "RestrictToOpDivIDs=false", "OpDivIDs=1"} {
 		t.Run(q, func(t *testing.T) {
@@ -127,3 +127,13 @@ func TestGetDatacallExport_ScopeFieldsRejectedFromQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestGetDatacallExport_UnknownWorkbookRejected(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/datacalls/1/export?workbook=board", nil)
+	r = withUser(r, adminUser)
+	w := httptest.NewRecorder()
+
+	GetDatacallExport(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
@@ -8,21 +9,30 @@ import (
 	"github.com/gorilla/mux"
 )
 
-// SaveDataCallFismaSystem handles the PUT request to mark a FISMA system as having completed a data call
-//
-//	@Summary	Mark a FISMA system as having completed a data call
-//	@Tags		datacalls
-//	@Produce	json
-//	@Security	bearerAuth
-//	@Param		datacallid		path		int	true	"Data call ID"
-//	@Param		fismasystemid	path	int	true	"FISMA system ID"
-//	@Success	204	"No Content"
-//	@Failure	403	{object}	apiResponse[any]
-//	@Failure	500	{object}	apiResponse[any]
-//	@Router		/datacalls/{datacallid}/fismasystems/{fismasystemid} [put]
-func SaveDataCallFismaSystem(w http.ResponseWriter, r *http.Request) {
-	authdUser := model.UserFromContext(r.Context())
+// guardDataCallFismaSystem authorizes marking or un-marking a system's
+// completion: read-only admins never write, ISSO/ISSM need the system
+// assignment, admin-tier writers must manage the system's OpDiv.
+func guardDataCallFismaSystem(ctx context.Context, authdUser *model.User, fismasystemID int32) error {
+	if authdUser.IsReadOnlyAdmin() {
+		return ErrForbidden
+	}
+
+	if !authdUser.IsAdmin() && !authdUser.IsAssignedFismaSystem(fismasystemID) {
+		return ErrForbidden
+	}
+
+	// OpDiv write-scope: an admin-tier writer may only mark completion for a
+	// system in an OpDiv they manage. ISSO/ISSM keep their assigned-system path.
+	if authdUser.IsAdmin() {
+		if _, err := guardManageFismaSystem(ctx, authdUser, fismasystemID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+// dataCallFismaSystemFromPath reads the data call and system ids off the path.
+func dataCallFismaSystemFromPath(r *http.Request) *model.DataCallFismaSystem {
 	vars := mux.Vars(r)
 
 	var datacallID, fismasystemID int32
@@ -35,29 +45,52 @@ func SaveDataCallFismaSystem(w http.ResponseWriter, r *http.Request) {
 		fmt.Sscan(v, &fismasystemID)
 	}
 
-	if authdUser.IsReadOnlyAdmin() {
-		respond(w, r, nil, ErrForbidden)
-		return
+	return &model.DataCallFismaSystem{
+		Datacallid:    datacallID,
+		Fismasystemid: fismasystemID,
 	}
+}
 
-	if !authdUser.IsAdmin() && !authdUser.IsAssignedFismaSystem(fismasystemID) {
-		respond(w, r, nil, ErrForbidden)
+// SaveDataCallFismaSystem handles the PUT request to mark a FISMA system as having completed a data call
+//
+//	@Summary		Mark a FISMA system as having completed a data call
+//	@Description	Every function applicable to the system needs a confirmed answer first. Otherwise the request fails with 400 and data.functions listing each missing, unconfirmed (carried forward, not yet saved or confirmed) or returned answer. An admin may pass force=true to mark it anyway; the mark is then recorded as forced.
+//	@Tags			datacalls
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			datacallid		path		int		true	"Data call ID"
+//	@Param			fismasystemid	path		int		true	"FISMA system ID"
+//	@Param			force			query		bool	false	"Mark complete despite incomplete functions (admins only)"
+//	@Success		204				"No Content"
+//	@Failure		400				{object}	apiResponse[any]
+//	@Failure		403				{object}	apiResponse[any]
+//	@Failure		500				{object}	apiResponse[any]
+//	@Router			/datacalls/{datacallid}/fismasystems/{fismasystemid} [put]
+func SaveDataCallFismaSystem(w http.ResponseWriter, r *http.Request) {
+	authdUser := model.UserFromContext(r.Context())
+
+	df := dataCallFismaSystemFromPath(r)
+
+	if err := guardDataCallFismaSystem(r.Context(), authdUser, df.Fismasystemid); err != nil {
+		respond(w, r, nil, err)
 		return
 	}
 
-	// OpDiv write-scope: an admin-tier writer may only mark completion for a
-	// system in an OpDiv they manage. ISSO/ISSM keep their assigned-system path.
-	if authdUser.IsAdmin() {
-		if _, err := guardManageFismaSystem(r.Context(), authdUser, fismasystemID); err != nil {
-			respond(w, r, nil, err)
-			return
-		}
+	var opts struct {
+		Force bool `schema:"force"`
+	}
+	if err := decoder.Decode(&opts, r.URL.Query()); err != nil {
+		respond(w, r, nil, err)
+		return
 	}
 
-	df := &model.DataCallFismaSystem{
-		Datacallid:    datacallID,
-		Fismasystemid: fismasystemID,
+	// Only an admin overrides the completeness check; an ISSO or ISSM asking
+	// to is refused rather than silently held to it.
+	if opts.Force && !authdUser.IsAdmin() {
+		respond(w, r, nil, ErrForbidden)
+		return
 	}
+	df.Forced = opts.Force
 
 	// Call the Save method on this object
 	df, err := df.Save(r.Context())
@@ -66,6 +99,34 @@ func SaveDataCallFismaSystem(w http.ResponseWriter, r *http.Request) {
 	respond(w, r, df, err)
 }
 
+// DeleteDataCallFismaSystem handles the DELETE request to withdraw a FISMA system's completion of a data call
+//
+//	@Summary		Un-mark a FISMA system's completion of a data call
+//	@Description	Authorized like marking it complete. The withdrawal is recorded in the event log.
+//	@Tags			datacalls
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			datacallid		path		int	true	"Data call ID"
+//	@Param			fismasystemid	path		int	true	"FISMA system ID"
+//	@Success		204				"No Content"
+//	@Failure		403				{object}	apiResponse[any]
+//	@Failure		404				{object}	apiResponse[any]
+//	@Failure		500				{object}	apiResponse[any]
+//	@Router			/datacalls/{datacallid}/fismasystems/{fismasystemid} [delete]
+func DeleteDataCallFismaSystem(w http.ResponseWriter, r *http.Request) {
+	authdUser := model.UserFromContext(r.Context())
+
+	df := dataCallFismaSystemFromPath(r)
+
+	if err := guardDataCallFismaSystem(r.Context(), authdUser, df.Fismasystemid); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	_, err := df.Delete(r.Context())
+	respond(w, r, nil, err)
+}
+
 // ListDataCallFismaSystems handles the GET request to list all FISMA systems that have marked a specific data call as complete
 //
 //	@Summary	List FISMA systems that have completed a data call
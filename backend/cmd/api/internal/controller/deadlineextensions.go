@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/gorilla/mux"
+)
+
+//	@Summary		List a data call's deadline extensions
+//	@Description	OpDiv-scoped admins see the extensions for their OpDivs and for systems in them.
+//	@Tags			datacalls
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			datacallid	path		int	true	"Data call ID"
+//	@Success		200			{object}	apiResponse[[]model.DeadlineExtension]
+//	@Failure		403			{object}	apiResponse[any]
+//	@Failure		500			{object}	apiResponse[any]
+//	@Router			/datacalls/{datacallid}/extensions [get]
+func ListDeadlineExtensions(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	if !user.HasAdminRead() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	input := model.FindDeadlineExtensionsInput{}
+	fmt.Sscan(mux.Vars(r)["datacallid"], &input.DataCallID)
+	input.ApplyTier(user)
+
+	extensions, err := model.FindDeadlineExtensions(r.Context(), input)
+	respond(w, r, extensions, err)
+}
+
+//	@Summary		Grant a deadline extension
+//	@Description	Extends the data call's deadline for one OpDiv (opdiv_id) or one FISMA system (fismasystemid). Granting again for the same target replaces the earlier extension. OpDiv-wide extensions are HHS-wide writes (OWNER, HHS_ADMIN); an OPDIV_ADMIN may extend systems in their own OpDivs.
+//	@Tags			datacalls
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			datacallid	path		int						true	"Data call ID"
+//	@Param			body		body		model.DeadlineExtension	true	"Extension to grant"
+//	@Success		201			{object}	apiResponse[model.DeadlineExtension]
+//	@Failure		400			{object}	apiResponse[any]
+//	@Failure		403			{object}	apiResponse[any]
+//	@Failure		404			{object}	apiResponse[any]
+//	@Failure		500			{object}	apiResponse[any]
+//	@Router			/datacalls/{datacallid}/extensions [post]
+func SaveDeadlineExtension(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	if !user.IsAdmin() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	ext := &model.DeadlineExtension{}
+	if err := getJSON(r.Body, ext); err != nil {
+		log.Println(err)
+		respond(w, r, nil, ErrMalformed)
+		return
+	}
+	fmt.Sscan(mux.Vars(r)["datacallid"], &ext.DataCallID)
+
+	if err := guardManageDeadlineExtension(r.Context(), user, ext); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	ext, err := ext.Save(r.Context())
+	respond(w, r, ext, err)
+}
+
+//	@Summary	Revoke a deadline extension
+//	@Tags		datacalls
+//	@Security	bearerAuth
+//	@Param		datacallid	path	int	true	"Data call ID"
+//	@Param		extensionid	path	int	true	"Extension ID"
+//	@Success	204			"No Content"
+//	@Failure	403			{object}	apiResponse[any]
+//	@Failure	404			{object}	apiResponse[any]
+//	@Failure	500			{object}	apiResponse[any]
+//	@Router		/datacalls/{datacallid}/extensions/{extensionid} [delete]
+func DeleteDeadlineExtension(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	if !user.IsAdmin() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	var dataCallID, extensionID int32
+	vars := mux.Vars(r)
+	fmt.Sscan(vars["datacallid"], &dataCallID)
+	fmt.Sscan(vars["extensionid"], &extensionID)
+
+	ext, err := model.FindDeadlineExtensionByID(r.Context(), dataCallID, extensionID)
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	if err := guardManageDeadlineExtension(r.Context(), user, ext); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	err = model.DeleteDeadlineExtension(r.Context(), dataCallID, extensionID)
+	respond(w, r, nil, err)
+}
+
+// guardManageDeadlineExtension applies the grant/revoke rule to the
+// extension's target. An OpDiv-wide extension is negotiated with HHS, so an
+// OPDIV_ADMIN may not grant one to their own OpDiv; a single system's is the
+// same write scope as managing that system. A body naming neither target is
+// let through for the model to reject as a 400.
+func guardManageDeadlineExtension(ctx context.Context, user *model.User, ext *model.DeadlineExtension) error {
+	if ext.OpDivID != nil && !user.CanWriteHHSWide() {
+		return ErrForbidden
+	}
+	if ext.FismaSystemID != nil {
+		if _, err := guardManageFismaSystem(ctx, user, *ext.FismaSystemID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
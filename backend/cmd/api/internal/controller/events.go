@@ -1,14 +1,23 @@
 package controller
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/CMS-Enterprise/ztmf/backend/cmd/api/internal/spreadsheet"
+	"github.com/CMS-Enterprise/ztmf/backend/internal/attachments"
+	"github.com/CMS-Enterprise/ztmf/backend/internal/config"
 	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
 )
 
 //	@Summary		Record a questionnaire question view
-//	@Description	Appends a 'viewed' event marking that the caller opened a questionnaire question, so time-spent analytics can bound how long the question was worked on before the next view. Editor-vs-viewer is derived server-side (from the caller's role and the data call deadline), not sent by the client. Recorded for any caller who can see the system; a caller may only record views for a system they have a relationship to (admins any, OpDiv-scoped admins their OpDivs, ISSO/ISSM/SYSTEM_DELEGATE their assigned systems). Returns 404 if the system or data call does not exist.
+//	@Description	Appends a 'viewed' event marking that the caller opened a questionnaire question, so time-spent analytics can bound how long the question was worked on before the next view. Editor-vs-viewer is derived server-side (from the caller's role, the data call's status, and the system's effective deadline including any extension), not sent by the client. Recorded for any caller who can see the system; a caller may only record views for a system they have a relationship to (admins any, OpDiv-scoped admins their OpDivs, ISSO/ISSM/SYSTEM_DELEGATE their assigned systems). Returns 404 if the system or data call does not exist.
 //	@Tags		events
 //	@Accept		json
 //	@Produce	json
@@ -52,13 +61,19 @@ func RecordQuestionView(w http.ResponseWriter, r *http.Request) {
 		respond(w, r, nil, err)
 		return
 	}
+	// The system's extension, if any, is part of "would refuse their answer".
+	if err := dc.ApplyDeadlineExtension(r.Context(), input.FismaSystemID); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
 
 	// Derive read-only server-side; never trust a client-sent value. It decides
 	// whether this view's dwell counts as viewer or editor time, so a client
 	// must not be able to choose it. Mirrors the questionnaire's rule: a
-	// read-only admin is always viewing, and any non-admin is viewing (not
-	// editing) once the data call's deadline has passed.
-	readOnly := user.IsReadOnlyAdmin() || (!user.IsAdmin() && time.Now().After(dc.Deadline))
+	// read-only admin is always viewing, and anyone else is viewing (not
+	// editing) whenever the data call would refuse their answer - outside its
+	// open state or past the deadline - which is exactly CheckWritable.
+	readOnly := user.IsReadOnlyAdmin() || dc.CheckWritable(user, time.Now().UTC()) != nil
 
 	// On error let respond() map it to a status; on success write 204 directly
 	// (respond() would treat a nil-body POST as 201-with-empty-body, and this
@@ -71,7 +86,7 @@ func RecordQuestionView(w http.ResponseWriter, r *http.Request) {
 }
 
 //	@Summary		List audit-trail events, newest first, one page at a time
-//	@Description	Returns one page of the audit trail ordered by createdat descending (ties broken by eventid, so paging is stable). The response echoes the limit and offset actually applied and carries the total count matching the filters, for page math.
+//	@Description	Returns one page of the audit trail ordered by createdat descending (ties broken by eventid, so paging is stable). The response echoes the limit and offset actually applied and carries the total count matching the filters, for page math, and a next_cursor that fetches the following page as cheaply as the first.
 //	@Tags		events
 //	@Produce	json
 //	@Security	bearerAuth
@@ -84,6 +99,7 @@ func RecordQuestionView(w http.ResponseWriter, r *http.Request) {
 //	@Param		payload.questionid		query		integer	false	"Filter by question ID referenced in the event payload"
 //	@Param		limit					query		integer	false	"Page size; absent or 0 applies the default of 50, values above 500 clamp to 500"
 //	@Param		offset					query		integer	false	"Rows to skip before the page; defaults to 0"
+//	@Param		cursor					query		string	false	"next_cursor of the previous page, to continue from it without an offset; cannot be combined with offset"
 //	@Param		from					query		string	false	"Only events at or after this RFC3339 timestamp"
 //	@Param		to						query		string	false	"Only events at or before this RFC3339 timestamp"
 //	@Success	200	{object}	apiResponse[model.EventsPage]
@@ -102,39 +118,297 @@ func GetEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// from/to are RFC3339 timestamps parsed by hand: the shared decoder has
-	// no time.Time converter and rejects unknown keys as a 400, so the two
-	// keys are pulled out of the values before it decodes the rest.
+	findEventsInput := &model.FindEventsInput{}
+	if err := decodeEventsQuery(r, findEventsInput, &findEventsInput.From, &findEventsInput.To); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	page, err := model.FindEvents(r.Context(), findEventsInput)
+
+	respond(w, r, page, err)
+}
+
+//	@Summary		Export the audit trail as NDJSON or CSV
+//	@Description	Streams every event matching the filters, oldest first, one per line as NDJSON or as CSV rows with the payload as JSON text. Takes the filters of GET /events but no paging; the response is written as the rows are read, so an export of any size is a single request. Restricted to unscoped admins, like GET /events.
+//	@Tags			events
+//	@Produce		application/x-ndjson,text/csv
+//	@Security		bearerAuth
+//	@Param			format					query	string	false	"ndjson (default) or csv"
+//	@Param			userid					query	string	false	"Filter by initiating user ID"
+//	@Param			action					query	string	false	"Filter by action"
+//	@Param			resource				query	string	false	"Filter by affected resource (table name)"
+//	@Param			payload.fismasystemid	query	integer	false	"Filter by FISMA system ID referenced in the event payload"
+//	@Param			payload.scoreid			query	integer	false	"Filter by score ID referenced in the event payload"
+//	@Param			payload.datacallid		query	integer	false	"Filter by data call ID referenced in the event payload"
+//	@Param			payload.questionid		query	integer	false	"Filter by question ID referenced in the event payload"
+//	@Param			from					query	string	false	"Only events at or after this RFC3339 timestamp"
+//	@Param			to						query	string	false	"Only events at or before this RFC3339 timestamp"
+//	@Success		200	{string}	binary	"The matching events"
+//	@Failure		400	{object}	apiResponse[any]
+//	@Failure		403	{object}	apiResponse[any]
+//	@Failure		500	{object}	apiResponse[any]
+//	@Router			/events/export [get]
+func ExportEvents(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	// Same audience as GetEvents: events carry no opdiv_id to scope on.
+	if !user.HasUnscopedRead() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	input := &model.ExportEventsInput{}
+	if err := decodeEventsQuery(r, input, &input.From, &input.To); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	format := model.EventsExportNDJSON
+	if input.Format != nil {
+		format = *input.Format
+	}
+	out := newEventsWriter(w, format)
+
+	// Headers go out with the first event, so an error before it (a failed
+	// query) is still a JSON error response. After it the status is on the
+	// wire and a failure can only cut the download short; it is logged.
+	err := model.StreamEvents(r.Context(), input, out.write)
+	if err == nil {
+		err = out.close()
+	}
+	if err != nil {
+		if !out.started {
+			respond(w, r, nil, err)
+			return
+		}
+		log.Printf("ExportEvents: export cut short after %d events: %v", out.count, err)
+	}
+}
+
+// eventsExportFlushEvery is how many events ExportEvents writes between
+// flushes, so a slow export still reaches the client as it goes.
+const eventsExportFlushEvery = 1000
+
+// eventsWriter writes exported events to the response in one format,
+// starting the response on the first event.
+type eventsWriter struct {
+	w       http.ResponseWriter
+	format  string
+	csv     *csv.Writer
+	json    *json.Encoder
+	started bool
+	count   int
+}
+
+func newEventsWriter(w http.ResponseWriter, format string) *eventsWriter {
+	return &eventsWriter{w: w, format: format}
+}
+
+func (e *eventsWriter) start() error {
+	e.started = true
+	filename := "events-" + time.Now().UTC().Format("20060102T150405Z")
+	// Unquoted, like GetDatacallExport's filename.
+	e.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s", filename, e.format))
+	if e.format == model.EventsExportCSV {
+		e.w.Header().Set("Content-Type", "text/csv")
+		e.csv = csv.NewWriter(e.w)
+		return e.csv.Write([]string{"eventid", "createdat", "userid", "action", "resource", "payload"})
+	}
+	e.w.Header().Set("Content-Type", "application/x-ndjson")
+	e.json = json.NewEncoder(e.w)
+	return nil
+}
+
+func (e *eventsWriter) write(ev *model.Event) error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+
+	if e.csv != nil {
+		payload, err := json.Marshal(ev.Payload)
+		if err != nil {
+			return err
+		}
+		createdAt := ""
+		if ev.CreatedAt != nil {
+			createdAt = ev.CreatedAt.UTC().Format(time.RFC3339Nano)
+		}
+		record := []string{strconv.FormatInt(ev.EventID, 10), createdAt,
+			spreadsheet.CSVText(ev.UserID), spreadsheet.CSVText(ev.Action), spreadsheet.CSVText(ev.Resource), spreadsheet.CSVText(string(payload))}
+		if err := e.csv.Write(record); err != nil {
+			return err
+		}
+	} else if err := e.json.Encode(ev); err != nil {
+		return err
+	}
+
+	e.count++
+	if e.count%eventsExportFlushEvery == 0 {
+		return e.flush()
+	}
+	return nil
+}
+
+// close finishes the export, starting it first when no event matched so
+// the client still gets an empty file (a CSV with its heading row).
+func (e *eventsWriter) close() error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+	return e.flush()
+}
+
+func (e *eventsWriter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	// A writer that cannot flush still delivers everything when the handler
+	// returns.
+	if err := http.NewResponseController(e.w).Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// decodeEventsQuery decodes the request's events filters into dest, parsing
+// from and to by hand: they are RFC3339 timestamps, the shared decoder has
+// no time.Time converter and rejects unknown keys as a 400, so the two keys
+// are pulled out of the values before it decodes the rest.
+func decodeEventsQuery(r *http.Request, dest any, from, to **time.Time) error {
 	qs := r.URL.Query()
 	fromStr, toStr := qs.Get("from"), qs.Get("to")
 	qs.Del("from")
 	qs.Del("to")
 
-	findEventsInput := &model.FindEventsInput{}
-	err := decoder.Decode(findEventsInput, qs)
-	if err != nil {
-		respond(w, r, nil, err)
-		return
+	if err := decoder.Decode(dest, qs); err != nil {
+		return err
 	}
 
 	if fromStr != "" {
 		t, err := parseRFC3339(fromStr)
 		if err != nil {
-			respond(w, r, nil, ErrInvalidQueryParam)
-			return
+			return ErrInvalidQueryParam
 		}
-		findEventsInput.From = &t
+		*from = &t
 	}
 	if toStr != "" {
 		t, err := parseRFC3339(toStr)
 		if err != nil {
-			respond(w, r, nil, ErrInvalidQueryParam)
-			return
+			return ErrInvalidQueryParam
 		}
-		findEventsInput.To = &t
+		*to = &t
 	}
+	return nil
+}
 
-	page, err := model.FindEvents(r.Context(), findEventsInput)
+//	@Summary		List archived months of events
+//	@Description	Lists the months the retention job has moved out of the events table into the archive store, oldest first, with each month's event count and, while it is rehydrated, who brought it back and until when. Restricted to unscoped admins, like GET /events.
+//	@Tags			events
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Success		200	{object}	apiResponse[[]model.EventArchive]
+//	@Failure		403	{object}	apiResponse[any]
+//	@Failure		500	{object}	apiResponse[any]
+//	@Router			/events/archives [get]
+func ListEventArchives(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	if !user.HasUnscopedRead() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
 
-	respond(w, r, page, err)
+	archives, err := model.FindEventArchives(r.Context())
+	respond(w, r, archives, err)
+}
+
+//	@Summary		Rehydrate archived events
+//	@Description	Puts every archived month overlapping [from, to) back into the events table, where GET /events, the export and the audit fields read it again, for EVENTS_REHYDRATE_DAYS; after that the retention job drops it again. Rehydrating a month that is already back extends its hold. Each month is checked against its recorded checksum before a row is written. Returns 404 when no archived month overlaps the range.
+//	@Tags			events
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		model.RehydrateEventsInput	true	"Range of events to bring back"
+//	@Success		201		{object}	apiResponse[[]model.EventArchive]
+//	@Failure		400		{object}	apiResponse[any]
+//	@Failure		403		{object}	apiResponse[any]
+//	@Failure		404		{object}	apiResponse[any]
+//	@Failure		500		{object}	apiResponse[any]
+//	@Router			/events/archives/rehydrate [post]
+func RehydrateEvents(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	// A rehydrate writes into the audit trail, so it takes the unscoped WRITE
+	// admins only; the read-only tier can list the archives but not restore.
+	if !user.IsAdmin() || !user.HasUnscopedRead() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	input := model.RehydrateEventsInput{}
+	if err := getJSON(r.Body, &input); err != nil {
+		log.Println(err)
+		respond(w, r, nil, ErrMalformed)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	store, err := attachments.EventArchive()
+	if err != nil {
+		log.Println(err)
+		respond(w, r, nil, err)
+		return
+	}
+
+	hold := time.Duration(config.GetInstance().EventArchive.RehydrateDays) * 24 * time.Hour
+	archives, err := model.RehydrateEvents(r.Context(), store, input, time.Now().Add(hold))
+	respond(w, r, archives, err)
+}
+
+//	@Summary		Verify the events hash chain
+//	@Description	Walks the events created in [from, to] in chain order, recomputing each event's hash and checking its link to the event before it, and reports the first break: altered, unlinked, unchained, truncated (only when to is open) or checkpoint. Signed checkpoints in the range are checked against EVENTS_CHECKPOINT_PUBLIC_KEY. A break is a finding, so the response is 200 either way; verified is false and break says where. Restricted to unscoped admins, like GET /events.
+//	@Tags			events
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			from	query		string	false	"Only events at or after this RFC3339 timestamp"
+//	@Param			to		query		string	false	"Only events at or before this RFC3339 timestamp"
+//	@Success		200		{object}	apiResponse[model.EventChainReport]
+//	@Failure		400		{object}	apiResponse[any]
+//	@Failure		403		{object}	apiResponse[any]
+//	@Failure		500		{object}	apiResponse[any]
+//	@Router			/events/verify [get]
+func VerifyEventChain(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	if !user.HasUnscopedRead() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	input := model.VerifyEventChainInput{}
+	if err := decodeEventsQuery(r, &input, &input.From, &input.To); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	_, key, err := config.GetInstance().EventCheckpointKeys()
+	if err != nil {
+		log.Println(err)
+		respond(w, r, nil, err)
+		return
+	}
+
+	report, err := model.VerifyEventChain(r.Context(), input, key)
+	respond(w, r, report, err)
 }
@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportEvents_ScopedTiersForbidden(t *testing.T) {
+	for _, user := range []*model.User{issoUser, opdivAdmin, opdivReadonly} {
+		t.Run(user.Role, func(t *testing.T) {
+			r := withUser(httptest.NewRequest("GET", "/api/v1/events/export", nil), user)
+			w := httptest.NewRecorder()
+			ExportEvents(w, r)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
+// TestExportEvents_RejectsBeforeDB covers the requests refused before the
+// export starts, each as a JSON 400: an unknown format, paging, which an
+// export has none of, and an unparseable bound. The payload filter alongside
+// the bad format pins that the embedded filters still decode.
+func TestExportEvents_RejectsBeforeDB(t *testing.T) {
+	for _, query := range []string{
+		"?format=pdf&payload.scoreid=3",
+		"?limit=10",
+		"?cursor=abc",
+		"?from=yesterday",
+	} {
+		t.Run(query, func(t *testing.T) {
+			r := withUser(httptest.NewRequest("GET", "/api/v1/events/export"+query, nil), adminUser)
+			w := httptest.NewRecorder()
+			ExportEvents(w, r)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func exportFixture() []*model.Event {
+	at := time.Date(2026, 2, 1, 12, 0, 0, 500, time.UTC)
+	return []*model.Event{
+		{EventID: 7, UserID: "u1", Action: "updated", Resource: "public.scores", CreatedAt: &at, Payload: map[string]any{"scoreid": 3}},
+		{EventID: 9, UserID: "u2", Action: "created", Resource: "session", CreatedAt: &at, Payload: map[string]any{"note": "a, \"quoted\"\nline"}},
+	}
+}
+
+func TestEventsWriter_CSV(t *testing.T) {
+	w := httptest.NewRecorder()
+	out := newEventsWriter(w, model.EventsExportCSV)
+	for _, e := range exportFixture() {
+		require.NoError(t, out.write(e))
+	}
+	require.NoError(t, out.close())
+
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment; filename=events-"))
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"eventid", "createdat", "userid", "action", "resource", "payload"}, records[0])
+	assert.Equal(t, []string{"7", "2026-02-01T12:00:00.0000005Z", "u1", "updated", "public.scores", `{"scoreid":3}`}, records[1])
+	assert.Equal(t, `{"note":"a, \"quoted\"\nline"}`, records[2][5])
+}
+
+// TestEventsWriter_CSVFormulas pins that a value a spreadsheet would run as
+// a formula is exported as text.
+func TestEventsWriter_CSVFormulas(t *testing.T) {
+	w := httptest.NewRecorder()
+	out := newEventsWriter(w, model.EventsExportCSV)
+	require.NoError(t, out.write(&model.Event{EventID: 1, UserID: "u1", Action: "=cmd|' /C calc'!A0", Resource: "@public.scores", Payload: -1}))
+	require.NoError(t, out.close())
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, []string{"1", "", "u1", "'=cmd|' /C calc'!A0", "'@public.scores", "'-1"}, records[1])
+}
+
+func TestEventsWriter_NDJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	out := newEventsWriter(w, model.EventsExportNDJSON)
+	for _, e := range exportFixture() {
+		require.NoError(t, out.write(e))
+	}
+	require.NoError(t, out.close())
+
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimRight(w.Body.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	var e model.Event
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &e))
+	assert.Equal(t, int64(9), e.EventID)
+	assert.Equal(t, "session", e.Resource)
+}
+
+// An export nothing matched is still a file: the CSV keeps its heading row.
+func TestEventsWriter_Empty(t *testing.T) {
+	w := httptest.NewRecorder()
+	out := newEventsWriter(w, model.EventsExportCSV)
+	require.NoError(t, out.close())
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "eventid,createdat,userid,action,resource,payload\n", w.Body.String())
+}
+
+func TestListEventArchives_ScopedTiersForbidden(t *testing.T) {
+	for _, user := range []*model.User{issoUser, opdivAdmin, opdivReadonly} {
+		t.Run(user.Role, func(t *testing.T) {
+			r := withUser(httptest.NewRequest("GET", "/api/v1/events/archives", nil), user)
+			w := httptest.NewRecorder()
+			ListEventArchives(w, r)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
+// Rehydrating writes into the audit trail: the read-only tier that may list
+// the archives may not restore them.
+func TestRehydrateEvents_NonWriteAdminsForbidden(t *testing.T) {
+	for _, user := range []*model.User{readonlyAdmin, opdivAdmin, opdivReadonly, issoUser} {
+		t.Run(user.Role, func(t *testing.T) {
+			body := strings.NewReader(`{"from":"2025-01-01T00:00:00Z","to":"2025-02-01T00:00:00Z"}`)
+			r := withUser(httptest.NewRequest("POST", "/api/v1/events/archives/rehydrate", body), user)
+			w := httptest.NewRecorder()
+			RehydrateEvents(w, r)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
+func TestRehydrateEvents_RejectsBeforeDB(t *testing.T) {
+	for _, body := range []string{
+		`{"from":"2025-01-01T00:00:00Z"}`,
+		`{"from":"2025-02-01T00:00:00Z","to":"2025-01-01T00:00:00Z"}`,
+		`{"from":"2025-01-01T00:00:00Z","to":"2025-02-01T00:00:00Z","days":30}`,
+	} {
+		t.Run(body, func(t *testing.T) {
+			r := withUser(httptest.NewRequest("POST", "/api/v1/events/archives/rehydrate", strings.NewReader(body)), adminUser)
+			w := httptest.NewRecorder()
+			RehydrateEvents(w, r)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestVerifyEventChain_ScopedTiersForbidden(t *testing.T) {
+	for _, user := range []*model.User{issoUser, opdivAdmin, opdivReadonly} {
+		t.Run(user.Role, func(t *testing.T) {
+			r := withUser(httptest.NewRequest("GET", "/api/v1/events/verify", nil), user)
+			w := httptest.NewRecorder()
+			VerifyEventChain(w, r)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
+func TestVerifyEventChain_RejectsBeforeDB(t *testing.T) {
+	for _, query := range []string{
+		"?from=yesterday",
+		"?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z",
+		"?resource=users",
+	} {
+		t.Run(query, func(t *testing.T) {
+			r := withUser(httptest.NewRequest("GET", "/api/v1/events/verify"+query, nil), readonlyAdmin)
+			w := httptest.NewRecorder()
+			VerifyEventChain(w, r)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
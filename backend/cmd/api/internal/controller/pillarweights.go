@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/gorilla/mux"
+)
+
+//	@Summary		List a data call's pillar weights
+//	@Description	Weights set how much each pillar counts toward the system scores /scores/aggregate reports for this data call. An empty list means equal weighting.
+//	@Tags			datacalls
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			datacallid	path		int	true	"Data call ID"
+//	@Success		200			{object}	apiResponse[[]model.PillarWeight]
+//	@Failure		403			{object}	apiResponse[any]
+//	@Failure		500			{object}	apiResponse[any]
+//	@Router			/datacalls/{datacallid}/pillar-weights [get]
+func ListPillarWeights(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	if !user.HasAdminRead() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	var dataCallID int32
+	fmt.Sscan(mux.Vars(r)["datacallid"], &dataCallID)
+
+	weights, err := model.FindPillarWeights(r.Context(), dataCallID)
+	respond(w, r, weights, err)
+}
+
+type setPillarWeightsInput struct {
+	Weights *[]*model.PillarWeight `json:"weights"`
+}
+
+// SetPillarWeights replaces a data call's pillar weights. The set must weight
+// every pillar, or be empty to return the call to equal weighting; closed
+// calls keep the weighting they were scored with.
+//
+//	@Summary	Set a data call's pillar weights (batch replace)
+//	@Tags		datacalls
+//	@Accept		json
+//	@Security	bearerAuth
+//	@Param		datacallid	path	int						true	"Data call ID"
+//	@Param		body		body	setPillarWeightsInput	true	"Weight for every pillar, or an empty list"
+//	@Success	204	"No Content"
+//	@Failure	400	{object}	apiResponse[any]
+//	@Failure	403	{object}	apiResponse[any]
+//	@Failure	404	{object}	apiResponse[any]
+//	@Failure	500	{object}	apiResponse[any]
+//	@Router		/datacalls/{datacallid}/pillar-weights [put]
+func SetPillarWeights(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	// The weighting decides every system's score for the cycle, across
+	// OpDivs: an HHS-wide write, like a rollover rule.
+	if !user.CanWriteHHSWide() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	var input setPillarWeightsInput
+	if err := getJSON(r.Body, &input); err != nil {
+		log.Println(err)
+		respond(w, r, nil, ErrMalformed)
+		return
+	}
+	// As with SetUserOpDivs, a missing key must not read as an intentional
+	// clear; returning to equal weighting takes an explicit [].
+	if input.Weights == nil {
+		respond(w, r, nil, ErrMalformed)
+		return
+	}
+
+	var dataCallID int32
+	fmt.Sscan(mux.Vars(r)["datacallid"], &dataCallID)
+
+	_, err := model.SavePillarWeights(r.Context(), dataCallID, *input.Weights)
+	respond(w, r, nil, err)
+}
@@ -16,7 +16,7 @@ import (
 //	@Produce	json
 //	@Security	bearerAuth
 //	@Param		fismasystemid	path		int	true	"FISMA system ID"
-//	@Param		datacallid		query		int	false	"Apply the reduced-pillar rule for this data call; omitted returns the environment's full catalog"
+//	@Param		datacallid		query		int	false	"Read this data call's pinned catalog version and apply its reduced-pillar rule; omitted returns the environment's full live catalog"
 //	@Success	200				{object}	apiResponse[[]model.Question]
 //	@Failure	400				{object}	apiResponse[any]
 //	@Failure	404				{object}	apiResponse[any]
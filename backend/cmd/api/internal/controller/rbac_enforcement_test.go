@@ -3,6 +3,7 @@ package controller
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
@@ -114,3 +115,251 @@ func TestConfirmScore_OpDivReadonlyForbidden(t *testing.T) {
 	ConfirmScore(w, r)
 	assert.Equal(t, http.StatusForbidden, w.Code)
 }
+
+// --- Score attachments: writes are blocked for read-only tiers before any DB access ---
+
+func TestUploadScoreAttachment_ReadonlyForbidden(t *testing.T) {
+	for _, user := range []*model.User{readonlyAdmin, opdivReadonly} {
+		t.Run(user.Role, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/v1/scores/123/attachments", nil)
+			r = mux.SetURLVars(r, map[string]string{"scoreid": "123"})
+			w := httptest.NewRecorder()
+			UploadScoreAttachment(w, withUser(r, user))
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
+func TestDeleteScoreAttachment_ReadonlyForbidden(t *testing.T) {
+	for _, user := range []*model.User{readonlyAdmin, opdivReadonly} {
+		t.Run(user.Role, func(t *testing.T) {
+			r := httptest.NewRequest("DELETE", "/api/v1/scores/123/attachments/4", nil)
+			r = mux.SetURLVars(r, map[string]string{"scoreid": "123", "attachmentid": "4"})
+			w := httptest.NewRecorder()
+			DeleteScoreAttachment(w, withUser(r, user))
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
+// --- Score comments: read-only tiers read threads but never write them ---
+
+func TestSaveScoreComment_ReadonlyForbidden(t *testing.T) {
+	for _, user := range []*model.User{readonlyAdmin, opdivReadonly} {
+		t.Run(user.Role, func(t *testing.T) {
+			body := strings.NewReader(`{"scoreid":123,"body":"why a 3?"}`)
+			r := withUser(httptest.NewRequest("POST", "/api/v1/scores/comments", body), user)
+			w := httptest.NewRecorder()
+			SaveScoreComment(w, r)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
+func TestResolveScoreComment_ReadonlyForbidden(t *testing.T) {
+	for _, user := range []*model.User{readonlyAdmin, opdivReadonly} {
+		for name, handler := range map[string]http.HandlerFunc{"resolve": ResolveScoreComment, "unresolve": UnresolveScoreComment} {
+			t.Run(user.Role+" "+name, func(t *testing.T) {
+				r := httptest.NewRequest("PUT", "/api/v1/scores/comments/4/"+name, nil)
+				r = mux.SetURLVars(r, map[string]string{"commentid": "4"})
+				w := httptest.NewRecorder()
+				handler(w, withUser(r, user))
+				assert.Equal(t, http.StatusForbidden, w.Code)
+			})
+		}
+	}
+}
+
+// --- SetDataCallStatus: HHS-wide write (OWNER / HHS_ADMIN only) ---
+
+func TestSetDataCallStatus_NonHHSWritersForbidden(t *testing.T) {
+	for _, user := range []*model.User{opdivAdmin, opdivReadonly, readonlyAdmin, issoUser} {
+		t.Run(user.Role, func(t *testing.T) {
+			body := jsonBody(t, map[string]string{"status": "open"})
+			r := httptest.NewRequest("PUT", "/api/v1/datacalls/1/status", body)
+			r = mux.SetURLVars(r, map[string]string{"datacallid": "1"})
+			r = withUser(r, user)
+			w := httptest.NewRecorder()
+			SetDataCallStatus(w, r)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
+// TestSaveDataCallSnapshot_NonHHSWritersForbidden pins that freezing a cycle
+// is gated like its lifecycle.
+func TestSaveDataCallSnapshot_NonHHSWritersForbidden(t *testing.T) {
+	for _, user := range []*model.User{opdivAdmin, opdivReadonly, readonlyAdmin, issoUser} {
+		t.Run(user.Role, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/v1/datacalls/1/snapshot", nil)
+			r = mux.SetURLVars(r, map[string]string{"datacallid": "1"})
+			r = withUser(r, user)
+			w := httptest.NewRecorder()
+			SaveDataCallSnapshot(w, r)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
+func TestGetDataCallSnapshot_ISSOForbidden(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/datacalls/1/snapshot", nil)
+	r = mux.SetURLVars(r, map[string]string{"datacallid": "1"})
+	w := httptest.NewRecorder()
+	GetDataCallSnapshot(w, withUser(r, issoUser))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// TestListDataCalls_IncludeDraftsNotBindable pins that the draft filter is
+// server-owned: naming it in the query is an unknown key (400), not a way for
+// an ISSO to see a staged cycle.
+func TestListDataCalls_IncludeDraftsNotBindable(t *testing.T) {
+	r := withUser(httptest.NewRequest("GET", "/api/v1/datacalls?IncludeDrafts=true", nil), issoUser)
+	w := httptest.NewRecorder()
+	ListDataCalls(w, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// --- Deadline extensions ---
+
+func TestSaveDeadlineExtension_NonAdminsForbidden(t *testing.T) {
+	for _, user := range []*model.User{opdivReadonly, readonlyAdmin, issoUser} {
+		t.Run(user.Role, func(t *testing.T) {
+			body := jsonBody(t, map[string]any{"fismasystemid": 1, "reason": "negotiated", "expires_at": "2099-01-01T00:00:00Z"})
+			r := httptest.NewRequest("POST", "/api/v1/datacalls/1/extensions", body)
+			r = mux.SetURLVars(r, map[string]string{"datacallid": "1"})
+			r = withUser(r, user)
+			w := httptest.NewRecorder()
+			SaveDeadlineExtension(w, r)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
+// TestSaveDeadlineExtension_OpDivAdminCannotExtendOwnOpDiv pins that an
+// OpDiv-wide extension is an HHS-wide write: the OpDiv it would benefit is the
+// one the OPDIV_ADMIN holds a grant for, which is exactly why it is refused.
+func TestSaveDeadlineExtension_OpDivAdminCannotExtendOwnOpDiv(t *testing.T) {
+	body := jsonBody(t, map[string]any{"opdiv_id": 1, "reason": "negotiated", "expires_at": "2099-01-01T00:00:00Z"})
+	r := httptest.NewRequest("POST", "/api/v1/datacalls/1/extensions", body)
+	r = mux.SetURLVars(r, map[string]string{"datacallid": "1"})
+	r = withUser(r, opdivAdmin)
+	w := httptest.NewRecorder()
+	SaveDeadlineExtension(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestListDeadlineExtensions_ISSOForbidden(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/datacalls/1/extensions", nil)
+	r = mux.SetURLVars(r, map[string]string{"datacallid": "1"})
+	r = withUser(r, issoUser)
+	w := httptest.NewRecorder()
+	ListDeadlineExtensions(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestDeleteDeadlineExtension_ReadonlyAdminForbidden(t *testing.T) {
+	r := httptest.NewRequest("DELETE", "/api/v1/datacalls/1/extensions/1", nil)
+	r = mux.SetURLVars(r, map[string]string{"datacallid": "1", "extensionid": "1"})
+	r = withUser(r, readonlyAdmin)
+	w := httptest.NewRecorder()
+	DeleteDeadlineExtension(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// --- Rollover rules: HHS-wide write (OWNER / HHS_ADMIN only) ---
+
+func TestSaveRolloverRule_NonHHSWritersForbidden(t *testing.T) {
+	for _, user := range []*model.User{opdivAdmin, opdivReadonly, readonlyAdmin, issoUser} {
+		t.Run(user.Role, func(t *testing.T) {
+			body := jsonBody(t, map[string]any{"source_datacallid": 1})
+			r := httptest.NewRequest("POST", "/api/v1/datacalls/2/rollover-rules", body)
+			r = mux.SetURLVars(r, map[string]string{"datacallid": "2"})
+			r = withUser(r, user)
+			w := httptest.NewRecorder()
+			SaveRolloverRule(w, r)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
+func TestDeleteRolloverRule_OpDivAdminForbidden(t *testing.T) {
+	r := httptest.NewRequest("DELETE", "/api/v1/datacalls/2/rollover-rules/1", nil)
+	r = mux.SetURLVars(r, map[string]string{"datacallid": "2", "ruleid": "1"})
+	r = withUser(r, opdivAdmin)
+	w := httptest.NewRecorder()
+	DeleteRolloverRule(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestListRolloverRules_ISSOForbidden(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/datacalls/2/rollover-rules", nil)
+	r = mux.SetURLVars(r, map[string]string{"datacallid": "2"})
+	r = withUser(r, issoUser)
+	w := httptest.NewRecorder()
+	ListRolloverRules(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// --- Rollover preview (unscoped read) and repair (unscoped WRITE admins) ---
+
+func TestRepairRollover_NonWriteAdminsForbidden(t *testing.T) {
+	for _, user := range []*model.User{readonlyAdmin, opdivAdmin, opdivReadonly, issoUser} {
+		t.Run(user.Role, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/v1/datacalls/2/rollover", nil)
+			r = mux.SetURLVars(r, map[string]string{"datacallid": "2"})
+			r = withUser(r, user)
+			w := httptest.NewRecorder()
+			RepairRollover(w, r)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
+func TestGetRolloverPreview_ScopedUsersForbidden(t *testing.T) {
+	for _, user := range []*model.User{opdivAdmin, opdivReadonly, issoUser} {
+		t.Run(user.Role, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/v1/datacalls/2/rollover", nil)
+			r = mux.SetURLVars(r, map[string]string{"datacallid": "2"})
+			r = withUser(r, user)
+			w := httptest.NewRecorder()
+			GetRolloverPreview(w, r)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
+// --- Pillar weights: admin read, HHS-wide write ---
+
+func TestSetPillarWeights_NonHHSWritersForbidden(t *testing.T) {
+	for _, user := range []*model.User{opdivAdmin, opdivReadonly, readonlyAdmin, issoUser} {
+		t.Run(user.Role, func(t *testing.T) {
+			body := jsonBody(t, map[string]any{"weights": []any{}})
+			r := httptest.NewRequest("PUT", "/api/v1/datacalls/2/pillar-weights", body)
+			r = mux.SetURLVars(r, map[string]string{"datacallid": "2"})
+			r = withUser(r, user)
+			w := httptest.NewRecorder()
+			SetPillarWeights(w, r)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
+// A body without the weights key is malformed rather than a clear, so a
+// client bug cannot silently reset a cycle to equal weighting.
+func TestSetPillarWeights_MissingWeightsMalformed(t *testing.T) {
+	r := httptest.NewRequest("PUT", "/api/v1/datacalls/2/pillar-weights", jsonBody(t, map[string]any{}))
+	r = mux.SetURLVars(r, map[string]string{"datacallid": "2"})
+	r = withUser(r, adminUser)
+	w := httptest.NewRecorder()
+	SetPillarWeights(w, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestListPillarWeights_ISSOForbidden(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/datacalls/2/pillar-weights", nil)
+	r = mux.SetURLVars(r, map[string]string{"datacallid": "2"})
+	r = withUser(r, issoUser)
+	w := httptest.NewRecorder()
+	ListPillarWeights(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/CMS-Enterprise/ztmf/backend/cmd/api/internal/spreadsheet"
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/xuri/excelize/v2"
+)
+
+// respondReport answers a report endpoint asked for format=xlsx or csv. It
+// labels the rows' systems, renders the file in full, then sends it as an
+// attachment named like GetDatacallExport's: unquoted, for the same reason.
+func respondReport(w http.ResponseWriter, r *http.Request, format, filename string, fismaSystemIDs []int32, build func(map[int32]*model.ReportSystem) *spreadsheet.Report) {
+	systems, err := model.FindReportSystems(r.Context(), fismaSystemIDs)
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+	report := build(systems)
+
+	var (
+		buf         bytes.Buffer
+		contentType string
+	)
+	switch format {
+	case model.ReportFormatCSV:
+		contentType = "text/csv"
+		err = report.CSV(&buf)
+	default:
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		var file *excelize.File
+		if file, err = report.Excel(); err == nil {
+			err = file.Write(&buf)
+		}
+	}
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s", filename, format))
+	w.Header().Set("Content-Type", contentType)
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("respondReport: error writing %s to response (%s): %v", format, filename, err)
+	}
+}
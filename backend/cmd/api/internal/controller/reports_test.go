@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReportFormat_UnknownIsBadRequest pins that a format the report
+// endpoints do not write is refused before the query runs, so the caller
+// gets a 400 rather than JSON they did not ask for.
+func TestReportFormat_UnknownIsBadRequest(t *testing.T) {
+	for name, tc := range map[string]struct {
+		handler http.HandlerFunc
+		url     string
+	}{
+		"diff":     {GetScoresDiff, "/api/v1/scores/diff?from=1&to=2&format=pdf"},
+		"progress": {GetScoresProgress, "/api/v1/scores/progress?datacallid=1&format=XLSX"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := withUser(httptest.NewRequest(http.MethodGet, tc.url, nil), adminUser)
+			w := httptest.NewRecorder()
+
+			tc.handler(w, r)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), "format")
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/gorilla/mux"
+)
+
+//	@Summary		Preview a data call's rollover
+//	@Description	Dry run: lists, per system and function, the answer the rollover carries forward into this data call and the data call it comes from. present is true where the data call already holds an answer to that function; the rest are what a repair would write. Nothing is written. Restricted to unscoped admins, since it spans every OpDiv.
+//	@Tags			datacalls
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			datacallid	path		int	true	"Target data call ID"
+//	@Success		200			{object}	apiResponse[model.RolloverReport]
+//	@Failure		403			{object}	apiResponse[any]
+//	@Failure		404			{object}	apiResponse[any]
+//	@Failure		500			{object}	apiResponse[any]
+//	@Router			/datacalls/{datacallid}/rollover [get]
+func GetRolloverPreview(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	// The preview lists every OpDiv's systems, so an OpDiv-scoped admin would
+	// see answers outside their grants; it takes the repair's read tier.
+	if !user.HasUnscopedRead() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	var dataCallID int32
+	fmt.Sscan(mux.Vars(r)["datacallid"], &dataCallID)
+
+	report, err := model.RolloverPreview(r.Context(), dataCallID)
+	respond(w, r, report, err)
+}
+
+//	@Summary		Repair a data call's rollover
+//	@Description	Writes the carried-forward answers the preview reports as missing, and only those: a question the data call already has any answer to is never written again, so a repair is safe to re-run. The data call must be open.
+//	@Tags			datacalls
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			datacallid	path		int	true	"Target data call ID"
+//	@Success		201			{object}	apiResponse[model.RolloverReport]
+//	@Failure		400			{object}	apiResponse[any]
+//	@Failure		403			{object}	apiResponse[any]
+//	@Failure		404			{object}	apiResponse[any]
+//	@Failure		500			{object}	apiResponse[any]
+//	@Router			/datacalls/{datacallid}/rollover [post]
+func RepairRollover(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	// A repair writes answers into every OpDiv's systems at once, so it takes
+	// the importer's gate: unscoped WRITE admins only.
+	if !user.IsAdmin() || !user.HasUnscopedRead() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	var dataCallID int32
+	fmt.Sscan(mux.Vars(r)["datacallid"], &dataCallID)
+
+	report, err := model.RepairRollover(r.Context(), dataCallID)
+	respond(w, r, report, err)
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/gorilla/mux"
+)
+
+//	@Summary		List a data call's rollover rules
+//	@Description	Rules choose, per OpDiv or scoring key, which earlier data call a system's answers roll forward from when this data call first opens. Listed in matching order: OpDiv rules, scoring-key rules, then the default rule.
+//	@Tags			datacalls
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			datacallid	path		int	true	"Target data call ID"
+//	@Success		200			{object}	apiResponse[[]model.RolloverRule]
+//	@Failure		403			{object}	apiResponse[any]
+//	@Failure		500			{object}	apiResponse[any]
+//	@Router			/datacalls/{datacallid}/rollover-rules [get]
+func ListRolloverRules(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	if !user.HasAdminRead() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	var dataCallID int32
+	fmt.Sscan(mux.Vars(r)["datacallid"], &dataCallID)
+
+	rules, err := model.FindRolloverRules(r.Context(), dataCallID)
+	respond(w, r, rules, err)
+}
+
+//	@Summary		Create or update a rollover rule
+//	@Description	Set opdiv_id or scoring_key to key the rule, or neither for the data call's default. The source must have gone live and have an earlier deadline than the target. Rules apply when the target first opens.
+//	@Tags			datacalls
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			datacallid	path		int					true	"Target data call ID"
+//	@Param			ruleid		path		int					false	"Rule ID (for update)"
+//	@Param			body		body		model.RolloverRule	true	"Rule to save"
+//	@Success		201			{object}	apiResponse[model.RolloverRule]
+//	@Failure		400			{object}	apiResponse[any]
+//	@Failure		403			{object}	apiResponse[any]
+//	@Failure		404			{object}	apiResponse[any]
+//	@Failure		500			{object}	apiResponse[any]
+//	@Router			/datacalls/{datacallid}/rollover-rules [post]
+//	@Router			/datacalls/{datacallid}/rollover-rules/{ruleid} [put]
+func SaveRolloverRule(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	// A rule decides where every matching system's answers come from for a
+	// whole cycle, across OpDivs: an HHS-wide write, like opening the cycle.
+	if !user.CanWriteHHSWide() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	rule := &model.RolloverRule{}
+	if err := getJSON(r.Body, rule); err != nil {
+		log.Println(err)
+		respond(w, r, nil, ErrMalformed)
+		return
+	}
+
+	// The path is authoritative for both ids.
+	vars := mux.Vars(r)
+	fmt.Sscan(vars["datacallid"], &rule.TargetDataCallID)
+	rule.RuleID = 0
+	if v, ok := vars["ruleid"]; ok {
+		fmt.Sscan(v, &rule.RuleID)
+	}
+
+	rule, err := rule.Save(r.Context())
+	respond(w, r, rule, err)
+}
+
+//	@Summary	Delete a rollover rule
+//	@Tags		datacalls
+//	@Security	bearerAuth
+//	@Param		datacallid	path	int	true	"Target data call ID"
+//	@Param		ruleid		path	int	true	"Rule ID"
+//	@Success	204			"No Content"
+//	@Failure	403			{object}	apiResponse[any]
+//	@Failure	404			{object}	apiResponse[any]
+//	@Failure	500			{object}	apiResponse[any]
+//	@Router		/datacalls/{datacallid}/rollover-rules/{ruleid} [delete]
+func DeleteRolloverRule(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	if !user.CanWriteHHSWide() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	var dataCallID, ruleID int32
+	vars := mux.Vars(r)
+	fmt.Sscan(vars["datacallid"], &dataCallID)
+	fmt.Sscan(vars["ruleid"], &ruleID)
+
+	err := model.DeleteRolloverRule(r.Context(), dataCallID, ruleID)
+	respond(w, r, nil, err)
+}
@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/attachments"
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/gorilla/mux"
+)
+
+// maxAttachmentRequestBytes bounds the whole multipart request: the file
+// itself plus room for the part headers.
+const maxAttachmentRequestBytes = model.MaxAttachmentBytes + 1<<20
+
+// findScopedScore loads the path's score the way ListScores would show it to
+// the caller, so a score outside their scope is 404 rather than 403.
+func findScopedScore(r *http.Request, user *model.User) (*model.Score, error) {
+	input := model.FindScoresInput{}
+
+	var scoreID int32
+	fmt.Sscan(mux.Vars(r)["scoreid"], &scoreID)
+	input.ScoreID = &scoreID
+
+	if input.ApplyTier(user) {
+		input.UserID = user.UserIDPtr()
+	}
+
+	scores, err := model.FindScores(r.Context(), input)
+	if err != nil {
+		return nil, err
+	}
+	if len(scores) == 0 {
+		return nil, model.ErrNoData
+	}
+	return scores[0], nil
+}
+
+// findWritableScore loads the path's score for an attachment write and
+// authorizes it as SaveScore authorizes an update: against the stored row.
+func findWritableScore(r *http.Request, user *model.User) (*model.Score, error) {
+	var scoreID int32
+	fmt.Sscan(mux.Vars(r)["scoreid"], &scoreID)
+
+	score, err := model.FindScoreByID(r.Context(), scoreID)
+	if err != nil {
+		return nil, err
+	}
+	if err := guardScoreWrite(r.Context(), user, score.FismaSystemID); err != nil {
+		return nil, err
+	}
+	return score, nil
+}
+
+//	@Summary		List a score's evidence attachments
+//	@Description	Returns the files attached to a score answer, oldest first. Scoped like ListScores; a score outside the caller's scope is 404.
+//	@Tags			scores
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			scoreid	path		int	true	"Score ID"
+//	@Success		200		{object}	apiResponse[[]model.ScoreAttachment]
+//	@Failure		404		{object}	apiResponse[any]
+//	@Failure		500		{object}	apiResponse[any]
+//	@Router			/scores/{scoreid}/attachments [get]
+func ListScoreAttachments(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+
+	score, err := findScopedScore(r, user)
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	list, err := model.FindScoreAttachments(r.Context(), score.ScoreID)
+	respond(w, r, list, err)
+}
+
+//	@Summary		Download a score's evidence attachment
+//	@Description	Streams the attached file with the content type it was uploaded with. Scoped like ListScores; a score outside the caller's scope is 404.
+//	@Tags			scores
+//	@Produce		octet-stream
+//	@Security		bearerAuth
+//	@Param			scoreid			path		int	true	"Score ID"
+//	@Param			attachmentid	path		int	true	"Attachment ID"
+//	@Success		200				{file}		binary
+//	@Failure		404				{object}	apiResponse[any]
+//	@Failure		500				{object}	apiResponse[any]
+//	@Router			/scores/{scoreid}/attachments/{attachmentid} [get]
+func DownloadScoreAttachment(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+
+	score, err := findScopedScore(r, user)
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	store, err := attachments.Default()
+	if err != nil {
+		log.Println(err)
+		respond(w, r, nil, err)
+		return
+	}
+
+	var attachmentID int32
+	fmt.Sscan(mux.Vars(r)["attachmentid"], &attachmentID)
+
+	attachment, body, err := model.OpenScoreAttachment(r.Context(), store, score.ScoreID, attachmentID)
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.SizeBytes, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, body); err != nil {
+		log.Println(err)
+	}
+}
+
+// UploadScoreAttachment attaches an evidence file (multipart field "file") to
+// a score. The declared content type is the part's; the model checks it
+// against the file's own bytes.
+//
+//	@Summary		Attach an evidence file to a score
+//	@Description	Accepts PDF, PNG, JPEG, plain text, CSV and Office (xlsx, docx, pptx) files up to 25 MiB. Authorized like updating the score: read-only admins never write, ISSO/ISSM need the system assignment, admins must manage the system's OpDiv. The data call's deadline applies as it does to the score.
+//	@Tags			scores
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			scoreid	path		int		true	"Score ID"
+//	@Param			file	formData	file	true	"Evidence file"
+//	@Success		201		{object}	apiResponse[model.ScoreAttachment]
+//	@Failure		400		{object}	apiResponse[any]
+//	@Failure		403		{object}	apiResponse[any]
+//	@Failure		404		{object}	apiResponse[any]
+//	@Failure		500		{object}	apiResponse[any]
+//	@Router			/scores/{scoreid}/attachments [post]
+func UploadScoreAttachment(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+
+	// Role-only rejection before any DB access, as in SaveScore.
+	if user.IsReadOnlyAdmin() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	score, err := findWritableScore(r, user)
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentRequestBytes)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		log.Println(err)
+		respond(w, r, nil, ErrMalformed)
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, model.MaxAttachmentBytes+1))
+	if err != nil {
+		log.Println(err)
+		respond(w, r, nil, ErrMalformed)
+		return
+	}
+
+	store, err := attachments.Default()
+	if err != nil {
+		log.Println(err)
+		respond(w, r, nil, err)
+		return
+	}
+
+	created, err := model.CreateScoreAttachment(r.Context(), store, score.ScoreID, header.Filename, header.Header.Get("Content-Type"), content)
+	respond(w, r, created, err)
+}
+
+//	@Summary		Remove an evidence file from a score
+//	@Description	Authorized like uploading. A file carried forward to another cycle's score stays attached there.
+//	@Tags			scores
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			scoreid			path	int	true	"Score ID"
+//	@Param			attachmentid	path	int	true	"Attachment ID"
+//	@Success		204
+//	@Failure		403	{object}	apiResponse[any]
+//	@Failure		404	{object}	apiResponse[any]
+//	@Failure		500	{object}	apiResponse[any]
+//	@Router			/scores/{scoreid}/attachments/{attachmentid} [delete]
+func DeleteScoreAttachment(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+
+	if user.IsReadOnlyAdmin() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	score, err := findWritableScore(r, user)
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	store, err := attachments.Default()
+	if err != nil {
+		log.Println(err)
+		respond(w, r, nil, err)
+		return
+	}
+
+	var attachmentID int32
+	fmt.Sscan(mux.Vars(r)["attachmentid"], &attachmentID)
+
+	err = model.DeleteScoreAttachment(r.Context(), store, score.ScoreID, attachmentID)
+	respond(w, r, nil, err)
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/cmd/api/internal/scorecard"
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/gorilla/mux"
+)
+
+//	@Summary		Download a FISMA system's scorecard as a PDF
+//	@Description	Pillar scores and tiers, the target maturity and its justification, the answers changed since the previous data call and the lowest-scoring functions, for one data call. Visible to the same users as the system itself.
+//	@Tags			fismasystems
+//	@Produce		application/pdf
+//	@Security		bearerAuth
+//	@Param			fismasystemid	path	int	true	"FISMA system ID"
+//	@Param			datacallid		query	int	false	"Data call ID; defaults to the latest data call"
+//	@Success		200	{string}	binary	"PDF scorecard"
+//	@Failure		400	{object}	apiResponse[any]
+//	@Failure		403	{object}	apiResponse[any]
+//	@Failure		404	{object}	apiResponse[any]
+//	@Failure		500	{object}	apiResponse[any]
+//	@Router			/fismasystems/{fismasystemid}/scorecard [get]
+func GetFismaSystemScorecard(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	input := model.FindScorecardInput{}
+
+	if err := decoder.Decode(&input, r.URL.Query()); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	v, ok := mux.Vars(r)["fismasystemid"]
+	if !ok {
+		respond(w, r, nil, ErrNotFound)
+		return
+	}
+	fmt.Sscan(v, &input.FismaSystemID)
+
+	// The same gate as GetFismaSystem: a system the user cannot see is a
+	// 403, one that does not exist a 404.
+	if err := guardViewFismaSystem(r.Context(), user, input.FismaSystemID); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	card, err := model.FindScorecard(r.Context(), input)
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	// Render in full before any header goes out, so a layout error is still
+	// a 500 rather than a truncated download.
+	pdf, err := scorecard.PDF(card, time.Now().UTC())
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+	var buf bytes.Buffer
+	if _, err := pdf.WriteTo(&buf); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	// Unquoted for the same reason as GetDatacallExport's filename.
+	filename := strings.ReplaceAll(fmt.Sprintf("%s-%s-scorecard", card.System.FismaAcronym, card.DataCall.DataCall), " ", "")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.pdf", filename))
+	w.Header().Set("Content-Type", "application/pdf")
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("GetFismaSystemScorecard: error writing pdf to response (fismasystemid=%d): %v", input.FismaSystemID, err)
+	}
+}
@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGetFismaSystemScorecard_RejectsBeforeDB covers the queries refused
+// before the access check: the system id cannot be redirected from the query,
+// and an unknown or malformed parameter is a 400.
+func TestGetFismaSystemScorecard_RejectsBeforeDB(t *testing.T) {
+	for _, query := range []string{"?FismaSystemID=2", "?datacallid=abc", "?format=pdf"} {
+		t.Run(query, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/v1/fismasystems/1/scorecard"+query, nil)
+			r = mux.SetURLVars(r, map[string]string{"fismasystemid": "1"})
+			w := httptest.NewRecorder()
+			GetFismaSystemScorecard(w, withUser(r, issoUser))
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/gorilla/mux"
+)
+
+//	@Summary		List review comments on answers
+//	@Description	Returns comment threads, oldest first, each top comment with its replies. Filter by a score (the comments on its system, function and data call) or by any of fismasystemid, functionid and datacallid; resolved filters whole threads. Scoped like ListScores, so an ISSO/ISSM sees only comments on their assigned systems.
+//	@Tags			scores
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			scoreid			query		int		false	"Comments anchored where this score is"
+//	@Param			fismasystemid	query		int		false	"Filter by FISMA system ID"
+//	@Param			functionid		query		int		false	"Filter by function ID"
+//	@Param			datacallid		query		int		false	"Filter by data call ID"
+//	@Param			resolved		query		bool	false	"Only resolved (true) or open (false) threads"
+//	@Success		200				{object}	apiResponse[[]model.ScoreComment]
+//	@Failure		400				{object}	apiResponse[any]
+//	@Failure		500				{object}	apiResponse[any]
+//	@Router			/scores/comments [get]
+func ListScoreComments(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	input := model.FindScoreCommentsInput{}
+
+	err := decoder.Decode(&input, r.URL.Query())
+
+	// Same tier scoping as ListScores, applied AFTER decode so a client cannot
+	// widen scope via query params.
+	if input.ApplyTier(user) {
+		input.UserID = user.UserIDPtr()
+	}
+
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	comments, err := model.FindScoreComments(r.Context(), input)
+	respond(w, r, comments, err)
+}
+
+// SaveScoreComment starts a thread or replies to one. The comment's system is
+// resolved first and authorized as an answer write on it, so commenting
+// reaches exactly the people who may answer or review the system.
+//
+//	@Summary		Comment on an answer
+//	@Description	Starts a thread on a score, or on a fismasystemid, functionid and datacallid, or replies to a thread with parentid. Authorized like saving a score on the system: read-only admins never write, ISSO/ISSM need the system assignment, admins must manage the system's OpDiv.
+//	@Tags			scores
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			comment	body		model.ScoreCommentInput	true	"Comment"
+//	@Success		201		{object}	apiResponse[model.ScoreComment]
+//	@Failure		400		{object}	apiResponse[any]
+//	@Failure		403		{object}	apiResponse[any]
+//	@Failure		404		{object}	apiResponse[any]
+//	@Failure		500		{object}	apiResponse[any]
+//	@Router			/scores/comments [post]
+func SaveScoreComment(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+
+	if user.IsReadOnlyAdmin() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	input := model.ScoreCommentInput{}
+	if err := getJSON(r.Body, &input); err != nil {
+		log.Println(err)
+		respond(w, r, nil, ErrMalformed)
+		return
+	}
+
+	anchor, err := model.ResolveScoreCommentAnchor(r.Context(), input)
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	if err := guardScoreWrite(r.Context(), user, anchor.FismaSystemID); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	comment, err := model.CreateScoreComment(r.Context(), anchor, input.Body)
+	respond(w, r, comment, err)
+}
+
+//	@Summary		Resolve a comment thread
+//	@Description	Marks the thread started by the comment resolved. Authorized like commenting. Resolving a resolved thread changes nothing.
+//	@Tags			scores
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			commentid	path		int	true	"ID of the thread's first comment"
+//	@Success		200			{object}	apiResponse[model.ScoreComment]
+//	@Failure		400			{object}	apiResponse[any]
+//	@Failure		403			{object}	apiResponse[any]
+//	@Failure		404			{object}	apiResponse[any]
+//	@Failure		500			{object}	apiResponse[any]
+//	@Router			/scores/comments/{commentid}/resolve [put]
+func ResolveScoreComment(w http.ResponseWriter, r *http.Request) {
+	setScoreCommentResolved(w, r, true)
+}
+
+//	@Summary		Reopen a comment thread
+//	@Description	Marks the thread started by the comment unresolved. Authorized like commenting.
+//	@Tags			scores
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			commentid	path		int	true	"ID of the thread's first comment"
+//	@Success		200			{object}	apiResponse[model.ScoreComment]
+//	@Failure		400			{object}	apiResponse[any]
+//	@Failure		403			{object}	apiResponse[any]
+//	@Failure		404			{object}	apiResponse[any]
+//	@Failure		500			{object}	apiResponse[any]
+//	@Router			/scores/comments/{commentid}/unresolve [put]
+func UnresolveScoreComment(w http.ResponseWriter, r *http.Request) {
+	setScoreCommentResolved(w, r, false)
+}
+
+func setScoreCommentResolved(w http.ResponseWriter, r *http.Request, resolved bool) {
+	user := model.UserFromContext(r.Context())
+
+	if user.IsReadOnlyAdmin() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	var commentID int32
+	fmt.Sscan(mux.Vars(r)["commentid"], &commentID)
+
+	comment, err := model.FindScoreCommentByID(r.Context(), commentID)
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	if err := guardScoreWrite(r.Context(), user, comment.FismaSystemID); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	updated, err := model.SetScoreCommentResolved(r.Context(), commentID, resolved)
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	// PUT-as-action: return the thread's first comment, as ConfirmScore does.
+	respondOK(w, updated)
+}
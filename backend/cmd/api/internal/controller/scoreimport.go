@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/CMS-Enterprise/ztmf/backend/cmd/api/internal/spreadsheet"
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+)
+
+// maxScoreImportBytes bounds the upload. A full cycle for every system is well
+// under a megabyte as CSV; the headroom is for workbooks, which zip but carry
+// styles and shared strings.
+const maxScoreImportBytes = 20 << 20
+
+// ImportScores loads answers recorded outside the application from a CSV or
+// XLSX upload (multipart field "file"). The format follows the file extension.
+// See model.ImportScores for what is checked and how the rows are attributed.
+//
+//	@Summary	Bulk import scores from a CSV or XLSX file
+//	@Tags		scores
+//	@Accept		multipart/form-data
+//	@Produce	json
+//	@Security	bearerAuth
+//	@Param		file	formData	file	true	"CSV or XLSX with fismasystemid, datacallid, functionoptionid and optional notes columns"
+//	@Success	201		{object}	apiResponse[model.ScoreImportResult]
+//	@Failure	400		{object}	apiResponse[any]	"data.rows is the per-row error report; nothing is written"
+//	@Failure	403		{object}	apiResponse[any]
+//	@Failure	500		{object}	apiResponse[any]
+//	@Router		/scores/import [post]
+func ImportScores(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	// An import writes answers for any system in any OpDiv, including closed
+	// cycles, with no per-row scoping - the same reach as mass email, so the
+	// same gate: unscoped WRITE admins only.
+	if !user.IsAdmin() || !user.HasUnscopedRead() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxScoreImportBytes)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		log.Println(err)
+		respond(w, r, nil, ErrMalformed)
+		return
+	}
+	defer file.Close()
+
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+	if format != spreadsheet.FormatCSV && format != spreadsheet.FormatXLSX {
+		respond(w, r, nil, ErrMalformed)
+		return
+	}
+
+	rows, findings, err := spreadsheet.ParseScoreImport(file, format)
+	if err != nil {
+		if errors.Is(err, spreadsheet.ErrUnreadableImport) {
+			log.Println(err)
+			err = ErrMalformed
+		}
+		respond(w, r, nil, err)
+		return
+	}
+
+	result, err := model.ImportScores(r.Context(), model.ScoreImportInput{
+		Source: model.ScoreImportSource{
+			Filename: filepath.Base(header.Filename),
+			Format:   format,
+		},
+		Rows:        rows,
+		ParseErrors: findings,
+	})
+
+	respond(w, r, result, err)
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// importRequest builds a multipart upload the way the admin page sends it.
+func importRequest(t *testing.T, user *model.User, filename, content string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = fw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	r := httptest.NewRequest("POST", "/api/v1/scores/import", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return withUser(r, user)
+}
+
+// --- ImportScores: restricted to unscoped WRITE admins (OWNER / HHS_ADMIN) ---
+
+func TestImportScores_NonWriteAdminsForbidden(t *testing.T) {
+	for _, user := range []*model.User{readonlyAdmin, opdivAdmin, opdivReadonly, issoUser} {
+		t.Run(user.Role, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ImportScores(w, importRequest(t, user, "scores.csv", "fismasystemid,datacallid,functionoptionid\n1,1,1\n"))
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
+func TestImportScores_UnsupportedExtension(t *testing.T) {
+	w := httptest.NewRecorder()
+	ImportScores(w, importRequest(t, adminUser, "scores.txt", "fismasystemid,datacallid,functionoptionid\n"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestImportScores_ReportsRowsBeforeDB pins the response shape of a rejected
+// file: a 400 whose data.rows is the per-row report. Parse and structural
+// findings short-circuit before any database access, so this needs no DB.
+func TestImportScores_ReportsRowsBeforeDB(t *testing.T) {
+	w := httptest.NewRecorder()
+	ImportScores(w, importRequest(t, adminUser, "history.csv",
+		"fismasystemid,datacallid,functionoptionid\nabc,1,1\n2,0,1\n"))
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var res struct {
+		Data struct {
+			Rows []model.ScoreImportRowError `json:"rows"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, []model.ScoreImportRowError{
+		{Row: 2, Field: "fismasystemid", Message: `not a whole number: "abc"`},
+		{Row: 3, Field: "datacallid", Message: "required"},
+	}, res.Data.Rows)
+}
@@ -2,10 +2,13 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 
+	"github.com/CMS-Enterprise/ztmf/backend/cmd/api/internal/spreadsheet"
 	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
 	"github.com/gorilla/mux"
 )
@@ -217,14 +220,166 @@ func ConfirmScore(w http.ResponseWriter, r *http.Request) {
 	respondOK(w, confirmed)
 }
 
+// guardScoreReview authorizes approving or returning an answer: an ISSM
+// assigned to the system, or an admin who manages its OpDiv. ISSOs and
+// delegates answer and submit but do not review, and read-only admins never
+// write.
+func guardScoreReview(ctx context.Context, user *model.User, fismaSystemID int32) error {
+	if user.IsReadOnlyAdmin() {
+		return ErrForbidden
+	}
+
+	if user.IsAdmin() {
+		_, err := guardManageFismaSystem(ctx, user, fismaSystemID)
+		return err
+	}
+
+	if user.Role != "ISSM" || !user.IsAssignedFismaSystem(fismaSystemID) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// reviewScore runs one review transition on the path's score. Like
+// ConfirmScore it loads the row first and authorizes against its own
+// fismasystemid; submit is authorized as an answer write, approve and return
+// as a review.
+func reviewScore(w http.ResponseWriter, r *http.Request, action string) {
+	user := model.UserFromContext(r.Context())
+
+	// Role-only rejection before any DB access, as in ConfirmScore.
+	if user.IsReadOnlyAdmin() {
+		respond(w, r, nil, ErrForbidden)
+		return
+	}
+
+	input := model.ScoreReviewInput{}
+	// The body is optional; only a return carries one. io.EOF is the empty-body
+	// signal from the JSON decoder.
+	if err := getJSON(r.Body, &input); err != nil && !errors.Is(err, io.EOF) {
+		log.Println(err)
+		respond(w, r, nil, ErrMalformed)
+		return
+	}
+
+	var scoreID int32
+	fmt.Sscan(mux.Vars(r)["scoreid"], &scoreID)
+
+	score, err := model.FindScoreByID(r.Context(), scoreID)
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	guard := guardScoreReview
+	if action == model.ScoreReviewSubmit {
+		guard = guardScoreWrite
+	}
+	if err := guard(r.Context(), user, score.FismaSystemID); err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	reviewed, err := score.Review(r.Context(), action, input)
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+
+	respondOK(w, reviewed)
+}
+
+// SubmitScore puts a saved answer up for review.
+//
+//	@Summary		Submit a score for review
+//	@Description	Moves a score from done to submitted. Authorized like saving the score, and subject to the data call's deadline in the same way. A returned score is saved or confirmed back to done before it is submitted again.
+//	@Tags			scores
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			scoreid	path		int	true	"Score ID"
+//	@Success		200		{object}	apiResponse[model.Score]
+//	@Failure		400		{object}	apiResponse[any]	"data.status when the score is not done"
+//	@Failure		403		{object}	apiResponse[any]
+//	@Failure		404		{object}	apiResponse[any]
+//	@Failure		500		{object}	apiResponse[any]
+//	@Router			/scores/{scoreid}/submit [put]
+func SubmitScore(w http.ResponseWriter, r *http.Request) {
+	reviewScore(w, r, model.ScoreReviewSubmit)
+}
+
+// ApproveScore accepts a submitted answer.
+//
+//	@Summary		Approve a submitted score
+//	@Description	Moves a score from submitted to approved. Restricted to an ISSM assigned to the system or an admin who manages its OpDiv; read-only admins, ISSOs and system delegates are forbidden.
+//	@Tags			scores
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			scoreid	path		int	true	"Score ID"
+//	@Success		200		{object}	apiResponse[model.Score]
+//	@Failure		400		{object}	apiResponse[any]	"data.status when the score is not submitted"
+//	@Failure		403		{object}	apiResponse[any]
+//	@Failure		404		{object}	apiResponse[any]
+//	@Failure		500		{object}	apiResponse[any]
+//	@Router			/scores/{scoreid}/approve [put]
+func ApproveScore(w http.ResponseWriter, r *http.Request) {
+	reviewScore(w, r, model.ScoreReviewApprove)
+}
+
+// ReturnScore sends a submitted or approved answer back for revision.
+//
+//	@Summary		Return a score for revision
+//	@Description	Moves a submitted or approved score to returned_for_revision with the reviewer's reason, which is shown on the score as reviewnote until it moves on. Authorized like approving.
+//	@Tags			scores
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			scoreid	path		int						true	"Score ID"
+//	@Param			review	body		model.ScoreReviewInput	true	"Reason for the return"
+//	@Success		200		{object}	apiResponse[model.Score]
+//	@Failure		400		{object}	apiResponse[any]
+//	@Failure		403		{object}	apiResponse[any]
+//	@Failure		404		{object}	apiResponse[any]
+//	@Failure		500		{object}	apiResponse[any]
+//	@Router			/scores/{scoreid}/return [put]
+func ReturnScore(w http.ResponseWriter, r *http.Request) {
+	reviewScore(w, r, model.ScoreReviewReturn)
+}
+
+//	@Summary		Get the revision history of a score
+//	@Description	Returns the score and every revision recorded for it, oldest first: the option, notes and status each write or review left, with the reviewer's note on a return, who made it and when, and the fields that changed from the previous revision. Scoped like ListScores; a score outside the caller's scope is 404.
+//	@Tags			scores
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			scoreid	path		int	true	"Score ID"
+//	@Success		200		{object}	apiResponse[model.ScoreHistory]
+//	@Failure		404		{object}	apiResponse[any]
+//	@Failure		500		{object}	apiResponse[any]
+//	@Router			/scores/{scoreid}/history [get]
+func GetScoreHistory(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+	input := model.FindScoresInput{}
+
+	var scoreID int32
+	fmt.Sscan(mux.Vars(r)["scoreid"], &scoreID)
+	input.ScoreID = &scoreID
+
+	if input.ApplyTier(user) {
+		input.UserID = user.UserIDPtr()
+	}
+
+	history, err := model.FindScoreHistory(r.Context(), input)
+	respond(w, r, history, err)
+}
+
 //	@Summary	Diff scores between two data calls
 //	@Description	Compares the score (functionoption) answers of two data calls and returns only the questionnaire functions whose answer changed, each annotated with who made the later change and when. Scoped to the caller's tier: unscoped admins see all systems, OpDiv-scoped admins their OpDivs' systems, and ISSO/ISSM their assigned systems.
 //	@Tags		scores
-//	@Produce	json
+//	@Produce	json,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,text/csv
 //	@Security	bearerAuth
-//	@Param		from			query		int	true	"Data call ID to compare from (earlier cycle)"
-//	@Param		to				query		int	true	"Data call ID to compare to (later cycle)"
-//	@Param		fismasystemid	query		int	false	"Limit the diff to a single FISMA system"
+//	@Param		from			query		int		true	"Data call ID to compare from (earlier cycle)"
+//	@Param		to				query		int		true	"Data call ID to compare to (later cycle)"
+//	@Param		fismasystemid	query		int		false	"Limit the diff to a single FISMA system"
+//	@Param		format			query		string	false	"xlsx or csv to download the diff as a file, each row labelled with its system's acronym, OpDiv and ISSO; JSON when omitted"
 //	@Success	200				{object}	apiResponse[[]model.ScoreDiff]
 //	@Failure	400				{object}	apiResponse[any]
 //	@Failure	500				{object}	apiResponse[any]
@@ -246,20 +401,37 @@ func GetScoresDiff(w http.ResponseWriter, r *http.Request) {
 		input.UserID = user.UserIDPtr()
 	}
 
+	if err == nil {
+		err = model.ValidateReportFormat(input.Format)
+	}
+
 	if err == nil {
 		diffs, err = model.FindScoreDiff(r.Context(), input)
 	}
 
+	if err == nil && input.Format != nil {
+		ids := make([]int32, 0, len(diffs))
+		for _, d := range diffs {
+			ids = append(ids, d.FismaSystemID)
+		}
+		filename := fmt.Sprintf("score-diff-%d-to-%d", *input.FromDataCallID, *input.ToDataCallID)
+		respondReport(w, r, *input.Format, filename, ids, func(systems map[int32]*model.ReportSystem) *spreadsheet.Report {
+			return spreadsheet.ScoreDiffReport(diffs, systems)
+		})
+		return
+	}
+
 	respond(w, r, diffs, err)
 }
 
 //	@Summary		Get per-system questionnaire progress for a data call
-//	@Description	Returns, for each FISMA system the caller can see, how many questionnaire functions apply to the system, how many have been genuinely updated in the given data call (answers pre-populated from the previous cycle do not count until touched), and when the most recent update happened. Scoped to the caller's tier: unscoped admins see all systems, OpDiv-scoped admins their OpDivs' systems, and ISSO/ISSM their assigned systems.
+//	@Description	Returns, for each FISMA system the caller can see, how many questionnaire functions apply to the system, how many have been genuinely updated in the given data call (answers pre-populated from the previous cycle do not count until touched), when the most recent update happened, and the deadline the system is held to (the data call's, or later where an extension for the system or its OpDiv applies). Scoped to the caller's tier: unscoped admins see all systems, OpDiv-scoped admins their OpDivs' systems, and ISSO/ISSM their assigned systems.
 //	@Tags		scores
-//	@Produce	json
+//	@Produce	json,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,text/csv
 //	@Security	bearerAuth
-//	@Param		datacallid		query		int	true	"Data call ID to report progress for"
-//	@Param		fismasystemid	query		int	false	"Limit progress to a single FISMA system"
+//	@Param		datacallid		query		int		true	"Data call ID to report progress for"
+//	@Param		fismasystemid	query		int		false	"Limit progress to a single FISMA system"
+//	@Param		format			query		string	false	"xlsx or csv to download the report as a file, each row labelled with its system's acronym, OpDiv and ISSO; JSON when omitted"
 //	@Success	200				{object}	apiResponse[[]model.ScoreProgress]
 //	@Failure	400				{object}	apiResponse[any]
 //	@Failure	500				{object}	apiResponse[any]
@@ -277,13 +449,143 @@ func GetScoresProgress(w http.ResponseWriter, r *http.Request) {
 
 	scopeScoreProgressInput(user, &input)
 
+	if err == nil {
+		err = model.ValidateReportFormat(input.Format)
+	}
+
 	if err == nil {
 		progress, err = model.FindScoreProgress(r.Context(), input)
 	}
 
+	if err == nil && input.Format != nil {
+		ids := make([]int32, 0, len(progress))
+		for _, p := range progress {
+			ids = append(ids, p.FismaSystemID)
+		}
+		filename := fmt.Sprintf("score-progress-datacall-%d", *input.DataCallID)
+		respondReport(w, r, *input.Format, filename, ids, func(systems map[int32]*model.ReportSystem) *spreadsheet.Report {
+			return spreadsheet.ScoreProgressReport(progress, systems)
+		})
+		return
+	}
+
 	respond(w, r, progress, err)
 }
 
+//	@Summary		Get per-system gaps to target maturity
+//	@Description	Returns, for each FISMA system the caller can see, its current tier and score in a data call against its target maturity tier (Advanced where the system has not set one), the gap in tiers and in score points for the system and each pillar, and for each pillar short of target the lowest-scoring functions driving the gap. Defaults to the latest data call. Scoped to the caller's tier as /scores/progress is.
+//	@Tags			scores
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			datacallid		query		int	false	"Data call ID (defaults to the latest)"
+//	@Param			fismasystemid	query		int	false	"Limit to a single FISMA system"
+//	@Param			opdiv_id		query		int	false	"Limit to one OpDiv's systems"
+//	@Param			drivers			query		int	false	"Lowest-scoring functions to list per pillar (1-20, default 3)"
+//	@Success		200				{object}	apiResponse[[]model.ScoreGap]
+//	@Failure		400				{object}	apiResponse[any]
+//	@Failure		500				{object}	apiResponse[any]
+//	@Router			/scores/gaps [get]
+func GetScoresGaps(w http.ResponseWriter, r *http.Request) {
+	var (
+		gaps []*model.ScoreGap
+		err  error
+	)
+
+	user := model.UserFromContext(r.Context())
+	input := model.FindScoreGapsInput{}
+
+	err = decoder.Decode(&input, r.URL.Query())
+
+	// opdiv_id only narrows within the caller's scope; the tier still decides
+	// which systems can be seen at all.
+	if input.ApplyTier(user) {
+		input.UserID = user.UserIDPtr()
+	}
+
+	if err == nil {
+		gaps, err = model.FindScoreGaps(r.Context(), input)
+	}
+
+	respond(w, r, gaps, err)
+}
+
+//	@Summary		Get a score trend across data calls
+//	@Description	Returns the system and pillar scores of one FISMA system, or of one OpDiv's systems averaged as the opdiv rollup averages them, for every data call from one deadline to another. Each point notes a tier change against the last scored cycle and marks cycles with no scores as missing. Scoped to the caller's tier as GET /scores is; an OpDiv series needs an admin tier.
+//	@Tags			scores
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			fismasystemid	query		int	false	"FISMA system to chart (this or opdiv_id)"
+//	@Param			opdiv_id		query		int	false	"OpDiv to chart (this or fismasystemid)"
+//	@Param			from			query		int	false	"First data call of the range"
+//	@Param			to				query		int	false	"Last data call of the range"
+//	@Success		200				{object}	apiResponse[model.ScoreTrend]
+//	@Failure		400				{object}	apiResponse[any]
+//	@Failure		403				{object}	apiResponse[any]
+//	@Failure		500				{object}	apiResponse[any]
+//	@Router			/scores/trend [get]
+func GetScoresTrend(w http.ResponseWriter, r *http.Request) {
+	var (
+		trend *model.ScoreTrend
+		err   error
+	)
+
+	user := model.UserFromContext(r.Context())
+	input := model.FindScoreTrendInput{}
+
+	err = decoder.Decode(&input, r.URL.Query())
+
+	if input.ApplyTier(user) {
+		input.UserID = user.UserIDPtr()
+	}
+
+	// An OpDiv series is the opdiv rollup over time, so it is gated as the
+	// rollup is.
+	if err == nil && input.OpDivID != nil {
+		err = guardScoreRollup(user, model.ScoreGroupOpDiv)
+	}
+
+	if err == nil {
+		trend, err = model.FindScoreTrend(r.Context(), input)
+	}
+
+	respond(w, r, trend, err)
+}
+
+//	@Summary		Simulate scores with hypothetical answers
+//	@Description	Projects a FISMA system's pillar and system scores and tiers in a data call as they would be if the given function options replaced its saved answers, alongside the scores as saved. Uses the same pillar math as /scores/aggregate, including reduced pillar scope and pillar weights. Writes nothing. Scoped to the caller's tier as GET /scores is.
+//	@Tags			scores
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		model.ScoreSimulationInput	true	"System, data call and overrides"
+//	@Success		200		{object}	apiResponse[model.ScoreSimulation]
+//	@Failure		400		{object}	apiResponse[any]
+//	@Failure		404		{object}	apiResponse[any]
+//	@Failure		500		{object}	apiResponse[any]
+//	@Router			/scores/simulate [post]
+func SimulateScores(w http.ResponseWriter, r *http.Request) {
+	user := model.UserFromContext(r.Context())
+
+	input := model.ScoreSimulationInput{}
+	if err := getJSON(r.Body, &input); err != nil {
+		log.Println(err)
+		respond(w, r, nil, ErrMalformed)
+		return
+	}
+
+	if input.ApplyTier(user) {
+		input.UserID = user.UserIDPtr()
+	}
+
+	simulation, err := model.SimulateScores(r.Context(), input)
+	if err != nil {
+		respond(w, r, nil, err)
+		return
+	}
+	// A POST here creates nothing, so 200 rather than respond's 201.
+	respondOK(w, simulation)
+}
+
 // scopeScoreProgressInput applies the caller's tier to the query input. Same
 // tier scoping as ListScores, applied AFTER decode so a client cannot widen
 // scope via query params: unscoped admins see all; OPDIV tiers fail-closed to
@@ -295,16 +597,20 @@ func scopeScoreProgressInput(user *model.User, input *model.FindScoreProgressInp
 	}
 }
 
-//	@Summary	Get aggregated scores
-//	@Tags		scores
-//	@Produce	json
-//	@Security	bearerAuth
-//	@Param		fismasystemid	query		int		false	"Filter by FISMA system ID"
-//	@Param		datacallid		query		int		false	"Filter by data call ID"
-//	@Param		include_pillars	query		bool	false	"Include per-pillar scores"
-//	@Success	200				{object}	apiResponse[[]model.ScoreAggregate]
-//	@Failure	500				{object}	apiResponse[any]
-//	@Router		/scores/aggregate [get]
+//	@Summary		Get aggregated scores
+//	@Description	One aggregate per data call and system. With group_by, one rollup per data call and group instead (model.ScoreRollup): the mean system and pillar scores of the group's systems, with system counts. Rollups are for admin tiers, and an OpDiv-scoped admin's cover only their OpDivs; group_by=hhs needs HHS-wide read.
+//	@Tags			scores
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			fismasystemid	query		int		false	"Filter by FISMA system ID"
+//	@Param			datacallid		query		int		false	"Filter by data call ID"
+//	@Param			include_pillars	query		bool	false	"Include per-pillar scores"
+//	@Param			group_by		query		string	false	"Roll systems up by group"	Enums(opdiv, hhs, datacenter_category)
+//	@Success		200				{object}	apiResponse[[]model.ScoreAggregate]
+//	@Failure		400				{object}	apiResponse[any]
+//	@Failure		403				{object}	apiResponse[any]
+//	@Failure		500				{object}	apiResponse[any]
+//	@Router			/scores/aggregate [get]
 func GetScoresAggregate(w http.ResponseWriter, r *http.Request) {
 	var (
 		aggregate []*model.ScoreAggregate
@@ -316,10 +622,17 @@ func GetScoresAggregate(w http.ResponseWriter, r *http.Request) {
 
 	err = decoder.Decode(&findScoresInput, r.URL.Query())
 
-	// Same tier scoping as ListScores, applied AFTER decode. This endpoint's
-	// self-scope default is the assigned-systems list, not UserID.
-	if findScoresInput.ApplyTier(user) {
-		findScoresInput.FismaSystemIDs = user.AssignedFismaSystems
+	// AFTER decode, so nothing in the query can widen it.
+	scopeScoresAggregateInput(user, &findScoresInput)
+
+	if err == nil && findScoresInput.GroupBy != nil {
+		if err := guardScoreRollup(user, *findScoresInput.GroupBy); err != nil {
+			respond(w, r, nil, err)
+			return
+		}
+		rollups, err := model.FindScoresRollup(r.Context(), findScoresInput)
+		respond(w, r, rollups, err)
+		return
 	}
 
 	if err == nil {
@@ -328,3 +641,26 @@ func GetScoresAggregate(w http.ResponseWriter, r *http.Request) {
 
 	respond(w, r, aggregate, err)
 }
+
+// scopeScoresAggregateInput applies the same tier scoping as ListScores. This
+// endpoint's self-scope default is the assigned-systems list, not UserID. The
+// group rollups take no scope of their own; FindScoresRollup inherits this one.
+func scopeScoresAggregateInput(user *model.User, input *model.FindScoresInput) {
+	if input.ApplyTier(user) {
+		input.FismaSystemIDs = user.AssignedFismaSystems
+	}
+}
+
+// guardScoreRollup limits rollups to admin tiers. A rollup over a handful of
+// assigned systems would read as an OpDiv or department figure it is not, and
+// for the same reason only an HHS-wide reader may ask for the department one:
+// an OpDiv-scoped admin's "HHS" would be their own OpDivs under another name.
+func guardScoreRollup(user *model.User, groupBy string) error {
+	if !user.HasAdminRead() {
+		return ErrForbidden
+	}
+	if groupBy == model.ScoreGroupHHS && !user.HasUnscopedRead() {
+		return ErrForbidden
+	}
+	return nil
+}
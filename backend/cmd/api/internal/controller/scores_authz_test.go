@@ -4,10 +4,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestFindScoresInput_QueryCannotWiden pins that the scores list endpoints'
@@ -19,7 +21,7 @@ import (
 // value in place, so the tag is what keeps a client-supplied value out of the
 // query in the first place.
 func TestFindScoresInput_QueryCannotWiden(t *testing.T) {
-	for _, q := range []string{"UserID", "FismaSystemIDs"} {
+	for _, q := range []string{"UserID", "FismaSystemIDs", "ScoreID"} {
 		t.Run(q, func(t *testing.T) {
 			input := model.FindScoresInput{}
 			err := decoder.Decode(&input, url.Values{q: {"1"}})
@@ -71,3 +73,92 @@ func TestListScores_ScopeFieldsRejectedFromQuery(t *testing.T) {
 		})
 	}
 }
+
+// TestGetScoresAggregate_RollupTierGate pins who may ask for group rollups. The
+// gate runs before any query, so the forbidden cases need no database.
+func TestGetScoresAggregate_RollupTierGate(t *testing.T) {
+	cases := []struct {
+		user    *model.User
+		groupBy string
+	}{
+		{issoUser, "opdiv"},
+		{issoUser, "hhs"},
+		{opdivAdmin, "hhs"},
+		{opdivReadonly, "hhs"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.user.Role+"/"+tc.groupBy, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/scores/aggregate?group_by="+tc.groupBy, nil)
+			r = withUser(r, tc.user)
+			w := httptest.NewRecorder()
+
+			GetScoresAggregate(w, r)
+
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
+
+// TestScopeScoresAggregateInput_RollupScope pins the scope a group rollup
+// inherits: an OpDiv-scoped admin's group_by=opdiv input is restricted to
+// their OpDivs, an OpDiv admin with no grants fails closed, and an unscoped
+// admin is not restricted.
+func TestScopeScoresAggregateInput_RollupScope(t *testing.T) {
+	for _, user := range []*model.User{opdivAdmin, opdivReadonly} {
+		t.Run(user.Role, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/scores/aggregate?group_by=opdiv", nil)
+			input := model.FindScoresInput{}
+			require.NoError(t, decoder.Decode(&input, r.URL.Query()))
+
+			scopeScoresAggregateInput(user, &input)
+
+			_, grants := user.EffectiveOpDivScope()
+			assert.True(t, input.RestrictToOpDivIDs)
+			assert.Equal(t, grants, input.OpDivIDs)
+			assert.Empty(t, input.FismaSystemIDs, "the OpDiv tier scopes by OpDiv, not by assignment")
+		})
+	}
+
+	t.Run("NoGrants", func(t *testing.T) {
+		input := model.FindScoresInput{}
+		scopeScoresAggregateInput(&model.User{Role: "OPDIV_ADMIN"}, &input)
+		assert.True(t, input.RestrictToOpDivIDs)
+		assert.Empty(t, input.OpDivIDs)
+	})
+
+	t.Run("Unscoped", func(t *testing.T) {
+		input := model.FindScoresInput{}
+		scopeScoresAggregateInput(readonlyAdmin, &input)
+		assert.False(t, input.RestrictToOpDivIDs)
+		assert.Empty(t, input.OpDivIDs)
+	})
+}
+
+func TestGetScoresTrend_OpDivTierGate(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/scores/trend?opdiv_id=1", nil)
+	r = withUser(r, issoUser)
+	w := httptest.NewRecorder()
+
+	GetScoresTrend(w, r)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// TestSimulateScores_ScopeNotBindable pins that the simulation's scope fields
+// are server-owned: naming them in the body is an unknown field (400), not a
+// way for an ISSO to simulate a system outside their assignments.
+func TestSimulateScores_ScopeNotBindable(t *testing.T) {
+	for _, body := range []string{
+		`{"fismasystemid":1001,"datacallid":3,"UserID":"someone-else"}`,
+		`{"fismasystemid":1001,"datacallid":3,"OpDivIDs":[1]}`,
+		`{"fismasystemid":1001,"datacallid":3,"RestrictToOpDivIDs":false}`,
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/scores/simulate", strings.NewReader(body))
+		r = withUser(r, issoUser)
+		w := httptest.NewRecorder()
+
+		SimulateScores(w, r)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
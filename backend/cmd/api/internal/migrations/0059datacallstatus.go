@@ -0,0 +1,50 @@
+package migrations
+
+func init() {
+	appendMigration(
+		"data call lifecycle status with scheduled opening",
+		`
+-- A data call used to be live the moment it was created: DataCall.Save ran the
+-- rollover immediately and the row became "latest" for every ISSO. status makes
+-- the lifecycle explicit so a cycle can be staged before anyone sees it:
+--   draft    - staged by an admin; hidden from non-admin pickers, no answers
+--              accepted, no rollover yet
+--   open     - answers accepted (until the deadline for non-admins); the
+--              rollover runs on the first transition into this state
+--   closed   - explicitly closed; only admins may still correct answers
+--   archived - read-only for everyone and hidden from pickers by default
+--
+-- varchar + CHECK rather than a native ENUM, matching 0046 and 0048.
+--
+-- DEFAULT 'open' rather than 'draft' is deliberate: every existing row, and
+-- every row the seed data and out-of-band loads insert with raw SQL, is a call
+-- that went live at creation. The application's create path always names the
+-- status it wants, so the default only ever describes those rows.
+--
+-- opens_at schedules the draft -> open transition; opened_at records when it
+-- actually happened and is what tells a first open (run the rollover) from a
+-- reopen (do not - ztmf#411's duplicate-copy hazard). Existing rows were opened
+-- at creation, so they are backfilled from datecreated.
+ALTER TABLE public.datacalls
+  ADD COLUMN IF NOT EXISTS status varchar(16) NOT NULL DEFAULT 'open'
+    CONSTRAINT datacalls_status_check CHECK (status IN ('draft', 'open', 'closed', 'archived')),
+  ADD COLUMN IF NOT EXISTS opens_at timestamptz,
+  ADD COLUMN IF NOT EXISTS opened_at timestamptz,
+  ADD COLUMN IF NOT EXISTS closed_at timestamptz;
+
+UPDATE public.datacalls SET opened_at = datecreated WHERE opened_at IS NULL;
+
+-- Serves the scheduler's "drafts that are due" probe, which runs every minute.
+CREATE INDEX IF NOT EXISTS datacalls_scheduled_open_idx
+    ON public.datacalls (opens_at) WHERE status = 'draft';
+`,
+		`
+DROP INDEX IF EXISTS public.datacalls_scheduled_open_idx;
+ALTER TABLE public.datacalls
+  DROP COLUMN IF EXISTS closed_at,
+  DROP COLUMN IF EXISTS opened_at,
+  DROP COLUMN IF EXISTS opens_at,
+  DROP COLUMN IF EXISTS status;
+`,
+	)
+}
@@ -0,0 +1,43 @@
+package migrations
+
+func init() {
+	appendMigration(
+		"create deadlineextensions for per-opdiv and per-system data call deadlines",
+		`
+-- A negotiated extension of one data call's deadline for either a whole OpDiv
+-- or a single FISMA system. The effective deadline for a system is the latest
+-- of the call's own deadline, its OpDiv's extension and its own extension
+-- (EffectiveDeadlineSQL in internal/model/deadlineextensions.go), so an
+-- extension can only ever give more time, and moving the call's deadline past
+-- an extension quietly supersedes it rather than cutting anyone short.
+--
+-- Exactly one of opdiv_id / fismasystemid is set; the partial unique indexes
+-- allow one live extension per (call, opdiv) and per (call, system), so
+-- re-granting is an update of the row rather than a second, ambiguous one.
+--
+-- granted_by is NOT NULL (unlike users_opdivs, which has seeded grants): every
+-- extension is a human decision that auditors will ask about. RESTRICT rather
+-- than SET NULL because users are soft-deleted, so the FK never fires in
+-- practice and a hard delete should not erase who granted the time.
+CREATE TABLE IF NOT EXISTS public.deadlineextensions (
+    extensionid   SERIAL PRIMARY KEY,
+    datacallid    INTEGER NOT NULL REFERENCES public.datacalls(datacallid) ON DELETE CASCADE,
+    opdiv_id      INTEGER REFERENCES public.opdivs(opdiv_id) ON DELETE CASCADE,
+    fismasystemid INTEGER REFERENCES public.fismasystems(fismasystemid) ON DELETE CASCADE,
+    expires_at    TIMESTAMP WITH TIME ZONE NOT NULL,
+    reason        TEXT NOT NULL CHECK (length(trim(reason)) > 0),
+    granted_by    UUID NOT NULL REFERENCES public.users(userid) ON DELETE RESTRICT,
+    granted_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT deadlineextensions_target_check CHECK (num_nonnulls(opdiv_id, fismasystemid) = 1)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS deadlineextensions_opdiv_key
+    ON public.deadlineextensions (datacallid, opdiv_id) WHERE opdiv_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS deadlineextensions_fismasystem_key
+    ON public.deadlineextensions (datacallid, fismasystemid) WHERE fismasystemid IS NOT NULL;
+`,
+		`
+DROP TABLE IF EXISTS public.deadlineextensions;
+`,
+	)
+}
@@ -0,0 +1,50 @@
+package migrations
+
+func init() {
+	appendMigration(
+		"create rolloverrules for per-opdiv and per-scoring-key rollover sources",
+		`
+-- Which cycle a new data call's answers roll forward from, as data (replaces
+-- the ztmf#500 FY2026 hardcode in scores.go). A row means: when target_datacallid
+-- first opens, the systems the row matches take their carried-over answers from
+-- source_datacallid and from nowhere else. Matching, most specific first
+-- (rolloverAssignmentSQL in internal/model/rolloverrules.go):
+--   opdiv_id set     - systems in that OpDiv
+--   scoring_key set  - systems scored under that key
+--   neither set      - every system no other rule for the target matched
+-- A system no rule matches rolls forward from the previous cycle exactly as
+-- if the target had no rules at all.
+--
+-- Rules key on the TARGET's id, so they can only be written once the target
+-- exists: create the cycle as a draft, add its rules, then open it. The FY2026
+-- hardcode is not seeded for that reason - its target did not exist when this
+-- ran. To reproduce it on a FY2026 draft: opdiv CMS -> "FY2025 Q3", and a
+-- default rule -> "FY25 ZTM".
+--
+-- scoring_key is TEXT without an FK, matching reducedpillarscopes: the
+-- vocabulary lives in datacenterenvironments.scoring_key, which is not unique.
+-- Both datacall FKs cascade: a rule without either end is meaningless.
+CREATE TABLE IF NOT EXISTS public.rolloverrules (
+    ruleid            SERIAL PRIMARY KEY,
+    target_datacallid INTEGER NOT NULL REFERENCES public.datacalls(datacallid) ON DELETE CASCADE,
+    opdiv_id          INTEGER REFERENCES public.opdivs(opdiv_id) ON DELETE CASCADE,
+    scoring_key       TEXT,
+    source_datacallid INTEGER NOT NULL REFERENCES public.datacalls(datacallid) ON DELETE CASCADE,
+    CONSTRAINT rolloverrules_key_check CHECK (num_nonnulls(opdiv_id, scoring_key) <= 1),
+    CONSTRAINT rolloverrules_not_self_check CHECK (source_datacallid <> target_datacallid)
+);
+
+-- One rule per (target, key), and one default per target, so a system can
+-- never match two rules of the same kind.
+CREATE UNIQUE INDEX IF NOT EXISTS rolloverrules_opdiv_key
+    ON public.rolloverrules (target_datacallid, opdiv_id) WHERE opdiv_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS rolloverrules_scoring_key
+    ON public.rolloverrules (target_datacallid, scoring_key) WHERE scoring_key IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS rolloverrules_default_key
+    ON public.rolloverrules (target_datacallid) WHERE opdiv_id IS NULL AND scoring_key IS NULL;
+`,
+		`
+DROP TABLE IF EXISTS public.rolloverrules;
+`,
+	)
+}
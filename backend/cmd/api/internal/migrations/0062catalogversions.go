@@ -0,0 +1,102 @@
+package migrations
+
+// snapshotCatalogSQL captures the live questions and functions as a new catalog
+// version and pins every data call to it. The migration below runs it once,
+// for the calls that predate versioning; populate runs it again after the seed
+// script, whose catalog and data calls both arrive after the migrations and
+// would otherwise be pinned to the empty catalog the migration found.
+const snapshotCatalogSQL = `
+WITH v AS (
+    INSERT INTO public.catalogversions (note) VALUES ('baseline')
+    RETURNING catalogversionid
+),
+q AS (
+    INSERT INTO public.catalogquestions (catalogversionid, questionid, question, notesprompt, ordr, pillarid)
+    SELECT v.catalogversionid, questionid, question, notesprompt, ordr, pillarid
+      FROM public.questions, v
+),
+f AS (
+    INSERT INTO public.catalogfunctions (catalogversionid, functionid, function, description, datacenterenvironment, ordr, questionid, pillarid)
+    SELECT v.catalogversionid, functionid, function, description, datacenterenvironment, ordr, questionid, pillarid
+      FROM public.functions, v
+)
+UPDATE public.datacalls SET catalogversionid = v.catalogversionid FROM v;
+`
+
+func init() {
+	appendMigration(
+		"create catalogversions and pin a questionnaire catalog version on each data call",
+		`
+-- questions and functions are edited in place, so until now a reworded
+-- question or a function moved to another pillar silently rewrote how every
+-- past cycle read in the export, the score diff and the aggregate. A catalog
+-- version is an immutable copy of both tables; each data call pins one, and
+-- the historical readers join the pinned copy instead of the live tables.
+--
+-- The live tables stay the working copy the admin pages edit (ids, and the
+-- functionoptions and scores FKs onto them, are unchanged). Every edit
+-- publishes a new version in the same transaction and re-pins the draft and
+-- open calls to it (publishCatalogVersion in internal/model/catalogversions.go),
+-- so a cycle follows the live questionnaire while it is being answered and
+-- keeps the version it had when it closed.
+--
+-- There is no record of earlier wording, so every existing call is pinned to
+-- the catalog as it stands now. functionoptions is not versioned: answers
+-- point at option ids, and their labels have not been edited per cycle.
+--
+-- No FKs from the copies to the live tables: a version outlives the rows it
+-- copied.
+CREATE TABLE IF NOT EXISTS public.catalogversions (
+    catalogversionid SERIAL PRIMARY KEY,
+    createdat        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    note             TEXT
+);
+
+CREATE TABLE IF NOT EXISTS public.catalogquestions (
+    catalogversionid INTEGER NOT NULL REFERENCES public.catalogversions(catalogversionid),
+    questionid       INTEGER NOT NULL,
+    question         VARCHAR(1000) NOT NULL,
+    notesprompt      VARCHAR(1000) NOT NULL,
+    ordr             SMALLINT,
+    pillarid         INTEGER NOT NULL,
+    PRIMARY KEY (catalogversionid, questionid)
+);
+
+CREATE TABLE IF NOT EXISTS public.catalogfunctions (
+    catalogversionid      INTEGER NOT NULL REFERENCES public.catalogversions(catalogversionid),
+    functionid            INTEGER NOT NULL,
+    function              VARCHAR(255),
+    description           VARCHAR(1024),
+    datacenterenvironment VARCHAR(255),
+    ordr                  SMALLINT,
+    questionid            INTEGER,
+    pillarid              INTEGER NOT NULL,
+    PRIMARY KEY (catalogversionid, functionid)
+);
+
+-- The applicability joins read a version's functions by environment.
+CREATE INDEX IF NOT EXISTS catalogfunctions_environment_idx
+    ON public.catalogfunctions (catalogversionid, datacenterenvironment);
+
+-- A new call pins the newest version without the writer having to ask, which
+-- keeps raw INSERTs (seed data, integration fixtures) pinned as well.
+CREATE OR REPLACE FUNCTION public.current_catalogversion() RETURNS INTEGER
+    LANGUAGE sql STABLE
+    AS $$ SELECT MAX(catalogversionid) FROM public.catalogversions $$;
+
+ALTER TABLE public.datacalls
+  ADD COLUMN IF NOT EXISTS catalogversionid INTEGER REFERENCES public.catalogversions(catalogversionid);
+`+snapshotCatalogSQL+`
+ALTER TABLE public.datacalls
+  ALTER COLUMN catalogversionid SET DEFAULT public.current_catalogversion(),
+  ALTER COLUMN catalogversionid SET NOT NULL;
+`,
+		`
+ALTER TABLE public.datacalls DROP COLUMN IF EXISTS catalogversionid;
+DROP FUNCTION IF EXISTS public.current_catalogversion();
+DROP TABLE IF EXISTS public.catalogfunctions;
+DROP TABLE IF EXISTS public.catalogquestions;
+DROP TABLE IF EXISTS public.catalogversions;
+`,
+	)
+}
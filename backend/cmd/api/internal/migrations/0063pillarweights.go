@@ -0,0 +1,27 @@
+package migrations
+
+func init() {
+	appendMigration(
+		"create pillarweights for per-data-call system score weighting",
+		`
+-- How much each pillar counts toward a system score, per data call. A call
+-- with no rows scores as it always has, the plain average of pillar scores;
+-- a call with rows takes the weighted average (buildPillarScoresSQL in
+-- internal/model/scores.go). Nothing is seeded, so every existing cycle keeps
+-- its equal weighting.
+--
+-- Weights are relative, not percentages: a reduced-scope system divides by
+-- the weights of the pillars it is scored on, as the equal average divides by
+-- their count. A pillar added after a call's weights were set weighs 1.
+CREATE TABLE IF NOT EXISTS public.pillarweights (
+    datacallid INTEGER NOT NULL REFERENCES public.datacalls(datacallid) ON DELETE CASCADE,
+    pillarid   INTEGER NOT NULL REFERENCES public.pillars(pillarid),
+    weight     NUMERIC(6,3) NOT NULL CHECK (weight > 0),
+    PRIMARY KEY (datacallid, pillarid)
+);
+`,
+		`
+DROP TABLE IF EXISTS public.pillarweights;
+`,
+	)
+}
@@ -0,0 +1,38 @@
+package migrations
+
+func init() {
+	appendMigration(
+		"create scoreattachments for evidence files on score answers",
+		`
+-- Evidence files attached to an answer. The bytes live in the attachment
+-- store (internal/attachments), local disk or S3, under storagekey; this
+-- table is the metadata and the only record of which answers hold a file.
+--
+-- The rollover carries attachments forward by reference: the new cycle's
+-- answer gets its own row naming the same storagekey, with carriedfrom
+-- pointing at the row it was copied from. A stored file is therefore shared
+-- by every row that names it, and is only removed from the store once the
+-- last of them is deleted.
+CREATE TABLE IF NOT EXISTS public.scoreattachments (
+    attachmentid SERIAL PRIMARY KEY,
+    scoreid      INTEGER NOT NULL REFERENCES public.scores(scoreid) ON DELETE CASCADE,
+    filename     VARCHAR(255) NOT NULL,
+    contenttype  VARCHAR(255) NOT NULL,
+    sizebytes    BIGINT NOT NULL CHECK (sizebytes > 0),
+    sha256       CHAR(64) NOT NULL,
+    storagekey   VARCHAR(512) NOT NULL,
+    uploadedby   uuid REFERENCES public.users(userid),
+    createdat    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    carriedfrom  INTEGER REFERENCES public.scoreattachments(attachmentid) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS scoreattachments_scoreid_idx ON public.scoreattachments (scoreid);
+
+-- The last-reference check on delete looks rows up by key.
+CREATE INDEX IF NOT EXISTS scoreattachments_storagekey_idx ON public.scoreattachments (storagekey);
+`,
+		`
+DROP TABLE IF EXISTS public.scoreattachments;
+`,
+	)
+}
@@ -0,0 +1,42 @@
+package migrations
+
+func init() {
+	appendMigration(
+		"add review states and the return reason to scores",
+		`
+-- Review workflow on top of the 0048 answer states. After an answer is saved
+-- or confirmed ('done') it can be submitted for review; an ISSM or an admin
+-- managing the system then approves it or returns it for revision with a
+-- reason:
+--
+--   done -> submitted -> approved
+--                     -> returned_for_revision
+--   approved          -> returned_for_revision
+--
+-- Saving a real change sets 'done' from any state, so an edited answer has to
+-- be submitted again; confirming a returned answer unchanged does the same.
+-- Every transition is recorded in events with its own action.
+ALTER TABLE public.scores
+  DROP CONSTRAINT IF EXISTS scores_status_check;
+ALTER TABLE public.scores
+  ADD CONSTRAINT scores_status_check
+    CHECK (status IN ('not_started', 'done', 'submitted', 'approved', 'returned_for_revision'));
+
+-- The reviewer's reason for the most recent return. Cleared by every other
+-- transition, so it is only ever set on a returned_for_revision row.
+ALTER TABLE public.scores
+  ADD COLUMN IF NOT EXISTS reviewnote TEXT;
+`,
+		`
+UPDATE public.scores
+   SET status = 'done'
+ WHERE status IN ('submitted', 'approved', 'returned_for_revision');
+ALTER TABLE public.scores
+  DROP COLUMN IF EXISTS reviewnote;
+ALTER TABLE public.scores
+  DROP CONSTRAINT IF EXISTS scores_status_check;
+ALTER TABLE public.scores
+  ADD CONSTRAINT scores_status_check CHECK (status IN ('not_started', 'done'));
+`,
+	)
+}
@@ -0,0 +1,42 @@
+package migrations
+
+func init() {
+	appendMigration(
+		"create scorecomments for threaded review comments on answers",
+		`
+-- Reviewer comments on an answer. A comment is anchored to the system,
+-- function and data call rather than to a score row, so a thread can start
+-- before the function is answered and outlives a change of option. A reply
+-- names its parent and shares its anchor; only the top comment of a thread
+-- is resolved.
+--
+-- authorrole is the author's role when they wrote the comment, so a thread
+-- still reads correctly after someone changes roles.
+CREATE TABLE IF NOT EXISTS public.scorecomments (
+    commentid     SERIAL PRIMARY KEY,
+    fismasystemid INTEGER NOT NULL REFERENCES public.fismasystems(fismasystemid),
+    functionid    INTEGER NOT NULL REFERENCES public.functions(functionid),
+    datacallid    INTEGER NOT NULL REFERENCES public.datacalls(datacallid),
+    parentid      INTEGER REFERENCES public.scorecomments(commentid) ON DELETE CASCADE,
+    body          TEXT NOT NULL CHECK (length(body) > 0),
+    authorid      uuid NOT NULL REFERENCES public.users(userid),
+    authorrole    VARCHAR(50) NOT NULL,
+    createdat     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolvedat    TIMESTAMPTZ,
+    resolvedby    uuid REFERENCES public.users(userid),
+    CONSTRAINT scorecomments_resolve_root_check CHECK (parentid IS NULL OR resolvedat IS NULL)
+);
+
+CREATE INDEX IF NOT EXISTS scorecomments_anchor_idx
+    ON public.scorecomments (datacallid, fismasystemid, functionid);
+
+-- Serves the per-system unresolved counts on the progress view.
+CREATE INDEX IF NOT EXISTS scorecomments_unresolved_idx
+    ON public.scorecomments (datacallid, fismasystemid)
+    WHERE parentid IS NULL AND resolvedat IS NULL;
+`,
+		`
+DROP TABLE IF EXISTS public.scorecomments;
+`,
+	)
+}
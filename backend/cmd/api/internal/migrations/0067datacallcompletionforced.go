@@ -0,0 +1,19 @@
+package migrations
+
+func init() {
+	appendMigration(
+		"record forced completions on datacalls_fismasystems",
+		`
+-- A system may only mark a data call complete once every applicable function
+-- has a confirmed answer; an admin can force the mark past that check.
+-- forced records that the mark was made with functions still missing or
+-- unconfirmed, so a forced completion stays distinguishable afterwards.
+ALTER TABLE public.datacalls_fismasystems
+  ADD COLUMN IF NOT EXISTS forced BOOLEAN NOT NULL DEFAULT FALSE;
+`,
+		`
+ALTER TABLE public.datacalls_fismasystems
+  DROP COLUMN IF EXISTS forced;
+`,
+	)
+}
@@ -0,0 +1,69 @@
+package migrations
+
+func init() {
+	appendMigration(
+		"create datacallsnapshots to freeze a data call's scores and answers at close",
+		`
+-- A closed data call's scores are what was reported to HHS, but the live
+-- aggregation reads today's function options, environment mapping, reduced
+-- pillar scopes, pillar weights and system environments. Closing a call now
+-- freezes its results: one datacallsnapshots row per frozen call, with the
+-- per-pillar and system scores as the aggregation computed them and the
+-- answer rows as the export rendered them. Reopening the call discards the
+-- snapshot; the next close takes a new one.
+CREATE TABLE IF NOT EXISTS public.datacallsnapshots (
+    datacallid INTEGER PRIMARY KEY REFERENCES public.datacalls(datacallid) ON DELETE CASCADE,
+    createdat  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    createdby  uuid REFERENCES public.users(userid)
+);
+
+-- One row per (system, pillar): the rows the pillar aggregation returned.
+-- system_score repeats on every pillar row as it does there. fismasystemid
+-- and pillarid carry no foreign keys so later catalog or inventory changes
+-- cannot reach back into a frozen cycle.
+CREATE TABLE IF NOT EXISTS public.snapshotpillarscores (
+    datacallid    INTEGER NOT NULL REFERENCES public.datacallsnapshots(datacallid) ON DELETE CASCADE,
+    fismasystemid INTEGER NOT NULL,
+    pillarid      INTEGER NOT NULL,
+    pillar        TEXT NOT NULL,
+    score         DOUBLE PRECISION NOT NULL,
+    weight        DOUBLE PRECISION NOT NULL,
+    weighted      BOOLEAN NOT NULL,
+    system_score  DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (datacallid, fismasystemid, pillarid)
+);
+
+-- The export's rows, in export order (ordr), denormalized so nothing they
+-- show is read live again.
+CREATE TABLE IF NOT EXISTS public.snapshotanswers (
+    datacallid                    INTEGER NOT NULL REFERENCES public.datacallsnapshots(datacallid) ON DELETE CASCADE,
+    ordr                          INTEGER NOT NULL,
+    datacall                      TEXT NOT NULL,
+    fismasystemid                 INTEGER NOT NULL,
+    fismaacronym                  TEXT NOT NULL,
+    datacenterenvironment         TEXT NOT NULL,
+    pillar                        TEXT NOT NULL,
+    question                      TEXT NOT NULL,
+    function                      TEXT NOT NULL,
+    description                   TEXT NOT NULL,
+    optiondescription             TEXT,
+    optionname                    TEXT,
+    score                         INTEGER,
+    notes                         TEXT,
+    notes_is_ai_summary           BOOLEAN,
+    target_maturity_tier          TEXT,
+    target_maturity_justification TEXT,
+    attachments                   TEXT,
+    PRIMARY KEY (datacallid, ordr)
+);
+
+CREATE INDEX IF NOT EXISTS snapshotanswers_system_idx
+    ON public.snapshotanswers (datacallid, fismasystemid);
+`,
+		`
+DROP TABLE IF EXISTS public.snapshotanswers;
+DROP TABLE IF EXISTS public.snapshotpillarscores;
+DROP TABLE IF EXISTS public.datacallsnapshots;
+`,
+	)
+}
@@ -0,0 +1,37 @@
+package migrations
+
+func init() {
+	appendMigration(
+		"create scoreaggregates to materialize the pillar score query",
+		`
+-- The pillar aggregation enumerates every expected function of every scored
+-- (system, data call) pair on each read, which is slow for the admin
+-- dashboard's many-system, many-call reads. scoreaggregates holds its result,
+-- one row per (data call, system, pillar) in the query's own shape. The
+-- writes that move a score refresh the rows they touch; the API seeds the
+-- table at startup when it is empty, and cmd/scoreaggregates compares it
+-- with the live query. Function options, the environment mapping and the
+-- reduced pillar scopes change only here, in migrations, with no write to
+-- refresh after: a migration that changes them should end with
+-- TRUNCATE public.scoreaggregates so the next startup reseeds.
+CREATE TABLE IF NOT EXISTS public.scoreaggregates (
+    datacallid    INTEGER NOT NULL REFERENCES public.datacalls(datacallid) ON DELETE CASCADE,
+    fismasystemid INTEGER NOT NULL REFERENCES public.fismasystems(fismasystemid) ON DELETE CASCADE,
+    pillarid      INTEGER NOT NULL,
+    pillar        TEXT NOT NULL,
+    score         DOUBLE PRECISION NOT NULL,
+    weight        DOUBLE PRECISION NOT NULL,
+    weighted      BOOLEAN NOT NULL,
+    system_score  DOUBLE PRECISION NOT NULL,
+    refreshedat   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (datacallid, fismasystemid, pillarid)
+);
+
+CREATE INDEX IF NOT EXISTS scoreaggregates_system_idx
+    ON public.scoreaggregates (fismasystemid);
+`,
+		`
+DROP TABLE IF EXISTS public.scoreaggregates;
+`,
+	)
+}
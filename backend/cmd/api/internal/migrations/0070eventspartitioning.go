@@ -0,0 +1,181 @@
+package migrations
+
+func init() {
+	appendMigration(
+		"partition events by month and track archived months",
+		`
+-- events takes every write, login and question view and had no way to shed
+-- old rows, so every lookup over it (the audit laterals in FindScores and
+-- FindScoreProgress, last_seen, the admin events page) paid for the whole
+-- history. The table becomes range partitioned on createdat, one partition
+-- per calendar month in UTC, so the retention job (cmd/eventsretention) can
+-- archive a month to the blob store and drop its partition whole rather than
+-- DELETE row by row.
+--
+-- Postgres 16 does not support identity columns on a partitioned table, so
+-- eventid moves from 0058's GENERATED ALWAYS identity to a sequence default.
+-- The INSERT sites all name their columns and omit eventid, so they draw from
+-- the sequence as before; rehydrating an archive is the one writer that
+-- supplies its own eventid, to put the archived rows back unchanged. Column
+-- order is kept so SELECT * readers see the same shape.
+--
+-- Index names are schema wide, so the old table's indexes are dropped before
+-- the partitioned table's are created under the same names, after the copy.
+ALTER TABLE public.events RENAME TO events_unpartitioned;
+
+DROP INDEX IF EXISTS public.events_score_audit_idx;
+DROP INDEX IF EXISTS public.events_resource_createdat_idx;
+DROP INDEX IF EXISTS public.events_user_activity_idx;
+DROP INDEX IF EXISTS public.events_pagination_idx;
+
+CREATE SEQUENCE public.events_eventid_seq AS BIGINT;
+
+CREATE TABLE public.events (
+    userid    uuid NOT NULL REFERENCES public.users (userid),
+    action    VARCHAR(30) NOT NULL,
+    resource  VARCHAR(30) NOT NULL,
+    createdat TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    payload   JSONB NOT NULL,
+    eventid   BIGINT NOT NULL DEFAULT nextval('public.events_eventid_seq')
+) PARTITION BY RANGE (createdat);
+
+ALTER SEQUENCE public.events_eventid_seq OWNED BY public.events.eventid;
+
+-- Catches a row stamped in a month with no partition yet (seed data dated
+-- ahead, or a retention job that has not run in months), so an insert never
+-- fails for want of one. events_ensure_partition moves such rows out when
+-- their month's partition is created.
+CREATE TABLE public.events_default PARTITION OF public.events DEFAULT;
+
+-- events_ensure_partition creates the partition for the UTC month holding
+-- in_month, named events_yYYYYmMM, and returns its name. It does nothing
+-- when the partition exists. Postgres refuses to create a partition over
+-- rows the default partition holds for its range, so when there are any the
+-- default is detached, the rows moved into the new partition, and the default
+-- reattached. The migration uses it for the backfill and the retention job
+-- to create months ahead and to restore a month being rehydrated.
+CREATE OR REPLACE FUNCTION public.events_ensure_partition(in_month DATE) RETURNS TEXT AS $$
+DECLARE
+    lo   TIMESTAMPTZ := date_trunc('month', in_month::timestamp) AT TIME ZONE 'UTC';
+    hi   TIMESTAMPTZ := (date_trunc('month', in_month::timestamp) + INTERVAL '1 month') AT TIME ZONE 'UTC';
+    part TEXT := to_char(in_month, '"events_y"YYYY"m"MM');
+BEGIN
+    IF to_regclass('public.' || part) IS NOT NULL THEN
+        RETURN part;
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM public.events_default WHERE createdat >= lo AND createdat < hi) THEN
+        ALTER TABLE public.events DETACH PARTITION public.events_default;
+        EXECUTE format('CREATE TABLE public.%I PARTITION OF public.events FOR VALUES FROM (%L) TO (%L)', part, lo, hi);
+        WITH moved AS (
+            DELETE FROM public.events_default WHERE createdat >= lo AND createdat < hi RETURNING *
+        )
+        INSERT INTO public.events SELECT * FROM moved;
+        ALTER TABLE public.events ATTACH PARTITION public.events_default DEFAULT;
+    ELSE
+        EXECUTE format('CREATE TABLE public.%I PARTITION OF public.events FOR VALUES FROM (%L) TO (%L)', part, lo, hi);
+    END IF;
+    RETURN part;
+END;
+$$ LANGUAGE plpgsql;
+
+-- One partition for every month from the oldest event through three months
+-- ahead; the retention job keeps creating them ahead from here.
+DO $$
+DECLARE
+    m DATE;
+BEGIN
+    FOR m IN
+        SELECT generate_series(
+            date_trunc('month', COALESCE((SELECT MIN(createdat) FROM public.events_unpartitioned), NOW()) AT TIME ZONE 'UTC'),
+            date_trunc('month', NOW() AT TIME ZONE 'UTC') + INTERVAL '3 months',
+            INTERVAL '1 month')::date
+    LOOP
+        PERFORM public.events_ensure_partition(m);
+    END LOOP;
+END;
+$$;
+
+INSERT INTO public.events (userid, action, resource, createdat, payload, eventid)
+SELECT userid, action, resource, createdat, payload, eventid
+  FROM public.events_unpartitioned;
+
+SELECT setval('public.events_eventid_seq', COALESCE((SELECT MAX(eventid) FROM public.events), 0) + 1, false);
+
+DROP TABLE public.events_unpartitioned;
+
+-- 0037, 0054 and 0058's indexes, now partitioned indexes on every month.
+CREATE INDEX events_score_audit_idx
+    ON public.events ((((payload->>'scoreid')::int)), createdat DESC)
+    WHERE resource = 'public.scores';
+
+CREATE INDEX events_resource_createdat_idx
+    ON public.events (resource, createdat DESC);
+
+CREATE INDEX events_user_activity_idx
+    ON public.events (userid, createdat DESC);
+
+CREATE INDEX events_pagination_idx
+    ON public.events (createdat DESC, eventid DESC);
+
+-- One row per month the retention job has archived and dropped. objectkey
+-- names the gzipped NDJSON object in the blob store, and eventcount and
+-- sha256 are what a rehydrate checks it against. A rehydrated month has its
+-- partition back until rehydrateduntil, after which the job drops it again
+-- without writing a new object.
+CREATE TABLE IF NOT EXISTS public.eventarchives (
+    archiveid       SERIAL PRIMARY KEY,
+    rangestart      TIMESTAMPTZ NOT NULL UNIQUE,
+    rangeend        TIMESTAMPTZ NOT NULL,
+    objectkey       TEXT NOT NULL,
+    eventcount      BIGINT NOT NULL,
+    sha256          CHAR(64) NOT NULL,
+    archivedat      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    rehydratedat    TIMESTAMPTZ,
+    rehydratedby    uuid REFERENCES public.users(userid),
+    rehydrateduntil TIMESTAMPTZ,
+    CONSTRAINT eventarchives_range_check CHECK (rangeend > rangestart)
+);
+`,
+		`
+-- Months already archived stay in the blob store: only what the partitions
+-- hold now goes back into the single table.
+DROP TABLE IF EXISTS public.eventarchives;
+
+CREATE TABLE public.events_unpartitioned (
+    userid    uuid NOT NULL REFERENCES public.users (userid),
+    action    VARCHAR(30) NOT NULL,
+    resource  VARCHAR(30) NOT NULL,
+    createdat TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    payload   JSONB NOT NULL,
+    eventid   BIGINT GENERATED ALWAYS AS IDENTITY
+);
+
+INSERT INTO public.events_unpartitioned (userid, action, resource, createdat, payload, eventid)
+OVERRIDING SYSTEM VALUE
+SELECT userid, action, resource, createdat, payload, eventid
+  FROM public.events;
+
+SELECT setval(pg_get_serial_sequence('public.events_unpartitioned', 'eventid'),
+              COALESCE((SELECT MAX(eventid) FROM public.events_unpartitioned), 0) + 1, false);
+
+DROP TABLE public.events;
+DROP FUNCTION IF EXISTS public.events_ensure_partition(DATE);
+
+ALTER TABLE public.events_unpartitioned RENAME TO events;
+
+CREATE INDEX events_score_audit_idx
+    ON public.events ((((payload->>'scoreid')::int)), createdat DESC)
+    WHERE resource = 'public.scores';
+
+CREATE INDEX events_resource_createdat_idx
+    ON public.events (resource, createdat DESC);
+
+CREATE INDEX events_user_activity_idx
+    ON public.events (userid, createdat DESC);
+
+CREATE INDEX events_pagination_idx
+    ON public.events (createdat DESC, eventid DESC);
+`,
+	)
+}
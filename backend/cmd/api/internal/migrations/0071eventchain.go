@@ -0,0 +1,63 @@
+package migrations
+
+func init() {
+	appendMigration(
+		"chain events by hash and record signed checkpoints",
+		`
+-- Makes the audit log tamper-evident. insertEvent (internal/model) stamps
+-- each event with prevhash, the hash of the event chained before it, and
+-- hash, the SHA-256 over prevhash and the event's own columns, so altering
+-- or deleting a row breaks the chain at the next one. eventid orders the
+-- chain: it is drawn under the eventchain row lock, with createdat from
+-- clock_timestamp(), so chained events get eventids and createdats in chain
+-- order even when their transactions overlap.
+--
+-- Rows written before the first chained insert keep NULL hashes and are
+-- reported as pre-chain, not as breaks; eventchain.genesisid is the first
+-- chained eventid, set by that insert.
+ALTER TABLE public.events ADD COLUMN IF NOT EXISTS prevhash CHAR(64);
+ALTER TABLE public.events ADD COLUMN IF NOT EXISTS hash CHAR(64);
+
+-- Verification walks the chain in eventid order across the partitions.
+CREATE INDEX IF NOT EXISTS events_chain_idx
+    ON public.events (eventid);
+
+-- The chain's head: the last chained event. Every chained insert locks this
+-- one row FOR UPDATE, which serializes appends to the chain, and moves it in
+-- the same transaction as the insert, so a rolled back write leaves the head
+-- where it was.
+CREATE TABLE IF NOT EXISTS public.eventchain (
+    singleton BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (singleton),
+    genesisid BIGINT,
+    headid    BIGINT,
+    headhash  CHAR(64)
+);
+
+INSERT INTO public.eventchain DEFAULT VALUES ON CONFLICT DO NOTHING;
+
+-- Signed statements that the chain's head was eventid/hash at createdat,
+-- published to the archive store under objectkey as well, where a database
+-- writer who rewrote the chain cannot reach them. keyid names the Ed25519
+-- key that signed.
+CREATE TABLE IF NOT EXISTS public.eventcheckpoints (
+    checkpointid SERIAL PRIMARY KEY,
+    eventid      BIGINT NOT NULL,
+    hash         CHAR(64) NOT NULL,
+    createdat    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    keyid        TEXT NOT NULL,
+    signature    TEXT NOT NULL,
+    objectkey    TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS eventcheckpoints_eventid_idx
+    ON public.eventcheckpoints (eventid);
+`,
+		`
+DROP TABLE IF EXISTS public.eventcheckpoints;
+DROP TABLE IF EXISTS public.eventchain;
+DROP INDEX IF EXISTS public.events_chain_idx;
+ALTER TABLE public.events DROP COLUMN IF EXISTS hash;
+ALTER TABLE public.events DROP COLUMN IF EXISTS prevhash;
+`,
+	)
+}
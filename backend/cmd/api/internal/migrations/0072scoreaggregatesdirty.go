@@ -0,0 +1,94 @@
+package migrations
+
+func init() {
+	appendMigration(
+		"mark scoreaggregates scopes dirty from the writes that move them",
+		`
+-- scoreaggregates was only as fresh as the refresh each model write ran
+-- after committing: a refresh that failed, or a write made outside the model,
+-- left it serving old numbers with nothing to say so. The tables the pillar
+-- query reads now mark the scope a write touches dirty, in the writing
+-- transaction, so the mark commits with the write whatever wrote it. Reads
+-- whose scope holds a mark fall back to the live query; a refresh clears the
+-- marks it covers, and the API sweeps any left over.
+--
+-- A NULL datacallid or fismasystemid is every data call or every system.
+CREATE TABLE IF NOT EXISTS public.scoreaggregatesdirty (
+    datacallid    INTEGER,
+    fismasystemid INTEGER,
+    markedat      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS scoreaggregatesdirty_scope_idx
+    ON public.scoreaggregatesdirty ((COALESCE(datacallid, 0)), (COALESCE(fismasystemid, 0)));
+
+-- Scores are written in bulk by imports and rollover, so they are marked once
+-- per statement from its transition tables, one mark per (data call, system).
+CREATE OR REPLACE FUNCTION public.scoreaggregates_mark_scores() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO public.scoreaggregatesdirty (datacallid, fismasystemid)
+        SELECT DISTINCT datacallid, fismasystemid FROM new_rows
+        ON CONFLICT DO NOTHING;
+    END IF;
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        INSERT INTO public.scoreaggregatesdirty (datacallid, fismasystemid)
+        SELECT DISTINCT datacallid, fismasystemid FROM old_rows
+        ON CONFLICT DO NOTHING;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER scores_aggregates_insert AFTER INSERT ON public.scores
+    REFERENCING NEW TABLE AS new_rows
+    FOR EACH STATEMENT EXECUTE FUNCTION public.scoreaggregates_mark_scores();
+CREATE TRIGGER scores_aggregates_update AFTER UPDATE ON public.scores
+    REFERENCING OLD TABLE AS old_rows NEW TABLE AS new_rows
+    FOR EACH STATEMENT EXECUTE FUNCTION public.scoreaggregates_mark_scores();
+CREATE TRIGGER scores_aggregates_delete AFTER DELETE ON public.scores
+    REFERENCING OLD TABLE AS old_rows
+    FOR EACH STATEMENT EXECUTE FUNCTION public.scoreaggregates_mark_scores();
+
+-- The rest change a row at a time. TG_ARGV[0] names the scope a row moves:
+-- its data call, its system, or everything.
+CREATE OR REPLACE FUNCTION public.scoreaggregates_mark_row() RETURNS TRIGGER AS $$
+DECLARE
+    r JSONB := to_jsonb(CASE WHEN TG_OP = 'DELETE' THEN OLD ELSE NEW END);
+BEGIN
+    INSERT INTO public.scoreaggregatesdirty (datacallid, fismasystemid)
+    VALUES (CASE WHEN TG_ARGV[0] = 'datacall' THEN (r->>'datacallid')::INTEGER END,
+            CASE WHEN TG_ARGV[0] = 'system' THEN (r->>'fismasystemid')::INTEGER END)
+    ON CONFLICT DO NOTHING;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER fismasystems_aggregates AFTER UPDATE ON public.fismasystems
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*)
+    EXECUTE FUNCTION public.scoreaggregates_mark_row('system');
+CREATE TRIGGER datacalls_aggregates AFTER UPDATE ON public.datacalls
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*)
+    EXECUTE FUNCTION public.scoreaggregates_mark_row('datacall');
+CREATE TRIGGER pillarweights_aggregates AFTER INSERT OR UPDATE OR DELETE ON public.pillarweights
+    FOR EACH ROW EXECUTE FUNCTION public.scoreaggregates_mark_row('datacall');
+CREATE TRIGGER datacallsnapshots_aggregates AFTER INSERT OR DELETE ON public.datacallsnapshots
+    FOR EACH ROW EXECUTE FUNCTION public.scoreaggregates_mark_row('datacall');
+CREATE TRIGGER datacenterenvironments_aggregates AFTER INSERT OR UPDATE OR DELETE ON public.datacenterenvironments
+    FOR EACH ROW EXECUTE FUNCTION public.scoreaggregates_mark_row('all');
+`,
+		`
+DROP TRIGGER IF EXISTS datacenterenvironments_aggregates ON public.datacenterenvironments;
+DROP TRIGGER IF EXISTS datacallsnapshots_aggregates ON public.datacallsnapshots;
+DROP TRIGGER IF EXISTS pillarweights_aggregates ON public.pillarweights;
+DROP TRIGGER IF EXISTS datacalls_aggregates ON public.datacalls;
+DROP TRIGGER IF EXISTS fismasystems_aggregates ON public.fismasystems;
+DROP TRIGGER IF EXISTS scores_aggregates_delete ON public.scores;
+DROP TRIGGER IF EXISTS scores_aggregates_update ON public.scores;
+DROP TRIGGER IF EXISTS scores_aggregates_insert ON public.scores;
+DROP FUNCTION IF EXISTS public.scoreaggregates_mark_row();
+DROP FUNCTION IF EXISTS public.scoreaggregates_mark_scores();
+DROP TABLE IF EXISTS public.scoreaggregatesdirty;
+`,
+	)
+}
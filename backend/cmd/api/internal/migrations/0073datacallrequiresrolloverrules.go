@@ -0,0 +1,28 @@
+package migrations
+
+func init() {
+	appendMigration(
+		"flag data calls that may not open without rollover rules",
+		`
+-- A cycle whose sources differ from the previous cycle's (the FY2026 split in
+-- migration 0061's note) must not open before its rollover rules are written,
+-- or the whole estate quietly rolls forward from the previous cycle. Which
+-- cycles those are is data an admin sets on the draft, not a name the API
+-- matches; the first open checks the flag (requireRolloverRules).
+ALTER TABLE public.datacalls
+  ADD COLUMN IF NOT EXISTS requires_rollover_rules BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- The one such cycle known when this ran: a FY2026 call staged but not yet
+-- opened and still without rules. A later cycle sets the flag itself.
+UPDATE public.datacalls dc
+   SET requires_rollover_rules = TRUE
+ WHERE dc.opened_at IS NULL
+   AND dc.datacall ~* '^\s*FY(2026|26)'
+   AND NOT EXISTS (SELECT 1 FROM public.rolloverrules r WHERE r.target_datacallid = dc.datacallid);
+`,
+		`
+ALTER TABLE public.datacalls
+  DROP COLUMN IF EXISTS requires_rollover_rules;
+`,
+	)
+}
@@ -39,7 +39,13 @@ func populate(path string) error {
 	}
 	defer conn.Release()
 
-	_, err = conn.Exec(ctx, string(sql))
+	if _, err = conn.Exec(ctx, string(sql)); err != nil {
+		return err
+	}
+
+	// The seed loads its catalog after migration 0062 took the baseline, so
+	// version it now and pin the seeded calls to it.
+	_, err = conn.Exec(ctx, snapshotCatalogSQL)
 	return err
 }
 
@@ -45,12 +45,33 @@ func Handler() http.Handler {
 	router.HandleFunc("/api/v1/datacalls/latest", controller.GetLatestDataCall).Methods("GET")
 	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}", controller.GetDataCallByID).Methods("GET")
 	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}", controller.SaveDataCall).Methods("PUT")
+	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/status", controller.SetDataCallStatus).Methods("PUT")
+	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/opens_at", controller.UnscheduleDataCall).Methods("DELETE")
+	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/snapshot", controller.GetDataCallSnapshot).Methods("GET")
+	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/snapshot", controller.SaveDataCallSnapshot).Methods("POST")
+
+	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/extensions", controller.ListDeadlineExtensions).Methods("GET")
+	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/extensions", controller.SaveDeadlineExtension).Methods("POST")
+	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/extensions/{extensionid:[0-9]+}", controller.DeleteDeadlineExtension).Methods("DELETE")
+
+	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/rollover-rules", controller.ListRolloverRules).Methods("GET")
+	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/rollover-rules", controller.SaveRolloverRule).Methods("POST")
+	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/rollover-rules/{ruleid:[0-9]+}", controller.SaveRolloverRule).Methods("PUT")
+	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/rollover-rules/{ruleid:[0-9]+}", controller.DeleteRolloverRule).Methods("DELETE")
+	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/rollover", controller.GetRolloverPreview).Methods("GET")
+	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/rollover", controller.RepairRollover).Methods("POST")
+	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/pillar-weights", controller.ListPillarWeights).Methods("GET")
+	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/pillar-weights", controller.SetPillarWeights).Methods("PUT")
+
 	// records that a fisma system has completed the data call
 	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/fismasystems/{fismasystemid:[0-9]+}", controller.SaveDataCallFismaSystem).Methods("PUT")
+	// withdraws a fisma system's completion of the data call
+	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/fismasystems/{fismasystemid:[0-9]+}", controller.DeleteDataCallFismaSystem).Methods("DELETE")
 	// returns a list of fisma systems that have marked this data call as complete
 	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/fismasystems", controller.ListDataCallFismaSystems).Methods("GET")
 
 	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/export", controller.GetDatacallExport).Methods("GET")
+	router.HandleFunc("/api/v1/datacalls/{datacallid:[0-9]+}/import", controller.ImportDataCallAnswers).Methods("POST")
 
 	router.HandleFunc("/api/v1/fismasystems", controller.ListFismaSystems).Methods("GET")
 	router.HandleFunc("/api/v1/fismasystems", controller.SaveFismaSystem).Methods("POST")
@@ -62,6 +83,7 @@ func Handler() http.Handler {
 	router.HandleFunc("/api/v1/fismasystems/{fismasystemid:[0-9]+}/target-maturity", controller.SaveFismaSystemTargetMaturity).Methods("PUT")
 	// returns a list of data calls that this fisma system has marked complete
 	router.HandleFunc("/api/v1/fismasystems/{fismasystemid:[0-9]+}/datacalls", controller.ListFismaSystemDataCalls).Methods("GET")
+	router.HandleFunc("/api/v1/fismasystems/{fismasystemid:[0-9]+}/scorecard", controller.GetFismaSystemScorecard).Methods("GET")
 
 	// System Delegate self-service (#467): ISSO-reachable add/remove/renew, scoped
 	// to the system in the path. Not behind the admin-only user write paths.
@@ -105,9 +127,25 @@ func Handler() http.Handler {
 	router.HandleFunc("/api/v1/scores/aggregate", controller.GetScoresAggregate).Methods("GET") // yes "aggregate" is a noun
 	router.HandleFunc("/api/v1/scores/diff", controller.GetScoresDiff).Methods("GET")
 	router.HandleFunc("/api/v1/scores/progress", controller.GetScoresProgress).Methods("GET")
+	router.HandleFunc("/api/v1/scores/gaps", controller.GetScoresGaps).Methods("GET")
+	router.HandleFunc("/api/v1/scores/trend", controller.GetScoresTrend).Methods("GET")
+	router.HandleFunc("/api/v1/scores/simulate", controller.SimulateScores).Methods("POST")
+	router.HandleFunc("/api/v1/scores/comments", controller.ListScoreComments).Methods("GET")
+	router.HandleFunc("/api/v1/scores/comments", controller.SaveScoreComment).Methods("POST")
+	router.HandleFunc("/api/v1/scores/comments/{commentid:[0-9]+}/resolve", controller.ResolveScoreComment).Methods("PUT")
+	router.HandleFunc("/api/v1/scores/comments/{commentid:[0-9]+}/unresolve", controller.UnresolveScoreComment).Methods("PUT")
 	router.HandleFunc("/api/v1/scores", controller.SaveScore).Methods("POST")
+	router.HandleFunc("/api/v1/scores/import", controller.ImportScores).Methods("POST")
 	router.HandleFunc("/api/v1/scores/{scoreid:[0-9]+}", controller.SaveScore).Methods("PUT")
 	router.HandleFunc("/api/v1/scores/{scoreid:[0-9]+}/confirm", controller.ConfirmScore).Methods("PUT")
+	router.HandleFunc("/api/v1/scores/{scoreid:[0-9]+}/submit", controller.SubmitScore).Methods("PUT")
+	router.HandleFunc("/api/v1/scores/{scoreid:[0-9]+}/approve", controller.ApproveScore).Methods("PUT")
+	router.HandleFunc("/api/v1/scores/{scoreid:[0-9]+}/return", controller.ReturnScore).Methods("PUT")
+	router.HandleFunc("/api/v1/scores/{scoreid:[0-9]+}/history", controller.GetScoreHistory).Methods("GET")
+	router.HandleFunc("/api/v1/scores/{scoreid:[0-9]+}/attachments", controller.ListScoreAttachments).Methods("GET")
+	router.HandleFunc("/api/v1/scores/{scoreid:[0-9]+}/attachments", controller.UploadScoreAttachment).Methods("POST")
+	router.HandleFunc("/api/v1/scores/{scoreid:[0-9]+}/attachments/{attachmentid:[0-9]+}", controller.DownloadScoreAttachment).Methods("GET")
+	router.HandleFunc("/api/v1/scores/{scoreid:[0-9]+}/attachments/{attachmentid:[0-9]+}", controller.DeleteScoreAttachment).Methods("DELETE")
 
 	router.HandleFunc("/api/v1/questions", controller.ListQuestions).Methods("GET")
 	router.HandleFunc("/api/v1/questions/{questionid:[0-9]+}", controller.GetQuestionByID).Methods("GET")
@@ -119,6 +157,8 @@ func Handler() http.Handler {
 	router.HandleFunc("/api/v1/functions", controller.SaveFunction).Methods("POST")
 	router.HandleFunc("/api/v1/functions/{functionid:[0-9]+}", controller.SaveFunction).Methods("PUT")
 
+	router.HandleFunc("/api/v1/catalogversions", controller.ListCatalogVersions).Methods("GET")
+
 	router.HandleFunc("/api/v1/datacenterenvironments", controller.ListDataCenterEnvironments).Methods("GET")
 
 	router.HandleFunc("/api/v1/systemattributes", controller.ListSystemAttributes).Methods("GET")
@@ -126,6 +166,10 @@ func Handler() http.Handler {
 	router.HandleFunc("/api/v1/datacentermismatches", controller.ListDataCenterMismatches).Methods("GET")
 
 	router.HandleFunc("/api/v1/events", controller.GetEvents).Methods("GET")
+	router.HandleFunc("/api/v1/events/export", controller.ExportEvents).Methods("GET")
+	router.HandleFunc("/api/v1/events/archives", controller.ListEventArchives).Methods("GET")
+	router.HandleFunc("/api/v1/events/archives/rehydrate", controller.RehydrateEvents).Methods("POST")
+	router.HandleFunc("/api/v1/events/verify", controller.VerifyEventChain).Methods("GET")
 	// records that a user opened a questionnaire question (time-spent analytics)
 	router.HandleFunc("/api/v1/events/view", controller.RecordQuestionView).Methods("POST")
 
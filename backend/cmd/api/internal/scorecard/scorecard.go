@@ -0,0 +1,367 @@
+// Package scorecard renders a system's model.Scorecard as a one-system PDF.
+// It embeds the Go fonts, so it needs no font files or cgo in the container
+// and prints any label the database holds.
+package scorecard
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/signintech/gopdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// Page geometry, in millimetres on US Letter.
+const (
+	pageHeight = 279.4
+	margin     = 15.0
+	bodyWidth  = 215.9 - 2*margin
+	lineH      = 6.0
+	// padding insets text from its cell's edges.
+	padding = 1.0
+	// bottom is the lowest a line may end; the footer sits below it.
+	bottom = pageHeight - margin - 4
+)
+
+const family = "Go"
+
+// PDF lays out card: the system and data call, the system score and tier
+// against the target maturity with its justification, a table of pillar
+// scores, the answers that changed since the previous cycle and the
+// lowest-scoring functions. now dates the footer. Callers write the result
+// with WriteTo.
+func PDF(card *model.Scorecard, now time.Time) (*gopdf.GoPdf, error) {
+	pdf := &gopdf.GoPdf{}
+	pdf.Start(gopdf.Config{Unit: gopdf.UnitMM, PageSize: *gopdf.PageSizeLetter})
+	pdf.SetMargins(margin, margin, margin, margin)
+	pdf.SetInfo(gopdf.PdfInfo{
+		Title:        fmt.Sprintf("%s scorecard, %s", card.System.FismaAcronym, card.DataCall.DataCall),
+		CreationDate: now,
+	})
+	for _, f := range []struct {
+		style int
+		ttf   []byte
+	}{{gopdf.Regular, goregular.TTF}, {gopdf.Bold, gobold.TTF}, {gopdf.Italic, goitalic.TTF}} {
+		if err := pdf.AddTTFFontDataWithOption(family, f.ttf, gopdf.TtfOption{Style: f.style}); err != nil {
+			return nil, err
+		}
+	}
+
+	w := &writer{pdf: pdf}
+	w.addPage()
+	w.header(card)
+	w.standing(card)
+	w.pillars(card)
+	w.changes(card)
+	w.lowest(card)
+	w.footers(now)
+	if w.err != nil {
+		return nil, w.err
+	}
+	return pdf, nil
+}
+
+// writer lays the document out top to bottom, breaking to a new page when a
+// line would run into the footer. It keeps the first error the document
+// reports and ignores what follows it, so the sections read straight through
+// and PDF returns that error once.
+type writer struct {
+	pdf *gopdf.GoPdf
+	err error
+}
+
+func (w *writer) check(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+func (w *writer) addPage() {
+	w.pdf.AddPage()
+	w.pdf.SetXY(margin, margin)
+}
+
+// ensure starts a new page unless h more millimetres fit on this one.
+func (w *writer) ensure(h float64) {
+	if w.pdf.GetY()+h > bottom {
+		w.addPage()
+	}
+}
+
+func (w *writer) font(style string, size float64) {
+	w.check(w.pdf.SetFont(family, style, size))
+}
+
+func (w *writer) ln(h float64) {
+	w.pdf.SetXY(margin, w.pdf.GetY()+h)
+}
+
+// cell writes text on one line of a width by h box at the current position,
+// inset by padding and aligned by align, and moves right past it. style
+// outlines ("D"), fills ("F") or does both ("FD") to the box first; "" draws
+// text alone.
+func (w *writer) cell(width, h float64, text string, align int, style string) {
+	x, y := w.pdf.GetX(), w.pdf.GetY()
+	if style != "" {
+		w.pdf.RectFromUpperLeftWithStyle(x, y, width, h, style)
+	}
+	if text != "" {
+		w.pdf.SetXY(x+padding, y)
+		w.check(w.pdf.CellWithOption(&gopdf.Rect{W: width - 2*padding, H: h}, text, gopdf.CellOption{Align: align | gopdf.Middle}))
+	}
+	w.pdf.SetXY(x+width, y)
+}
+
+// lines wraps text at spaces, and mid-word where a word is wider than width.
+func (w *writer) lines(text string, width float64) []string {
+	lines, err := w.pdf.SplitTextWithWordWrap(text, width-2*padding)
+	if errors.Is(err, gopdf.ErrEmptyString) {
+		return []string{""}
+	}
+	w.check(err)
+	return lines
+}
+
+// paragraph writes text wrapped to width, starting at the current x and
+// returning to it on each line, and leaves the position below the last line.
+func (w *writer) paragraph(width, h float64, text string) {
+	x := w.pdf.GetX()
+	for _, line := range w.lines(text, width) {
+		w.ensure(h)
+		w.pdf.SetX(x)
+		w.cell(width, h, line, gopdf.Left, "")
+		w.ln(h)
+	}
+}
+
+func (w *writer) heading(text string) {
+	w.ln(4)
+	w.ensure(9 + lineH)
+	w.font("B", 12)
+	w.pdf.SetTextColor(0, 51, 102)
+	w.cell(bodyWidth, 8, text, gopdf.Left, "")
+	w.ln(8)
+	w.pdf.SetStrokeColor(0, 51, 102)
+	w.pdf.Line(margin, w.pdf.GetY(), margin+bodyWidth, w.pdf.GetY())
+	w.pdf.SetStrokeColor(0, 0, 0)
+	w.pdf.SetTextColor(0, 0, 0)
+	w.ln(1)
+}
+
+// field writes a bold label and its value beside it.
+func (w *writer) field(label, value string) {
+	w.ensure(lineH)
+	w.font("B", 10)
+	w.cell(45, lineH, label, gopdf.Left, "")
+	w.font("", 10)
+	w.paragraph(bodyWidth-45, lineH, value)
+}
+
+func (w *writer) note(text string) {
+	w.font("I", 10)
+	w.paragraph(bodyWidth, lineH, text)
+}
+
+// column is one table column: its heading, width and alignment.
+type column struct {
+	heading string
+	width   float64
+	align   int
+}
+
+// table writes a shaded heading row and one row per entry of rows, cutting
+// any cell too long for its column. A table that breaks across pages
+// repeats its heading row.
+func (w *writer) table(columns []column, rows [][]string) {
+	headings := func() {
+		w.font("B", 9)
+		w.pdf.SetFillColor(225, 232, 240)
+		for _, c := range columns {
+			w.cell(c.width, lineH, c.heading, c.align, "FD")
+		}
+		w.ln(lineH)
+		w.font("", 9)
+	}
+
+	w.ensure(2 * lineH)
+	headings()
+	for _, row := range rows {
+		if w.pdf.GetY()+lineH > bottom {
+			w.addPage()
+			headings()
+		}
+		for i, c := range columns {
+			w.cell(c.width, lineH, w.fit(row[i], c.width-2*padding), c.align, "D")
+		}
+		w.ln(lineH)
+	}
+}
+
+// fit cuts text, with an ellipsis, to width.
+func (w *writer) fit(text string, width float64) string {
+	if w.width(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && w.width(string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+func (w *writer) width(text string) float64 {
+	width, err := w.pdf.MeasureTextWidth(text)
+	w.check(err)
+	return width
+}
+
+// footers dates and numbers every page once the page count is known.
+func (w *writer) footers(now time.Time) {
+	pages := w.pdf.GetNumberOfPages()
+	for page := 1; page <= pages; page++ {
+		w.check(w.pdf.SetPage(page))
+		w.pdf.SetXY(margin, pageHeight-margin+3)
+		w.font("", 8)
+		w.pdf.SetTextColor(110, 110, 110)
+		w.cell(bodyWidth/2, 4, "Generated "+now.Format("January 2, 2006"), gopdf.Left, "")
+		w.cell(bodyWidth/2, 4, fmt.Sprintf("Page %d of %d", page, pages), gopdf.Right, "")
+	}
+}
+
+func (w *writer) header(card *model.Scorecard) {
+	w.font("B", 18)
+	w.paragraph(bodyWidth, 9, card.System.FismaAcronym+" Zero Trust Scorecard")
+	w.font("", 11)
+	w.paragraph(bodyWidth, lineH, card.System.FismaName)
+	w.pdf.SetTextColor(90, 90, 90)
+	w.paragraph(bodyWidth, lineH, fmt.Sprintf("%s, due %s", card.DataCall.DataCall, card.DataCall.Deadline.Format("January 2, 2006")))
+	w.pdf.SetTextColor(0, 0, 0)
+}
+
+// standing writes the system score and tier, the previous cycle's for
+// comparison, and the target maturity.
+func (w *writer) standing(card *model.Scorecard) {
+	w.heading("Maturity")
+	if card.Score == nil {
+		w.field("System score", "Not scored in this data call")
+	} else {
+		w.field("System score", fmt.Sprintf("%.2f (%s)", card.Score.SystemScore, card.Score.SystemTier))
+	}
+	if card.Previous != nil {
+		previous := "Not scored"
+		if card.PreviousScore != nil {
+			previous = fmt.Sprintf("%.2f (%s)", card.PreviousScore.SystemScore, card.PreviousScore.SystemTier)
+		}
+		w.field("Previous cycle", fmt.Sprintf("%s in %s", previous, card.Previous.DataCall))
+	}
+
+	target := card.TargetTier
+	if card.TargetTierDefaulted {
+		target += " (default; no target set)"
+	}
+	w.field("Target maturity", target)
+	if card.System.TargetMaturityJustification != nil && strings.TrimSpace(*card.System.TargetMaturityJustification) != "" {
+		w.field("Justification", *card.System.TargetMaturityJustification)
+	}
+}
+
+func (w *writer) pillars(card *model.Scorecard) {
+	w.heading("Pillar Scores")
+	if card.Score == nil || len(card.Score.PillarScores) == 0 {
+		w.note("No pillar scores in this data call.")
+		return
+	}
+
+	target := slices.Index(model.Tiers(), card.TargetTier)
+	rows := make([][]string, 0, len(card.Score.PillarScores))
+	for _, p := range card.Score.PillarScores {
+		status := "Meets target"
+		if slices.Index(model.Tiers(), p.Tier) < target {
+			status = "Below target"
+		}
+		rows = append(rows, []string{p.Pillar, fmt.Sprintf("%.2f", p.Score), p.Tier, fmt.Sprintf("%.2f", p.Weight), status})
+	}
+	w.table([]column{
+		{"Pillar", 56, gopdf.Left},
+		{"Score", 25, gopdf.Right},
+		{"Tier", 35, gopdf.Left},
+		{"Weight", 25, gopdf.Right},
+		{"Against Target", bodyWidth - 141, gopdf.Left},
+	}, rows)
+}
+
+func (w *writer) changes(card *model.Scorecard) {
+	if card.Previous == nil {
+		w.heading("Changes Since Previous Cycle")
+		w.note("No previous data call to compare with.")
+		return
+	}
+	w.heading("Changes Since " + card.Previous.DataCall)
+	if len(card.Changes) == 0 {
+		w.note("No answers changed.")
+		return
+	}
+
+	rows := make([][]string, 0, len(card.Changes))
+	for _, c := range card.Changes {
+		to := diffSide(c.To)
+		if c.From != nil && c.To != nil && c.From.FunctionOptionID == c.To.FunctionOptionID {
+			to = "Notes edited"
+		}
+		changed := ""
+		if c.ChangedAt != nil {
+			changed = c.ChangedAt.Format("2006-01-02")
+		}
+		if c.ChangedBy != nil {
+			changed = strings.TrimSpace(c.ChangedBy.Name + " " + changed)
+		}
+		rows = append(rows, []string{c.Function, diffSide(c.From), to, changed})
+	}
+	w.table([]column{
+		{"Function", 62, gopdf.Left},
+		{"From", 38, gopdf.Left},
+		{"To", 38, gopdf.Left},
+		{"Changed", bodyWidth - 138, gopdf.Left},
+	}, rows)
+}
+
+// diffSide labels one side of an answer change.
+func diffSide(side *model.ScoreDiffSide) string {
+	if side == nil {
+		return "Not answered"
+	}
+	return fmt.Sprintf("%s (%d)", side.OptionName, side.Score)
+}
+
+func (w *writer) lowest(card *model.Scorecard) {
+	w.heading("Lowest-Scoring Functions")
+	if len(card.LowestFunctions) == 0 {
+		w.note("No scored functions in this data call.")
+		return
+	}
+
+	rows := make([][]string, 0, len(card.LowestFunctions))
+	for _, f := range card.LowestFunctions {
+		answer := "Not answered"
+		if f.OptionName != nil {
+			answer = *f.OptionName
+		}
+		function := ""
+		if f.Function != nil {
+			function = *f.Function
+		}
+		rows = append(rows, []string{f.Pillar, function, answer, fmt.Sprintf("%.2f", f.Score)})
+	}
+	w.table([]column{
+		{"Pillar", 40, gopdf.Left},
+		{"Function", 76, gopdf.Left},
+		{"Answer", 45, gopdf.Left},
+		{"Score", bodyWidth - 161, gopdf.Right},
+	}, rows)
+}
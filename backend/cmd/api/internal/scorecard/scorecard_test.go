@@ -0,0 +1,142 @@
+package scorecard
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strptr(s string) *string { return &s }
+
+var (
+	cmapRange = regexp.MustCompile(`<([0-9A-F]{4})><([0-9A-F]{4})><([0-9A-F]{4})>`)
+	showText  = regexp.MustCompile(`\[<([0-9A-F]*)>\] TJ`)
+)
+
+// render writes card uncompressed and decodes the text it draws through the
+// fonts' ToUnicode maps, each drawn string on a line of its own, so a cell
+// can be matched whole as "\n<text>\n". It checks the card fits on one page.
+func render(t *testing.T, card *model.Scorecard) string {
+	t.Helper()
+	pdf, err := PDF(card, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 1, pdf.GetNumberOfPages())
+	pdf.SetNoCompression()
+	var buf bytes.Buffer
+	_, err = pdf.WriteTo(&buf)
+	require.NoError(t, err)
+	raw := buf.String()
+	require.True(t, strings.HasPrefix(raw, "%PDF-"))
+
+	// The three faces share the Go fonts' glyph numbering, so one map
+	// decodes them all; a clash would mean it no longer does.
+	glyphs := map[string]rune{}
+	for _, m := range cmapRange.FindAllStringSubmatch(raw, -1) {
+		r, err := strconv.ParseUint(m[3], 16, 32)
+		require.NoError(t, err)
+		if prev, ok := glyphs[m[1]]; ok {
+			require.Equal(t, prev, rune(r), "glyph %s", m[1])
+		}
+		glyphs[m[1]] = rune(r)
+	}
+
+	var text strings.Builder
+	text.WriteByte('\n')
+	for _, m := range showText.FindAllStringSubmatch(raw, -1) {
+		for i := 0; i+4 <= len(m[1]); i += 4 {
+			r, ok := glyphs[m[1][i:i+4]]
+			require.True(t, ok, "glyph %s has no mapping", m[1][i:i+4])
+			text.WriteRune(r)
+		}
+		text.WriteByte('\n')
+	}
+	return text.String()
+}
+
+// TestPDF checks that a full scorecard names each section's content: the
+// score against the target and its justification, a pillar below target, a
+// notes-only change beside a retiered one, and the lowest functions.
+func TestPDF(t *testing.T) {
+	changedAt := time.Date(2026, 9, 3, 0, 0, 0, 0, time.UTC)
+	card := &model.Scorecard{
+		System: &model.FismaSystem{
+			FismaAcronym:                "ABC",
+			FismaName:                   "Abc Système",
+			TargetMaturityJustification: strptr("Handles PII for beneficiaries."),
+		},
+		DataCall: &model.DataCall{DataCall: "FY2026", Deadline: time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)},
+		Score: &model.ScoreAggregate{SystemScore: 2.75, SystemTier: "Initial", Weighting: model.ScoreWeightingEqual,
+			PillarScores: []*model.PillarScore{
+				{PillarID: 1, Pillar: "Identity", Score: 3.5, Tier: "Advanced", Weight: 1},
+				{PillarID: 2, Pillar: "Devices", Score: 2, Tier: "Traditional", Weight: 1},
+			}},
+		TargetTier:    "Advanced",
+		Previous:      &model.DataCall{DataCall: "FY2025"},
+		PreviousScore: &model.ScoreAggregate{SystemScore: 2.5, SystemTier: "Initial"},
+		Changes: []*model.ScoreDiff{
+			{Function: "MFA",
+				From:      &model.ScoreDiffSide{FunctionOptionID: 1, OptionName: "Traditional", Score: 1},
+				To:        &model.ScoreDiffSide{FunctionOptionID: 2, OptionName: "Advanced", Score: 3},
+				ChangedAt: &changedAt, ChangedBy: &model.AuditRef{Name: "Pat Isso"}},
+			{Function: "SSO",
+				From: &model.ScoreDiffSide{FunctionOptionID: 5, OptionName: "Initial", Score: 2},
+				To:   &model.ScoreDiffSide{FunctionOptionID: 5, OptionName: "Initial", Score: 2}},
+		},
+		LowestFunctions: []*model.ScorecardFunction{
+			{PillarID: 2, Pillar: "Devices", GapDriver: model.GapDriver{Function: strptr("Asset Inventory"), Score: 1}},
+		},
+	}
+
+	text := render(t, card)
+	for _, want := range []string{
+		"\nABC Zero Trust Scorecard\n",
+		"\nAbc Système\n",
+		"\nFY2026, due September 30, 2026\n",
+		"\n2.75 (Initial)\n",
+		"\n2.50 (Initial) in FY2025\n",
+		"\nAdvanced\n",
+		"\nHandles PII for beneficiaries.\n",
+		"\nBelow target\n",
+		"\nMeets target\n",
+		"\nChanges Since FY2025\n",
+		"\nTraditional (1)\n",
+		"\nNotes edited\n",
+		"\nPat Isso 2026-09-03\n",
+		"\nAsset Inventory\n",
+		"\nNot answered\n",
+		"\nGenerated October 1, 2026\n",
+		"\nPage 1 of 1\n",
+	} {
+		assert.Contains(t, text, want)
+	}
+}
+
+// TestPDFEmpty pins the first cycle of a system with no answers: no scores,
+// no previous cycle, and the default target.
+func TestPDFEmpty(t *testing.T) {
+	card := &model.Scorecard{
+		System:              &model.FismaSystem{FismaAcronym: "NEW", FismaName: "New System"},
+		DataCall:            &model.DataCall{DataCall: "FY2026"},
+		TargetTier:          "Advanced",
+		TargetTierDefaulted: true,
+	}
+
+	text := render(t, card)
+	for _, want := range []string{
+		"\nNot scored in this data call\n",
+		"\nAdvanced (default; no target set)\n",
+		"\nNo pillar scores in this data call.\n",
+		"\nNo previous data call to compare with.\n",
+		"\nNo scored functions in this data call.\n",
+	} {
+		assert.Contains(t, text, want)
+	}
+	assert.NotContains(t, text, "Justification")
+}
@@ -0,0 +1,123 @@
+package spreadsheet
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/xuri/excelize/v2"
+)
+
+// ParseAnswerWorkbook reads an answer workbook in the layout Excel writes, for
+// model.PreviewAnswerImport. It reads the executive workbook's Answers sheet,
+// or else the first sheet. Columns are found by heading, so a sheet reordered,
+// or with columns added, still reads; Fisma Acronym, Pillar and Function are
+// required, along with at least one of Answer, Maturity Tier and Score. Data
+// rows are numbered as the sheet shows them and blank rows are skipped. A
+// Score that is not a whole number becomes a finding and its row is left out.
+func ParseAnswerWorkbook(r io.Reader) ([]model.AnswerImportRow, []model.ScoreImportRowError, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrUnreadableImport, err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, nil, ErrUnreadableImport
+	}
+	sheet := sheets[0]
+	for _, name := range sheets {
+		if name == sheetAnswers {
+			sheet = name
+		}
+	}
+	records, err := f.GetRows(sheet)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrUnreadableImport, err)
+	}
+
+	return parseAnswerWorkbookRecords(records)
+}
+
+// parseAnswerWorkbookRecords is the sheet-independent half of
+// ParseAnswerWorkbook.
+func parseAnswerWorkbookRecords(records [][]string) ([]model.AnswerImportRow, []model.ScoreImportRowError, error) {
+	if len(records) == 0 {
+		return nil, []model.ScoreImportRowError{{Row: 1, Message: "missing header row"}}, nil
+	}
+
+	index := map[string]int{}
+	for i, h := range records[0] {
+		index[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	has := func(heading string) bool {
+		_, ok := index[strings.ToLower(heading)]
+		return ok
+	}
+
+	var findings []model.ScoreImportRowError
+	for _, heading := range []string{headingFismaAcronym, headingPillar, headingFunction} {
+		if !has(heading) {
+			findings = append(findings, model.ScoreImportRowError{Row: 1, Field: heading, Message: "missing column"})
+		}
+	}
+	if !has(headingAnswer) && !has(headingMaturityTier) && !has(headingScore) {
+		findings = append(findings, model.ScoreImportRowError{Row: 1, Field: headingAnswer,
+			Message: fmt.Sprintf("missing column; keep at least one of %s, %s and %s", headingAnswer, headingMaturityTier, headingScore)})
+	}
+	if len(findings) > 0 {
+		return nil, findings, nil
+	}
+
+	cell := func(rec []string, heading string) string {
+		i, ok := index[strings.ToLower(heading)]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+
+	var rows []model.AnswerImportRow
+	for n, rec := range records[1:] {
+		rowNum := n + 2
+		if isBlankRecord(rec) {
+			continue
+		}
+
+		row := model.AnswerImportRow{
+			Row:               rowNum,
+			FismaAcronym:      cell(rec, headingFismaAcronym),
+			Pillar:            cell(rec, headingPillar),
+			Function:          cell(rec, headingFunction),
+			Question:          cell(rec, headingQuestion),
+			OptionDescription: cell(rec, headingAnswer),
+			OptionName:        cell(rec, headingMaturityTier),
+		}
+
+		if v := cell(rec, headingScore); v != "" {
+			score, err := strconv.Atoi(v)
+			if err != nil {
+				findings = append(findings, model.ScoreImportRowError{Row: rowNum, Field: headingScore, Message: fmt.Sprintf("not a whole number: %q", v)})
+				continue
+			}
+			row.Score = &score
+		}
+
+		// With the column present, a blank cell is a request to clear the
+		// notes; the model leaves them alone only when the column is absent.
+		if i, ok := index[strings.ToLower(headingNotes)]; ok {
+			notes := ""
+			if i < len(rec) && strings.TrimSpace(rec[i]) != "" {
+				notes = rec[i]
+			}
+			row.Notes = &notes
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, findings, nil
+}
@@ -0,0 +1,82 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseAnswerWorkbookRoundTrip reads back what Excel writes: every row of
+// the export, answered or not, comes back with the cells the import matches
+// on, and the notes column is present so a blank notes cell reads as "".
+func TestParseAnswerWorkbookRoundTrip(t *testing.T) {
+	answers := []*model.Answer{
+		{
+			FismaAcronym: "SYS-A", DataCenterEnvironment: "Env", Pillar: "Identity",
+			Function: "Fn1", Description: "Desc", Question: "Q1",
+			OptionDescription: strptr("Uses MFA everywhere"), OptionName: strptr("Advanced"),
+			Score: intptr(3), Notes: strptr("  see ADO 123"),
+		},
+		{
+			FismaAcronym: "SYS-A", DataCenterEnvironment: "Env", Pillar: "Devices",
+			Function: "Fn2", Description: "Desc", Question: "Q2",
+		},
+	}
+
+	f, err := Excel(answers)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, f.Write(&buf))
+
+	rows, findings, err := ParseAnswerWorkbook(&buf)
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+	assert.Equal(t, []model.AnswerImportRow{
+		{
+			Row: 2, FismaAcronym: "SYS-A", Pillar: "Identity", Function: "Fn1", Question: "Q1",
+			OptionDescription: "Uses MFA everywhere", OptionName: "Advanced", Score: intptr(3),
+			Notes: strptr("  see ADO 123"),
+		},
+		{Row: 3, FismaAcronym: "SYS-A", Pillar: "Devices", Function: "Fn2", Question: "Q2", Notes: strptr("")},
+	}, rows)
+}
+
+// TestParseAnswerWorkbookRecords covers what an offline edit can do to the
+// sheet: reordered columns, a dropped notes column (notes stay nil), blank rows
+// that keep the row numbers, and a Score that is not a number.
+func TestParseAnswerWorkbookRecords(t *testing.T) {
+	rows, findings, err := parseAnswerWorkbookRecords([][]string{
+		{"Score", "function", "Pillar", "FISMA ACRONYM"},
+		{"4", "Fn1", "Identity", "SYS-A"},
+		{},
+		{"four", "Fn2", "Identity", "SYS-A"},
+		{"", "Fn3", "Identity", "SYS-B"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []model.ScoreImportRowError{
+		{Row: 4, Field: "Score", Message: `not a whole number: "four"`},
+	}, findings)
+	assert.Equal(t, []model.AnswerImportRow{
+		{Row: 2, FismaAcronym: "SYS-A", Pillar: "Identity", Function: "Fn1", Score: intptr(4)},
+		{Row: 5, FismaAcronym: "SYS-B", Pillar: "Identity", Function: "Fn3"},
+	}, rows)
+}
+
+func TestParseAnswerWorkbookMissingColumns(t *testing.T) {
+	rows, findings, err := parseAnswerWorkbookRecords([][]string{{"Fisma Acronym", "Function", "Question"}})
+	require.NoError(t, err)
+	assert.Nil(t, rows)
+	assert.Equal(t, []model.ScoreImportRowError{
+		{Row: 1, Field: "Pillar", Message: "missing column"},
+		{Row: 1, Field: "Answer", Message: "missing column; keep at least one of Answer, Maturity Tier and Score"},
+	}, findings)
+}
+
+func TestParseAnswerWorkbookUnreadable(t *testing.T) {
+	_, _, err := ParseAnswerWorkbook(bytes.NewReader([]byte("not a workbook")))
+	assert.ErrorIs(t, err, ErrUnreadableImport)
+}
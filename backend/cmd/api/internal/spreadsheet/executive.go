@@ -0,0 +1,309 @@
+package spreadsheet
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/xuri/excelize/v2"
+)
+
+// Sheets of the executive workbook, in order.
+const (
+	sheetSummary = "Summary"
+	sheetPillars = "Pillars"
+	sheetOpDivs  = "OpDivs"
+	sheetGaps    = "Target Gaps"
+	sheetAnswers = "Answers"
+)
+
+// scoreNumFmt is excelize's built-in "0.00", the two places the UI shows.
+const scoreNumFmt = 2
+
+// ExecutiveExcel builds the leadership workbook: a summary of system scores
+// and tiers with a chart of systems by tier, a pillar-by-system matrix, OpDiv
+// rollups with a chart of their scores, every pillar short of its system's
+// target, and the answers sheet Excel writes. Scores are written as numbers,
+// shown to two places, so the sheets sort and pivot.
+func ExecutiveExcel(answers []*model.Answer, summary *model.ExportSummary) (*excelize.File, error) {
+	f := excelize.NewFile()
+	if err := f.SetSheetName("Sheet1", sheetSummary); err != nil {
+		return nil, err
+	}
+	for _, sheet := range []string{sheetPillars, sheetOpDivs, sheetGaps, sheetAnswers} {
+		if _, err := f.NewSheet(sheet); err != nil {
+			return nil, err
+		}
+	}
+
+	scoreStyle, err := f.NewStyle(&excelize.Style{NumFmt: scoreNumFmt})
+	if err != nil {
+		return nil, err
+	}
+
+	systems := append([]*model.ScoreGap{}, summary.Systems...)
+	sort.SliceStable(systems, func(i, j int) bool { return systems[i].FismaAcronym < systems[j].FismaAcronym })
+	pillars := summaryPillars(systems)
+
+	w := &sheetWriter{f: f, scoreStyle: scoreStyle}
+	w.summary(systems, summary.OpDivCodes)
+	w.pillars(systems, pillars, summary.OpDivCodes, summary.HHS)
+	w.opDivs(summary.OpDivs, summary.HHS, pillars)
+	w.gaps(systems, summary.OpDivCodes)
+	writeAnswers(f, sheetAnswers, answers)
+
+	if w.err != nil {
+		return nil, w.err
+	}
+	return f, nil
+}
+
+// summaryPillar is one column of the pillar matrix.
+type summaryPillar struct {
+	id   int32
+	name string
+}
+
+// summaryPillars lists every pillar any system is scored on, in pillar order.
+// A system under reduced pillar scope lacks some; its cells stay blank.
+func summaryPillars(systems []*model.ScoreGap) []summaryPillar {
+	seen := map[int32]bool{}
+	var pillars []summaryPillar
+	for _, s := range systems {
+		for _, p := range s.Pillars {
+			if !seen[p.PillarID] {
+				seen[p.PillarID] = true
+				pillars = append(pillars, summaryPillar{p.PillarID, p.Pillar})
+			}
+		}
+	}
+	sort.Slice(pillars, func(i, j int) bool { return pillars[i].id < pillars[j].id })
+	return pillars
+}
+
+// sheetWriter writes cells by column and row number and keeps the first
+// error, so the sheet builders read as the layouts they are.
+type sheetWriter struct {
+	f          *excelize.File
+	scoreStyle int
+	err        error
+}
+
+func (w *sheetWriter) cell(col, row int) string {
+	name, err := excelize.CoordinatesToCellName(col, row)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return name
+}
+
+func (w *sheetWriter) set(sheet string, col, row int, value any) {
+	if err := w.f.SetCellValue(sheet, w.cell(col, row), value); err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// score writes a score cell, shown to two places.
+func (w *sheetWriter) score(sheet string, col, row int, value float64) {
+	w.set(sheet, col, row, value)
+	cell := w.cell(col, row)
+	if err := w.f.SetCellStyle(sheet, cell, cell, w.scoreStyle); err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+func (w *sheetWriter) headings(sheet string, headings ...string) {
+	for i, h := range headings {
+		w.set(sheet, i+1, 1, h)
+	}
+}
+
+// chart adds a clustered column chart of one series at cell.
+func (w *sheetWriter) chart(sheet, cell, title, name, categories, values string) {
+	err := w.f.AddChart(sheet, cell, &excelize.Chart{
+		Type:   excelize.Col,
+		Series: []excelize.ChartSeries{{Name: name, Categories: categories, Values: values}},
+		Title:  excelize.ChartTitle{Paragraph: []excelize.RichTextRun{{Text: title}}},
+		Legend: excelize.ChartLegend{Position: "none"},
+	})
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// ref is an absolute reference to a column range of sheet.
+func ref(sheet, col string, from, to int) string {
+	return "'" + sheet + "'!$" + col + "$" + strconv.Itoa(from) + ":$" + col + "$" + strconv.Itoa(to)
+}
+
+func opDivCode(codes map[int32]string, id *int32) string {
+	if id == nil {
+		return ""
+	}
+	return codes[*id]
+}
+
+// summary writes one row per system and, beside it, the count of systems in
+// each tier with its chart.
+func (w *sheetWriter) summary(systems []*model.ScoreGap, codes map[int32]string) {
+	const sheet = sheetSummary
+	w.headings(sheet, headingFismaAcronym, "System Name", "OpDiv", "System Score", "System Tier",
+		"Target Tier", "Tiers To Target", "Weighting")
+
+	counts := map[string]int{}
+	for i, s := range systems {
+		row := i + 2
+		w.set(sheet, 1, row, s.FismaAcronym)
+		w.set(sheet, 2, row, s.FismaName)
+		w.set(sheet, 3, row, opDivCode(codes, s.OpDivID))
+		w.score(sheet, 4, row, s.SystemScore)
+		w.set(sheet, 5, row, s.SystemTier)
+		w.set(sheet, 6, row, targetLabel(s))
+		w.set(sheet, 7, row, s.TierGap)
+		w.set(sheet, 8, row, s.Weighting)
+		counts[s.SystemTier]++
+	}
+
+	// The tier table sits right of the systems so the chart reads from it.
+	w.set(sheet, 10, 1, "System Tier")
+	w.set(sheet, 11, 1, "Systems")
+	tiers := model.Tiers()
+	for i, tier := range tiers {
+		w.set(sheet, 10, i+2, tier)
+		w.set(sheet, 11, i+2, counts[tier])
+	}
+	if len(systems) > 0 {
+		last := len(tiers) + 1
+		w.chart(sheet, "M2", "Systems by tier", "'"+sheet+"'!$K$1", ref(sheet, "J", 2, last), ref(sheet, "K", 2, last))
+	}
+}
+
+// pillars writes the pillar-by-system matrix, closing with the HHS average of
+// each pillar and its chart when the export covers the department.
+func (w *sheetWriter) pillars(systems []*model.ScoreGap, pillars []summaryPillar, codes map[int32]string, hhs *model.ScoreRollup) {
+	const sheet = sheetPillars
+	headings := []string{headingFismaAcronym, "OpDiv"}
+	column := map[int32]int{}
+	for _, p := range pillars {
+		headings = append(headings, p.name)
+		column[p.id] = len(headings)
+	}
+	w.headings(sheet, headings...)
+
+	for i, s := range systems {
+		row := i + 2
+		w.set(sheet, 1, row, s.FismaAcronym)
+		w.set(sheet, 2, row, opDivCode(codes, s.OpDivID))
+		for _, p := range s.Pillars {
+			w.score(sheet, column[p.PillarID], row, p.Score)
+		}
+	}
+
+	if hhs == nil || len(pillars) == 0 {
+		return
+	}
+	avgRow := len(systems) + 2
+	w.set(sheet, 1, avgRow, "HHS average")
+	for _, p := range hhs.PillarScores {
+		if col, ok := column[p.PillarID]; ok {
+			w.score(sheet, col, avgRow, p.Score)
+		}
+	}
+
+	first, last := w.cell(3, 1), w.cell(len(pillars)+2, 1)
+	firstAvg, lastAvg := w.cell(3, avgRow), w.cell(len(pillars)+2, avgRow)
+	w.chart(sheet, w.cell(len(pillars)+4, 2), "HHS average by pillar",
+		"'"+sheet+"'!$A$"+strconv.Itoa(avgRow),
+		"'"+sheet+"'!"+first+":"+last,
+		"'"+sheet+"'!"+firstAvg+":"+lastAvg)
+}
+
+// opDivs writes one row per OpDiv with its pillar averages, then HHS when the
+// export covers the department, and a chart of the OpDiv scores.
+func (w *sheetWriter) opDivs(opDivs []*model.ScoreRollup, hhs *model.ScoreRollup, pillars []summaryPillar) {
+	const sheet = sheetOpDivs
+	headings := []string{"OpDiv", "Name", "Systems", "System Score", "System Tier", "Weighting"}
+	column := map[int32]int{}
+	for _, p := range pillars {
+		headings = append(headings, p.name)
+		column[p.id] = len(headings)
+	}
+	w.headings(sheet, headings...)
+
+	rows := append([]*model.ScoreRollup{}, opDivs...)
+	if hhs != nil {
+		rows = append(rows, hhs)
+	}
+	for i, r := range rows {
+		row := i + 2
+		w.set(sheet, 1, row, r.GroupKey)
+		w.set(sheet, 2, row, r.GroupName)
+		w.set(sheet, 3, row, r.SystemCount)
+		w.score(sheet, 4, row, r.SystemScore)
+		w.set(sheet, 5, row, r.SystemTier)
+		w.set(sheet, 6, row, r.Weighting)
+		for _, p := range r.PillarScores {
+			if col, ok := column[p.PillarID]; ok {
+				w.score(sheet, col, row, p.Score)
+			}
+		}
+	}
+
+	if len(opDivs) > 0 {
+		last := len(opDivs) + 1
+		w.chart(sheet, w.cell(len(headings)+2, 2), "System score by OpDiv",
+			"'"+sheet+"'!$D$1", ref(sheet, "A", 2, last), ref(sheet, "D", 2, last))
+	}
+}
+
+// gaps writes one row per pillar that falls short of its system's target,
+// naming the lowest-scoring functions in it.
+func (w *sheetWriter) gaps(systems []*model.ScoreGap, codes map[int32]string) {
+	const sheet = sheetGaps
+	w.headings(sheet, headingFismaAcronym, "OpDiv", "Target Tier", "Pillar", "Pillar Score", "Pillar Tier",
+		"Tiers To Target", "Points To Target", "Lowest-Scoring Functions")
+
+	row := 2
+	for _, s := range systems {
+		for _, p := range s.Pillars {
+			if p.TierGap == 0 {
+				continue
+			}
+			w.set(sheet, 1, row, s.FismaAcronym)
+			w.set(sheet, 2, row, opDivCode(codes, s.OpDivID))
+			w.set(sheet, 3, row, targetLabel(s))
+			w.set(sheet, 4, row, p.Pillar)
+			w.score(sheet, 5, row, p.Score)
+			w.set(sheet, 6, row, p.Tier)
+			w.set(sheet, 7, row, p.TierGap)
+			w.score(sheet, 8, row, p.PointsGap)
+			w.set(sheet, 9, row, driverList(p.Drivers))
+			row++
+		}
+	}
+}
+
+// targetLabel is the system's target tier, marked when it is the default
+// rather than one the system set.
+func targetLabel(s *model.ScoreGap) string {
+	if s.TargetTierDefaulted {
+		return s.TargetTier + " (default)"
+	}
+	return s.TargetTier
+}
+
+// driverList names the drivers with the option each is answered at.
+func driverList(drivers []*model.GapDriver) string {
+	names := make([]string, 0, len(drivers))
+	for _, d := range drivers {
+		name := derefString(d.Function)
+		if d.OptionName != nil {
+			name += " (" + *d.OptionName + ")"
+		} else {
+			name += " (not answered)"
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, "; ")
+}
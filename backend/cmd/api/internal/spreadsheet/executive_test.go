@@ -0,0 +1,182 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func executiveFixture() ([]*model.Answer, *model.ExportSummary) {
+	opdiv := int32(7)
+	pillar := func(id int32, name string, score float64, tierGap int) *model.PillarGap {
+		return &model.PillarGap{
+			PillarScore: model.PillarScore{PillarID: id, Pillar: name, Score: score, Tier: model.Tier(score), Weight: 1},
+			TierGap:     tierGap,
+			PointsGap:   float64(tierGap),
+			Drivers:     []*model.GapDriver{{Function: strptr("MFA"), OptionName: strptr("Traditional")}, {Function: strptr("SSO")}},
+		}
+	}
+
+	summary := &model.ExportSummary{
+		Systems: []*model.ScoreGap{
+			{
+				FismaSystemID: 2, FismaAcronym: "ZED", FismaName: "Zed System", OpDivID: &opdiv,
+				TargetTier: "Advanced", TargetTierDefaulted: true, SystemScore: 2.5, SystemTier: "Initial", TierGap: 1,
+				Weighting: model.ScoreWeightingEqual,
+				// Reduced pillar scope: no Devices pillar.
+				Pillars: []*model.PillarGap{pillar(1, "Identity", 2.5, 1)},
+			},
+			{
+				FismaSystemID: 1, FismaAcronym: "ABC", FismaName: "Abc System", OpDivID: &opdiv,
+				TargetTier: "Initial", SystemScore: 3.5, SystemTier: "Advanced",
+				Weighting: model.ScoreWeightingEqual,
+				Pillars:   []*model.PillarGap{pillar(1, "Identity", 3, 0), pillar(2, "Devices", 4, 0)},
+			},
+		},
+		OpDivs: []*model.ScoreRollup{{
+			GroupKey: "CMS", GroupName: "Centers for Medicare", SystemCount: 2, SystemScore: 3, SystemTier: "Initial",
+			Weighting: model.ScoreWeightingEqual,
+			PillarScores: []*model.PillarRollup{
+				{PillarScore: model.PillarScore{PillarID: 1, Pillar: "Identity", Score: 2.75}},
+				{PillarScore: model.PillarScore{PillarID: 2, Pillar: "Devices", Score: 4}},
+			},
+		}},
+		HHS: &model.ScoreRollup{
+			GroupKey: "HHS", GroupName: "HHS", SystemCount: 2, SystemScore: 3, SystemTier: "Initial",
+			Weighting: model.ScoreWeightingEqual,
+			PillarScores: []*model.PillarRollup{
+				{PillarScore: model.PillarScore{PillarID: 1, Pillar: "Identity", Score: 2.75}},
+				{PillarScore: model.PillarScore{PillarID: 2, Pillar: "Devices", Score: 4}},
+			},
+		},
+		OpDivCodes: map[int32]string{7: "CMS"},
+	}
+
+	answers := []*model.Answer{{
+		FismaAcronym: "ABC", Pillar: "Identity", Function: "MFA", Question: "Q",
+		OptionDescription: strptr("Uses MFA"), OptionName: strptr("Advanced"), Score: intptr(3),
+	}}
+	return answers, summary
+}
+
+// TestExecutiveExcel reads the workbook back: the sheets in order, systems
+// sorted by acronym, a blank matrix cell for a pillar a system is not scored
+// on, the HHS average row, gap rows only for pillars short of target, and a
+// native chart on each of Summary, Pillars and OpDivs.
+func TestExecutiveExcel(t *testing.T) {
+	answers, summary := executiveFixture()
+
+	f, err := ExecutiveExcel(answers, summary)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, f.Write(&buf))
+	data := buf.Bytes()
+
+	book, err := excelize.OpenReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	defer book.Close()
+
+	assert.Equal(t, []string{"Summary", "Pillars", "OpDivs", "Target Gaps", "Answers"}, book.GetSheetList())
+
+	rows, err := book.GetRows("Summary")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ABC", "Abc System", "CMS", "3.50", "Advanced", "Initial", "0", "equal", "", "Not Assessed", "0"}, rows[1])
+	assert.Equal(t, []string{"ZED", "Zed System", "CMS", "2.50", "Initial", "Advanced (default)", "1", "equal", "", "Traditional", "0"}, rows[2])
+	assert.Equal(t, []string{"", "", "", "", "", "", "", "", "", "Initial", "1"}, rows[3])
+
+	rows, err = book.GetRows("Pillars")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Fisma Acronym", "OpDiv", "Identity", "Devices"}, rows[0])
+	assert.Equal(t, []string{"ZED", "CMS", "2.50"}, rows[2])
+	assert.Equal(t, []string{"HHS average", "", "2.75", "4.00"}, rows[3])
+
+	rows, err = book.GetRows("OpDivs")
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, []string{"CMS", "Centers for Medicare", "2", "3.00", "Initial", "equal", "2.75", "4.00"}, rows[1])
+	assert.Equal(t, "HHS", rows[2][0])
+
+	rows, err = book.GetRows("Target Gaps")
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, []string{"ZED", "CMS", "Advanced (default)", "Identity", "2.50", "Initial", "1", "1.00",
+		"MFA (Traditional); SSO (not answered)"}, rows[1])
+
+	rows, err = book.GetRows("Answers")
+	require.NoError(t, err)
+	assert.Equal(t, "ABC", rows[1][0])
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	charts := 0
+	for _, file := range zr.File {
+		if strings.HasPrefix(file.Name, "xl/charts/chart") {
+			charts++
+		}
+	}
+	assert.Equal(t, 3, charts)
+
+	// The answers sheet reads back for the import.
+	imported, findings, err := ParseAnswerWorkbook(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+	require.Len(t, imported, 1)
+	assert.Equal(t, "MFA", imported[0].Function)
+}
+
+// TestExecutiveExcelNoScores pins that a data call nobody has answered yet
+// still yields every sheet, with headings and no charts.
+func TestExecutiveExcelNoScores(t *testing.T) {
+	f, err := ExecutiveExcel(nil, &model.ExportSummary{OpDivCodes: map[int32]string{}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Summary", "Pillars", "OpDivs", "Target Gaps", "Answers"}, f.GetSheetList())
+	v, err := f.GetCellValue("Target Gaps", "A1")
+	require.NoError(t, err)
+	assert.Equal(t, "Fisma Acronym", v)
+}
+
+// TestExecutiveExcelWithoutHHS pins a scoped or filtered export, which has no
+// HHS rollup: no average row or its chart on Pillars, and no HHS row on OpDivs.
+func TestExecutiveExcelWithoutHHS(t *testing.T) {
+	answers, summary := executiveFixture()
+	summary.HHS = nil
+
+	f, err := ExecutiveExcel(answers, summary)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, f.Write(&buf))
+	data := buf.Bytes()
+
+	book, err := excelize.OpenReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	defer book.Close()
+
+	rows, err := book.GetRows("Pillars")
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	for _, row := range rows {
+		assert.NotContains(t, row, "HHS average")
+	}
+
+	rows, err = book.GetRows("OpDivs")
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "CMS", rows[1][0])
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	charts := 0
+	for _, file := range zr.File {
+		if strings.HasPrefix(file.Name, "xl/charts/chart") {
+			charts++
+		}
+	}
+	assert.Equal(t, 2, charts)
+}
@@ -0,0 +1,158 @@
+package spreadsheet
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/xuri/excelize/v2"
+)
+
+// Report is a one-sheet table a JSON report endpoint can also answer as a
+// file: Excel writes it as a workbook and CSV as comma-separated text, from
+// the same headings and rows. A nil cell is left blank.
+type Report struct {
+	Sheet    string
+	Headings []string
+	Rows     [][]any
+}
+
+// Excel writes the report to a workbook of one sheet. Times are written as
+// Excel dates, so they sort and filter as dates.
+func (rep *Report) Excel() (*excelize.File, error) {
+	f := excelize.NewFile()
+	if err := f.SetSheetName("Sheet1", rep.Sheet); err != nil {
+		return nil, err
+	}
+
+	w := &sheetWriter{f: f}
+	w.headings(rep.Sheet, rep.Headings...)
+	for i, row := range rep.Rows {
+		for j, v := range row {
+			if v != nil {
+				w.set(rep.Sheet, j+1, i+2, v)
+			}
+		}
+	}
+	if w.err != nil {
+		return nil, w.err
+	}
+	return f, nil
+}
+
+// CSV writes the report as comma-separated text with a heading row. Times
+// are written in RFC 3339, and text through CSVText.
+func (rep *Report) CSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(rep.Headings); err != nil {
+		return err
+	}
+	record := make([]string, len(rep.Headings))
+	for _, row := range rep.Rows {
+		for j, v := range row {
+			switch v := v.(type) {
+			case nil:
+				record[j] = ""
+			case time.Time:
+				record[j] = v.Format(time.RFC3339)
+			case string:
+				record[j] = CSVText(v)
+			default:
+				record[j] = fmt.Sprint(v)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// CSVText is s made safe for a CSV cell a spreadsheet will open. Notes,
+// names and the like are typed by users, and a cell starting with =, +, -
+// or @ (or a tab or carriage return, which some spreadsheets skip before
+// one) is run as a formula; a leading ' makes it text and is not shown.
+// Only text goes through it: a negative number stays a number.
+func CSVText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// systemCells are the columns that name a report row's system, so the file
+// stands alone without the app to look ids up in.
+func systemCells(systems map[int32]*model.ReportSystem, id int32) []any {
+	s, ok := systems[id]
+	if !ok {
+		return []any{id, nil, nil, nil, nil}
+	}
+	return []any{id, s.FismaAcronym, s.FismaName, cell(s.OpDiv), cell(s.ISSOName)}
+}
+
+var systemHeadings = []string{"Fisma System ID", headingFismaAcronym, "System Name", "OpDiv", "ISSO"}
+
+// cell unwraps a nullable value for a report row, nil staying blank.
+func cell[T any](v *T) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+// ScoreDiffReport lays out /scores/diff: one row per changed function, with
+// each side's answer and notes and who made the change.
+func ScoreDiffReport(diffs []*model.ScoreDiff, systems map[int32]*model.ReportSystem) *Report {
+	rep := &Report{
+		Sheet: "Score Changes",
+		Headings: append(append([]string{}, systemHeadings...),
+			headingFunction, headingQuestion,
+			"From Maturity Tier", "From Score", "From Notes",
+			"To Maturity Tier", "To Score", "To Notes",
+			"Changed At", "Changed By"),
+	}
+	for _, d := range diffs {
+		row := append(systemCells(systems, d.FismaSystemID), d.Function, d.Question)
+		row = append(row, diffSideCells(d.From)...)
+		row = append(row, diffSideCells(d.To)...)
+		row = append(row, cell(d.ChangedAt), nil)
+		if d.ChangedBy != nil {
+			row[len(row)-1] = d.ChangedBy.Name
+		}
+		rep.Rows = append(rep.Rows, row)
+	}
+	return rep
+}
+
+// diffSideCells is one side of a change; all blank when the function was not
+// answered on that side.
+func diffSideCells(side *model.ScoreDiffSide) []any {
+	if side == nil {
+		return []any{nil, nil, nil}
+	}
+	return []any{side.OptionName, side.Score, cell(side.Notes)}
+}
+
+// ScoreProgressReport lays out /scores/progress: one row per system with its
+// question counts by state and its deadline.
+func ScoreProgressReport(progress []*model.ScoreProgress, systems map[int32]*model.ReportSystem) *Report {
+	rep := &Report{
+		Sheet: "Progress",
+		Headings: append(append([]string{}, systemHeadings...),
+			"Questions Expected", "Questions Answered", "Questions Updated",
+			"Not Started", "Done", "Submitted", "Approved", "Returned",
+			"Unresolved Comments", "Last Updated At", "Updated Since Start", "Deadline"),
+	}
+	for _, p := range progress {
+		row := append(systemCells(systems, p.FismaSystemID),
+			p.QuestionsExpected, p.QuestionsAnswered, p.QuestionsUpdated,
+			p.QuestionsNotStarted, p.QuestionsDone, p.QuestionsSubmitted, p.QuestionsApproved, p.QuestionsReturned,
+			p.UnresolvedComments, cell(p.LastUpdatedAt), p.UpdatedSinceStart, cell(p.EffectiveDeadline))
+		rep.Rows = append(rep.Rows, row)
+	}
+	return rep
+}
@@ -0,0 +1,113 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func reportSystems() map[int32]*model.ReportSystem {
+	return map[int32]*model.ReportSystem{
+		1: {FismaSystemID: 1, FismaAcronym: "ABC", FismaName: "Abc System", OpDiv: strptr("CMS"), ISSOName: strptr("Pat Isso")},
+	}
+}
+
+// TestScoreDiffReport lays out a retiered answer and a newly answered one,
+// the second for a system with no labels, and reads both files back.
+func TestScoreDiffReport(t *testing.T) {
+	changedAt := time.Date(2026, 9, 3, 14, 5, 0, 0, time.UTC)
+	diffs := []*model.ScoreDiff{
+		{FismaSystemID: 1, Function: "MFA", Question: "Q1",
+			From:      &model.ScoreDiffSide{OptionName: "Traditional", Score: 1, Notes: strptr("old")},
+			To:        &model.ScoreDiffSide{OptionName: "Advanced", Score: 3},
+			ChangedAt: &changedAt, ChangedBy: &model.AuditRef{Name: "Pat Isso"}},
+		{FismaSystemID: 2, Function: "SSO", Question: "Q2",
+			To: &model.ScoreDiffSide{OptionName: "Initial", Score: 2}},
+	}
+	rep := ScoreDiffReport(diffs, reportSystems())
+
+	var buf bytes.Buffer
+	require.NoError(t, rep.CSV(&buf))
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"Fisma System ID", "Fisma Acronym", "System Name", "OpDiv", "ISSO", "Function", "Question",
+		"From Maturity Tier", "From Score", "From Notes", "To Maturity Tier", "To Score", "To Notes", "Changed At", "Changed By"}, records[0])
+	assert.Equal(t, []string{"1", "ABC", "Abc System", "CMS", "Pat Isso", "MFA", "Q1",
+		"Traditional", "1", "old", "Advanced", "3", "", "2026-09-03T14:05:00Z", "Pat Isso"}, records[1])
+	assert.Equal(t, []string{"2", "", "", "", "", "SSO", "Q2",
+		"", "", "", "Initial", "2", "", "", ""}, records[2])
+
+	f, err := rep.Excel()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Score Changes"}, f.GetSheetList())
+	rows, err := f.GetRows("Score Changes")
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, "ABC", rows[1][1])
+	assert.Equal(t, "3", rows[1][11])
+	// Changed At is a date cell, not text.
+	v, err := f.GetCellValue("Score Changes", "N2", excelize.Options{RawCellValue: true})
+	require.NoError(t, err)
+	assert.NotContains(t, v, "2026")
+}
+
+func TestScoreProgressReport(t *testing.T) {
+	deadline := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+	progress := []*model.ScoreProgress{{
+		FismaSystemID: 1, QuestionsExpected: 40, QuestionsAnswered: 38, QuestionsUpdated: 12,
+		QuestionsNotStarted: 26, QuestionsDone: 10, QuestionsSubmitted: 2, UnresolvedComments: 1,
+		UpdatedSinceStart: true, EffectiveDeadline: &deadline,
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, ScoreProgressReport(progress, reportSystems()).CSV(&buf))
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, []string{"1", "ABC", "Abc System", "CMS", "Pat Isso",
+		"40", "38", "12", "26", "10", "2", "0", "0", "1", "", "true", "2026-09-30T00:00:00Z"}, records[1])
+	assert.Len(t, records[0], len(records[1]))
+}
+
+// TestReportCSVFormulas pins that text a spreadsheet would run as a formula
+// is written as text, and that numbers, negative ones included, are not
+// touched.
+func TestReportCSVFormulas(t *testing.T) {
+	rep := &Report{
+		Sheet:    "Formulas",
+		Headings: []string{"Cell"},
+		Rows: [][]any{
+			{"=HYPERLINK(\"http://x\",\"y\")"}, {"+1"}, {"-2+3"}, {"@SUM(A1)"}, {"\t=1"}, {"\r=1"},
+			{"plain"}, {"a=b"}, {-4}, {-0.5},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, rep.CSV(&buf))
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	got := []string{}
+	for _, r := range records[1:] {
+		got = append(got, r[0])
+	}
+	assert.Equal(t, []string{
+		"'=HYPERLINK(\"http://x\",\"y\")", "'+1", "'-2+3", "'@SUM(A1)", "'\t=1", "'\r=1",
+		"plain", "a=b", "-4", "-0.5",
+	}, got)
+}
+
+// TestReportNoRows pins that an empty report is still a file with headings.
+func TestReportNoRows(t *testing.T) {
+	f, err := ScoreProgressReport(nil, nil).Excel()
+	require.NoError(t, err)
+	v, err := f.GetCellValue("Progress", "B1")
+	require.NoError(t, err)
+	assert.Equal(t, "Fisma Acronym", v)
+}
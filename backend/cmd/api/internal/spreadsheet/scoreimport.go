@@ -0,0 +1,155 @@
+package spreadsheet
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/xuri/excelize/v2"
+)
+
+// Score import file formats accepted by ParseScoreImport.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ErrUnreadableImport is returned when the upload cannot be read as the format
+// it claims to be at all (a corrupt workbook, a binary file named .csv). Row
+// level problems are reported through the findings slice instead.
+var ErrUnreadableImport = errors.New("import file could not be read")
+
+// scoreImportColumns are the header names a score import must carry, matched
+// case-insensitively. Notes is optional. Any other column is ignored, so an
+// operator can keep acronyms or question text alongside the ids for their own
+// reading without the importer caring.
+var scoreImportColumns = []string{"fismasystemid", "datacallid", "functionoptionid"}
+
+// ParseScoreImport reads a score import file into rows for model.ImportScores.
+// The first row is the header; data rows are numbered from 2 so a finding's row
+// matches what a spreadsheet shows. Fully blank rows are skipped. A cell that
+// does not parse (a non-numeric id) becomes a finding and its row is left out
+// of the returned rows, so the model reports it once rather than again as a
+// missing id.
+func ParseScoreImport(r io.Reader, format string) ([]model.ScoreImportRow, []model.ScoreImportRowError, error) {
+	var records [][]string
+
+	switch format {
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.TrimLeadingSpace = true
+		recs, err := cr.ReadAll()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrUnreadableImport, err)
+		}
+		records = recs
+	case FormatXLSX:
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrUnreadableImport, err)
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, nil, ErrUnreadableImport
+		}
+		rows, err := f.GetRows(sheets[0])
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrUnreadableImport, err)
+		}
+		records = rows
+	default:
+		return nil, nil, fmt.Errorf("%w: unsupported format %q", ErrUnreadableImport, format)
+	}
+
+	return parseScoreImportRecords(records)
+}
+
+// parseScoreImportRecords is the format-independent half of ParseScoreImport.
+func parseScoreImportRecords(records [][]string) ([]model.ScoreImportRow, []model.ScoreImportRowError, error) {
+	if len(records) == 0 {
+		return nil, []model.ScoreImportRowError{{Row: 1, Message: "missing header row"}}, nil
+	}
+
+	index := map[string]int{}
+	for i, h := range records[0] {
+		index[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+
+	var findings []model.ScoreImportRowError
+	for _, col := range scoreImportColumns {
+		if _, ok := index[col]; !ok {
+			findings = append(findings, model.ScoreImportRowError{Row: 1, Field: col, Message: "missing column"})
+		}
+	}
+	if len(findings) > 0 {
+		return nil, findings, nil
+	}
+
+	cell := func(rec []string, col string) string {
+		i, ok := index[col]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+
+	var rows []model.ScoreImportRow
+	for n, rec := range records[1:] {
+		rowNum := n + 2
+		if isBlankRecord(rec) {
+			continue
+		}
+
+		row := model.ScoreImportRow{Row: rowNum}
+		ok := true
+		for _, target := range []struct {
+			col string
+			dst *int32
+		}{
+			{"fismasystemid", &row.FismaSystemID},
+			{"datacallid", &row.DataCallID},
+			{"functionoptionid", &row.FunctionOptionID},
+		} {
+			v := cell(rec, target.col)
+			if v == "" {
+				// Left at zero: the model reports the missing id with the
+				// same message the JSON paths use.
+				continue
+			}
+			id, err := strconv.ParseInt(v, 10, 32)
+			if err != nil {
+				findings = append(findings, model.ScoreImportRowError{Row: rowNum, Field: target.col, Message: fmt.Sprintf("not a whole number: %q", v)})
+				ok = false
+				continue
+			}
+			*target.dst = int32(id)
+		}
+
+		// Notes keep their inner whitespace; only an empty cell means "no notes".
+		if i, has := index["notes"]; has && i < len(rec) && strings.TrimSpace(rec[i]) != "" {
+			notes := rec[i]
+			row.Notes = &notes
+		}
+
+		if ok {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows, findings, nil
+}
+
+func isBlankRecord(rec []string) bool {
+	for _, v := range rec {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+// TestParseScoreImportCSV covers the header-driven mapping: columns are found
+// by name in any order and case, extra columns are ignored, blank rows are
+// skipped without shifting the row numbers the report uses, and an empty notes
+// cell means no notes rather than an empty string.
+func TestParseScoreImportCSV(t *testing.T) {
+	in := strings.Join([]string{
+		"FismaAcronym,FunctionOptionID,DataCallID,FismaSystemID,Notes",
+		"SYS-A,11,3,7,carried from the 2019 workbook",
+		",,,,",
+		"SYS-B,12,3,8,",
+	}, "\n")
+
+	rows, findings, err := ParseScoreImport(strings.NewReader(in), FormatCSV)
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+
+	require.Len(t, rows, 2)
+	assert.Equal(t, model.ScoreImportRow{Row: 2, FismaSystemID: 7, DataCallID: 3, FunctionOptionID: 11, Notes: strptr("carried from the 2019 workbook")}, rows[0])
+	assert.Equal(t, model.ScoreImportRow{Row: 4, FismaSystemID: 8, DataCallID: 3, FunctionOptionID: 12}, rows[1])
+}
+
+// TestParseScoreImportCellErrors pins that an unparseable id is a finding on
+// its row and the row is withheld, while a blank id passes through as zero for
+// the model's "required" check - one finding per problem, not two.
+func TestParseScoreImportCellErrors(t *testing.T) {
+	in := "fismasystemid,datacallid,functionoptionid\nabc,3,11\n7,,11\n"
+
+	rows, findings, err := ParseScoreImport(strings.NewReader(in), FormatCSV)
+	require.NoError(t, err)
+
+	assert.Equal(t, []model.ScoreImportRowError{
+		{Row: 2, Field: "fismasystemid", Message: `not a whole number: "abc"`},
+	}, findings)
+	require.Len(t, rows, 1)
+	assert.Equal(t, 3, rows[0].Row)
+	assert.Equal(t, int32(0), rows[0].DataCallID)
+}
+
+// TestParseScoreImportMissingColumn reports a missing required column against
+// the header row and returns no rows, since nothing below it can be read.
+func TestParseScoreImportMissingColumn(t *testing.T) {
+	rows, findings, err := ParseScoreImport(strings.NewReader("fismasystemid,notes\n7,x\n"), FormatCSV)
+	require.NoError(t, err)
+	assert.Nil(t, rows)
+	assert.Equal(t, []model.ScoreImportRowError{
+		{Row: 1, Field: "datacallid", Message: "missing column"},
+		{Row: 1, Field: "functionoptionid", Message: "missing column"},
+	}, findings)
+}
+
+// TestParseScoreImportXLSX reads the first sheet of a workbook, numbering rows
+// as the spreadsheet does.
+func TestParseScoreImportXLSX(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := f.GetSheetList()[0]
+	require.NoError(t, f.SetSheetRow(sheet, "A1", &[]any{"fismasystemid", "datacallid", "functionoptionid", "notes"}))
+	require.NoError(t, f.SetSheetRow(sheet, "A2", &[]any{7, 3, 11, "note"}))
+	require.NoError(t, f.SetSheetRow(sheet, "A3", &[]any{8, 3, 12}))
+
+	var buf bytes.Buffer
+	require.NoError(t, f.Write(&buf))
+
+	rows, findings, err := ParseScoreImport(&buf, FormatXLSX)
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+	assert.Equal(t, []model.ScoreImportRow{
+		{Row: 2, FismaSystemID: 7, DataCallID: 3, FunctionOptionID: 11, Notes: strptr("note")},
+		{Row: 3, FismaSystemID: 8, DataCallID: 3, FunctionOptionID: 12},
+	}, rows)
+}
+
+func TestParseScoreImportUnreadable(t *testing.T) {
+	_, _, err := ParseScoreImport(strings.NewReader("not a zip"), FormatXLSX)
+	assert.ErrorIs(t, err, ErrUnreadableImport)
+}
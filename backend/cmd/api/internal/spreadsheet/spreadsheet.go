@@ -7,24 +7,40 @@ import (
 	"github.com/xuri/excelize/v2"
 )
 
-func Excel(answers []*model.Answer) (*excelize.File, error) {
-
-	sheet := "Sheet1"
+// Headings of the answer workbook's columns that ParseAnswerWorkbook reads
+// back; it finds them by name, not position.
+const (
+	headingFismaAcronym = "Fisma Acronym"
+	headingPillar       = "Pillar"
+	headingFunction     = "Function"
+	headingQuestion     = "Question"
+	headingAnswer       = "Answer"
+	headingMaturityTier = "Maturity Tier"
+	headingScore        = "Score"
+	headingNotes        = "ADO Answer Details"
+)
 
+func Excel(answers []*model.Answer) (*excelize.File, error) {
 	f := excelize.NewFile()
+	writeAnswers(f, "Sheet1", answers)
+	return f, nil
+}
 
-	f.SetCellValue(sheet, "A1", "Fisma Acronym")
+// writeAnswers writes the answer rows, one per system and function, to sheet.
+func writeAnswers(f *excelize.File, sheet string, answers []*model.Answer) {
+	f.SetCellValue(sheet, "A1", headingFismaAcronym)
 	f.SetCellValue(sheet, "B1", "Data Center Environment")
-	f.SetCellValue(sheet, "C1", "Pillar")
-	f.SetCellValue(sheet, "D1", "Function")
+	f.SetCellValue(sheet, "C1", headingPillar)
+	f.SetCellValue(sheet, "D1", headingFunction)
 	f.SetCellValue(sheet, "E1", "Function Description")
-	f.SetCellValue(sheet, "F1", "Question")
-	f.SetCellValue(sheet, "G1", "Answer")
-	f.SetCellValue(sheet, "H1", "Maturity Tier")
-	f.SetCellValue(sheet, "I1", "Score")
-	f.SetCellValue(sheet, "J1", "ADO Answer Details")
+	f.SetCellValue(sheet, "F1", headingQuestion)
+	f.SetCellValue(sheet, "G1", headingAnswer)
+	f.SetCellValue(sheet, "H1", headingMaturityTier)
+	f.SetCellValue(sheet, "I1", headingScore)
+	f.SetCellValue(sheet, "J1", headingNotes)
 	f.SetCellValue(sheet, "K1", "Target Maturity Level")
 	f.SetCellValue(sheet, "L1", "Target Justification")
+	f.SetCellValue(sheet, "M1", "Evidence Attachments")
 
 	for i, a := range answers {
 		row := i + 2 // i starts at 0 and headers are in row 1
@@ -52,9 +68,8 @@ func Excel(answers []*model.Answer) (*excelize.File, error) {
 		}
 		f.SetCellValue(sheet, fmt.Sprintf("K%d", row), targetTier)
 		f.SetCellValue(sheet, fmt.Sprintf("L%d", row), targetJustification)
+		f.SetCellValue(sheet, fmt.Sprintf("M%d", row), derefString(a.Attachments))
 	}
-
-	return f, nil
 }
 
 // derefString returns the pointed-to string, or "" when the pointer is nil, so a
@@ -1,13 +1,16 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/CMS-Enterprise/ztmf/backend/cmd/api/internal/migrations"
 	"github.com/CMS-Enterprise/ztmf/backend/cmd/api/internal/router"
 	"github.com/CMS-Enterprise/ztmf/backend/internal/config"
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
 )
 
 // @title           ZTMF API
@@ -24,6 +27,18 @@ func main() {
 
 	migrations.Run()
 
+	// Before serving, so the first aggregate read after the migration that
+	// creates the table is not empty.
+	if seeded, err := model.SeedScoreAggregates(context.Background()); err != nil {
+		log.Printf("SCORE_AGGREGATES_STALE seed failed: %v", err)
+	} else if seeded {
+		log.Print("seeded score aggregates")
+	}
+
+	go openScheduledDataCalls(time.Minute)
+	go refreshDirtyScoreAggregates(time.Minute)
+	go ensureEventPartitions(24 * time.Hour)
+
 	server := &http.Server{
 		Addr:    ":" + cfg.Port,
 		Handler: router.Handler(),
@@ -52,3 +67,48 @@ func main() {
 		log.Fatal("Failed to start server:", server.ListenAndServe())
 	}
 }
+
+// openScheduledDataCalls opens staged data calls once their opens_at passes.
+// Every API task runs it; model.OpenScheduledDataCalls makes the transition
+// safe to race, so at most one task rolls over any given call.
+func openScheduledDataCalls(every time.Duration) {
+	for range time.Tick(every) {
+		opened, err := model.OpenScheduledDataCalls(context.Background())
+		if err != nil {
+			log.Printf("scheduled data call open failed: %v", err)
+		}
+		for _, id := range opened {
+			log.Printf("opened scheduled data call %d", id)
+		}
+	}
+}
+
+// ensureEventPartitions keeps the events partitions created ahead, at startup
+// and then every interval. A partition that already exists is left alone
+// without a lock, so the daily run is cheap; two tasks creating the same
+// month at once leave one of them to log the failure and find it made on the
+// next run.
+func ensureEventPartitions(every time.Duration) {
+	for {
+		if err := model.EnsureEventPartitions(context.Background(), time.Now()); err != nil {
+			log.Printf("EVENTS_PARTITIONS create ahead failed: %v", err)
+		}
+		time.Sleep(every)
+	}
+}
+
+// refreshDirtyScoreAggregates refreshes the score aggregate scopes left dirty
+// by a failed refresh or a write outside the model; until it does, reads of
+// them run the live query. Refreshes of the same scope lock each other out,
+// so tasks sweeping together queue rather than collide.
+func refreshDirtyScoreAggregates(every time.Duration) {
+	for range time.Tick(every) {
+		refreshed, err := model.RefreshDirtyScoreAggregates(context.Background())
+		if err != nil {
+			log.Printf("SCORE_AGGREGATES_STALE sweep failed: %v", err)
+		}
+		if refreshed > 0 {
+			log.Printf("refreshed %d dirty score aggregate scopes", refreshed)
+		}
+	}
+}
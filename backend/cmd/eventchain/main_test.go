@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRange(t *testing.T) {
+	input, err := parseRange("", "")
+	require.NoError(t, err)
+	assert.Nil(t, input.From)
+	assert.Nil(t, input.To)
+
+	input, err = parseRange("2026-01-01T00:00:00Z", "")
+	require.NoError(t, err)
+	require.NotNil(t, input.From)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), input.From.UTC())
+	assert.Nil(t, input.To)
+
+	_, err = parseRange("", "yesterday")
+	assert.ErrorContains(t, err, "-to")
+}
+
+func TestReport(t *testing.T) {
+	var out bytes.Buffer
+	report(&out, &model.EventChainReport{Verified: true, Checked: 120, PreChain: 4, Gaps: 1, Checkpoints: 2, SignaturesChecked: true})
+	assert.Equal(t, "EVENTS_CHAIN verified=true checked=120 prechain=4 gaps=1 checkpoints=2 skipped=0 signatures=true\n", out.String())
+}
+
+func TestReport_Break(t *testing.T) {
+	at := time.Date(2026, 9, 14, 8, 30, 0, 0, time.UTC)
+
+	var out bytes.Buffer
+	report(&out, &model.EventChainReport{
+		Checked: 41,
+		Break:   &model.EventChainBreak{EventID: 977, CreatedAt: &at, Kind: model.EventChainAltered, Detail: "the event does not hash to its stored hash"},
+	})
+	assert.Equal(t,
+		"break eventid=977 kind=altered createdat=2026-09-14T08:30:00Z detail=\"the event does not hash to its stored hash\"\n"+
+			"EVENTS_CHAIN verified=false checked=41 prechain=0 gaps=0 checkpoints=0 skipped=0 signatures=false\n",
+		out.String())
+}
+
+func TestReportCheckpoint(t *testing.T) {
+	cp := &model.EventCheckpoint{EventID: 978, Hash: "ab12", KeyID: "0f1e2d3c4b5a6978", ObjectKey: "checkpoints/00000000000000000978.json"}
+
+	var out bytes.Buffer
+	reportCheckpoint(&out, cp, true)
+	reportCheckpoint(&out, cp, false)
+	assert.Equal(t,
+		"checkpoint published eventid=978 hash=ab12 key=0f1e2d3c4b5a6978 object=checkpoints/00000000000000000978.json\n"+
+			"checkpoint unchanged eventid=978 hash=ab12 key=0f1e2d3c4b5a6978 object=checkpoints/00000000000000000978.json\n",
+		out.String())
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport(t *testing.T) {
+	until := time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+
+	var out bytes.Buffer
+	report(&out, &model.EventRetentionResult{
+		Archived: []*model.EventArchive{{RangeStart: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), EventCount: 1200, ObjectKey: "2024/03.ndjson.gz"}},
+		Released: []*model.EventArchive{{RangeStart: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), EventCount: 900}},
+		Held:     []*model.EventArchive{{RangeStart: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), RehydratedUntil: &until}},
+	})
+
+	assert.Equal(t,
+		"archived month=2024-03 events=1200 key=2024/03.ndjson.gz\n"+
+			"released month=2024-01 events=900\n"+
+			"held month=2024-02 until=2026-10-20T09:00:00Z\n"+
+			"EVENTS_RETENTION archived=1 released=1 held=1\n",
+		out.String())
+}
+
+func TestReport_Nothing(t *testing.T) {
+	var out bytes.Buffer
+	report(&out, &model.EventRetentionResult{})
+	assert.Equal(t, "EVENTS_RETENTION archived=0 released=0 held=0\n", out.String())
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport(t *testing.T) {
+	stored, live := 3.25, 3.5
+
+	var out bytes.Buffer
+	report(&out, []*model.ScoreAggregateDrift{
+		{DataCallID: 5, FismaSystemID: 1001, PillarID: 2, Kind: model.ScoreAggregateDiffers, StoredScore: &stored, LiveScore: &live, StoredSystemScore: &stored, LiveSystemScore: &live},
+		{DataCallID: 5, FismaSystemID: 1002, PillarID: 1, Kind: model.ScoreAggregateMissing, LiveScore: &live, LiveSystemScore: &live},
+	})
+
+	assert.Equal(t,
+		"differs datacall=5 fismasystem=1001 pillar=2 score=3.2500/3.5000 system_score=3.2500/3.5000\n"+
+			"missing datacall=5 fismasystem=1002 pillar=1 score=-/3.5000 system_score=-/3.5000\n"+
+			"SCORE_AGGREGATES_DRIFT rows=2\n",
+		out.String())
+}
+
+func TestReport_Consistent(t *testing.T) {
+	var out bytes.Buffer
+	report(&out, nil)
+	assert.Equal(t, "SCORE_AGGREGATES_DRIFT rows=0\n", out.String())
+}
@@ -0,0 +1,64 @@
+package attachments
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, "scores/7/abc", strings.NewReader("evidence"), 8, "text/plain"))
+
+	rc, err := store.Get(ctx, "scores/7/abc")
+	require.NoError(t, err)
+	body, err := io.ReadAll(rc)
+	rc.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "evidence", string(body))
+
+	require.NoError(t, store.Delete(ctx, "scores/7/abc"))
+	_, err = store.Get(ctx, "scores/7/abc")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, store.Delete(ctx, "scores/7/abc"), ErrNotFound)
+}
+
+// TestLocalStore_ShortWrite pins that a body shorter than its declared size
+// leaves nothing behind, rather than a truncated file under the key.
+func TestLocalStore_ShortWrite(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	store, err := NewLocalStore(root)
+	require.NoError(t, err)
+
+	assert.Error(t, store.Put(ctx, "short", strings.NewReader("abc"), 10, "text/plain"))
+	_, err = store.Get(ctx, "short")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	entries, err := os.ReadDir(root)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the temporary file is removed")
+}
+
+func TestLocalStore_KeyCannotEscapeRoot(t *testing.T) {
+	ctx := context.Background()
+	root := filepath.Join(t.TempDir(), "store")
+	store, err := NewLocalStore(root)
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "../outside", "a/../../outside", "/etc/passwd"} {
+		assert.Error(t, store.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"), key)
+		_, err := store.Get(ctx, key)
+		assert.Error(t, err, key)
+		assert.NotErrorIs(t, err, ErrNotFound, key)
+	}
+}
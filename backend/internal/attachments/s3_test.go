@@ -0,0 +1,75 @@
+package attachments
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 is an in-memory bucket answering the calls S3Store makes.
+type fakeS3 struct {
+	objects map[string][]byte
+	puts    []*s3.PutObjectInput
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, in *s3.PutObjectInput, opts ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	body, err := io.ReadAll(in.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.objects[*in.Key] = body
+	f.puts = append(f.puts, in)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, in *s3.GetObjectInput, opts ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	body, ok := f.objects[*in.Key]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(body))}, nil
+}
+
+func (f *fakeS3) HeadObject(ctx context.Context, in *s3.HeadObjectInput, opts ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	if _, ok := f.objects[*in.Key]; !ok {
+		return nil, &types.NotFound{}
+	}
+	return &s3.HeadObjectOutput{}, nil
+}
+
+func (f *fakeS3) DeleteObject(ctx context.Context, in *s3.DeleteObjectInput, opts ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	delete(f.objects, *in.Key)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func TestS3Store_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeS3{objects: map[string][]byte{}}
+	store := NewS3Store(client, "bucket", "attachments/")
+
+	require.NoError(t, store.Put(ctx, "scores/7/abc", bytes.NewReader([]byte("evidence")), 8, "application/pdf"))
+	require.Len(t, client.puts, 1)
+	put := client.puts[0]
+	assert.Equal(t, "bucket", *put.Bucket)
+	assert.Equal(t, "attachments/scores/7/abc", *put.Key, "keys are written under the prefix")
+	assert.Equal(t, "application/pdf", *put.ContentType)
+	assert.Equal(t, int64(8), *put.ContentLength)
+	assert.Equal(t, types.ServerSideEncryptionAes256, put.ServerSideEncryption)
+
+	rc, err := store.Get(ctx, "scores/7/abc")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, "evidence", string(body))
+
+	require.NoError(t, store.Delete(ctx, "scores/7/abc"))
+	_, err = store.Get(ctx, "scores/7/abc")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, store.Delete(ctx, "scores/7/abc"), ErrNotFound, "a missing object is reported, not silently deleted")
+}
@@ -0,0 +1,80 @@
+// Package attachments stores the files attached to score answers. The model
+// keeps the metadata in scoreattachments; a Store keeps the bytes under the
+// key recorded there. The events retention job keeps its archives in a Store
+// of its own, built the same way.
+package attachments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/config"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// ErrNotFound is returned by Get and Delete for a key the store does not hold.
+var ErrNotFound = errors.New("attachment not found")
+
+// Store is the storage backend behind attachments. Keys are generated by the
+// model, never taken from a request.
+type Store interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+var (
+	store     Store
+	storeOnce sync.Once
+	storeErr  error
+
+	eventArchive     Store
+	eventArchiveOnce sync.Once
+	eventArchiveErr  error
+)
+
+// Default returns the Store configured by ATTACHMENTS_BACKEND, built once and
+// shared by every request.
+func Default() (Store, error) {
+	storeOnce.Do(func() {
+		cfg := config.GetInstance().Attachments
+		store, storeErr = open(cfg.Backend, cfg.Dir, cfg.Bucket, cfg.Prefix, "ATTACHMENTS")
+	})
+	return store, storeErr
+}
+
+// EventArchive returns the Store configured by EVENTS_ARCHIVE_BACKEND, where
+// events retention writes the months it archives. It is a second Store of
+// the same kinds, never the attachments one: archives and evidence files
+// have different owners and lifetimes.
+func EventArchive() (Store, error) {
+	eventArchiveOnce.Do(func() {
+		cfg := config.GetInstance().EventArchive
+		eventArchive, eventArchiveErr = open(cfg.Backend, cfg.Dir, cfg.Bucket, cfg.Prefix, "EVENTS_ARCHIVE")
+	})
+	return eventArchive, eventArchiveErr
+}
+
+// open builds the Store backend names. envPrefix is the settings' prefix,
+// so an error names the variable to fix.
+func open(backend, dir, bucket, prefix, envPrefix string) (Store, error) {
+	switch backend {
+	case "local":
+		return NewLocalStore(dir)
+	case "s3":
+		if bucket == "" {
+			return nil, fmt.Errorf("%s_S3_BUCKET is required for the s3 backend", envPrefix)
+		}
+		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		return NewS3Store(s3.NewFromConfig(awsCfg), bucket, prefix), nil
+	default:
+		return nil, fmt.Errorf("unknown %s_BACKEND %q", envPrefix, backend)
+	}
+}
@@ -2,8 +2,11 @@ package config
 
 import (
 	"context"
+	"crypto/ed25519"
 	"crypto/x509"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"log"
 	"sync"
 
@@ -95,6 +98,36 @@ type config struct {
 		SecretId    string  `env:"DB_SECRET_ID"`
 		PopulateSql *string `env:"DB_POPULATE"` // path to sql to populate test database
 	}
+	// Attachments selects where evidence files on score answers are stored
+	// (internal/attachments): "local" writes under Dir, "s3" under Prefix in
+	// Bucket. Local is for development; deployed environments use s3 so every
+	// API task sees the same files.
+	Attachments struct {
+		Backend string `env:"ATTACHMENTS_BACKEND" envDefault:"local"`
+		Dir     string `env:"ATTACHMENTS_DIR" envDefault:"attachments"`
+		Bucket  string `env:"ATTACHMENTS_S3_BUCKET"`
+		Prefix  string `env:"ATTACHMENTS_S3_PREFIX" envDefault:"attachments/"`
+	}
+	// EventArchive configures events retention (cmd/eventsretention): months
+	// of events older than RetentionDays are archived to a blob store chosen
+	// like Attachments' and dropped, and a month rehydrated for an
+	// investigation stays back in the table for RehydrateDays.
+	EventArchive struct {
+		Backend       string `env:"EVENTS_ARCHIVE_BACKEND" envDefault:"local"`
+		Dir           string `env:"EVENTS_ARCHIVE_DIR" envDefault:"eventarchive"`
+		Bucket        string `env:"EVENTS_ARCHIVE_S3_BUCKET"`
+		Prefix        string `env:"EVENTS_ARCHIVE_S3_PREFIX" envDefault:"events/"`
+		RetentionDays int    `env:"EVENTS_RETENTION_DAYS" envDefault:"730"`
+		RehydrateDays int    `env:"EVENTS_REHYDRATE_DAYS" envDefault:"14"`
+	}
+	// EventChain holds the Ed25519 keys behind the events hash chain's signed
+	// checkpoints, base64 encoded: SigningKey is the 32-byte seed, set only
+	// where checkpoints are written (cmd/eventchain), and PublicKey verifies
+	// them. See EventCheckpointKeys.
+	EventChain struct {
+		SigningKey string `env:"EVENTS_CHECKPOINT_SIGNING_KEY"`
+		PublicKey  string `env:"EVENTS_CHECKPOINT_PUBLIC_KEY"`
+	}
 	// SMTP config will be loaded from env vars if provided.
 	// If config secret is provided, struct field values will be overwritten by unmarshalling JSON from config secret value hence the pointer to struct
 	SMTP *smtp
@@ -268,3 +301,34 @@ func (c *config) IsLocal() bool {
 func (c *config) IsLocalOrTest() bool {
 	return c.Env == "local" || c.Env == "test"
 }
+
+// EventCheckpointKeys decodes the event chain's checkpoint keys. The private
+// key is nil unless EVENTS_CHECKPOINT_SIGNING_KEY is set; the public key is
+// derived from it then, and must match EVENTS_CHECKPOINT_PUBLIC_KEY when both
+// are set, so a verifier is never handed a key the signer does not hold. Both
+// nil means checkpoints are neither written nor signature checked.
+func (c *config) EventCheckpointKeys() (ed25519.PrivateKey, ed25519.PublicKey, error) {
+	var (
+		priv ed25519.PrivateKey
+		pub  ed25519.PublicKey
+	)
+	if c.EventChain.SigningKey != "" {
+		seed, err := base64.StdEncoding.DecodeString(c.EventChain.SigningKey)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, nil, fmt.Errorf("EVENTS_CHECKPOINT_SIGNING_KEY must be a base64 %d-byte Ed25519 seed", ed25519.SeedSize)
+		}
+		priv = ed25519.NewKeyFromSeed(seed)
+		pub = priv.Public().(ed25519.PublicKey)
+	}
+	if c.EventChain.PublicKey != "" {
+		key, err := base64.StdEncoding.DecodeString(c.EventChain.PublicKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, nil, fmt.Errorf("EVENTS_CHECKPOINT_PUBLIC_KEY must be a base64 %d-byte Ed25519 public key", ed25519.PublicKeySize)
+		}
+		if pub != nil && !pub.Equal(ed25519.PublicKey(key)) {
+			return nil, nil, errors.New("EVENTS_CHECKPOINT_PUBLIC_KEY does not match EVENTS_CHECKPOINT_SIGNING_KEY")
+		}
+		pub = key
+	}
+	return priv, pub, nil
+}
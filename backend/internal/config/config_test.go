@@ -2,6 +2,8 @@ package config
 
 import (
 	"context"
+	"crypto/ed25519"
+	"encoding/base64"
 	"testing"
 )
 
@@ -63,3 +65,46 @@ func TestEnvironmentGates(t *testing.T) {
 		}
 	}
 }
+
+// The public key is derived from the seed, so a signer needs only the one
+// variable; a public key that disagrees with the seed is refused.
+func TestEventCheckpointKeys(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	seed[0] = 7
+	want := ed25519.NewKeyFromSeed(seed)
+	wantPub := want.Public().(ed25519.PublicKey)
+
+	cfg := &config{}
+	priv, pub, err := cfg.EventCheckpointKeys()
+	if err != nil || priv != nil || pub != nil {
+		t.Fatalf("EventCheckpointKeys() unset = %v, %v, %v; want nil keys", priv, pub, err)
+	}
+
+	cfg.EventChain.SigningKey = base64.StdEncoding.EncodeToString(seed)
+	priv, pub, err = cfg.EventCheckpointKeys()
+	if err != nil || !priv.Equal(want) || !pub.Equal(wantPub) {
+		t.Fatalf("EventCheckpointKeys() from seed = %v, %v; want the seed's keys", pub, err)
+	}
+
+	cfg.EventChain.PublicKey = base64.StdEncoding.EncodeToString(wantPub)
+	if _, _, err := cfg.EventCheckpointKeys(); err != nil {
+		t.Errorf("EventCheckpointKeys() with matching public key returned error: %v", err)
+	}
+
+	cfg.EventChain.SigningKey = ""
+	priv, pub, err = cfg.EventCheckpointKeys()
+	if err != nil || priv != nil || !pub.Equal(wantPub) {
+		t.Errorf("EventCheckpointKeys() public only = %v, %v, %v; want only the public key", priv, pub, err)
+	}
+
+	other := make([]byte, ed25519.SeedSize)
+	cfg.EventChain.SigningKey = base64.StdEncoding.EncodeToString(other)
+	if _, _, err := cfg.EventCheckpointKeys(); err == nil {
+		t.Error("EventCheckpointKeys() with mismatched keys returned nil, want error")
+	}
+
+	cfg.EventChain.SigningKey = "not base64"
+	if _, _, err := cfg.EventCheckpointKeys(); err == nil {
+		t.Error("EventCheckpointKeys() with a bad seed returned nil, want error")
+	}
+}
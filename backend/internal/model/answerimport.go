@@ -0,0 +1,510 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// Kinds of AnswerImportChange.
+const (
+	AnswerImportCreate = "create" // the system has not answered the function in this data call
+	AnswerImportUpdate = "update" // the workbook changes the stored option or notes
+)
+
+// AnswerImportRow is one row of the answer workbook spreadsheet.Excel writes,
+// as spreadsheet.ParseAnswerWorkbook reads it back. Row is the sheet row. The
+// option cells are as typed, blank when empty; Notes is nil when the workbook
+// has no notes column, which leaves stored notes alone, while a blank notes
+// cell clears them.
+type AnswerImportRow struct {
+	Row               int
+	FismaAcronym      string
+	Pillar            string
+	Function          string
+	Question          string
+	OptionDescription string
+	OptionName        string
+	Score             *int
+	Notes             *string
+}
+
+// AnswerImportInput is a parsed workbook for one data call. ParseErrors are
+// the parser's findings, reported with the rest. UserID and OpDivScope limit
+// the systems a row can name to those the caller sees in the export. Preview
+// and Accept are the request's choice between previewing and applying, and of
+// the rows to apply; only they decode from the query string.
+type AnswerImportInput struct {
+	Preview     bool                  `schema:"preview"`
+	Accept      []int                 `schema:"accept"`
+	DataCallID  int32                 `schema:"-"`
+	Rows        []AnswerImportRow     `schema:"-"`
+	ParseErrors []ScoreImportRowError `schema:"-"`
+	UserID      *string               `schema:"-"`
+	OpDivScope
+}
+
+// AnswerImportChange is a workbook row that differs from what is stored: the
+// stored answer (nil on a create) beside the one the row would save.
+type AnswerImportChange struct {
+	Row                    int     `json:"row"`
+	Kind                   string  `json:"kind"`
+	FismaSystemID          int32   `json:"fismasystemid"`
+	FismaAcronym           string  `json:"fismaacronym"`
+	FunctionID             int32   `json:"functionid"`
+	Function               string  `json:"function"`
+	ScoreID                *int32  `json:"scoreid"`
+	StoredFunctionOptionID *int32  `json:"stored_functionoptionid"`
+	StoredOptionName       *string `json:"stored_optionname"`
+	StoredNotes            *string `json:"stored_notes"`
+	FunctionOptionID       int32   `json:"functionoptionid"`
+	OptionName             string  `json:"optionname"`
+	Notes                  *string `json:"notes"`
+}
+
+// AnswerImportPreview sorts every data row of the workbook into exactly one
+// of Changes, Unchanged (row numbers) and Findings.
+type AnswerImportPreview struct {
+	DataCallID int32                 `json:"datacallid"`
+	Changes    []AnswerImportChange  `json:"changes"`
+	Unchanged  []int                 `json:"unchanged"`
+	Findings   []ScoreImportRowError `json:"findings"`
+}
+
+// AnswerImportResult is returned once accepted changes are saved. Unchanged
+// lists accepted rows that had nothing left to save.
+type AnswerImportResult struct {
+	DataCallID int32    `json:"datacallid"`
+	Applied    []*Score `json:"applied"`
+	Unchanged  []int    `json:"unchanged"`
+}
+
+// answerImportTarget is one export row a workbook row can match: a system and
+// function of the data call's questionnaire, with the stored answer if any.
+type answerImportTarget struct {
+	FismaSystemID    int32
+	FismaAcronym     string
+	Pillar           string
+	Question         string
+	Function         string
+	FunctionID       int32
+	ScoreID          *int32
+	FunctionOptionID *int32
+	Notes            *string
+}
+
+const answerImportTargetColumns = "fismasystems.fismasystemid, fismasystems.fismaacronym, pillars.pillar, questions.question, functions.function, functions.functionid, scores.scoreid, scores.functionoptionid, scores.notes"
+
+// PreviewAnswerImport matches each workbook row to a system, function and
+// option and compares it with the stored answer, writing nothing. Rows match
+// against the rows the export would write for the data call now: its pinned
+// catalog, under the caller's scope. Systems whose answers the call does not
+// accept from this caller - a draft, a closed call for a non-admin, a passed
+// deadline the system has no extension for - get a finding on every row.
+//
+// The caller authorizes the systems in Changes before applying them.
+func PreviewAnswerImport(ctx context.Context, input AnswerImportInput) (*AnswerImportPreview, error) {
+	user := UserFromContext(ctx)
+	if user == nil {
+		return nil, &InvalidInputError{data: map[string]any{"user": "required"}}
+	}
+
+	if len(input.Rows) == 0 && len(input.ParseErrors) == 0 {
+		return nil, &InvalidInputError{data: map[string]any{"file": "contains no answers"}}
+	}
+	if len(input.Rows) > maxScoreImportRows {
+		return nil, &InvalidInputError{data: map[string]any{"file": fmt.Sprintf("at most %d rows per import", maxScoreImportRows)}}
+	}
+
+	dataCall, err := FindDataCallByID(ctx, input.DataCallID)
+	if err != nil {
+		return nil, err
+	}
+
+	acronyms := []string{}
+	seen := map[string]bool{}
+	for _, r := range input.Rows {
+		a := strings.TrimSpace(r.FismaAcronym)
+		if a != "" && !seen[a] {
+			seen[a] = true
+			acronyms = append(acronyms, a)
+		}
+	}
+
+	sqlb := answerRowsSelect(FindAnswersInput{
+		DataCallID: input.DataCallID,
+		UserID:     input.UserID,
+		OpDivScope: input.OpDivScope,
+	}, answerImportTargetColumns).Where(squirrel.Eq{"fismasystems.fismaacronym": acronyms})
+
+	targets, err := query(ctx, sqlb, pgx.RowToAddrOfStructByName[answerImportTarget])
+	if err != nil {
+		return nil, err
+	}
+
+	functionIDs := []int32{}
+	writable := map[int32]error{}
+	for _, t := range targets {
+		functionIDs = append(functionIDs, t.FunctionID)
+		if _, ok := writable[t.FismaSystemID]; ok {
+			continue
+		}
+		// The same check Score.Save makes, once per system.
+		if err := dataCall.ApplyDeadlineExtension(ctx, t.FismaSystemID); err != nil {
+			return nil, err
+		}
+		writable[t.FismaSystemID] = dataCall.CheckWritable(user, time.Now().UTC())
+	}
+
+	options, err := query(ctx, stmntBuilder.
+		Select(functionOptionColumns...).
+		From("functionoptions").
+		Where(squirrel.Eq{"functionid": functionIDs}).
+		OrderBy("score ASC, functionoptionid ASC"), pgx.RowToAddrOfStructByName[FunctionOption])
+	if err != nil {
+		return nil, err
+	}
+
+	preview := diffAnswerImport(input.Rows, targets, options, writable)
+	preview.DataCallID = input.DataCallID
+	preview.Findings = append(preview.Findings, input.ParseErrors...)
+	sort.SliceStable(preview.Findings, func(i, j int) bool { return preview.Findings[i].Row < preview.Findings[j].Row })
+
+	return preview, nil
+}
+
+// diffAnswerImport is the matching and comparison half of PreviewAnswerImport,
+// kept free of the database so the rules are unit-testable. writable holds
+// each target system's CheckWritable result.
+func diffAnswerImport(rows []AnswerImportRow, targets []*answerImportTarget, options []*FunctionOption, writable map[int32]error) *AnswerImportPreview {
+	preview := &AnswerImportPreview{Changes: []AnswerImportChange{}, Unchanged: []int{}, Findings: []ScoreImportRowError{}}
+
+	systems := map[string]bool{}
+	for _, t := range targets {
+		systems[t.FismaAcronym] = true
+	}
+	optionsByFunction := map[int32][]*FunctionOption{}
+	for _, o := range options {
+		optionsByFunction[o.FunctionID] = append(optionsByFunction[o.FunctionID], o)
+	}
+
+	type answerKey struct{ fismaSystemID, functionID int32 }
+	firstRow := map[answerKey]int{}
+
+	for _, r := range rows {
+		finding := func(field, message string) {
+			preview.Findings = append(preview.Findings, ScoreImportRowError{Row: r.Row, Field: field, Message: message})
+		}
+
+		acronym := strings.TrimSpace(r.FismaAcronym)
+		switch {
+		case acronym == "":
+			finding("Fisma Acronym", "required")
+			continue
+		case strings.TrimSpace(r.Pillar) == "":
+			finding("Pillar", "required")
+			continue
+		case strings.TrimSpace(r.Function) == "":
+			finding("Function", "required")
+			continue
+		case !systems[acronym]:
+			finding("Fisma Acronym", fmt.Sprintf("no system %q among those in this data call you can see", acronym))
+			continue
+		}
+
+		target, message := matchAnswerImportTarget(targets, acronym, r)
+		if target == nil {
+			finding("Function", message)
+			continue
+		}
+
+		key := answerKey{target.FismaSystemID, target.FunctionID}
+		if first, ok := firstRow[key]; ok {
+			finding("", fmt.Sprintf("duplicates row %d (same system and function)", first))
+			continue
+		}
+		firstRow[key] = r.Row
+
+		if err := writable[target.FismaSystemID]; err != nil {
+			finding("", err.Error())
+			continue
+		}
+
+		notes := target.Notes
+		if r.Notes != nil {
+			notes = nil
+			if *r.Notes != "" {
+				notes = r.Notes
+			}
+		}
+		if notes != nil && utf8.RuneCountInString(*notes) > 2000 {
+			finding("ADO Answer Details", ErrNotesTooLong.Error())
+			continue
+		}
+
+		functionOptions := optionsByFunction[target.FunctionID]
+		option, message := matchAnswerImportOption(functionOptions, target.FunctionOptionID, r)
+		if message != "" {
+			finding("Answer", message)
+			continue
+		}
+		if option == nil {
+			// Blank on both sides: a function the system has yet to answer.
+			if notes != nil {
+				finding("Answer", "notes need an answer; choose an option")
+				continue
+			}
+			preview.Unchanged = append(preview.Unchanged, r.Row)
+			continue
+		}
+
+		if target.FunctionOptionID != nil && *target.FunctionOptionID == option.FunctionOptionID &&
+			derefString(target.Notes) == derefString(notes) {
+			preview.Unchanged = append(preview.Unchanged, r.Row)
+			continue
+		}
+
+		change := AnswerImportChange{
+			Row:                    r.Row,
+			Kind:                   AnswerImportCreate,
+			FismaSystemID:          target.FismaSystemID,
+			FismaAcronym:           target.FismaAcronym,
+			FunctionID:             target.FunctionID,
+			Function:               target.Function,
+			ScoreID:                target.ScoreID,
+			StoredFunctionOptionID: target.FunctionOptionID,
+			StoredNotes:            target.Notes,
+			FunctionOptionID:       option.FunctionOptionID,
+			OptionName:             option.OptionName,
+			Notes:                  notes,
+		}
+		if target.ScoreID != nil {
+			change.Kind = AnswerImportUpdate
+		}
+		for _, o := range functionOptions {
+			if target.FunctionOptionID != nil && o.FunctionOptionID == *target.FunctionOptionID {
+				change.StoredOptionName = &o.OptionName
+			}
+		}
+		preview.Changes = append(preview.Changes, change)
+	}
+
+	return preview
+}
+
+// matchAnswerImportTarget finds the row's function among the system's export
+// rows by pillar and function name, and by question text only when the name
+// alone is ambiguous (a function carried over from another edition can share
+// its name). It returns the reason when there is no single match.
+func matchAnswerImportTarget(targets []*answerImportTarget, acronym string, r AnswerImportRow) (*answerImportTarget, string) {
+	var matches []*answerImportTarget
+	for _, t := range targets {
+		if t.FismaAcronym == acronym && sameCell(t.Pillar, r.Pillar) && sameCell(t.Function, r.Function) {
+			matches = append(matches, t)
+		}
+	}
+
+	if len(matches) > 1 && strings.TrimSpace(r.Question) != "" {
+		var byQuestion []*answerImportTarget
+		for _, t := range matches {
+			if sameCell(t.Question, r.Question) {
+				byQuestion = append(byQuestion, t)
+			}
+		}
+		matches = byQuestion
+	}
+
+	switch len(matches) {
+	case 1:
+		return matches[0], ""
+	case 0:
+		return nil, fmt.Sprintf("no function %q under pillar %q in this data call's questionnaire for %s",
+			strings.TrimSpace(r.Function), strings.TrimSpace(r.Pillar), acronym)
+	default:
+		return nil, fmt.Sprintf("function %q matches more than one question; keep the Question column as exported", strings.TrimSpace(r.Function))
+	}
+}
+
+// matchAnswerImportOption picks the option the row's Answer, Maturity Tier and
+// Score cells name. Cells that still name the stored option are ones the
+// editor did not touch, so they are set aside and the changed ones decide: an
+// editor who retypes the tier alone does not also have to retype the
+// description. It returns nil and no message when every option cell is blank
+// and nothing is stored.
+func matchAnswerImportOption(options []*FunctionOption, stored *int32, r AnswerImportRow) (*FunctionOption, string) {
+	type cell func(o *FunctionOption) bool
+
+	var cells []cell
+	if strings.TrimSpace(r.OptionDescription) != "" {
+		cells = append(cells, func(o *FunctionOption) bool { return sameCell(o.Description, r.OptionDescription) })
+	}
+	if strings.TrimSpace(r.OptionName) != "" {
+		cells = append(cells, func(o *FunctionOption) bool { return sameCell(o.OptionName, r.OptionName) })
+	}
+	if r.Score != nil {
+		cells = append(cells, func(o *FunctionOption) bool { return int(o.Score) == *r.Score })
+	}
+
+	var current *FunctionOption
+	for _, o := range options {
+		if stored != nil && o.FunctionOptionID == *stored {
+			current = o
+		}
+	}
+
+	if len(cells) == 0 {
+		if stored != nil {
+			return nil, "blank; an import cannot remove an answer"
+		}
+		return nil, ""
+	}
+
+	var changed []cell
+	for _, c := range cells {
+		if current == nil || !c(current) {
+			changed = append(changed, c)
+		}
+	}
+	if len(changed) == 0 {
+		return current, ""
+	}
+
+	var matches []*FunctionOption
+	for _, o := range options {
+		all := true
+		for _, c := range changed {
+			all = all && c(o)
+		}
+		if all {
+			matches = append(matches, o)
+		}
+	}
+
+	switch len(matches) {
+	case 1:
+		return matches[0], ""
+	case 0:
+		return nil, "Answer, Maturity Tier and Score do not name one option of this function"
+	default:
+		return nil, "names more than one option of this function; fill in Maturity Tier"
+	}
+}
+
+// sameCell compares a workbook cell with a stored value, ignoring the
+// surrounding space and letter case a spreadsheet edit can introduce.
+func sameCell(stored, cell string) bool {
+	return strings.EqualFold(strings.TrimSpace(stored), strings.TrimSpace(cell))
+}
+
+// ApplyAnswerImport saves the accepted rows of a preview, each through
+// Score.Save: a create or an update attributed to the caller exactly as if it
+// were made in the questionnaire, deadline check included. Accepting a row
+// with a finding, or a row that is not in the workbook, fails the import with
+// a per-row report before anything is written; an accepted row that turned
+// out unchanged is listed rather than saved.
+//
+// The saves are independent, as in the questionnaire. If one fails the ones
+// before it stand, and previewing the workbook again shows what is left.
+func ApplyAnswerImport(ctx context.Context, preview *AnswerImportPreview, accept []int) (*AnswerImportResult, error) {
+	user := UserFromContext(ctx)
+	if user == nil {
+		return nil, &InvalidInputError{data: map[string]any{"user": "required"}}
+	}
+	if len(accept) == 0 {
+		return nil, &InvalidInputError{data: map[string]any{"accept": "name the preview rows to apply"}}
+	}
+
+	changes, unchanged, err := acceptAnswerImport(preview, accept)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AnswerImportResult{DataCallID: preview.DataCallID, Applied: []*Score{}, Unchanged: unchanged}
+	// One refresh of the call's aggregates for the whole import, even one cut
+	// short, rather than one per row.
+	if len(changes) > 0 {
+		defer refreshScoreAggregates(ctx, FindScoresInput{DataCallID: &preview.DataCallID})
+	}
+	for _, c := range changes {
+		s := &Score{
+			FismaSystemID:    c.FismaSystemID,
+			DataCallID:       preview.DataCallID,
+			FunctionOptionID: c.FunctionOptionID,
+			Notes:            c.Notes,
+		}
+		if c.ScoreID != nil {
+			s.ScoreID = *c.ScoreID
+		}
+		// Notes typed into the workbook are the editor's own, whatever wrote
+		// the ones they replace.
+		if derefString(c.Notes) != derefString(c.StoredNotes) {
+			aiSummary := false
+			s.NotesIsAISummary = &aiSummary
+		}
+
+		saved, err := s.Save(ctx, withDeferredAggregates())
+		if err != nil {
+			log.Printf("ANSWER_IMPORT user=%s datacall=%d applied=%d failed_row=%d err=%v",
+				user.UserID, preview.DataCallID, len(result.Applied), c.Row, err)
+			return nil, err
+		}
+		result.Applied = append(result.Applied, saved)
+	}
+
+	log.Printf("ANSWER_IMPORT user=%s datacall=%d applied=%d", user.UserID, preview.DataCallID, len(result.Applied))
+	return result, nil
+}
+
+// acceptAnswerImport picks the accepted rows out of the preview: the changes
+// to save in row order and the accepted rows that are unchanged. Any accepted
+// row with a finding, or that the preview does not have, is an error.
+func acceptAnswerImport(preview *AnswerImportPreview, accept []int) ([]AnswerImportChange, []int, error) {
+	accepted := map[int]bool{}
+	for _, row := range accept {
+		accepted[row] = true
+	}
+
+	var findings []ScoreImportRowError
+	for _, f := range preview.Findings {
+		if accepted[f.Row] {
+			findings = append(findings, f)
+		}
+	}
+
+	known := map[int]bool{}
+	for _, f := range preview.Findings {
+		known[f.Row] = true
+	}
+	changes := []AnswerImportChange{}
+	for _, c := range preview.Changes {
+		known[c.Row] = true
+		if accepted[c.Row] {
+			changes = append(changes, c)
+		}
+	}
+	unchanged := []int{}
+	for _, row := range preview.Unchanged {
+		known[row] = true
+		if accepted[row] {
+			unchanged = append(unchanged, row)
+		}
+	}
+
+	for row := range accepted {
+		if !known[row] {
+			findings = append(findings, ScoreImportRowError{Row: row, Message: "not a data row of the workbook"})
+		}
+	}
+	if len(findings) > 0 {
+		return nil, nil, scoreImportReport(findings)
+	}
+
+	return changes, unchanged, nil
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAnswerImportIntegration previews and applies a one-row workbook that
+// retypes a stored answer's tier: the preview writes nothing, and applying it
+// updates the answer through Score.Save, attributed to the importer.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestAnswerImportIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	var (
+		userID                              string
+		scoreID, dataCallID, storedOptionID int32
+		newOptionID                         int32
+		acronym, pillar, function, tier     string
+	)
+	require.NoError(t, conn.QueryRow(ctx, `
+SELECT (SELECT userid FROM users WHERE role='OWNER' AND deleted=false LIMIT 1),
+       s.scoreid, s.datacallid, s.functionoptionid, other.functionoptionid,
+       fs.fismaacronym, p.pillar, f.function, other.optionname
+  FROM scores s
+  JOIN datacalls dc         ON dc.datacallid = s.datacallid AND dc.status IN ('open', 'closed')
+  JOIN fismasystems fs      ON fs.fismasystemid = s.fismasystemid AND fs.decommissioned = FALSE
+  JOIN functionoptions fo   ON fo.functionoptionid = s.functionoptionid
+  JOIN catalogfunctions f   ON f.catalogversionid = dc.catalogversionid AND f.functionid = fo.functionid
+  JOIN catalogquestions q   ON q.catalogversionid = f.catalogversionid AND q.questionid = f.questionid
+  JOIN pillars p            ON p.pillarid = q.pillarid
+  JOIN functionoptions other ON other.functionid = fo.functionid AND other.functionoptionid <> fo.functionoptionid
+ WHERE NOT EXISTS (SELECT 1 FROM datacallsnapshots ds WHERE ds.datacallid = s.datacallid)
+ ORDER BY s.scoreid LIMIT 1`).Scan(&userID, &scoreID, &dataCallID, &storedOptionID, &newOptionID,
+		&acronym, &pillar, &function, &tier))
+	t.Cleanup(func() {
+		conn.Exec(context.Background(), "UPDATE scores SET functionoptionid = $2 WHERE scoreid = $1", scoreID, storedOptionID)
+	})
+
+	user, err := FindUserByID(ctx, userID)
+	require.NoError(t, err)
+	ctx = UserToContext(ctx, user)
+
+	input := AnswerImportInput{
+		DataCallID: dataCallID,
+		Rows:       []AnswerImportRow{{Row: 2, FismaAcronym: acronym, Pillar: pillar, Function: function, OptionName: tier}},
+	}
+	preview, err := PreviewAnswerImport(ctx, input)
+	require.NoError(t, err)
+	require.Empty(t, preview.Findings)
+	require.Len(t, preview.Changes, 1)
+	assert.Equal(t, AnswerImportUpdate, preview.Changes[0].Kind)
+	assert.Equal(t, newOptionID, preview.Changes[0].FunctionOptionID)
+
+	stored, err := FindScoreByID(ctx, scoreID)
+	require.NoError(t, err)
+	assert.Equal(t, storedOptionID, stored.FunctionOptionID, "a preview writes nothing")
+
+	result, err := ApplyAnswerImport(ctx, preview, []int{2})
+	require.NoError(t, err)
+	require.Len(t, result.Applied, 1)
+	assert.Equal(t, newOptionID, result.Applied[0].FunctionOptionID)
+	assert.Equal(t, scoreStatusDone, result.Applied[0].Status)
+	require.NotNil(t, result.Applied[0].LastEditedBy)
+	assert.Equal(t, userID, result.Applied[0].LastEditedBy.UserID)
+
+	// Applied, the same workbook has nothing left to change.
+	preview, err = PreviewAnswerImport(ctx, input)
+	require.NoError(t, err)
+	assert.Empty(t, preview.Changes)
+	assert.Equal(t, []int{2}, preview.Unchanged)
+}
@@ -0,0 +1,173 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func answerImportFixture() ([]*answerImportTarget, []*FunctionOption) {
+	id := func(n int32) *int32 { return &n }
+	notes := "see ADO 123"
+
+	targets := []*answerImportTarget{
+		// SYS-A answered Fn1 at Initial, and has not answered Fn2.
+		{FismaSystemID: 1, FismaAcronym: "SYS-A", Pillar: "Identity", Question: "Q1", Function: "Fn1", FunctionID: 10,
+			ScoreID: id(500), FunctionOptionID: id(101), Notes: &notes},
+		{FismaSystemID: 1, FismaAcronym: "SYS-A", Pillar: "Devices", Question: "Q2", Function: "Fn2", FunctionID: 20},
+		// SYS-B is past its deadline.
+		{FismaSystemID: 2, FismaAcronym: "SYS-B", Pillar: "Identity", Question: "Q1", Function: "Fn1", FunctionID: 10},
+	}
+
+	var options []*FunctionOption
+	for _, fn := range []int32{10, 20} {
+		for score, name := range []string{"Traditional", "Initial", "Advanced", "Optimal"} {
+			options = append(options, &FunctionOption{
+				FunctionOptionID: fn*10 + int32(score),
+				FunctionID:       fn,
+				Score:            int32(score + 1),
+				OptionName:       name,
+				Description:      name + " practice",
+			})
+		}
+	}
+	return targets, options
+}
+
+// TestDiffAnswerImport sorts each row into a change, unchanged, or a finding,
+// the way an edited export comes back: untouched rows are unchanged, a retyped
+// tier is an update, a first answer is a create.
+func TestDiffAnswerImport(t *testing.T) {
+	targets, options := answerImportFixture()
+	writable := map[int32]error{1: nil, 2: ErrPastDeadline}
+	notes := func(s string) *string { return &s }
+	score := func(n int) *int { return &n }
+
+	preview := diffAnswerImport([]AnswerImportRow{
+		// Untouched export row.
+		{Row: 2, FismaAcronym: "SYS-A", Pillar: "Identity", Function: "Fn1", OptionDescription: "Initial practice",
+			OptionName: "Initial", Score: score(2), Notes: notes("see ADO 123")},
+		// Unanswered and left blank.
+		{Row: 3, FismaAcronym: "SYS-A", Pillar: "Devices", Function: "Fn2", Notes: notes("")},
+		{Row: 4, FismaAcronym: "SYS-B", Pillar: "Identity", Function: "Fn1", OptionName: "Optimal"},
+		{Row: 5, FismaAcronym: "SYS-Z", Pillar: "Identity", Function: "Fn1", OptionName: "Optimal"},
+		{Row: 6, FismaAcronym: "SYS-A", Pillar: "Identity", Function: "Fn9", OptionName: "Optimal"},
+	}, targets, options, writable)
+
+	assert.Empty(t, preview.Changes)
+	assert.Equal(t, []int{2, 3}, preview.Unchanged)
+	assert.Equal(t, []ScoreImportRowError{
+		{Row: 4, Message: ErrPastDeadline.Error()},
+		{Row: 5, Field: "Fisma Acronym", Message: `no system "SYS-Z" among those in this data call you can see`},
+		{Row: 6, Field: "Function", Message: `no function "Fn9" under pillar "Identity" in this data call's questionnaire for SYS-A`},
+	}, preview.Findings)
+
+	preview = diffAnswerImport([]AnswerImportRow{
+		// The tier alone retyped; description and score still name Initial.
+		{Row: 2, FismaAcronym: "SYS-A", Pillar: "identity ", Function: "Fn1", OptionDescription: "Initial practice",
+			OptionName: "advanced", Score: score(2), Notes: notes("see ADO 123")},
+		{Row: 3, FismaAcronym: "SYS-A", Pillar: "Devices", Function: "Fn2", Score: score(4), Notes: notes("new")},
+		{Row: 4, FismaAcronym: "SYS-A", Pillar: "Devices", Function: "Fn2", Score: score(1)},
+	}, targets, options, writable)
+
+	require.Len(t, preview.Changes, 2)
+	update := preview.Changes[0]
+	assert.Equal(t, AnswerImportUpdate, update.Kind)
+	assert.Equal(t, int32(500), *update.ScoreID)
+	assert.Equal(t, "Initial", *update.StoredOptionName)
+	assert.Equal(t, int32(102), update.FunctionOptionID)
+	assert.Equal(t, "Advanced", update.OptionName)
+	assert.Equal(t, "see ADO 123", *update.Notes)
+
+	create := preview.Changes[1]
+	assert.Equal(t, AnswerImportCreate, create.Kind)
+	assert.Nil(t, create.ScoreID)
+	assert.Equal(t, int32(203), create.FunctionOptionID)
+	assert.Equal(t, "new", *create.Notes)
+
+	assert.Equal(t, []ScoreImportRowError{
+		{Row: 4, Message: "duplicates row 3 (same system and function)"},
+	}, preview.Findings)
+}
+
+// TestDiffAnswerImportNotes pins the notes rules: no notes column keeps the
+// stored notes, a blank cell clears them, and the questionnaire's limit holds.
+func TestDiffAnswerImportNotes(t *testing.T) {
+	targets, options := answerImportFixture()
+	writable := map[int32]error{1: nil}
+	blank, long := "", strings.Repeat("x", 2001)
+
+	preview := diffAnswerImport([]AnswerImportRow{
+		{Row: 2, FismaAcronym: "SYS-A", Pillar: "Identity", Function: "Fn1", OptionName: "Initial"},
+	}, targets, options, writable)
+	assert.Equal(t, []int{2}, preview.Unchanged)
+
+	preview = diffAnswerImport([]AnswerImportRow{
+		{Row: 2, FismaAcronym: "SYS-A", Pillar: "Identity", Function: "Fn1", OptionName: "Initial", Notes: &blank},
+		{Row: 3, FismaAcronym: "SYS-A", Pillar: "Devices", Function: "Fn2", OptionName: "Initial", Notes: &long},
+	}, targets, options, writable)
+	require.Len(t, preview.Changes, 1)
+	assert.Nil(t, preview.Changes[0].Notes)
+	assert.Equal(t, []ScoreImportRowError{
+		{Row: 3, Field: "ADO Answer Details", Message: ErrNotesTooLong.Error()},
+	}, preview.Findings)
+}
+
+func TestMatchAnswerImportOption(t *testing.T) {
+	_, options := answerImportFixture()
+	fn1 := options[:4]
+	initial := int32(101)
+	score := func(n int) *int { return &n }
+
+	tests := []struct {
+		name    string
+		stored  *int32
+		row     AnswerImportRow
+		want    int32
+		message string
+	}{
+		{"every cell agrees", nil, AnswerImportRow{OptionDescription: "Optimal practice", OptionName: "Optimal", Score: score(4)}, 103, ""},
+		{"score alone", nil, AnswerImportRow{Score: score(1)}, 100, ""},
+		{"untouched cells set aside", &initial, AnswerImportRow{OptionDescription: "Initial practice", OptionName: "Initial", Score: score(3)}, 102, ""},
+		{"cells disagree", nil, AnswerImportRow{OptionName: "Optimal", Score: score(1)}, 0, "Answer, Maturity Tier and Score do not name one option of this function"},
+		{"cleared answer", &initial, AnswerImportRow{}, 0, "blank; an import cannot remove an answer"},
+		{"blank and unanswered", nil, AnswerImportRow{}, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, message := matchAnswerImportOption(fn1, tt.stored, tt.row)
+			assert.Equal(t, tt.message, message)
+			if tt.want == 0 {
+				assert.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			assert.Equal(t, tt.want, got.FunctionOptionID)
+		})
+	}
+}
+
+// TestAcceptAnswerImport pins that accepting a row with a finding, or one the
+// workbook does not have, rejects the whole apply.
+func TestAcceptAnswerImport(t *testing.T) {
+	preview := &AnswerImportPreview{
+		Changes:   []AnswerImportChange{{Row: 2}, {Row: 4}},
+		Unchanged: []int{3},
+		Findings:  []ScoreImportRowError{{Row: 5, Message: "deadline has passed"}},
+	}
+
+	changes, unchanged, err := acceptAnswerImport(preview, []int{4, 3})
+	require.NoError(t, err)
+	assert.Equal(t, []AnswerImportChange{{Row: 4}}, changes)
+	assert.Equal(t, []int{3}, unchanged)
+
+	_, _, err = acceptAnswerImport(preview, []int{2, 5, 9})
+	var invalid *InvalidInputError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, []ScoreImportRowError{
+		{Row: 5, Message: "deadline has passed"},
+		{Row: 9, Message: "not a data row of the workbook"},
+	}, invalid.data["rows"])
+}
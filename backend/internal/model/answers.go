@@ -30,6 +30,9 @@ type Answer struct {
 	// (the export renders the Advanced default in that case).
 	TargetMaturityTier          *string `db:"target_maturity_tier"`
 	TargetMaturityJustification *string `db:"target_maturity_justification"`
+	// Attachments names the answer's evidence files, oldest first, or is nil
+	// when it has none.
+	Attachments *string
 }
 
 type FindAnswersInput struct {
@@ -40,6 +43,10 @@ type FindAnswersInput struct {
 	// key rather than a silent scope redirect (ztmf-misc#268); OpDivScope carries
 	// the same guard for the OpDiv fields.
 	UserID *string `schema:"-"`
+	// Workbook picks the export's layout: the answers alone (the default), or
+	// ExportWorkbookExecutive, which adds the summary sheets FindExportSummary
+	// feeds.
+	Workbook *string `schema:"workbook"`
 	OpDivScope
 }
 
@@ -47,13 +54,40 @@ type FindAnswersInput struct {
 // leveraging all the necessary joins that would otherwise require multiple DB calls
 // if using lower-level methods such as FindFismaSystems, FindScores, FindQuestions, etc
 // this is primarily meant for use in exporting to spreadsheets
+//
+// A data call frozen at close exports the rows its snapshot recorded
+// (datacallsnapshots.go).
 func FindAnswers(ctx context.Context, input FindAnswersInput) ([]*Answer, error) {
-	sqlb := stmntBuilder.Select("datacalls.datacall, fismasystems.fismasystemid, fismasystems.fismaacronym, fismasystems.datacenterenvironment, fismasystems.target_maturity_tier, fismasystems.target_maturity_justification, pillars.pillar, questions.question, functions.function, functions.description, functionoptions.description AS optiondescription, functionoptions.optionname, functionoptions.score, scores.notes, scores.notes_is_ai_summary").
+	frozen, err := isDataCallFrozen(ctx, input.DataCallID)
+	if err != nil {
+		return nil, err
+	}
+	if frozen {
+		return query(ctx, snapshotAnswersSelect(input), pgx.RowToAddrOfStructByName[Answer])
+	}
+
+	return query(ctx, answersSelect(input), pgx.RowToAddrOfStructByName[Answer])
+}
+
+// answersSelect is the live export query: the data call's questionnaire for
+// every system in input's scope, with each system's answer where it has one.
+func answersSelect(input FindAnswersInput) squirrel.SelectBuilder {
+	return answerRowsSelect(input, "datacalls.datacall, fismasystems.fismasystemid, fismasystems.fismaacronym, fismasystems.datacenterenvironment, fismasystems.target_maturity_tier, fismasystems.target_maturity_justification, pillars.pillar, questions.question, functions.function, functions.description, functionoptions.description AS optiondescription, functionoptions.optionname, functionoptions.score, scores.notes, scores.notes_is_ai_summary, (SELECT string_agg(sa.filename, '; ' ORDER BY sa.createdat, sa.attachmentid) FROM scoreattachments sa WHERE sa.scoreid=scores.scoreid) AS attachments")
+}
+
+// answerRowsSelect selects columns over the export's rows, one per system and
+// function in export order. The answer import (answerimport.go) matches a
+// workbook against the same rows, so anything the export writes reads back.
+func answerRowsSelect(input FindAnswersInput, columns string) squirrel.SelectBuilder {
+	sqlb := stmntBuilder.Select(columns).
 		From("fismasystems").
-		InnerJoin("functions ON (EXISTS (SELECT 1 FROM datacenterenvironments dce WHERE dce.datacenterenvironment=fismasystems.datacenterenvironment AND dce.scoring_key=functions.datacenterenvironment) OR EXISTS (SELECT 1 FROM scores answered JOIN functionoptions answeredopt ON answeredopt.functionoptionid=answered.functionoptionid WHERE answered.fismasystemid=fismasystems.fismasystemid AND answered.datacallid=? AND answeredopt.functionid=functions.functionid))", input.DataCallID).
-		InnerJoin("questions ON questions.questionid=functions.questionid").
-		InnerJoin("pillars ON pillars.pillarid=questions.pillarid").
 		InnerJoin("datacalls ON datacalls.datacallid=?", input.DataCallID).
+		// The catalog is the version the data call pins, aliased to the live
+		// table names so the rest of the query reads as before: a closed
+		// cycle exports with the wording it was answered under.
+		InnerJoin("catalogfunctions functions ON functions.catalogversionid=datacalls.catalogversionid AND (EXISTS (SELECT 1 FROM datacenterenvironments dce WHERE dce.datacenterenvironment=fismasystems.datacenterenvironment AND dce.scoring_key=functions.datacenterenvironment) OR EXISTS (SELECT 1 FROM scores answered JOIN functionoptions answeredopt ON answeredopt.functionoptionid=answered.functionoptionid WHERE answered.fismasystemid=fismasystems.fismasystemid AND answered.datacallid=? AND answeredopt.functionid=functions.functionid))", input.DataCallID).
+		InnerJoin("catalogquestions questions ON questions.catalogversionid=functions.catalogversionid AND questions.questionid=functions.questionid").
+		InnerJoin("pillars ON pillars.pillarid=questions.pillarid").
 		LeftJoin("scores ON scores.fismasystemid=fismasystems.fismasystemid AND scores.datacallid=? AND scores.functionoptionid IN (SELECT selected.functionoptionid FROM functionoptions selected WHERE selected.functionid=functions.functionid)", input.DataCallID).
 		LeftJoin("functionoptions ON functionoptions.functionoptionid=scores.functionoptionid").
 		Where("(fismasystems.decommissioned=FALSE OR scores.scoreid IS NOT NULL)").
@@ -82,6 +116,12 @@ func FindAnswers(ctx context.Context, input FindAnswersInput) ([]*Answer, error)
 	// export byte-stable.
 		OrderBy("fismasystems.fismasystemid, pillars.ordr, questions.ordr, questions.questionid, functions.functionid ASC")
 
+	return scopeAnswers(sqlb, input)
+}
+
+// scopeAnswers applies input's system filter and the caller's scope to an
+// export query that reads fismasystems under its own name.
+func scopeAnswers(sqlb squirrel.SelectBuilder, input FindAnswersInput) squirrel.SelectBuilder {
 	if input.UserID != nil {
 		sqlb = sqlb.InnerJoin("users_fismasystems ON users_fismasystems.userid=? AND users_fismasystems.fismasystemid=fismasystems.fismasystemid", input.UserID)
 	}
@@ -96,6 +136,5 @@ func FindAnswers(ctx context.Context, input FindAnswersInput) ([]*Answer, error)
 		sqlb = sqlb.Where(squirrel.Eq{"fismasystems.fismasystemid": input.FismaSystemIDs})
 	}
 
-	return query(ctx, sqlb, pgx.RowToAddrOfStructByName[Answer])
-
+	return sqlb
 }
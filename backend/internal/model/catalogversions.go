@@ -0,0 +1,118 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// catalogLockClass keys the advisory lock that serializes catalog edits (see
+// rolloverLockClass for the key space). Without it two concurrent edits could
+// each publish a version missing the other's change, and whichever committed
+// last would pin open cycles to a catalog that never existed.
+const catalogLockClass = 392
+
+// CatalogVersion is one immutable copy of the questionnaire catalog -
+// questions and functions - as migration 0062 describes. Every data call pins
+// one in DataCall.CatalogVersionID.
+type CatalogVersion struct {
+	CatalogVersionID int32     `json:"catalogversionid"`
+	CreatedAt        time.Time `json:"createdat"`
+	Note             *string   `json:"note"`
+}
+
+func FindCatalogVersions(ctx context.Context) ([]*CatalogVersion, error) {
+	sqlb := stmntBuilder.
+		Select("catalogversionid", "createdat", "note").
+		From("catalogversions").
+		OrderBy("catalogversionid DESC")
+
+	return query(ctx, sqlb, pgx.RowToAddrOfStructByName[CatalogVersion])
+}
+
+// pinnedCatalogSQL is a scalar subquery for the catalog version a data call
+// pins. dataCallExpr is an SQL expression valid in the caller's scope.
+func pinnedCatalogSQL(dataCallExpr string) string {
+	return fmt.Sprintf("(SELECT catalogversionid FROM datacalls WHERE datacallid = %s)", dataCallExpr)
+}
+
+// saveCatalogRow is queryRow for writes to questions and functions: the live
+// row and the catalog version that includes it commit together, so a reader
+// can never see an edit that no version records. note labels the version.
+//
+// The event is recorded after the commit, as queryRow does, so it never
+// describes a write that rolled back.
+func saveCatalogRow[T any](ctx context.Context, sqlb SqlBuilder, note string, fn pgx.RowToFunc[T]) (*T, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, trapError(err)
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		conn.Release()
+		return nil, trapError(err)
+	}
+	defer func() {
+		tx.Rollback(ctx)
+		conn.Release()
+	}()
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1, 0)", catalogLockClass); err != nil {
+		return nil, trapError(err)
+	}
+
+	sql, args, _ := sqlb.ToSql()
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, trapError(err)
+	}
+	res, err := pgx.CollectOneRow(rows, fn)
+	if err != nil {
+		return nil, trapError(err)
+	}
+
+	if err := publishCatalogVersion(ctx, tx, note); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, trapError(err)
+	}
+
+	recordEvent(ctx, sqlb, res)
+
+	// The new version re-pinned every draft and open call.
+	refreshOpenScoreAggregates(ctx)
+
+	return &res, nil
+}
+
+// publishCatalogVersion copies the live catalog into a new version and pins
+// every draft and open data call to it: a cycle being answered follows the
+// questionnaire its respondents see, and one that has closed keeps the version
+// it closed with. The caller holds the catalog lock.
+func publishCatalogVersion(ctx context.Context, tx pgx.Tx, note string) error {
+	var versionID int32
+	err := tx.QueryRow(ctx, "INSERT INTO catalogversions (note) VALUES ($1) RETURNING catalogversionid", note).Scan(&versionID)
+	if err != nil {
+		return trapError(err)
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`
+		INSERT INTO catalogquestions (catalogversionid, questionid, question, notesprompt, ordr, pillarid)
+		SELECT $1, questionid, question, notesprompt, ordr, pillarid FROM questions`, versionID)
+	batch.Queue(`
+		INSERT INTO catalogfunctions (catalogversionid, functionid, function, description, datacenterenvironment, ordr, questionid, pillarid)
+		SELECT $1, functionid, function, description, datacenterenvironment, ordr, questionid, pillarid FROM functions`, versionID)
+	batch.Queue("UPDATE datacalls SET catalogversionid = $1 WHERE status IN ($2, $3)", versionID, DataCallDraft, DataCallOpen)
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return trapError(err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCatalogVersionPinningIntegration pins the versioning contract: editing
+// a question publishes a new catalog version, a closed data call keeps reading
+// the wording it closed with, also once reopened, and a draft follows the
+// edit.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestCatalogVersionPinningIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	purgeIntegrationTestRows(t)
+	defer purgeIntegrationTestRows(t)
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	q, err := (&Question{Question: "catalog fixture, original wording", NotesPrompt: "n/a", PillarID: 2}).Save(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		c, err := db.Conn(context.Background())
+		if err != nil {
+			return
+		}
+		defer c.Release()
+		_, _ = c.Exec(context.Background(), `DELETE FROM questions WHERE questionid = $1`, q.QuestionID)
+	})
+
+	// Both calls pin the version that publishing the question produced.
+	suffix := time.Now().UnixNano()
+	insertDataCall := func(name, status string) int32 {
+		var id int32
+		err := conn.QueryRow(ctx, `
+			INSERT INTO datacalls (datacall, datecreated, deadline, status, opened_at)
+			VALUES ($1, NOW(), NOW(), $2, CASE WHEN $2 <> 'draft' THEN NOW() END)
+			RETURNING datacallid
+		`, fmt.Sprintf("%scatalog_%s_%d", integrationTestPrefix, name, suffix), status).Scan(&id)
+		require.NoError(t, err)
+		return id
+	}
+	closed := insertDataCall("closed", DataCallClosed)
+	draft := insertDataCall("draft", DataCallDraft)
+
+	closedBefore, err := FindDataCallByID(ctx, closed)
+	require.NoError(t, err)
+
+	q.Question = "catalog fixture, reworded"
+	_, err = q.Save(ctx)
+	require.NoError(t, err)
+
+	wordingFor := func(dataCallID int32) string {
+		var wording string
+		err := conn.QueryRow(ctx, `
+			SELECT cq.question
+			  FROM datacalls dc
+			  JOIN catalogquestions cq ON cq.catalogversionid = dc.catalogversionid
+			 WHERE dc.datacallid = $1 AND cq.questionid = $2
+		`, dataCallID, q.QuestionID).Scan(&wording)
+		require.NoError(t, err)
+		return wording
+	}
+
+	closedAfter, err := FindDataCallByID(ctx, closed)
+	require.NoError(t, err)
+	assert.Equal(t, closedBefore.CatalogVersionID, closedAfter.CatalogVersionID, "a closed call keeps its pin")
+	assert.Equal(t, "catalog fixture, original wording", wordingFor(closed))
+
+	reopened, err := SetDataCallStatus(ctx, closed, DataCallOpen)
+	require.NoError(t, err)
+	assert.Equal(t, closedBefore.CatalogVersionID, reopened.CatalogVersionID, "a reopen keeps the pin")
+	assert.Equal(t, "catalog fixture, original wording", wordingFor(closed))
+
+	draftAfter, err := FindDataCallByID(ctx, draft)
+	require.NoError(t, err)
+	assert.Greater(t, draftAfter.CatalogVersionID, closedAfter.CatalogVersionID, "a draft moves to the published version")
+	assert.Equal(t, "catalog fixture, reworded", wordingFor(draft))
+
+	var live string
+	require.NoError(t, conn.QueryRow(ctx, `SELECT question FROM questions WHERE questionid = $1`, q.QuestionID).Scan(&live))
+	assert.Equal(t, "catalog fixture, reworded", live, "the live table is the working copy")
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Why an applicable function keeps a system from completing a data call.
+const (
+	// IncompleteMissing: the function has no answer in the data call.
+	IncompleteMissing = "missing"
+	// IncompleteUnconfirmed: the answer was carried forward from the previous
+	// cycle and neither saved nor confirmed since ('not_started').
+	IncompleteUnconfirmed = "unconfirmed"
+	// IncompleteReturned: a reviewer returned the answer for revision.
+	IncompleteReturned = "returned"
+)
+
+// IncompleteFunction is an applicable function a system has not finished
+// answering in a data call.
+type IncompleteFunction struct {
+	FunctionID  int32   `json:"functionid"`
+	Function    *string `json:"function"`
+	Description *string `json:"description"`
+	Pillar      string  `json:"pillar"`
+	Reason      string  `json:"reason"`
+}
+
+// FindIncompleteFunctions returns the functions applicable to the system in
+// the data call that lack a confirmed answer, by pillar and function. The
+// applicable set is the one FindScoreProgress counts as expected, so a system
+// with nothing returned here shows every question updated there.
+func FindIncompleteFunctions(ctx context.Context, dataCallID, fismaSystemID int32) ([]*IncompleteFunction, error) {
+	sql, args := buildIncompleteFunctionsSQL(dataCallID, fismaSystemID)
+	return query(ctx, rawQuery{sql: sql, args: args}, pgx.RowToAddrOfStructByName[IncompleteFunction])
+}
+
+// buildIncompleteFunctionsSQL is extracted so unit tests can pin the shape of
+// the applicable set without a database connection.
+func buildIncompleteFunctionsSQL(dataCallID, fismaSystemID int32) (string, []any) {
+	pillarScope := reducedPillarScopeSQL("dce.scoring_key", "p.pillar", "$1")
+
+	// The joins up to pillars are expected's in buildScoreProgressSQL; keep
+	// them in step. DISTINCT absorbs the fan-out from the environment mapping
+	// the way COUNT(DISTINCT) does there. Should a function carry more than
+	// one answer row, the lateral prefers a finished one.
+	sql := fmt.Sprintf(`
+SELECT DISTINCT f.functionid, f.function, f.description, p.pillar,
+       CASE
+         WHEN ans.status IS NULL THEN '%[3]s'
+         WHEN ans.status = 'not_started' THEN '%[4]s'
+         ELSE '%[5]s'
+       END AS reason
+FROM fismasystems fs
+INNER JOIN datacenterenvironments dce ON dce.datacenterenvironment = fs.datacenterenvironment
+INNER JOIN catalogfunctions f ON f.catalogversionid = %[1]s
+                             AND f.datacenterenvironment = dce.scoring_key
+INNER JOIN catalogquestions q ON q.questionid = f.questionid AND q.catalogversionid = f.catalogversionid
+INNER JOIN pillars p ON p.pillarid = q.pillarid
+  AND %[2]s
+LEFT JOIN LATERAL (
+    SELECT s.status
+    FROM scores s
+    INNER JOIN functionoptions fo ON fo.functionoptionid = s.functionoptionid
+    WHERE s.fismasystemid = fs.fismasystemid
+      AND s.datacallid = $1
+      AND fo.functionid = f.functionid
+    ORDER BY s.status IN ('not_started', 'returned_for_revision'), s.scoreid DESC
+    LIMIT 1
+) ans ON TRUE
+WHERE fs.fismasystemid = $2
+  AND (ans.status IS NULL OR ans.status IN ('not_started', 'returned_for_revision'))
+ORDER BY p.pillar, f.functionid
+`, pinnedCatalogSQL("$1"), pillarScope, IncompleteMissing, IncompleteUnconfirmed, IncompleteReturned)
+
+	return sql, []any{dataCallID, fismaSystemID}
+}
+
+// validateCompleteness refuses completion while any applicable function is
+// incomplete, listing them under "functions".
+func validateCompleteness(incomplete []*IncompleteFunction) error {
+	if len(incomplete) == 0 {
+		return nil
+	}
+	return &InvalidInputError{data: map[string]any{
+		"functions": incomplete,
+	}}
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildIncompleteFunctionsSQL_Shape(t *testing.T) {
+	sql, args := buildIncompleteFunctionsSQL(5, 1001)
+
+	assert.Equal(t, []any{int32(5), int32(1001)}, args)
+	assert.Contains(t, sql, "f.datacenterenvironment = dce.scoring_key", "functions match on the scoring key, as progress counts them")
+	assert.Contains(t, sql, "INNER JOIN catalogquestions q ON q.questionid = f.questionid", "orphan functions are not applicable")
+	assert.Contains(t, sql, "ans.status IS NULL OR ans.status IN ('not_started', 'returned_for_revision')", "done, submitted and approved answers are complete")
+	assert.Contains(t, sql, "ORDER BY s.status IN ('not_started', 'returned_for_revision')", "a finished answer row wins over an unfinished duplicate")
+	assert.Contains(t, sql, "'"+IncompleteMissing+"'")
+	assert.Contains(t, sql, "'"+IncompleteUnconfirmed+"'")
+	assert.Contains(t, sql, "'"+IncompleteReturned+"'")
+}
+
+func TestValidateCompleteness(t *testing.T) {
+	assert.NoError(t, validateCompleteness(nil))
+
+	incomplete := []*IncompleteFunction{{FunctionID: 7001, Pillar: "Devices", Reason: IncompleteUnconfirmed}}
+	var invalid *InvalidInputError
+	require.ErrorAs(t, validateCompleteness(incomplete), &invalid)
+	assert.Equal(t, incomplete, invalid.Data()["functions"])
+}
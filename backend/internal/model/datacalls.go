@@ -2,71 +2,465 @@ package model
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"strings"
 	"time"
 
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
 )
 
-var dataCallColumns = []string{"datacallid", "datacall", "datecreated", "deadline"}
+var dataCallColumns = []string{"datacallid", "datacall", "datecreated", "deadline", "status", "opens_at", "opened_at", "closed_at", "catalogversionid", "requires_rollover_rules"}
+
+// Data call lifecycle states, as stored in datacalls.status and pinned by
+// migration 0059's CHECK. Like the score states in scores.go these are stored
+// data: changing a VALUE is a data migration, renaming the identifier is free.
+const (
+	// DataCallDraft is a staged cycle: invisible to non-admin pickers, closed to
+	// answers, and not yet rolled over.
+	DataCallDraft = "draft"
+	// DataCallOpen accepts answers - from non-admins until the deadline, from
+	// admins until it is closed.
+	DataCallOpen = "open"
+	// DataCallClosed has been explicitly closed. Admins may still correct
+	// answers, exactly as they may after the deadline.
+	DataCallClosed = "closed"
+	// DataCallArchived is read-only for everyone and hidden from pickers unless
+	// asked for.
+	DataCallArchived = "archived"
+)
+
+// dataCallTransitions lists the allowed status changes. Reopening a closed call
+// is allowed (a deadline extended after the fact); reopening never re-runs the
+// rollover because opened_at is already set. A draft may be archived directly
+// to discard a staged cycle without it ever going live.
+var dataCallTransitions = map[string][]string{
+	DataCallDraft:    {DataCallOpen, DataCallArchived},
+	DataCallOpen:     {DataCallClosed},
+	DataCallClosed:   {DataCallOpen, DataCallArchived},
+	DataCallArchived: {DataCallClosed},
+}
+
+func validDataCallTransition(from, to string) bool {
+	for _, s := range dataCallTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
 
 type DataCall struct {
 	DataCallID  int32     `json:"datacallid"`
 	DataCall    string    `json:"datacall"`
 	DateCreated time.Time `json:"datecreated"`
 	Deadline    time.Time `json:"deadline"`
+	// Status is the lifecycle state (see the DataCall* constants). On create it
+	// may be "draft" to stage the cycle, or empty/"open" to go live at once -
+	// the pre-lifecycle behavior existing clients rely on. After creation it
+	// only changes through SetDataCallStatus.
+	Status string `json:"status"`
+	// OpensAt schedules a draft to open; setting it on create implies draft.
+	// OpenScheduledDataCalls performs the transition once it has passed. An
+	// update that leaves it out keeps it; DELETE /datacalls/{id}/opens_at
+	// clears it.
+	OpensAt  *time.Time `json:"opens_at" db:"opens_at"`
+	OpenedAt *time.Time `json:"opened_at" db:"opened_at"`
+	ClosedAt *time.Time `json:"closed_at" db:"closed_at"`
+	// CatalogVersionID is the questionnaire catalog version the call reads
+	// its questions and functions from (catalogversions.go). Server-owned: a
+	// new call pins the newest version, and draft and open calls move to each
+	// version an edit publishes.
+	CatalogVersionID int32 `json:"catalogversionid" db:"catalogversionid" readonly:"true"`
+	// RequiresRolloverRules holds the call's first open until it has rollover
+	// rules (requireRolloverRules), for a cycle that must not simply roll
+	// forward from the previous one. Rules key on the call's id, so setting it
+	// on create implies draft. An update that leaves it out keeps it.
+	RequiresRolloverRules *bool `json:"requires_rollover_rules" db:"requires_rollover_rules"`
+	// EffectiveDeadline and Extension are not stored on the call: they are
+	// resolved for one FISMA system by ApplyDeadlineExtension and omitted
+	// until it runs.
+	EffectiveDeadline *time.Time         `json:"effectivedeadline,omitempty" db:"-"`
+	Extension         *DeadlineExtension `json:"extension,omitempty" db:"-"`
+}
+
+// CheckWritable reports whether user may write answers to this data call at
+// now, as ErrDataCallNotOpen or ErrPastDeadline. The state decides first (see
+// checkState); an open call then applies the deadline rule that predates the
+// lifecycle - admins are exempt, everyone else stops at the deadline, or at
+// the extended one when ApplyDeadlineExtension has resolved it.
+//
+// Exported because the questionnaire's read-only classification
+// (RecordQuestionView) must agree with the write path by construction.
+func (d *DataCall) CheckWritable(user *User, now time.Time) error {
+	if err := d.checkState(user); err != nil {
+		return err
+	}
+
+	deadline := d.Deadline
+	if d.EffectiveDeadline != nil {
+		deadline = *d.EffectiveDeadline
+	}
+
+	if now.After(deadline) && (user == nil || !user.IsAdmin()) {
+		return ErrPastDeadline
+	}
+	return nil
+}
+
+// checkState is the lifecycle half of CheckWritable: a draft or archived call
+// takes no writes from anyone, a closed call only from admins. Marking a system
+// complete uses it alone, since completion has never been deadline-gated.
+func (d *DataCall) checkState(user *User) error {
+	switch d.Status {
+	case DataCallOpen:
+		return nil
+	case DataCallClosed:
+		if user != nil && user.IsAdmin() {
+			return nil
+		}
+	}
+	return ErrDataCallNotOpen
 }
 
+func (d *DataCall) validate(current *DataCall) error {
+	err := InvalidInputError{data: map[string]any{}}
+
+	if strings.TrimSpace(d.DataCall) == "" {
+		err.data["datacall"] = "required"
+	}
+
+	if current == nil {
+		switch d.Status {
+		case "", DataCallDraft, DataCallOpen:
+		default:
+			err.data["status"] = "a new data call must be draft or open"
+		}
+		if d.OpensAt != nil && d.Status == DataCallOpen {
+			err.data["opens_at"] = "only a draft data call can be scheduled to open"
+		}
+		if d.requiresRolloverRules() && d.Status == DataCallOpen {
+			err.data["requires_rollover_rules"] = "create the data call as a draft, add its rollover rules, then open it"
+		}
+	} else {
+		if d.Status != "" && d.Status != current.Status {
+			err.data["status"] = "change status through PUT /datacalls/{datacallid}/status"
+		}
+		if current.Status != DataCallDraft && !sameTime(d.OpensAt, current.OpensAt) {
+			err.data["opens_at"] = "only a draft data call can be scheduled to open"
+		}
+	}
+
+	if d.OpensAt != nil && !d.OpensAt.Before(d.Deadline) {
+		err.data["opens_at"] = "must be before the deadline"
+	}
+
+	if len(err.data) > 0 {
+		return &err
+	}
+	return nil
+}
+
+func (d *DataCall) requiresRolloverRules() bool {
+	return d.RequiresRolloverRules != nil && *d.RequiresRolloverRules
+}
+
+// sameTime compares optional timestamps by instant, nil equal only to nil.
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// Save creates or updates a data call's name, deadline, and (while a draft)
+// its scheduled opening, which it can set or move but not clear. Status is
+// set on create only; every later change goes through SetDataCallStatus so
+// the rollover is tied to the transition into open rather than to a row
+// being inserted.
 func (d *DataCall) Save(ctx context.Context) (*DataCall, error) {
 
-	var sqlb SqlBuilder
+	var (
+		sqlb    SqlBuilder
+		current *DataCall
+	)
 
-	// if valid, err := d.isValid(); !valid {
-	// 	return err
-	// }
+	if d.DataCallID != 0 {
+		var err error
+		if current, err = FindDataCallByID(ctx, d.DataCallID); err != nil {
+			return nil, err
+		}
+		// Absent means unchanged, not "clear the schedule": clients that
+		// predate the lifecycle send neither field, and a draft renamed by
+		// one must not lose its opening. UnscheduleDataCall clears it.
+		if d.OpensAt == nil {
+			d.OpensAt = current.OpensAt
+		}
+		if d.RequiresRolloverRules == nil {
+			d.RequiresRolloverRules = current.RequiresRolloverRules
+		}
+	}
+
+	if err := d.validate(current); err != nil {
+		return nil, err
+	}
+
+	if current == nil {
+		// A schedule or a rules requirement implies a draft; with none of them
+		// the call goes live at once, as every call did before the lifecycle
+		// existed. It is still inserted as a draft and opened through the same
+		// transition, so there is exactly one place the rollover runs.
+		status := DataCallOpen
+		if d.Status == DataCallDraft || d.OpensAt != nil || d.requiresRolloverRules() {
+			status = DataCallDraft
+		}
 
-	if d.DataCallID == 0 {
 		sqlb = stmntBuilder.
 			Insert("datacalls").
-			Columns("datacall", "deadline").
-			Values(d.DataCall, d.Deadline).
-			Suffix("RETURNING " + strings.Join(dataCallColumns, ", "))
-	} else {
-		sqlb = stmntBuilder.
-			Update("datacalls").
-			Set("datacall", d.DataCall).
-			Set("deadline", d.Deadline).
-			Where("datacallid=?", d.DataCallID).
+			Columns("datacall", "deadline", "status", "opens_at", "requires_rollover_rules").
+			Values(d.DataCall, d.Deadline, DataCallDraft, d.OpensAt, d.requiresRolloverRules()).
 			Suffix("RETURNING " + strings.Join(dataCallColumns, ", "))
+
+		dataCall, err := queryRow(ctx, sqlb, pgx.RowToStructByName[DataCall])
+		if err != nil {
+			return nil, err
+		}
+		if status == DataCallOpen {
+			return SetDataCallStatus(ctx, dataCall.DataCallID, DataCallOpen)
+		}
+		return dataCall, nil
 	}
 
+	sqlb = stmntBuilder.
+		Update("datacalls").
+		Set("datacall", d.DataCall).
+		Set("deadline", d.Deadline).
+		Set("opens_at", d.OpensAt).
+		Set("requires_rollover_rules", d.requiresRolloverRules()).
+		Where("datacallid=?", d.DataCallID).
+		Suffix("RETURNING " + strings.Join(dataCallColumns, ", "))
+
 	dataCall, err := queryRow(ctx, sqlb, pgx.RowToStructByName[DataCall])
 	if err != nil {
 		return nil, err
 	}
 
-	// Roll the previous cycle's answers into a *newly created* data call only.
-	// Never on update: re-running the copy on an edit would duplicate every
-	// carried-over score (ztmf#411). Run it synchronously so the outcome is
-	// observable, but do not fail the create on a copy error - the datacall row
-	// is already committed and is valid without a rollover (the first-ever cycle
-	// legitimately copies zero rows). copyPreviousScores emits the loud
-	// ROLLOVER_ANOMALY signal on any zero/partial/errored copy.
-	if d.DataCallID == 0 {
+	// The deadline decides which reduced pillar scopes apply to the call.
+	if !current.Deadline.Equal(dataCall.Deadline) {
+		refreshScoreAggregates(ctx, FindScoresInput{DataCallID: &dataCall.DataCallID})
+	}
+	return dataCall, nil
+}
+
+// UnscheduleDataCall clears a draft's opens_at, so it stays a draft until
+// opened by hand. It is the only way to clear one: Save cannot tell a
+// schedule left out of the body from one meant to be removed.
+func UnscheduleDataCall(ctx context.Context, dataCallID int32) (*DataCall, error) {
+	current, err := FindDataCallByID(ctx, dataCallID)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status != DataCallDraft {
+		return nil, &InvalidInputError{data: map[string]any{"opens_at": "only a draft data call can be scheduled to open"}}
+	}
+
+	sqlb := stmntBuilder.
+		Update("datacalls").
+		Set("opens_at", nil).
+		Where("datacallid=? AND status=?", dataCallID, DataCallDraft).
+		Suffix("RETURNING " + strings.Join(dataCallColumns, ", "))
+
+	dataCall, err := queryRow(ctx, sqlb, pgx.RowToStructByName[DataCall])
+	if errors.Is(err, ErrNoData) {
+		return nil, &InvalidInputError{data: map[string]any{"status": "the data call changed state concurrently; reload and retry"}}
+	}
+	return dataCall, err
+}
+
+// SetDataCallStatus moves a data call through its lifecycle. The UPDATE is
+// conditioned on the status it was read in, so when two admins (or an admin and
+// the scheduler) race the same transition exactly one wins and the loser gets a
+// 400 rather than a second rollover.
+//
+// The rollover runs on the first transition into open - opened_at still NULL -
+// and never on a reopen, which would duplicate every carried-over score
+// (ztmf#411). As before, it runs synchronously so its outcome is observable but
+// does not fail the transition: the call is valid without a rollover (the
+// first-ever cycle legitimately copies zero rows), and copyPreviousScores emits
+// ROLLOVER_ANOMALY on any zero, partial, or errored copy. The one exception is
+// a call flagged requires_rollover_rules, which refuses to open until it has
+// rollover rules (requireRolloverRules).
+//
+// Closing a call freezes its scores and export in the same transaction as the
+// status change (snapshotDataCall), unless a snapshot already exists - an
+// archived call closed again keeps the one it was archived with. Reopening
+// discards the snapshot, since the answers may change again.
+func SetDataCallStatus(ctx context.Context, dataCallID int32, status string) (*DataCall, error) {
+	current, err := FindDataCallByID(ctx, dataCallID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !validDataCallTransition(current.Status, status) {
+		return nil, &InvalidInputError{data: map[string]any{
+			"status": fmt.Sprintf("cannot change a %s data call to %q", current.Status, status),
+		}}
+	}
+
+	if status == DataCallOpen && current.OpenedAt == nil {
+		if err := requireRolloverRules(ctx, current); err != nil {
+			return nil, err
+		}
+	}
+
+	sqlb := stmntBuilder.
+		Update("datacalls").
+		Set("status", status).
+		Where("datacallid=? AND status=?", dataCallID, current.Status).
+		Suffix("RETURNING " + strings.Join(dataCallColumns, ", "))
+
+	switch status {
+	case DataCallOpen:
+		// The first open pins the newest catalog version. A reopen keeps the
+		// one the cycle was answered and exported against.
+		sqlb = sqlb.Set("opened_at", squirrel.Expr("COALESCE(opened_at, NOW())")).Set("closed_at", nil).
+			Set("catalogversionid", squirrel.Expr("CASE WHEN opened_at IS NULL THEN current_catalogversion() ELSE catalogversionid END"))
+	case DataCallClosed:
+		sqlb = sqlb.Set("closed_at", squirrel.Expr("COALESCE(closed_at, NOW())"))
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, trapError(err)
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		conn.Release()
+		return nil, trapError(err)
+	}
+	defer func() {
+		tx.Rollback(ctx)
+		conn.Release()
+	}()
+
+	sql, args, _ := sqlb.ToSql()
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, trapError(err)
+	}
+	dataCall, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[DataCall])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, &InvalidInputError{data: map[string]any{"status": "the data call changed state concurrently; reload and retry"}}
+	}
+	if err != nil {
+		return nil, trapError(err)
+	}
+
+	user := UserFromContext(ctx)
+	switch status {
+	case DataCallClosed:
+		if _, err := snapshotDataCall(ctx, tx, dataCallID, user); err != nil {
+			return nil, err
+		}
+	case DataCallOpen:
+		if _, err := tx.Exec(ctx, "DELETE FROM datacallsnapshots WHERE datacallid = $1", dataCallID); err != nil {
+			return nil, trapError(err)
+		}
+	}
+
+	// The event queryRow would have recorded for the UPDATE; the scheduler
+	// opens calls with no user and records none, as before.
+	if user != nil {
+		err = insertEventTx(ctx, tx,
+			user.UserID, eventActionUpdated, "datacalls", dataCall)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, trapError(err)
+	}
+
+	log.Printf("DATACALL_STATUS datacall=%d from=%s to=%s", dataCallID, current.Status, status)
+
+	if status == DataCallOpen && current.OpenedAt == nil {
 		if _, err := copyPreviousScores(ctx, dataCall.DataCallID); err != nil {
 			log.Println(err)
 		}
 	}
 
+	// After the rollover, so its rows are in; a close or reopen swaps the
+	// call to or from its snapshot.
+	refreshScoreAggregates(ctx, FindScoresInput{DataCallID: &dataCallID})
+
 	return dataCall, nil
 }
 
-func FindDataCalls(ctx context.Context) ([]*DataCall, error) {
+// OpenScheduledDataCalls opens every draft whose opens_at has passed and returns
+// the ids it opened. The API runs it on a ticker; with more than one task
+// running, SetDataCallStatus's conditional UPDATE lets exactly one of them win
+// each call, and the others see the concurrent-change error and skip it. A
+// draft that is still a draft after a refused open was refused on its merits
+// (a missing rollover rule, say): it is logged as DATACALL_OPEN_REFUSED and
+// tried again on the next tick, without holding up the calls after it.
+func OpenScheduledDataCalls(ctx context.Context) ([]int32, error) {
+	sqlb := stmntBuilder.
+		Select("datacallid").
+		From("datacalls").
+		Where("status=? AND opens_at <= NOW()", DataCallDraft).
+		OrderBy("opens_at", "datacallid")
+
+	due, err := query(ctx, sqlb, pgx.RowTo[int32])
+	if err != nil {
+		return nil, err
+	}
+
+	var opened []int32
+	for _, id := range due {
+		if _, err := SetDataCallStatus(ctx, id, DataCallOpen); err != nil {
+			var invalid *InvalidInputError
+			if !errors.As(err, &invalid) {
+				return opened, err
+			}
+			current, err := FindDataCallByID(ctx, id)
+			if err != nil {
+				return opened, err
+			}
+			if current.Status == DataCallDraft {
+				log.Printf("DATACALL_OPEN_REFUSED datacall=%d reason=%v", id, invalid.Data())
+			}
+			continue
+		}
+		opened = append(opened, id)
+	}
+	return opened, nil
+}
+
+// FindDataCallsInput filters the data call list. IncludeDrafts is server-owned:
+// the controller sets it for admin readers, so a client cannot reveal a staged
+// cycle by asking.
+type FindDataCallsInput struct {
+	IncludeArchived *bool `schema:"include_archived"`
+	IncludeDrafts   bool  `schema:"-"`
+}
+
+func FindDataCalls(ctx context.Context, input FindDataCallsInput) ([]*DataCall, error) {
 	sqlb := stmntBuilder.Select(dataCallColumns...).
 		From("datacalls").
 		OrderBy("deadline DESC", "datacallid DESC")
 
+	if !input.IncludeDrafts {
+		sqlb = sqlb.Where("status <> ?", DataCallDraft)
+	}
+	if input.IncludeArchived == nil || !*input.IncludeArchived {
+		sqlb = sqlb.Where("status <> ?", DataCallArchived)
+	}
+
 	return query(ctx, sqlb, pgx.RowToAddrOfStructByName[DataCall])
 }
 
@@ -96,10 +490,14 @@ func findPreviousDataCall(ctx context.Context, dataCallID int32) (*DataCall, err
 	// its own deadline is not strictly before itself. Among the strictly-earlier
 	// candidates, datacallid DESC breaks deadline ties; a cycle sharing this
 	// call's exact deadline is not a candidate at all.
+	//
+	// A draft is never a source: it has not been rolled over itself and nobody
+	// has answered it, so it would copy nothing and shadow the real prior cycle.
 	prevDcSqlb := stmntBuilder.
 		Select(dataCallColumns...).
 		From("datacalls").
 		Where("deadline < (SELECT deadline FROM datacalls WHERE datacallid=?)", dataCallID).
+		Where("status <> ?", DataCallDraft).
 		OrderBy("deadline DESC", "datacallid DESC").
 		Limit(1)
 
@@ -110,9 +508,14 @@ func FindLatestDataCall(ctx context.Context) (*DataCall, error) {
 	// The current/latest datacall is the one with the furthest-out deadline
 	// (datacallid DESC only as a tiebreak): the annual cadence is deadline-driven,
 	// and historical loads can carry a higher datacallid than the real current call.
+	//
+	// Only a call that has gone live qualifies: a staged draft must not become
+	// every ISSO's current cycle the moment it is created, and an archived call
+	// is by definition not current.
 	sqlb := stmntBuilder.
 		Select(dataCallColumns...).
 		From("datacalls").
+		Where(squirrel.Eq{"status": []string{DataCallOpen, DataCallClosed}}).
 		OrderBy("deadline DESC", "datacallid DESC").
 		Limit(1)
 
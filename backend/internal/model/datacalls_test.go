@@ -0,0 +1,148 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDataCallCheckWritable pins the write gate the questionnaire and
+// validateDeadline share. State decides before the deadline does: a draft or
+// archived call refuses even an admin, a closed call refuses everyone but an
+// admin, and only an open call falls through to the pre-lifecycle deadline
+// rule.
+func TestDataCallCheckWritable(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	before := now.Add(24 * time.Hour)
+	after := now.Add(-24 * time.Hour)
+
+	admin := &User{Role: "OWNER"}
+	opdivAdmin := &User{Role: "OPDIV_ADMIN"}
+	isso := &User{Role: "ISSO"}
+
+	cases := []struct {
+		name     string
+		status   string
+		deadline time.Time
+		user     *User
+		want     error
+	}{
+		{"open before deadline, isso", DataCallOpen, before, isso, nil},
+		{"open past deadline, isso", DataCallOpen, after, isso, ErrPastDeadline},
+		{"open past deadline, admin", DataCallOpen, after, admin, nil},
+		{"open past deadline, opdiv admin", DataCallOpen, after, opdivAdmin, nil},
+		{"closed before deadline, isso", DataCallClosed, before, isso, ErrDataCallNotOpen},
+		{"closed, admin", DataCallClosed, after, admin, nil},
+		{"draft, admin", DataCallDraft, before, admin, ErrDataCallNotOpen},
+		{"draft, isso", DataCallDraft, before, isso, ErrDataCallNotOpen},
+		{"archived, admin", DataCallArchived, before, admin, ErrDataCallNotOpen},
+		{"open, no user", DataCallOpen, before, nil, nil},
+		{"closed, no user", DataCallClosed, before, nil, ErrDataCallNotOpen},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dc := &DataCall{Status: tc.status, Deadline: tc.deadline}
+			err := dc.CheckWritable(tc.user, now)
+			if tc.want == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, tc.want), "got %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+// TestValidDataCallTransition pins the lifecycle graph. The transitions that
+// matter most are the ones it refuses: nothing returns to draft (a call that has
+// been rolled over cannot be un-staged) and an archived call must be unarchived
+// to closed before it can reopen.
+func TestValidDataCallTransition(t *testing.T) {
+	allowed := [][2]string{
+		{DataCallDraft, DataCallOpen},
+		{DataCallDraft, DataCallArchived},
+		{DataCallOpen, DataCallClosed},
+		{DataCallClosed, DataCallOpen},
+		{DataCallClosed, DataCallArchived},
+		{DataCallArchived, DataCallClosed},
+	}
+	for _, tr := range allowed {
+		assert.True(t, validDataCallTransition(tr[0], tr[1]), "%s -> %s", tr[0], tr[1])
+	}
+
+	refused := [][2]string{
+		{DataCallOpen, DataCallDraft},
+		{DataCallClosed, DataCallDraft},
+		{DataCallOpen, DataCallArchived},
+		{DataCallArchived, DataCallOpen},
+		{DataCallOpen, DataCallOpen},
+		{DataCallOpen, "bogus"},
+	}
+	for _, tr := range refused {
+		assert.False(t, validDataCallTransition(tr[0], tr[1]), "%s -> %s", tr[0], tr[1])
+	}
+}
+
+// TestDataCallValidate covers the create/update rules: status is only chosen
+// at creation, only a draft carries a schedule, which must precede the
+// deadline, and a call that requires rollover rules is created as a draft.
+func TestDataCallValidate(t *testing.T) {
+	deadline := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+	early := deadline.Add(-30 * 24 * time.Hour)
+	late := deadline.Add(time.Hour)
+	yes := true
+
+	invalidKeys := func(err error) []string {
+		var invalid *InvalidInputError
+		if !errors.As(err, &invalid) {
+			return nil
+		}
+		var keys []string
+		for k := range invalid.Data() {
+			keys = append(keys, k)
+		}
+		return keys
+	}
+
+	t.Run("create open with no schedule", func(t *testing.T) {
+		d := &DataCall{DataCall: "FY27", Deadline: deadline}
+		assert.NoError(t, d.validate(nil))
+	})
+	t.Run("create scheduled draft", func(t *testing.T) {
+		d := &DataCall{DataCall: "FY27", Deadline: deadline, OpensAt: &early}
+		assert.NoError(t, d.validate(nil))
+	})
+	t.Run("create closed refused", func(t *testing.T) {
+		d := &DataCall{DataCall: "FY27", Deadline: deadline, Status: DataCallClosed}
+		assert.ElementsMatch(t, []string{"status"}, invalidKeys(d.validate(nil)))
+	})
+	t.Run("schedule after deadline refused", func(t *testing.T) {
+		d := &DataCall{DataCall: "FY27", Deadline: deadline, OpensAt: &late}
+		assert.ElementsMatch(t, []string{"opens_at"}, invalidKeys(d.validate(nil)))
+	})
+	t.Run("create requiring rules", func(t *testing.T) {
+		d := &DataCall{DataCall: "FY27", Deadline: deadline, RequiresRolloverRules: &yes}
+		assert.NoError(t, d.validate(nil))
+	})
+	t.Run("create open requiring rules refused", func(t *testing.T) {
+		d := &DataCall{DataCall: "FY27", Deadline: deadline, Status: DataCallOpen, RequiresRolloverRules: &yes}
+		assert.ElementsMatch(t, []string{"requires_rollover_rules"}, invalidKeys(d.validate(nil)))
+	})
+	t.Run("update cannot change status", func(t *testing.T) {
+		current := &DataCall{Status: DataCallOpen}
+		d := &DataCall{DataCall: "FY27", Deadline: deadline, Status: DataCallClosed}
+		assert.ElementsMatch(t, []string{"status"}, invalidKeys(d.validate(current)))
+	})
+	t.Run("update cannot schedule an open call", func(t *testing.T) {
+		current := &DataCall{Status: DataCallOpen}
+		d := &DataCall{DataCall: "FY27", Deadline: deadline, OpensAt: &early}
+		assert.ElementsMatch(t, []string{"opens_at"}, invalidKeys(d.validate(current)))
+	})
+	t.Run("update reschedules a draft", func(t *testing.T) {
+		current := &DataCall{Status: DataCallDraft}
+		d := &DataCall{DataCall: "FY27", Deadline: deadline, OpensAt: &early}
+		assert.NoError(t, d.validate(current))
+	})
+}
@@ -9,14 +9,65 @@ import (
 type DataCallFismaSystem struct {
 	Datacallid    int32 `json:"datacallid"`
 	Fismasystemid int32 `json:"fismasystemid"`
+	// Forced is true when an admin marked the system complete with applicable
+	// functions still incomplete. On Save it asks for that override; the
+	// caller authorizes it.
+	Forced bool `json:"forced"`
 }
 
+// Save marks the system complete for the data call. Only a call whose
+// lifecycle state accepts writes from the caller may be marked (see
+// DataCall.checkState); the deadline is not consulted, as it never was.
+//
+// Every applicable function must have a confirmed answer first: one that is
+// missing, carried forward unconfirmed or returned for revision fails the
+// mark with the list of them (see FindIncompleteFunctions), unless Forced.
+// A forced mark of a system that turns out complete is stored unforced.
 func (df *DataCallFismaSystem) Save(ctx context.Context) (*DataCallFismaSystem, error) {
+	dataCall, err := FindDataCallByID(ctx, df.Datacallid)
+	if err != nil {
+		return nil, err
+	}
+	if err := dataCall.checkState(UserFromContext(ctx)); err != nil {
+		return nil, err
+	}
+
+	incomplete, err := FindIncompleteFunctions(ctx, df.Datacallid, df.Fismasystemid)
+	if err != nil {
+		return nil, err
+	}
+	if !df.Forced {
+		if err := validateCompleteness(incomplete); err != nil {
+			return nil, err
+		}
+	}
+
 	sqlb := stmntBuilder.
 		Insert("datacalls_fismasystems").
-		Columns("datacallid", "fismasystemid").
-		Values(df.Datacallid, df.Fismasystemid).
-		Suffix("ON CONFLICT DO NOTHING RETURNING datacallid, fismasystemid")
+		Columns("datacallid", "fismasystemid", "forced").
+		Values(df.Datacallid, df.Fismasystemid, len(incomplete) > 0).
+		Suffix("ON CONFLICT DO NOTHING RETURNING datacallid, fismasystemid, forced")
+
+	return queryRow(ctx, sqlb, pgx.RowToStructByName[DataCallFismaSystem])
+}
+
+// Delete withdraws the system's completion of the data call, under the same
+// lifecycle rule as Save. The withdrawal is recorded as a deleted event
+// carrying the mark as it stood. ErrNoData when the system had not marked the
+// call complete.
+func (df *DataCallFismaSystem) Delete(ctx context.Context) (*DataCallFismaSystem, error) {
+	dataCall, err := FindDataCallByID(ctx, df.Datacallid)
+	if err != nil {
+		return nil, err
+	}
+	if err := dataCall.checkState(UserFromContext(ctx)); err != nil {
+		return nil, err
+	}
+
+	sqlb := stmntBuilder.
+		Delete("datacalls_fismasystems").
+		Where("datacallid=? AND fismasystemid=?", df.Datacallid, df.Fismasystemid).
+		Suffix("RETURNING datacallid, fismasystemid, forced")
 
 	return queryRow(ctx, sqlb, pgx.RowToStructByName[DataCallFismaSystem])
 }
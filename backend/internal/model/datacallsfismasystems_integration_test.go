@@ -81,3 +81,74 @@ func TestFindFismaSystemDataCallsOrderIntegration(t *testing.T) {
 	assert.Equal(t, backfilledID, dataCalls[len(dataCalls)-1].DataCallID,
 		"the 2019-deadline backfill must sort last despite the newest datecreated")
 }
+
+// TestDataCallFismaSystemCompletenessIntegration marks a system complete in a
+// fresh data call where nothing is answered: refused with every applicable
+// function missing, accepted when forced and stored as forced, and withdrawn
+// with a deleted event.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestDataCallFismaSystemCompletenessIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	purgeIntegrationTestRows(t)
+	defer purgeIntegrationTestRows(t)
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	const systemID = int32(1001)
+
+	var dataCallID int32
+	require.NoError(t, conn.QueryRow(ctx, `
+		INSERT INTO datacalls (datacall, deadline)
+		VALUES ($1, '2102-01-01T00:00:00Z'::timestamptz)
+		RETURNING datacallid
+	`, fmt.Sprintf("%scompleteness_%d", integrationTestPrefix, time.Now().UnixNano())).Scan(&dataCallID))
+
+	var userID string
+	require.NoError(t, conn.QueryRow(ctx, "SELECT userid FROM users WHERE role='OWNER' AND deleted=false LIMIT 1").Scan(&userID))
+	user, err := FindUserByID(ctx, userID)
+	require.NoError(t, err)
+	ctx = UserToContext(ctx, user)
+
+	incomplete, err := FindIncompleteFunctions(ctx, dataCallID, systemID)
+	require.NoError(t, err)
+	require.NotEmpty(t, incomplete, "nothing is answered in a fresh data call")
+	for _, f := range incomplete {
+		assert.Equal(t, IncompleteMissing, f.Reason)
+	}
+
+	progress, err := FindScoreProgress(ctx, FindScoreProgressInput{DataCallID: &dataCallID, FismaSystemID: int32Ptr(systemID)})
+	require.NoError(t, err)
+	require.Len(t, progress, 1)
+	assert.Equal(t, progress[0].QuestionsExpected, int32(len(incomplete)), "the same applicable set as progress")
+
+	_, err = (&DataCallFismaSystem{Datacallid: dataCallID, Fismasystemid: systemID}).Save(ctx)
+	var invalid *InvalidInputError
+	require.ErrorAs(t, err, &invalid)
+	assert.Len(t, invalid.Data()["functions"], len(incomplete))
+
+	marked, err := (&DataCallFismaSystem{Datacallid: dataCallID, Fismasystemid: systemID, Forced: true}).Save(ctx)
+	require.NoError(t, err)
+	assert.True(t, marked.Forced)
+
+	unmarked, err := (&DataCallFismaSystem{Datacallid: dataCallID, Fismasystemid: systemID}).Delete(ctx)
+	require.NoError(t, err)
+	assert.True(t, unmarked.Forced)
+
+	var events int
+	require.NoError(t, conn.QueryRow(ctx, `
+		SELECT COUNT(*) FROM events
+		 WHERE resource = 'datacalls_fismasystems' AND action = 'deleted'
+		   AND (payload->>'datacallid')::int = $1`, dataCallID).Scan(&events))
+	assert.Equal(t, 1, events)
+
+	_, err = (&DataCallFismaSystem{Datacallid: dataCallID, Fismasystemid: systemID}).Delete(ctx)
+	assert.ErrorIs(t, err, ErrNoData)
+}
@@ -0,0 +1,216 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// DataCallSnapshot records that a data call's results are frozen: its pillar
+// and system scores and its export rows as they stood when it was closed.
+// FindScoresAggregate (and everything built on the pillar query) and
+// FindAnswers read a frozen call from the snapshot, so later edits to the
+// catalog, function options, environment mapping, reduced pillar scopes,
+// pillar weights or a system's environment leave what was reported alone.
+type DataCallSnapshot struct {
+	DataCallID int32     `json:"datacallid"`
+	CreatedAt  time.Time `json:"createdat"`
+	CreatedBy  *string   `json:"createdby"`
+	// Systems is the number of systems with frozen scores; Answers the
+	// number of frozen export rows.
+	Systems int32 `json:"systems"`
+	Answers int32 `json:"answers"`
+}
+
+// snapshotAnswerColumns are the Answer fields snapshotanswers stores, under
+// the names FindAnswers selects them as.
+var snapshotAnswerColumns = []string{
+	"datacall", "fismasystemid", "fismaacronym", "datacenterenvironment", "pillar", "question", "function", "description",
+	"optiondescription", "optionname", "score", "notes", "notes_is_ai_summary",
+	"target_maturity_tier", "target_maturity_justification", "attachments",
+}
+
+func isDataCallFrozen(ctx context.Context, dataCallID int32) (bool, error) {
+	frozen, err := query(ctx, rawQuery{
+		sql:  "SELECT EXISTS (SELECT 1 FROM datacallsnapshots WHERE datacallid = $1)",
+		args: []any{dataCallID},
+	}, pgx.RowTo[bool])
+	if err != nil {
+		return false, err
+	}
+	return len(frozen) > 0 && frozen[0], nil
+}
+
+// snapshotAnswersSelect reads a frozen call's export rows in export order,
+// under the same filter and scope as the live query.
+func snapshotAnswersSelect(input FindAnswersInput) squirrel.SelectBuilder {
+	cols := make([]string, len(snapshotAnswerColumns))
+	for i, c := range snapshotAnswerColumns {
+		cols[i] = "sn." + c
+	}
+
+	sqlb := stmntBuilder.
+		Select(cols...).
+		From("snapshotanswers sn").
+		InnerJoin("fismasystems ON fismasystems.fismasystemid = sn.fismasystemid").
+		Where("sn.datacallid=?", input.DataCallID).
+		OrderBy("sn.ordr")
+
+	return scopeAnswers(sqlb, input)
+}
+
+// snapshotDataCall freezes the data call inside tx, computing the snapshot
+// with the live queries exactly as they would answer now. It returns false,
+// and writes nothing, when the call is already frozen.
+func snapshotDataCall(ctx context.Context, tx pgx.Tx, dataCallID int32, user *User) (bool, error) {
+	var createdBy *string
+	if user != nil {
+		createdBy = &user.UserID
+	}
+
+	tag, err := tx.Exec(ctx,
+		"INSERT INTO datacallsnapshots (datacallid, createdby) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		dataCallID, createdBy)
+	if err != nil {
+		return false, trapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	// The live pillar query, without the frozen branch: this call's own
+	// snapshot row above must not take it out of the computation.
+	expectedCTE, args := buildExpectedFunctionsCTE(FindScoresInput{DataCallID: &dataCallID})
+	_, err = tx.Exec(ctx,
+		"INSERT INTO snapshotpillarscores (datacallid, fismasystemid, pillarid, pillar, score, weight, weighted, system_score)"+
+			pillarScoresSQL(expectedCTE, storedAnswersCTE, ""),
+		args...)
+	if err != nil {
+		return false, trapError(err)
+	}
+
+	sql, args, err := answersSelect(FindAnswersInput{DataCallID: dataCallID}).ToSql()
+	if err != nil {
+		return false, err
+	}
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return false, trapError(err)
+	}
+	answers, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[Answer])
+	if err != nil {
+		return false, trapError(err)
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"snapshotanswers"},
+		append([]string{"datacallid", "ordr"}, snapshotAnswerColumns...),
+		pgx.CopyFromSlice(len(answers), func(i int) ([]any, error) {
+			a := answers[i]
+			return []any{
+				dataCallID, int32(i + 1),
+				a.DataCall, a.FismaSystemID, a.FismaAcronym, a.DataCenterEnvironment, a.Pillar, a.Question, a.Function, a.Description,
+				a.OptionDescription, a.OptionName, a.Score, a.Notes, a.NotesIsAISummary,
+				a.TargetMaturityTier, a.TargetMaturityJustification, a.Attachments,
+			}, nil
+		}))
+	if err != nil {
+		return false, trapError(err)
+	}
+
+	return true, nil
+}
+
+// FindDataCallSnapshot returns the data call's snapshot, or ErrNoData when
+// the call is not frozen.
+func FindDataCallSnapshot(ctx context.Context, dataCallID int32) (*DataCallSnapshot, error) {
+	sqlb := stmntBuilder.
+		Select(
+			"ds.datacallid", "ds.createdat", "ds.createdby",
+			"(SELECT COUNT(DISTINCT fismasystemid) FROM snapshotpillarscores WHERE datacallid = ds.datacallid)::int AS systems",
+			"(SELECT COUNT(*) FROM snapshotanswers WHERE datacallid = ds.datacallid)::int AS answers",
+		).
+		From("datacallsnapshots ds").
+		Where("ds.datacallid=?", dataCallID)
+
+	snapshots, err := query(ctx, sqlb, pgx.RowToAddrOfStructByName[DataCallSnapshot])
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, ErrNoData
+	}
+	return snapshots[0], nil
+}
+
+// SnapshotDataCall freezes a closed or archived data call that has no
+// snapshot, such as one closed before snapshots were taken. Closing a call
+// takes its snapshot on its own (SetDataCallStatus); an existing snapshot is
+// never replaced.
+func SnapshotDataCall(ctx context.Context, dataCallID int32) (*DataCallSnapshot, error) {
+	user := UserFromContext(ctx)
+	if user == nil {
+		return nil, &InvalidInputError{data: map[string]any{"user": "required"}}
+	}
+
+	dataCall, err := FindDataCallByID(ctx, dataCallID)
+	if err != nil {
+		return nil, err
+	}
+	if dataCall.Status != DataCallClosed && dataCall.Status != DataCallArchived {
+		return nil, &InvalidInputError{data: map[string]any{
+			"status": fmt.Sprintf("only a closed or archived data call is frozen; this one is %s", dataCall.Status),
+		}}
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, trapError(err)
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		conn.Release()
+		return nil, trapError(err)
+	}
+	defer func() {
+		tx.Rollback(ctx)
+		conn.Release()
+	}()
+
+	// Hold the call's row so a concurrent reopen, which discards snapshots,
+	// cannot interleave with taking this one.
+	var status string
+	if err := tx.QueryRow(ctx, "SELECT status FROM datacalls WHERE datacallid = $1 FOR UPDATE", dataCallID).Scan(&status); err != nil {
+		return nil, trapError(err)
+	}
+	if status != DataCallClosed && status != DataCallArchived {
+		return nil, &InvalidInputError{data: map[string]any{"status": "the data call changed state concurrently; reload and retry"}}
+	}
+
+	created, err := snapshotDataCall(ctx, tx, dataCallID, user)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, &InvalidInputError{data: map[string]any{"datacallid": "the data call is already frozen"}}
+	}
+
+	err = insertEventTx(ctx, tx,
+		user.UserID, eventActionCreated, "datacallsnapshots", map[string]any{"datacallid": dataCallID})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, trapError(err)
+	}
+
+	refreshScoreAggregates(ctx, FindScoresInput{DataCallID: &dataCallID})
+
+	return FindDataCallSnapshot(ctx, dataCallID)
+}
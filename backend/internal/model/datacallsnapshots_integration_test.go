@@ -0,0 +1,75 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDataCallSnapshotIntegration freezes a closed data call and checks the
+// aggregate and export read back exactly what they computed live, that a
+// second snapshot is refused, and that a frozen call cannot be simulated.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestDataCallSnapshotIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	var userID string
+	var dataCallID int32
+	err = conn.QueryRow(ctx, `
+SELECT (SELECT userid FROM users WHERE role='OWNER' AND deleted=false LIMIT 1),
+       (SELECT dc.datacallid FROM datacalls dc
+         WHERE dc.status = 'closed'
+           AND NOT EXISTS (SELECT 1 FROM datacallsnapshots ds WHERE ds.datacallid = dc.datacallid)
+         ORDER BY dc.datacallid LIMIT 1)`).Scan(&userID, &dataCallID)
+	if err != nil || dataCallID == 0 {
+		t.Skip("no closed data call without a snapshot in the seed")
+	}
+	t.Cleanup(func() {
+		conn.Exec(context.Background(), "DELETE FROM datacallsnapshots WHERE datacallid = $1", dataCallID)
+	})
+
+	user, err := FindUserByID(ctx, userID)
+	require.NoError(t, err)
+	ctx = UserToContext(ctx, user)
+
+	includePillars := true
+	input := FindScoresInput{DataCallID: &dataCallID, IncludePillars: &includePillars}
+	liveScores, err := FindScoresAggregate(ctx, input)
+	require.NoError(t, err)
+	liveAnswers, err := FindAnswers(ctx, FindAnswersInput{DataCallID: dataCallID})
+	require.NoError(t, err)
+
+	snapshot, err := SnapshotDataCall(ctx, dataCallID)
+	require.NoError(t, err)
+	assert.Equal(t, dataCallID, snapshot.DataCallID)
+	assert.Equal(t, int32(len(liveScores)), snapshot.Systems)
+	assert.Equal(t, int32(len(liveAnswers)), snapshot.Answers)
+
+	frozenScores, err := FindScoresAggregate(ctx, input)
+	require.NoError(t, err)
+	assert.Equal(t, liveScores, frozenScores)
+	frozenAnswers, err := FindAnswers(ctx, FindAnswersInput{DataCallID: dataCallID})
+	require.NoError(t, err)
+	assert.Equal(t, liveAnswers, frozenAnswers)
+
+	_, err = SnapshotDataCall(ctx, dataCallID)
+	var invalid *InvalidInputError
+	assert.ErrorAs(t, err, &invalid, "a snapshot is never replaced")
+
+	if len(liveScores) > 0 {
+		_, err = SimulateScores(ctx, ScoreSimulationInput{FismaSystemID: &liveScores[0].FismaSystemID, DataCallID: &dataCallID})
+		assert.ErrorAs(t, err, &invalid, "a frozen call cannot be simulated")
+	}
+}
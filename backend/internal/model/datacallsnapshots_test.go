@@ -0,0 +1,48 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBuildPillarScoresSQL_FrozenBranch pins how a frozen data call is read:
+// the live branch skips calls with a snapshot and the snapshot rows are taken
+// under the same scope, bound to the same args as the live branch.
+func TestBuildPillarScoresSQL_FrozenBranch(t *testing.T) {
+	userID := "u-1"
+	sql, args := buildPillarScoresSQL(normalizeInput(FindScoresInput{UserID: &userID}))
+
+	assert.Contains(t, sql, "WHERE NOT EXISTS (SELECT 1 FROM datacallsnapshots ds WHERE ds.datacallid = e.datacallid)")
+	assert.Contains(t, sql, "UNION ALL")
+	assert.Contains(t, sql, "FROM snapshotpillarscores sn")
+	assert.Equal(t, 2, strings.Count(sql, "users_fismasystems"), "both branches take the per-user scope")
+	assert.Less(t, strings.Index(sql, "UNION ALL"), strings.Index(sql, "ORDER BY datacallid"), "the order applies to the union")
+	assert.Equal(t, []any{userID}, args, "the frozen branch reuses the live branch's placeholders")
+}
+
+// TestPillarScoresSQL_Live verifies the query without a frozen branch, which
+// takes a snapshot and simulates, computes every call from its answers.
+func TestPillarScoresSQL_Live(t *testing.T) {
+	expectedCTE, _ := buildExpectedFunctionsCTE(FindScoresInput{})
+	sql := pillarScoresSQL(expectedCTE, storedAnswersCTE, "")
+
+	assert.NotContains(t, sql, "UNION ALL")
+	assert.NotContains(t, sql, "datacallsnapshots")
+}
+
+func TestSnapshotAnswersSelect(t *testing.T) {
+	fsid := int32(1001)
+	sql, args, err := snapshotAnswersSelect(FindAnswersInput{
+		DataCallID:     3,
+		FismaSystemIDs: []*int32{&fsid},
+	}).ToSql()
+
+	assert.NoError(t, err)
+	assert.Contains(t, sql, "FROM snapshotanswers sn")
+	assert.Contains(t, sql, "sn.datacallid=$1")
+	assert.Contains(t, sql, "ORDER BY sn.ordr")
+	assert.Equal(t, int32(3), args[0])
+	assert.Len(t, args, 2)
+}
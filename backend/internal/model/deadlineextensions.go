@@ -0,0 +1,193 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+var deadlineExtensionColumns = []string{"extensionid", "datacallid", "opdiv_id", "fismasystemid", "expires_at", "reason", "granted_by", "granted_at"}
+
+// DeadlineExtension gives one OpDiv, or one FISMA system, more time to answer
+// a data call. Exactly one of OpDivID and FismaSystemID is set. ExpiresAt is
+// the extended deadline itself; an extension never shortens a deadline (see
+// effectiveDeadline).
+type DeadlineExtension struct {
+	ExtensionID   int32     `json:"extensionid"`
+	DataCallID    int32     `json:"datacallid"`
+	OpDivID       *int32    `json:"opdiv_id" db:"opdiv_id"`
+	FismaSystemID *int32    `json:"fismasystemid"`
+	ExpiresAt     time.Time `json:"expires_at" db:"expires_at"`
+	Reason        string    `json:"reason"`
+	// GrantedBy and GrantedAt are set by Save from the session and the clock;
+	// whatever a client sends for them is overwritten.
+	GrantedBy string    `json:"granted_by" db:"granted_by"`
+	GrantedAt time.Time `json:"granted_at" db:"granted_at"`
+}
+
+func (e *DeadlineExtension) validate(dataCall *DataCall) error {
+	err := InvalidInputError{data: map[string]any{}}
+
+	if (e.OpDivID == nil) == (e.FismaSystemID == nil) {
+		err.data["target"] = "exactly one of opdiv_id or fismasystemid is required"
+	}
+
+	if strings.TrimSpace(e.Reason) == "" {
+		err.data["reason"] = "required"
+	}
+
+	if e.ExpiresAt.IsZero() {
+		err.data["expires_at"] = "required"
+	} else if !e.ExpiresAt.After(dataCall.Deadline) {
+		err.data["expires_at"] = "must be after the data call's deadline"
+	}
+
+	if dataCall.Status == DataCallArchived {
+		err.data["datacallid"] = "data call is archived"
+	}
+
+	if len(err.data) > 0 {
+		return &err
+	}
+	return nil
+}
+
+// Save grants the extension, or re-grants it: a second grant for the same
+// (data call, OpDiv) or (data call, system) replaces the first's expiry and
+// reason and takes the new grantor, so there is never more than one extension
+// to reason about per target. The event log keeps the earlier grant.
+func (e *DeadlineExtension) Save(ctx context.Context) (*DeadlineExtension, error) {
+	user := UserFromContext(ctx)
+	if user == nil {
+		// granted_by is the point of the record; refuse an unattributed grant.
+		return nil, &InvalidInputError{data: map[string]any{"user": "required"}}
+	}
+
+	dataCall, err := FindDataCallByID(ctx, e.DataCallID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.validate(dataCall); err != nil {
+		return nil, err
+	}
+
+	// ON CONFLICT must name the partial index's predicate to match it.
+	conflict := "(datacallid, opdiv_id) WHERE opdiv_id IS NOT NULL"
+	if e.FismaSystemID != nil {
+		conflict = "(datacallid, fismasystemid) WHERE fismasystemid IS NOT NULL"
+	}
+
+	sqlb := stmntBuilder.
+		Insert("deadlineextensions").
+		Columns("datacallid", "opdiv_id", "fismasystemid", "expires_at", "reason", "granted_by").
+		Values(e.DataCallID, e.OpDivID, e.FismaSystemID, e.ExpiresAt, strings.TrimSpace(e.Reason), user.UserID).
+		Suffix("ON CONFLICT " + conflict + " DO UPDATE SET expires_at=EXCLUDED.expires_at, reason=EXCLUDED.reason, granted_by=EXCLUDED.granted_by, granted_at=NOW() RETURNING " + strings.Join(deadlineExtensionColumns, ", "))
+
+	return queryRow(ctx, sqlb, pgx.RowToStructByName[DeadlineExtension])
+}
+
+type FindDeadlineExtensionsInput struct {
+	DataCallID int32 `schema:"-"`
+	OpDivScope
+}
+
+// FindDeadlineExtensions lists a data call's extensions, OpDiv-wide ones first.
+// An OpDiv-scoped reader sees the extensions that target one of their OpDivs
+// or a system inside one.
+func FindDeadlineExtensions(ctx context.Context, input FindDeadlineExtensionsInput) ([]*DeadlineExtension, error) {
+	sqlb := stmntBuilder.
+		Select(deadlineExtensionColumns...).
+		From("deadlineextensions").
+		Where("datacallid=?", input.DataCallID).
+		OrderBy("opdiv_id NULLS LAST", "fismasystemid", "extensionid")
+
+	if f := input.OpDivWhere(squirrel.Expr(
+		"(opdiv_id = ANY(?) OR fismasystemid IN (SELECT fismasystemid FROM fismasystems WHERE opdiv_id = ANY(?)))",
+		input.OpDivIDs, input.OpDivIDs,
+	)); f != nil {
+		sqlb = sqlb.Where(f)
+	}
+
+	return query(ctx, sqlb, pgx.RowToAddrOfStructByName[DeadlineExtension])
+}
+
+func FindDeadlineExtensionByID(ctx context.Context, dataCallID, extensionID int32) (*DeadlineExtension, error) {
+	sqlb := stmntBuilder.
+		Select(deadlineExtensionColumns...).
+		From("deadlineextensions").
+		Where("datacallid=? AND extensionid=?", dataCallID, extensionID)
+
+	return queryRow(ctx, sqlb, pgx.RowToStructByName[DeadlineExtension])
+}
+
+// DeleteDeadlineExtension revokes an extension. The target falls back to the
+// next-latest deadline that applies to it: its OpDiv's extension if a system
+// extension was revoked, otherwise the call's own deadline.
+func DeleteDeadlineExtension(ctx context.Context, dataCallID, extensionID int32) error {
+	sqlb := stmntBuilder.
+		Delete("deadlineextensions").
+		Where("datacallid=? AND extensionid=?", dataCallID, extensionID).
+		Suffix("RETURNING " + strings.Join(deadlineExtensionColumns, ", "))
+
+	_, err := queryRow(ctx, sqlb, pgx.RowToStructByName[DeadlineExtension])
+	return err
+}
+
+// ApplyDeadlineExtension resolves the data call's deadline for one FISMA
+// system, setting EffectiveDeadline and, when an extension is what moved it,
+// Extension. A system gets the latest of the call's deadline, its OpDiv's
+// extension and its own, so a system extension shorter than its OpDiv's is
+// harmless and an extension the call's deadline has since overtaken is
+// ignored. CheckWritable honors EffectiveDeadline once this has run.
+func (d *DataCall) ApplyDeadlineExtension(ctx context.Context, fismaSystemID int32) error {
+	sqlb := stmntBuilder.
+		Select(deadlineExtensionColumns...).
+		From("deadlineextensions").
+		Where("datacallid=?", d.DataCallID).
+		Where("(fismasystemid=? OR opdiv_id=(SELECT opdiv_id FROM fismasystems WHERE fismasystemid=?))", fismaSystemID, fismaSystemID).
+		OrderBy("expires_at DESC", "fismasystemid NULLS LAST").
+		Limit(1)
+
+	ext, err := queryRow(ctx, sqlb, pgx.RowToStructByName[DeadlineExtension])
+	if err != nil && !errors.Is(err, ErrNoData) {
+		return err
+	}
+
+	d.EffectiveDeadline, d.Extension = effectiveDeadline(d.Deadline, ext)
+	return nil
+}
+
+// effectiveDeadline is the Go half of the rule effectiveDeadlineSQL applies in
+// bulk: the later of the call's deadline and the extension's expiry.
+func effectiveDeadline(deadline time.Time, ext *DeadlineExtension) (*time.Time, *DeadlineExtension) {
+	if ext == nil || !ext.ExpiresAt.After(deadline) {
+		return &deadline, nil
+	}
+	return &ext.ExpiresAt, ext
+}
+
+// effectiveDeadlineSQL is a scalar subquery yielding a system's effective
+// deadline for a data call, for queries that report many systems at once.
+// GREATEST ignores NULLs, so a system with no extension gets the call's own
+// deadline; an unknown data call yields NULL. Arguments are SQL expressions
+// valid in the caller's scope, as for reducedPillarScopeSQL.
+func effectiveDeadlineSQL(dataCallExpr, fismaSystemExpr, opDivExpr string) string {
+	return fmt.Sprintf(`(
+          SELECT GREATEST(edc.deadline, MAX(ede.expires_at))
+          FROM datacalls edc
+          LEFT JOIN deadlineextensions ede ON ede.datacallid = edc.datacallid
+           AND (ede.fismasystemid = %s OR ede.opdiv_id = %s)
+          WHERE edc.datacallid = %s
+          GROUP BY edc.deadline
+      )`,
+		fismaSystemExpr,
+		opDivExpr,
+		dataCallExpr,
+	)
+}
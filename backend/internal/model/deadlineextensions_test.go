@@ -0,0 +1,87 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDeadlineExtensionValidate covers the grant rules: one target, a reason,
+// and an expiry that actually extends - an "extension" on or before the call's
+// deadline would be a no-op that reads like a grant.
+func TestDeadlineExtensionValidate(t *testing.T) {
+	deadline := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+	open := &DataCall{Deadline: deadline, Status: DataCallOpen}
+	later := deadline.Add(14 * 24 * time.Hour)
+
+	invalidKeys := func(err error) []string {
+		var invalid *InvalidInputError
+		if !errors.As(err, &invalid) {
+			return nil
+		}
+		var keys []string
+		for k := range invalid.Data() {
+			keys = append(keys, k)
+		}
+		return keys
+	}
+
+	t.Run("system extension", func(t *testing.T) {
+		e := &DeadlineExtension{FismaSystemID: int32Ptr(7), ExpiresAt: later, Reason: "ATO renewal"}
+		assert.NoError(t, e.validate(open))
+	})
+	t.Run("both targets", func(t *testing.T) {
+		e := &DeadlineExtension{OpDivID: int32Ptr(1), FismaSystemID: int32Ptr(7), ExpiresAt: later, Reason: "x"}
+		assert.ElementsMatch(t, []string{"target"}, invalidKeys(e.validate(open)))
+	})
+	t.Run("no target, no reason", func(t *testing.T) {
+		e := &DeadlineExtension{ExpiresAt: later, Reason: "  "}
+		assert.ElementsMatch(t, []string{"target", "reason"}, invalidKeys(e.validate(open)))
+	})
+	t.Run("expiry does not extend", func(t *testing.T) {
+		e := &DeadlineExtension{OpDivID: int32Ptr(1), ExpiresAt: deadline, Reason: "x"}
+		assert.ElementsMatch(t, []string{"expires_at"}, invalidKeys(e.validate(open)))
+	})
+	t.Run("archived call", func(t *testing.T) {
+		e := &DeadlineExtension{OpDivID: int32Ptr(1), ExpiresAt: later, Reason: "x"}
+		assert.ElementsMatch(t, []string{"datacallid"}, invalidKeys(e.validate(&DataCall{Deadline: deadline, Status: DataCallArchived})))
+	})
+}
+
+// TestEffectiveDeadline pins that an extension only ever adds time: one the
+// call's deadline has since moved past is ignored rather than reported as the
+// reason the system has the deadline it has.
+func TestEffectiveDeadline(t *testing.T) {
+	deadline := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+
+	got, ext := effectiveDeadline(deadline, nil)
+	assert.True(t, got.Equal(deadline))
+	assert.Nil(t, ext)
+
+	later := &DeadlineExtension{ExtensionID: 1, ExpiresAt: deadline.Add(time.Hour)}
+	got, ext = effectiveDeadline(deadline, later)
+	assert.True(t, got.Equal(later.ExpiresAt))
+	assert.Same(t, later, ext)
+
+	overtaken := &DeadlineExtension{ExtensionID: 2, ExpiresAt: deadline.Add(-time.Hour)}
+	got, ext = effectiveDeadline(deadline, overtaken)
+	assert.True(t, got.Equal(deadline))
+	assert.Nil(t, ext)
+}
+
+// TestCheckWritableHonorsEffectiveDeadline is the point of the feature: an
+// ISSO past the call's deadline but inside their extension can still save.
+func TestCheckWritableHonorsEffectiveDeadline(t *testing.T) {
+	now := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)
+	extended := now.Add(24 * time.Hour)
+	dc := &DataCall{Status: DataCallOpen, Deadline: now.Add(-24 * time.Hour)}
+	isso := &User{Role: "ISSO"}
+
+	assert.ErrorIs(t, dc.CheckWritable(isso, now), ErrPastDeadline)
+
+	dc.EffectiveDeadline = &extended
+	assert.NoError(t, dc.CheckWritable(isso, now))
+	assert.ErrorIs(t, dc.CheckWritable(isso, extended.Add(time.Second)), ErrPastDeadline)
+}
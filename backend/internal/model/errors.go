@@ -18,6 +18,10 @@ var (
 	ErrNotUnique    = errors.New("not unique")
 	ErrNoReference  = errors.New("reference not found")
 	ErrPastDeadline = errors.New("deadline has passed")
+	// ErrDataCallNotOpen is returned for an answer written to a data call whose
+	// lifecycle state does not accept it: a draft or archived call, or a closed
+	// call for a non-admin. Mapped to 403 alongside ErrPastDeadline.
+	ErrDataCallNotOpen = errors.New("data call is not open")
 	ErrNotesTooLong = errors.New("notes exceed maximum length of 2000 characters")
 	// ErrDelegatesNotEnabled is returned when the System Delegate add flow targets
 	// a system whose OpDiv has the "Add System Delegate Role" capability off (#467).
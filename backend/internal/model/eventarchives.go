@@ -0,0 +1,578 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/attachments"
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// eventArchiveLockClass is the first key of the advisory lock that serializes
+// archiving, dropping and rehydrating one month of events (the second key is
+// the month as yyyymm). See rolloverLockClass for the key space.
+const eventArchiveLockClass = 433
+
+// eventPartitionsAhead is how many months past the current one the retention
+// job keeps partitions created for, matching migration 0070's backfill.
+const eventPartitionsAhead = 3
+
+// eventPartitionLayout is the time layout of a month partition's name, as
+// events_ensure_partition (migration 0070) names it.
+const eventPartitionLayout = "events_y2006m01"
+
+// EventArchive is one month of events the retention job moved out of the
+// events table into the archive store. Between a rehydrate and
+// RehydratedUntil the month's events are back in the table as well.
+type EventArchive struct {
+	ArchiveID       int32      `json:"archiveid"`
+	RangeStart      time.Time  `json:"rangestart"`
+	RangeEnd        time.Time  `json:"rangeend"`
+	ObjectKey       string     `json:"objectkey"`
+	EventCount      int64      `json:"eventcount"`
+	SHA256          string     `json:"sha256"`
+	ArchivedAt      time.Time  `json:"archivedat"`
+	RehydratedAt    *time.Time `json:"rehydratedat"`
+	RehydratedBy    *AuditRef  `json:"rehydratedby"`
+	RehydratedUntil *time.Time `json:"rehydrateduntil"`
+}
+
+// Rehydrated reports whether the month's events are back in the table at now.
+func (a *EventArchive) Rehydrated(now time.Time) bool {
+	return a.RehydratedUntil != nil && a.RehydratedUntil.After(now)
+}
+
+// archivedEvent is one line of an archive object. Payload stays raw so a
+// rehydrate writes back exactly the JSON that was archived, and the chain's
+// hashes ride along so a rehydrated month still verifies; they are absent on
+// events from before the chain began.
+type archivedEvent struct {
+	EventID   int64           `json:"eventid"`
+	UserID    string          `json:"userid"`
+	Action    string          `json:"action"`
+	Resource  string          `json:"resource"`
+	CreatedAt time.Time       `json:"createdat"`
+	Payload   json.RawMessage `json:"payload"`
+	PrevHash  *string         `json:"prevhash,omitempty"`
+	Hash      *string         `json:"hash,omitempty"`
+}
+
+// eventMonth returns the UTC month holding t as its [start, end) range.
+func eventMonth(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// eventMonthKey is the month as yyyymm, the advisory lock's second key.
+func eventMonthKey(start time.Time) int32 {
+	return int32(start.Year()*100 + int(start.Month()))
+}
+
+// eventArchiveKey is the store key of a month's archive object.
+func eventArchiveKey(start time.Time) string {
+	return start.Format("2006/01") + ".ndjson.gz"
+}
+
+// parseEventPartition returns the month a partition of events holds, and
+// false for a partition that is not a month (events_default).
+func parseEventPartition(name string) (time.Time, bool) {
+	start, err := time.Parse(eventPartitionLayout, name)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return start, true
+}
+
+// writeEventArchive encodes events as gzipped NDJSON, one event per line, and
+// returns how many it wrote.
+func writeEventArchive(w io.Writer, next func() (*archivedEvent, error)) (int64, error) {
+	gz := gzip.NewWriter(w)
+	enc := json.NewEncoder(gz)
+	var n int64
+	for {
+		e, err := next()
+		if err != nil {
+			return n, err
+		}
+		if e == nil {
+			break
+		}
+		if err := enc.Encode(e); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, gz.Close()
+}
+
+// readEventArchive decodes an archive object, calling each with every event
+// in the order written.
+func readEventArchive(r io.Reader, each func(*archivedEvent) error) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(gz))
+	for {
+		e := &archivedEvent{}
+		err := dec.Decode(e)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := each(e); err != nil {
+			return err
+		}
+	}
+}
+
+var eventArchiveColumns = []string{
+	"a.archiveid", "a.rangestart", "a.rangeend", "a.objectkey", "a.eventcount", "a.sha256", "a.archivedat",
+	"a.rehydratedat", "a.rehydrateduntil", "a.rehydratedby", "u.fullname", "u.email", "u.role",
+}
+
+func scanEventArchive(row pgx.CollectableRow) (*EventArchive, error) {
+	a := EventArchive{}
+	var byID, byName, byEmail, byRole *string
+	err := row.Scan(&a.ArchiveID, &a.RangeStart, &a.RangeEnd, &a.ObjectKey, &a.EventCount, &a.SHA256, &a.ArchivedAt,
+		&a.RehydratedAt, &a.RehydratedUntil, &byID, &byName, &byEmail, &byRole)
+	if err != nil {
+		return &a, err
+	}
+	if byID != nil {
+		a.RehydratedBy = &AuditRef{
+			UserID: *byID,
+			Name:   derefString(byName),
+			Email:  derefString(byEmail),
+			Role:   derefString(byRole),
+		}
+	}
+	return &a, nil
+}
+
+const eventArchivesFrom = "eventarchives a LEFT JOIN users u ON u.userid = a.rehydratedby"
+
+// FindEventArchives lists the archived months, oldest first.
+func FindEventArchives(ctx context.Context) ([]*EventArchive, error) {
+	sqlb := stmntBuilder.
+		Select(eventArchiveColumns...).
+		From(eventArchivesFrom).
+		OrderBy("a.rangestart")
+
+	return query(ctx, sqlb, scanEventArchive)
+}
+
+// findEventArchive reads a month's archive row inside tx, locking it, or nil
+// when the month has not been archived.
+func findEventArchive(ctx context.Context, tx pgx.Tx, start time.Time) (*EventArchive, error) {
+	sql, args, _ := stmntBuilder.
+		Select(eventArchiveColumns...).
+		From(eventArchivesFrom).
+		Where("a.rangestart = ?", start).
+		Suffix("FOR UPDATE OF a").
+		ToSql()
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, trapError(err)
+	}
+	archive, err := pgx.CollectOneRow(rows, scanEventArchive)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, trapError(err)
+	}
+	return archive, nil
+}
+
+// EventRetentionResult is what one run of ApplyEventRetention did to the
+// months past the retention age.
+type EventRetentionResult struct {
+	// Archived are the months written to the store and dropped this run,
+	// including rehydrated months that came back with more events than their
+	// archive and were written again.
+	Archived []*EventArchive
+	// Released are rehydrated months whose hold ended, dropped again without
+	// a new object.
+	Released []*EventArchive
+	// Held are rehydrated months still within their hold.
+	Held []*EventArchive
+}
+
+// ApplyEventRetention keeps partitions created ahead of now, then archives to
+// store and drops every month that ended before now minus retention. A month
+// is written and recorded before its partition is dropped, in one
+// transaction per month, so a run that fails part way loses nothing and the
+// next run picks up where it stopped.
+func ApplyEventRetention(ctx context.Context, store attachments.Store, now time.Time, retention time.Duration) (*EventRetentionResult, error) {
+	if retention <= 0 {
+		return nil, &InvalidInputError{data: map[string]any{"retention": "must be positive"}}
+	}
+
+	partitions, err := ensureEventPartitions(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := now.Add(-retention)
+	result := &EventRetentionResult{}
+	for _, name := range partitions {
+		start, ok := parseEventPartition(name)
+		if !ok {
+			continue
+		}
+		if _, end := eventMonth(start); end.After(cutoff) {
+			continue
+		}
+		if err := retireEventMonth(ctx, store, start, now, result); err != nil {
+			return result, fmt.Errorf("events %s: %w", start.Format("2006-01"), err)
+		}
+	}
+	return result, nil
+}
+
+// EnsureEventPartitions creates the partitions of the current month and the
+// eventPartitionsAhead months after it where missing. The API runs it daily
+// so new events keep landing in their month's partition even when the
+// retention job has not run: rows that land in events_default have to be
+// moved out under an exclusive lock on events when their month is created.
+func EnsureEventPartitions(ctx context.Context, now time.Time) error {
+	_, err := ensureEventPartitions(ctx, now)
+	return err
+}
+
+// ensureEventPartitions creates the partitions of the current month and the
+// months ahead where missing and returns the names of every partition of
+// events.
+func ensureEventPartitions(ctx context.Context, now time.Time) ([]string, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, trapError(err)
+	}
+	defer conn.Release()
+
+	month, _ := eventMonth(now)
+	for i := 0; i <= eventPartitionsAhead; i++ {
+		if _, err := conn.Exec(ctx, "SELECT public.events_ensure_partition($1)", month.AddDate(0, i, 0)); err != nil {
+			return nil, trapError(err)
+		}
+	}
+
+	rows, err := conn.Query(ctx, `
+		SELECT c.relname
+		  FROM pg_inherits i
+		  JOIN pg_class c ON c.oid = i.inhrelid
+		 WHERE i.inhparent = 'public.events'::regclass
+		 ORDER BY c.relname`)
+	if err != nil {
+		return nil, trapError(err)
+	}
+	partitions, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	return partitions, trapError(err)
+}
+
+// retireEventMonth archives and drops one expired month's partition, or, for
+// a month already archived and rehydrated, drops it again once its hold ends.
+func retireEventMonth(ctx context.Context, store attachments.Store, start, now time.Time, result *EventRetentionResult) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return trapError(err)
+	}
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		conn.Release()
+		return trapError(err)
+	}
+	defer func() {
+		tx.Rollback(ctx)
+		conn.Release()
+	}()
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1, $2)", eventArchiveLockClass, eventMonthKey(start)); err != nil {
+		return trapError(err)
+	}
+
+	// A rehydrate could have run between listing the partitions and taking
+	// the lock; re-read under it.
+	archive, err := findEventArchive(ctx, tx, start)
+	if err != nil {
+		return err
+	}
+	if archive != nil && archive.Rehydrated(now) {
+		result.Held = append(result.Held, archive)
+		return nil
+	}
+
+	_, end := eventMonth(start)
+	if archive != nil {
+		// Rows stamped in an archived month after it was archived land in
+		// the default partition, and a rehydrate moves them into the
+		// month's; dropping those unarchived would lose them.
+		var count int64
+		if err := tx.QueryRow(ctx, "SELECT COUNT(*) FROM events WHERE createdat >= $1 AND createdat < $2", start, end).Scan(&count); err != nil {
+			return trapError(err)
+		}
+		if count == archive.EventCount {
+			if _, err := tx.Exec(ctx, "UPDATE eventarchives SET rehydratedat = NULL, rehydratedby = NULL, rehydrateduntil = NULL WHERE archiveid = $1", archive.ArchiveID); err != nil {
+				return trapError(err)
+			}
+			if err := dropEventPartition(ctx, tx, start); err != nil {
+				return err
+			}
+			result.Released = append(result.Released, archive)
+			return tx.Commit(ctx)
+		}
+		log.Printf("EVENTS_RETENTION month=%s archived=%d now=%d reason=rearchive", start.Format("2006-01"), archive.EventCount, count)
+	}
+
+	archive, err = archiveEventMonth(ctx, tx, store, start, end)
+	if err != nil {
+		return err
+	}
+	if err := dropEventPartition(ctx, tx, start); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return trapError(err)
+	}
+	result.Archived = append(result.Archived, archive)
+	return nil
+}
+
+// archiveEventMonth writes the month's events to store, oldest first, and
+// records the object, replacing any earlier archive of the month. The object
+// is built in memory so its size and checksum are known before the upload;
+// compressed, a month of events is a few megabytes.
+func archiveEventMonth(ctx context.Context, tx pgx.Tx, store attachments.Store, start, end time.Time) (*EventArchive, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT eventid, userid, action, resource, createdat, payload, prevhash, hash
+		  FROM events
+		 WHERE createdat >= $1 AND createdat < $2
+		 ORDER BY createdat, eventid`, start, end)
+	if err != nil {
+		return nil, trapError(err)
+	}
+	defer rows.Close()
+
+	var buf bytes.Buffer
+	hash := sha256.New()
+	count, err := writeEventArchive(io.MultiWriter(&buf, hash), func() (*archivedEvent, error) {
+		if !rows.Next() {
+			return nil, rows.Err()
+		}
+		e := &archivedEvent{}
+		err := rows.Scan(&e.EventID, &e.UserID, &e.Action, &e.Resource, &e.CreatedAt, &e.Payload, &e.PrevHash, &e.Hash)
+		return e, err
+	})
+	if err != nil {
+		return nil, trapError(err)
+	}
+
+	key := eventArchiveKey(start)
+	if err := store.Put(ctx, key, &buf, int64(buf.Len()), "application/gzip"); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO eventarchives (rangestart, rangeend, objectkey, eventcount, sha256)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (rangestart) DO UPDATE
+		   SET objectkey = EXCLUDED.objectkey, eventcount = EXCLUDED.eventcount, sha256 = EXCLUDED.sha256,
+		       archivedat = NOW(), rehydratedat = NULL, rehydratedby = NULL, rehydrateduntil = NULL`,
+		start, end, key, count, hex.EncodeToString(hash.Sum(nil)))
+	if err != nil {
+		return nil, trapError(err)
+	}
+	return findEventArchive(ctx, tx, start)
+}
+
+func dropEventPartition(ctx context.Context, tx pgx.Tx, start time.Time) error {
+	name := pgx.Identifier{"public", start.Format(eventPartitionLayout)}.Sanitize()
+	if _, err := tx.Exec(ctx, "DROP TABLE IF EXISTS "+name); err != nil {
+		return trapError(err)
+	}
+	return nil
+}
+
+// RehydrateEventsInput names the range of archived events an investigation
+// needs back. Every archived month overlapping [From, To) is restored whole.
+type RehydrateEventsInput struct {
+	From *time.Time `json:"from"`
+	To   *time.Time `json:"to"`
+}
+
+func (i RehydrateEventsInput) Validate() error {
+	err := InvalidInputError{data: map[string]any{}}
+	if i.From == nil {
+		err.data["from"] = "required"
+	}
+	if i.To == nil {
+		err.data["to"] = "required"
+	}
+	if i.From != nil && i.To != nil && !i.To.After(*i.From) {
+		err.data["to"] = "must be after from"
+	}
+	if len(err.data) > 0 {
+		return &err
+	}
+	return nil
+}
+
+// RehydrateEvents puts the archived months overlapping input's range back
+// into the events table until until, where every events reader sees them
+// again. A month already rehydrated has its hold extended. The object is
+// checked against the recorded checksum and count before a row is written.
+// It returns ErrNoData when no archived month overlaps the range.
+func RehydrateEvents(ctx context.Context, store attachments.Store, input RehydrateEventsInput, until time.Time) ([]*EventArchive, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	user := UserFromContext(ctx)
+	if user == nil {
+		return nil, &InvalidInputError{data: map[string]any{"user": "required"}}
+	}
+
+	sqlb := stmntBuilder.
+		Select("rangestart").
+		From("eventarchives").
+		Where("rangestart < ? AND rangeend > ?", *input.To, *input.From).
+		OrderBy("rangestart")
+	months, err := query(ctx, sqlb, pgx.RowTo[time.Time])
+	if err != nil {
+		return nil, err
+	}
+	if len(months) == 0 {
+		return nil, ErrNoData
+	}
+
+	archives := make([]*EventArchive, 0, len(months))
+	for _, start := range months {
+		archive, err := rehydrateEventMonth(ctx, store, start, user, until)
+		if err != nil {
+			return archives, err
+		}
+		archives = append(archives, archive)
+	}
+	return archives, nil
+}
+
+func rehydrateEventMonth(ctx context.Context, store attachments.Store, start time.Time, user *User, until time.Time) (*EventArchive, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, trapError(err)
+	}
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		conn.Release()
+		return nil, trapError(err)
+	}
+	defer func() {
+		tx.Rollback(ctx)
+		conn.Release()
+	}()
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1, $2)", eventArchiveLockClass, eventMonthKey(start)); err != nil {
+		return nil, trapError(err)
+	}
+	archive, err := findEventArchive(ctx, tx, start)
+	if err != nil {
+		return nil, err
+	}
+	if archive == nil {
+		return nil, ErrNoData
+	}
+
+	var present bool
+	name := "public." + start.Format(eventPartitionLayout)
+	if err := tx.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", name).Scan(&present); err != nil {
+		return nil, trapError(err)
+	}
+	if !present {
+		if err := restoreEventMonth(ctx, tx, store, archive); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE eventarchives
+		   SET rehydratedat = NOW(), rehydratedby = $2, rehydrateduntil = GREATEST(rehydrateduntil, $3)
+		 WHERE archiveid = $1`, archive.ArchiveID, user.UserID, until)
+	if err != nil {
+		return nil, trapError(err)
+	}
+
+	err = insertEventTx(ctx, tx,
+		user.UserID, eventActionRehydrated, "eventarchives", map[string]any{"archiveid": archive.ArchiveID})
+	if err != nil {
+		return nil, err
+	}
+
+	if archive, err = findEventArchive(ctx, tx, start); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, trapError(err)
+	}
+	return archive, nil
+}
+
+// restoreEventMonth recreates the month's partition and copies the archived
+// events into it with their original eventids.
+func restoreEventMonth(ctx context.Context, tx pgx.Tx, store attachments.Store, archive *EventArchive) error {
+	body, err := store.Get(ctx, archive.ObjectKey)
+	if errors.Is(err, attachments.ErrNotFound) {
+		log.Printf("EVENTS_ARCHIVE_MISSING archiveid=%d key=%s", archive.ArchiveID, archive.ObjectKey)
+		return ErrNoData
+	}
+	if err != nil {
+		return err
+	}
+	object, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(object)
+	if hex.EncodeToString(sum[:]) != archive.SHA256 {
+		return fmt.Errorf("event archive %d (%s) does not match its checksum", archive.ArchiveID, archive.ObjectKey)
+	}
+
+	if _, err := tx.Exec(ctx, "SELECT public.events_ensure_partition($1)", archive.RangeStart); err != nil {
+		return trapError(err)
+	}
+
+	var events [][]any
+	err = readEventArchive(bytes.NewReader(object), func(e *archivedEvent) error {
+		events = append(events, []any{e.EventID, e.UserID, e.Action, e.Resource, e.CreatedAt, e.Payload, e.PrevHash, e.Hash})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if int64(len(events)) != archive.EventCount {
+		return fmt.Errorf("event archive %d (%s) holds %d events, expected %d", archive.ArchiveID, archive.ObjectKey, len(events), archive.EventCount)
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"public", "events"},
+		[]string{"eventid", "userid", "action", "resource", "createdat", "payload", "prevhash", "hash"},
+		pgx.CopyFromRows(events))
+	return trapError(err)
+}
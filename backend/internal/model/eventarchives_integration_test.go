@@ -0,0 +1,111 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/attachments"
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEventRetentionIntegration walks one month through the whole cycle:
+// archived and dropped, rehydrated with its original eventids, then dropped
+// again once the hold ends. The month is January 1999 and the run's now is
+// March 1999, so no other test's events are old enough to be touched.
+func TestEventRetentionIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+
+	month := time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(1999, 3, 15, 0, 0, 0, 0, time.UTC)
+	t.Cleanup(func() {
+		_, _ = conn.Exec(ctx, `DELETE FROM public.eventarchives WHERE rangestart < '2000-01-01'`)
+		for m := month; m.Year() == 1999; m = m.AddDate(0, 1, 0) {
+			_, _ = conn.Exec(ctx, "DROP TABLE IF EXISTS public."+m.Format(eventPartitionLayout))
+		}
+		_, _ = conn.Exec(ctx, `DELETE FROM public.events WHERE resource = 'eventarchives'`)
+		conn.Release()
+	})
+
+	var user User
+	require.NoError(t, conn.QueryRow(ctx, `SELECT userid, role FROM public.users WHERE role = 'HHS_ADMIN' LIMIT 1`).Scan(&user.UserID, &user.Role))
+
+	_, err = conn.Exec(ctx, "SELECT public.events_ensure_partition($1)", month)
+	require.NoError(t, err)
+	var ids []int64
+	for _, day := range []int{3, 3, 20} {
+		var id int64
+		require.NoError(t, conn.QueryRow(ctx,
+			`INSERT INTO public.events (userid, action, resource, createdat, payload)
+			 VALUES ($1, 'updated', 'retention_test', $2, '{"scoreid": 9}') RETURNING eventid`,
+			user.UserID, month.AddDate(0, 0, day-1)).Scan(&id))
+		ids = append(ids, id)
+	}
+
+	countMonth := func() int {
+		var n int
+		require.NoError(t, conn.QueryRow(ctx,
+			`SELECT COUNT(*) FROM public.events WHERE createdat >= $1 AND createdat < $2`, month, month.AddDate(0, 1, 0)).Scan(&n))
+		return n
+	}
+
+	store, err := attachments.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	result, err := ApplyEventRetention(ctx, store, now, 24*time.Hour)
+	require.NoError(t, err)
+	require.Len(t, result.Archived, 1)
+	archive := result.Archived[0]
+	assert.Equal(t, month, archive.RangeStart.UTC())
+	assert.Equal(t, int64(3), archive.EventCount)
+	assert.Equal(t, "1999/01.ndjson.gz", archive.ObjectKey)
+	assert.Equal(t, 0, countMonth())
+
+	// A second run finds nothing left to do.
+	result, err = ApplyEventRetention(ctx, store, now, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Empty(t, result.Archived)
+
+	until := now.Add(7 * 24 * time.Hour)
+	from, to := month.AddDate(0, 0, 10), month.AddDate(0, 0, 11)
+	rehydrated, err := RehydrateEvents(UserToContext(ctx, &user), store, RehydrateEventsInput{From: &from, To: &to}, until)
+	require.NoError(t, err)
+	require.Len(t, rehydrated, 1)
+	require.NotNil(t, rehydrated[0].RehydratedBy)
+	assert.Equal(t, user.UserID, rehydrated[0].RehydratedBy.UserID)
+
+	rows, err := conn.Query(ctx,
+		`SELECT eventid FROM public.events WHERE resource = 'retention_test' AND createdat < '2000-01-01' ORDER BY createdat, eventid`)
+	require.NoError(t, err)
+	var back []int64
+	for rows.Next() {
+		var id int64
+		require.NoError(t, rows.Scan(&id))
+		back = append(back, id)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, ids, back)
+
+	// Within the hold the month stays; after it, it goes without a new object.
+	result, err = ApplyEventRetention(ctx, store, now, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Len(t, result.Held, 1)
+	assert.Equal(t, 3, countMonth())
+
+	result, err = ApplyEventRetention(ctx, store, until.Add(time.Hour), 24*time.Hour)
+	require.NoError(t, err)
+	require.Len(t, result.Released, 1)
+	assert.Equal(t, 0, countMonth())
+
+	// A range nothing was archived for.
+	from, to = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(1980, 2, 1, 0, 0, 0, 0, time.UTC)
+	_, err = RehydrateEvents(UserToContext(ctx, &user), store, RehydrateEventsInput{From: &from, To: &to}, until)
+	assert.ErrorIs(t, err, ErrNoData)
+}
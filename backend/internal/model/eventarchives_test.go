@@ -0,0 +1,80 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Months are UTC whatever zone the time is given in: late on the 31st in New
+// York is already the next month.
+func TestEventMonth(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	start, end := eventMonth(time.Date(2026, 1, 31, 21, 0, 0, 0, ny))
+	assert.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), end)
+	assert.Equal(t, int32(202602), eventMonthKey(start))
+	assert.Equal(t, "2026/02.ndjson.gz", eventArchiveKey(start))
+}
+
+func TestParseEventPartition(t *testing.T) {
+	start, ok := parseEventPartition("events_y2025m11")
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, "events_y2025m11", start.Format(eventPartitionLayout))
+
+	_, ok = parseEventPartition("events_default")
+	assert.False(t, ok)
+}
+
+// TestEventArchiveRoundTrip pins that an archive reads back exactly what was
+// written, payload bytes and chain hashes included, in order.
+func TestEventArchiveRoundTrip(t *testing.T) {
+	at := time.Date(2025, 11, 3, 8, 15, 0, 123456000, time.UTC)
+	prev, hash := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	events := []*archivedEvent{
+		{EventID: 41, UserID: "u1", Action: "updated", Resource: "public.scores", CreatedAt: at, Payload: json.RawMessage(`{"scoreid":7}`), PrevHash: &prev, Hash: &hash},
+		{EventID: 12, UserID: "u2", Action: "viewed", Resource: "questionnaire", CreatedAt: at, Payload: json.RawMessage(`{"questionid":3,"readonly":true}`)},
+	}
+
+	var buf bytes.Buffer
+	i := 0
+	n, err := writeEventArchive(&buf, func() (*archivedEvent, error) {
+		if i == len(events) {
+			return nil, nil
+		}
+		i++
+		return events[i-1], nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	var read []*archivedEvent
+	require.NoError(t, readEventArchive(&buf, func(e *archivedEvent) error {
+		read = append(read, e)
+		return nil
+	}))
+	assert.Equal(t, events, read)
+}
+
+func TestRehydrateEventsInputValidate(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	assert.NoError(t, RehydrateEventsInput{From: &from, To: &to}.Validate())
+
+	err := RehydrateEventsInput{}.Validate()
+	require.Error(t, err)
+	assert.Equal(t, map[string]any{"from": "required", "to": "required"}, err.(*InvalidInputError).data)
+
+	err = RehydrateEventsInput{From: &to, To: &from}.Validate()
+	require.Error(t, err)
+	assert.Equal(t, "must be after from", err.(*InvalidInputError).data["to"])
+}
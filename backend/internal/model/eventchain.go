@@ -0,0 +1,523 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/attachments"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// eventChainGenesis is the prevhash of the first chained event.
+var eventChainGenesis = strings.Repeat("0", 64)
+
+// Kinds of break VerifyEventChain reports, at the first event where one is
+// found.
+const (
+	// EventChainAltered: the event no longer hashes to its stored hash, so a
+	// column of it was changed after it was written.
+	EventChainAltered = "altered"
+	// EventChainUnlinked: the event's prevhash is not the hash of the chained
+	// event before it, so one between them was deleted or forged.
+	EventChainUnlinked = "unlinked"
+	// EventChainUnchained: the event was written after the chain began but
+	// carries no hash, so it did not go through insertEvent.
+	EventChainUnchained = "unchained"
+	// EventChainTruncated: the chain's head is not the last chained event,
+	// so events at the end were deleted.
+	EventChainTruncated = "truncated"
+	// EventChainCheckpoint: a signed checkpoint names a hash the event does
+	// not have, or its signature does not verify.
+	EventChainCheckpoint = "checkpoint"
+)
+
+// eventLink is an event as the chain hashes it. Payload is the jsonb's text,
+// which Postgres renders the same way on every read.
+type eventLink struct {
+	EventID   int64
+	UserID    string
+	Action    string
+	Resource  string
+	CreatedAt time.Time
+	Payload   string
+	PrevHash  *string
+	Hash      *string
+}
+
+// eventChainHash is an event's link: the SHA-256, hex encoded, of the JSON
+// array of prevHash and the event's columns. createdat enters as Unix
+// microseconds, the precision it is stored at.
+func eventChainHash(prevHash string, l *eventLink) string {
+	b, _ := json.Marshal([]any{prevHash, l.EventID, l.UserID, l.Action, l.Resource, l.CreatedAt.UnixMicro(), l.Payload})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// pendingEvent is an event for insertEventsTx to append.
+type pendingEvent struct {
+	UserID   string
+	Action   string
+	Resource string
+	Payload  any
+}
+
+// insertEventTx appends an event to the log and the chain inside tx; see
+// insertEventsTx.
+func insertEventTx(ctx context.Context, tx pgx.Tx, userID, action, resource string, payload any) error {
+	return insertEventsTx(ctx, tx, []pendingEvent{{userID, action, resource, payload}})
+}
+
+// insertEventsTx appends events to the log and the chain inside tx, in
+// order, in four round trips however many there are. It locks the chain's
+// head for the rest of tx, which serializes chained inserts: the eventids and
+// createdat are drawn under the lock, from the sequence and clock_timestamp(),
+// so both rise with the chain even when transactions overlap, and a rolled
+// back tx leaves the head where it was. CURRENT_TIMESTAMP would not do: it is
+// when tx began, and a tx that began first can reach the lock second. Callers
+// record their events as the last write of tx, to hold the lock for as short
+// a time as they can.
+func insertEventsTx(ctx context.Context, tx pgx.Tx, events []pendingEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var headHash *string
+	if err := tx.QueryRow(ctx, "SELECT headhash FROM eventchain FOR UPDATE").Scan(&headHash); err != nil {
+		return trapError(err)
+	}
+	prevHash := eventChainGenesis
+	if headHash != nil {
+		prevHash = *headHash
+	}
+
+	userIDs := make([]string, len(events))
+	payloads := make([]string, len(events))
+	for i, e := range events {
+		b, err := json.Marshal(e.Payload)
+		if err != nil {
+			return err
+		}
+		userIDs[i], payloads[i] = e.UserID, string(b)
+	}
+
+	// The uuids and payloads are read back in the form the verifier will read
+	// them, so each hash is taken over what is stored. Ordinality keeps the
+	// rows in events' order; the volatile columns are drawn in that order too.
+	rows, err := tx.Query(ctx, `
+		SELECT nextval('public.events_eventid_seq'), clock_timestamp(), u::uuid::text, p::jsonb::text
+		  FROM (SELECT u, p FROM unnest($1::text[], $2::text[]) WITH ORDINALITY AS r(u, p, i) ORDER BY i) r`,
+		userIDs, payloads)
+	if err != nil {
+		return trapError(err)
+	}
+	links := make([]*eventLink, 0, len(events))
+	for rows.Next() {
+		e := events[len(links)]
+		l := &eventLink{Action: e.Action, Resource: e.Resource}
+		if err := rows.Scan(&l.EventID, &l.CreatedAt, &l.UserID, &l.Payload); err != nil {
+			rows.Close()
+			return trapError(err)
+		}
+		links = append(links, l)
+	}
+	if err := rows.Err(); err != nil {
+		return trapError(err)
+	}
+
+	copyRows := make([][]any, len(links))
+	for i, l := range links {
+		prev := prevHash
+		l.PrevHash = &prev
+		hash := eventChainHash(prevHash, l)
+		l.Hash = &hash
+		prevHash = hash
+		copyRows[i] = []any{l.EventID, l.UserID, l.Action, l.Resource, l.CreatedAt, json.RawMessage(l.Payload), prev, hash}
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"public", "events"},
+		[]string{"eventid", "userid", "action", "resource", "createdat", "payload", "prevhash", "hash"},
+		pgx.CopyFromRows(copyRows))
+	if err != nil {
+		return trapError(err)
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE eventchain SET headid = $1, headhash = $2, genesisid = COALESCE(genesisid, $3)",
+		links[len(links)-1].EventID, prevHash, links[0].EventID)
+	return trapError(err)
+}
+
+// eventChainState is the eventchain row: nil ids before the first chained
+// insert.
+type eventChainState struct {
+	GenesisID *int64
+	HeadID    *int64
+	HeadHash  *string
+}
+
+func findEventChainState(ctx context.Context) (*eventChainState, error) {
+	sqlb := stmntBuilder.Select("genesisid", "headid", "headhash").From("eventchain")
+	return queryRow(ctx, sqlb, func(row pgx.CollectableRow) (eventChainState, error) {
+		s := eventChainState{}
+		err := row.Scan(&s.GenesisID, &s.HeadID, &s.HeadHash)
+		return s, err
+	})
+}
+
+// EventCheckpoint is a signed statement that the chain's head was EventID
+// with Hash at CreatedAt. It is kept in eventcheckpoints and published to
+// the archive store under ObjectKey.
+type EventCheckpoint struct {
+	CheckpointID int32     `json:"checkpointid"`
+	EventID      int64     `json:"eventid"`
+	Hash         string    `json:"hash"`
+	CreatedAt    time.Time `json:"createdat"`
+	KeyID        string    `json:"keyid"`
+	Signature    string    `json:"signature"`
+	ObjectKey    string    `json:"objectkey"`
+}
+
+// statement is the message a checkpoint's signature covers.
+func (c *EventCheckpoint) statement() []byte {
+	b, _ := json.Marshal(struct {
+		EventID   int64  `json:"eventid"`
+		Hash      string `json:"hash"`
+		CreatedAt string `json:"createdat"`
+	}{c.EventID, c.Hash, c.CreatedAt.UTC().Format(time.RFC3339Nano)})
+	return b
+}
+
+// signEventCheckpoint returns c's signature by key, base64 encoded.
+func signEventCheckpoint(c *EventCheckpoint, key ed25519.PrivateKey) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(key, c.statement()))
+}
+
+// verify reports whether the checkpoint is signed by key.
+func (c *EventCheckpoint) verify(key ed25519.PublicKey) bool {
+	sig, err := base64.StdEncoding.DecodeString(c.Signature)
+	return err == nil && ed25519.Verify(key, c.statement(), sig)
+}
+
+// EventCheckpointKeyID names a checkpoint key by the first 8 bytes of the
+// SHA-256 of its public key, hex encoded, so a checkpoint says which key to
+// check it with.
+func EventCheckpointKeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+func findEventCheckpoints(ctx context.Context, latest bool) ([]*EventCheckpoint, error) {
+	sqlb := stmntBuilder.
+		Select("checkpointid", "eventid", "hash", "createdat", "keyid", "signature", "objectkey").
+		From("eventcheckpoints")
+	if latest {
+		sqlb = sqlb.OrderBy("checkpointid DESC").Limit(1)
+	} else {
+		sqlb = sqlb.OrderBy("checkpointid")
+	}
+	return query(ctx, sqlb, pgx.RowToAddrOfStructByName[EventCheckpoint])
+}
+
+// CreateEventCheckpoint signs the chain's current head with key, publishes
+// the checkpoint to store and records it. It returns the latest checkpoint
+// unchanged, and false, when the head has not moved since it was taken, and
+// ErrNoData when nothing has been chained yet.
+func CreateEventCheckpoint(ctx context.Context, store attachments.Store, key ed25519.PrivateKey, now time.Time) (*EventCheckpoint, bool, error) {
+	state, err := findEventChainState(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if state.HeadID == nil {
+		return nil, false, ErrNoData
+	}
+
+	latest, err := findEventCheckpoints(ctx, true)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(latest) > 0 && latest[0].EventID == *state.HeadID {
+		return latest[0], false, nil
+	}
+
+	pub := key.Public().(ed25519.PublicKey)
+	cp := &EventCheckpoint{
+		EventID:   *state.HeadID,
+		Hash:      *state.HeadHash,
+		CreatedAt: now.UTC().Truncate(time.Microsecond),
+		KeyID:     EventCheckpointKeyID(pub),
+		ObjectKey: fmt.Sprintf("checkpoints/%020d.json", *state.HeadID),
+	}
+	cp.Signature = signEventCheckpoint(cp, key)
+
+	// The published object carries the public key too, so it can be checked
+	// with nothing but itself and a trusted copy of that key.
+	object, err := json.Marshal(struct {
+		*EventCheckpoint
+		PublicKey string `json:"publickey"`
+	}{cp, base64.StdEncoding.EncodeToString(pub)})
+	if err != nil {
+		return nil, false, err
+	}
+	if err := store.Put(ctx, cp.ObjectKey, bytes.NewReader(object), int64(len(object)), "application/json"); err != nil {
+		return nil, false, err
+	}
+
+	sqlb := stmntBuilder.
+		Insert("eventcheckpoints").
+		Columns("eventid", "hash", "createdat", "keyid", "signature", "objectkey").
+		Values(cp.EventID, cp.Hash, cp.CreatedAt, cp.KeyID, cp.Signature, cp.ObjectKey).
+		Suffix("RETURNING checkpointid, eventid, hash, createdat, keyid, signature, objectkey")
+	created, err := queryRow(ctx, sqlb, pgx.RowToAddrOfStructByName[EventCheckpoint])
+	if err != nil {
+		return nil, false, err
+	}
+	return *created, true, nil
+}
+
+// VerifyEventChainInput bounds a verification by createdat, inclusively.
+// Either end may be open; with no To the walk runs to the chain's head and
+// also checks that the head is still there.
+type VerifyEventChainInput struct {
+	From *time.Time `schema:"-"`
+	To   *time.Time `schema:"-"`
+}
+
+// EventChainReport is the result of walking the chain over a range.
+type EventChainReport struct {
+	From     *time.Time `json:"from"`
+	To       *time.Time `json:"to"`
+	Verified bool       `json:"verified"`
+	// Checked counts the chained events whose hash and link were checked.
+	Checked int64 `json:"checked"`
+	// PreChain counts the events written before the chain began, which
+	// carry no hash to check.
+	PreChain int64 `json:"prechain"`
+	// Gaps counts the archived months the walk stepped over: a link to an
+	// event the retention job archived is not a break.
+	Gaps int `json:"gaps"`
+	// Checkpoints counts the signed checkpoints matched; CheckpointsSkipped
+	// those signed with a key other than the one verifying.
+	Checkpoints        int  `json:"checkpoints"`
+	CheckpointsSkipped int  `json:"checkpoints_skipped"`
+	SignaturesChecked  bool `json:"signatures_checked"`
+	// HeadID is the chain's head when the walk began.
+	HeadID *int64           `json:"headid"`
+	Break  *EventChainBreak `json:"break"`
+}
+
+// EventChainBreak is where a verification stopped.
+type EventChainBreak struct {
+	EventID   int64      `json:"eventid"`
+	CreatedAt *time.Time `json:"createdat"`
+	Kind      string     `json:"kind"`
+	Detail    string     `json:"detail"`
+}
+
+// errChainBreak stops the walk at the first break.
+var errChainBreak = errors.New("event chain break")
+
+// eventChainWalk holds what the walk needs beyond the current event.
+type eventChainWalk struct {
+	report      *EventChainReport
+	genesisID   *int64
+	prev        *eventLink
+	archives    []*EventArchive
+	checkpoints map[int64][]*EventCheckpoint
+	key         ed25519.PublicKey
+	keyID       string
+}
+
+// archivedBetween reports whether an archived month lies between two events,
+// so the link from one to the other may run through events no longer in the
+// table.
+func (w *eventChainWalk) archivedBetween(from, to time.Time) bool {
+	for _, a := range w.archives {
+		if a.RangeStart.Before(to) && a.RangeEnd.After(from) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *eventChainWalk) fail(l *eventLink, kind, detail string) error {
+	at := l.CreatedAt
+	w.report.Break = &EventChainBreak{EventID: l.EventID, CreatedAt: &at, Kind: kind, Detail: detail}
+	return errChainBreak
+}
+
+// step checks one event, in eventid order.
+func (w *eventChainWalk) step(l *eventLink) error {
+	if w.genesisID == nil || l.EventID < *w.genesisID {
+		if l.Hash != nil {
+			return w.fail(l, EventChainUnlinked, "an event from before the chain began carries a hash")
+		}
+		w.report.PreChain++
+		return nil
+	}
+	if l.Hash == nil {
+		return w.fail(l, EventChainUnchained, "written after the chain began without a hash")
+	}
+	if l.PrevHash == nil || eventChainHash(*l.PrevHash, l) != *l.Hash {
+		return w.fail(l, EventChainAltered, "the event does not hash to its stored hash")
+	}
+
+	switch {
+	case l.EventID == *w.genesisID:
+		if *l.PrevHash != eventChainGenesis {
+			return w.fail(l, EventChainUnlinked, "the first chained event does not start the chain")
+		}
+	case w.prev != nil && *l.PrevHash == *w.prev.Hash:
+	default:
+		var since time.Time
+		if w.prev != nil {
+			since = w.prev.CreatedAt
+		}
+		if !w.archivedBetween(since, l.CreatedAt) {
+			return w.fail(l, EventChainUnlinked, "prevhash is not the hash of the chained event before it")
+		}
+		w.report.Gaps++
+	}
+
+	for _, cp := range w.checkpoints[l.EventID] {
+		if w.key != nil && cp.KeyID != w.keyID {
+			w.report.CheckpointsSkipped++
+			continue
+		}
+		if cp.Hash != *l.Hash {
+			return w.fail(l, EventChainCheckpoint, fmt.Sprintf("checkpoint %d names a different hash", cp.CheckpointID))
+		}
+		if w.key != nil && !cp.verify(w.key) {
+			return w.fail(l, EventChainCheckpoint, fmt.Sprintf("checkpoint %d's signature does not verify", cp.CheckpointID))
+		}
+		w.report.Checkpoints++
+	}
+
+	w.report.Checked++
+	w.prev = l
+	return nil
+}
+
+// VerifyEventChain walks the events in input's range in chain order,
+// recomputing each hash and checking each link, and reports the first break.
+// Checkpoints in the range are matched against the events they name and,
+// when key is given, their signatures checked. A break is a finding, not an
+// error: the report carries it and the error is nil.
+func VerifyEventChain(ctx context.Context, input VerifyEventChainInput, key ed25519.PublicKey) (*EventChainReport, error) {
+	if input.From != nil && input.To != nil && input.From.After(*input.To) {
+		return nil, &InvalidInputError{data: map[string]any{"from": "must not be after to"}}
+	}
+
+	state, err := findEventChainState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	archives, err := FindEventArchives(ctx)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints, err := findEventCheckpoints(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &EventChainReport{From: input.From, To: input.To, HeadID: state.HeadID, SignaturesChecked: key != nil}
+	w := &eventChainWalk{
+		report:      report,
+		genesisID:   state.GenesisID,
+		archives:    archives,
+		checkpoints: map[int64][]*EventCheckpoint{},
+		key:         key,
+	}
+	if key != nil {
+		w.keyID = EventCheckpointKeyID(key)
+	}
+	for _, cp := range checkpoints {
+		w.checkpoints[cp.EventID] = append(w.checkpoints[cp.EventID], cp)
+	}
+
+	where := func(sqlb squirrel.SelectBuilder) squirrel.SelectBuilder {
+		if input.From != nil {
+			sqlb = sqlb.Where("createdat >= ?", *input.From)
+		}
+		if input.To != nil {
+			sqlb = sqlb.Where("createdat <= ?", *input.To)
+		}
+		// Events chained after the head was read are the next walk's.
+		if state.HeadID != nil {
+			sqlb = sqlb.Where("(hash IS NULL OR eventid <= ?)", *state.HeadID)
+		}
+		return sqlb
+	}
+
+	// The link into the range's first event is checked against the chained
+	// event before it, wherever that falls.
+	if state.GenesisID != nil {
+		first, err := queryRow(ctx, where(stmntBuilder.Select("MIN(eventid)").From("events").Where("hash IS NOT NULL")), pgx.RowTo[*int64])
+		if err != nil {
+			return nil, err
+		}
+		if *first != nil && **first > *state.GenesisID {
+			prev, err := query(ctx, eventLinkSelect().
+				Where("eventid < ? AND hash IS NOT NULL", **first).
+				OrderBy("eventid DESC").
+				Limit(1), scanEventLink)
+			if err != nil {
+				return nil, err
+			}
+			if len(prev) > 0 {
+				w.prev = prev[0]
+			}
+		}
+	}
+
+	err = queryEach(ctx, where(eventLinkSelect()).OrderBy("eventid"), scanEventLink, w.step)
+	if errors.Is(err, errChainBreak) {
+		return report, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// On a walk run to the end, the last chained event must be the head. The walk
+	// ends elsewhere only when the head falls before the range, so it must
+	// then still be in the table as the chain left it.
+	if input.To == nil && state.HeadID != nil && (w.prev == nil || w.prev.EventID != *state.HeadID || *w.prev.Hash != *state.HeadHash) {
+		head, err := query(ctx, eventLinkSelect().Where("eventid = ?", *state.HeadID), scanEventLink)
+		if err != nil {
+			return nil, err
+		}
+		if len(head) == 0 || head[0].Hash == nil || *head[0].Hash != *state.HeadHash || (input.From != nil && !head[0].CreatedAt.Before(*input.From)) {
+			report.Break = &EventChainBreak{
+				EventID: *state.HeadID,
+				Kind:    EventChainTruncated,
+				Detail:  "the chain's head is no longer its last event",
+			}
+			return report, nil
+		}
+	}
+
+	report.Verified = true
+	return report, nil
+}
+
+func eventLinkSelect() squirrel.SelectBuilder {
+	return stmntBuilder.
+		Select("eventid", "userid::text", "action", "resource", "createdat", "payload::text", "prevhash", "hash").
+		From("events")
+}
+
+func scanEventLink(row pgx.CollectableRow) (*eventLink, error) {
+	l := eventLink{}
+	err := row.Scan(&l.EventID, &l.UserID, &l.Action, &l.Resource, &l.CreatedAt, &l.Payload, &l.PrevHash, &l.Hash)
+	return &l, err
+}
@@ -0,0 +1,167 @@
+package model
+
+import (
+	"context"
+	"crypto/ed25519"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/attachments"
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEventChainIntegration appends events from concurrent writers and in a
+// batch, and checks that they still form one unbroken chain, that altering one is
+// reported at that event, and that a checkpoint of the head signs and
+// verifies. Other tests write and delete events directly, so the walk is
+// bounded to this test's own window, which starts after an anchor event so
+// the first link has its predecessor. The events are left in place: deleting
+// them would break the chain for everything after.
+func TestEventChainIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	var userID string
+	require.NoError(t, conn.QueryRow(ctx, `SELECT userid FROM public.users WHERE role = 'HHS_ADMIN' LIMIT 1`).Scan(&userID))
+
+	require.NoError(t, insertEvent(ctx, userID, eventActionCreated, "chain_test", map[string]any{"anchor": true}))
+	var from time.Time
+	require.NoError(t, conn.QueryRow(ctx, "SELECT NOW()").Scan(&from))
+
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- insertEvent(ctx, userID, eventActionUpdated, "chain_test", map[string]any{"n": i})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// A batch, as an import appends its provenance, chains like single writes.
+	batch := make([]pendingEvent, 5)
+	for i := range batch {
+		batch[i] = pendingEvent{userID, eventActionImported, "chain_test", map[string]any{"batch": i}}
+	}
+	tx, err := conn.Begin(ctx)
+	require.NoError(t, err)
+	require.NoError(t, insertEventsTx(ctx, tx, batch))
+	require.NoError(t, tx.Commit(ctx))
+
+	var to time.Time
+	require.NoError(t, conn.QueryRow(ctx, "SELECT NOW()").Scan(&to))
+	window := VerifyEventChainInput{From: &from, To: &to}
+
+	report, err := VerifyEventChain(ctx, window, nil)
+	require.NoError(t, err)
+	require.Nil(t, report.Break, "concurrent inserts must chain: %+v", report.Break)
+	assert.True(t, report.Verified)
+	assert.GreaterOrEqual(t, report.Checked, int64(writers+len(batch)))
+
+	// Alter one event's payload, then put it back as it was.
+	var victim int64
+	var payload string
+	require.NoError(t, conn.QueryRow(ctx,
+		`SELECT eventid, payload::text FROM public.events
+		  WHERE resource = 'chain_test' AND createdat >= $1 AND payload->>'n' = '7'`, from).Scan(&victim, &payload))
+	_, err = conn.Exec(ctx, `UPDATE public.events SET payload = '{"n": -1}' WHERE eventid = $1`, victim)
+	require.NoError(t, err)
+
+	report, err = VerifyEventChain(ctx, window, nil)
+	_, restoreErr := conn.Exec(ctx, `UPDATE public.events SET payload = $2::jsonb WHERE eventid = $1`, victim, payload)
+	require.NoError(t, restoreErr)
+	require.NoError(t, err)
+	require.NotNil(t, report.Break)
+	assert.Equal(t, EventChainAltered, report.Break.Kind)
+	assert.Equal(t, victim, report.Break.EventID)
+
+	report, err = VerifyEventChain(ctx, window, nil)
+	require.NoError(t, err)
+	assert.Nil(t, report.Break)
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	store, err := attachments.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	cp, created, err := CreateEventCheckpoint(ctx, store, priv, time.Now())
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.True(t, cp.verify(pub))
+	assert.Equal(t, EventCheckpointKeyID(pub), cp.KeyID)
+	object, err := store.Get(ctx, cp.ObjectKey)
+	require.NoError(t, err)
+	object.Close()
+
+	again, created, err := CreateEventCheckpoint(ctx, store, priv, time.Now())
+	require.NoError(t, err)
+	assert.False(t, created, "an unmoved head is not checkpointed twice")
+	assert.Equal(t, cp.CheckpointID, again.CheckpointID)
+}
+
+// TestEventChainOverlappingIntegration pins that a transaction which begins
+// first but reaches the chain second is stamped after the one that overtook
+// it, so a range bounded between the two still walks an unbroken chain.
+func TestEventChainOverlappingIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	var userID string
+	require.NoError(t, conn.QueryRow(ctx, `SELECT userid FROM public.users WHERE role = 'HHS_ADMIN' LIMIT 1`).Scan(&userID))
+	require.NoError(t, insertEvent(ctx, userID, eventActionCreated, "chain_test", map[string]any{"anchor": "overlap"}))
+
+	slow, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer slow.Release()
+	tx, err := slow.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+	_, err = tx.Exec(ctx, "SELECT 1")
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+
+	require.NoError(t, insertEvent(ctx, userID, eventActionUpdated, "chain_test", map[string]any{"overlap": "fast"}))
+	require.NoError(t, insertEventTx(ctx, tx, userID, eventActionUpdated, "chain_test", map[string]any{"overlap": "slow"}))
+	require.NoError(t, tx.Commit(ctx))
+	require.NoError(t, insertEvent(ctx, userID, eventActionUpdated, "chain_test", map[string]any{"overlap": "after"}))
+
+	stamp := func(which string) (int64, time.Time) {
+		var id int64
+		var at time.Time
+		require.NoError(t, conn.QueryRow(ctx,
+			`SELECT eventid, createdat FROM public.events
+			  WHERE resource = 'chain_test' AND payload->>'overlap' = $1
+			  ORDER BY eventid DESC LIMIT 1`, which).Scan(&id, &at))
+		return id, at
+	}
+	fastID, fastAt := stamp("fast")
+	slowID, slowAt := stamp("slow")
+	assert.Greater(t, slowID, fastID)
+	assert.True(t, slowAt.After(fastAt), "createdat must rise with eventid")
+
+	var to time.Time
+	require.NoError(t, conn.QueryRow(ctx, "SELECT NOW()").Scan(&to))
+	report, err := VerifyEventChain(ctx, VerifyEventChainInput{From: &fastAt, To: &to}, nil)
+	require.NoError(t, err)
+	require.Nil(t, report.Break, "a bound between overlapping writers must not drop a link: %+v", report.Break)
+	assert.GreaterOrEqual(t, report.Checked, int64(3))
+}
@@ -0,0 +1,194 @@
+package model
+
+import (
+	"crypto/ed25519"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chainLinks builds n events chained from genesis, eventids from first, an
+// hour apart.
+func chainLinks(first int64, n int) []*eventLink {
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	prev := eventChainGenesis
+	links := make([]*eventLink, n)
+	for i := range links {
+		p := prev
+		l := &eventLink{
+			EventID:   first + int64(i),
+			UserID:    "00000000-0000-0000-0000-000000000001",
+			Action:    eventActionUpdated,
+			Resource:  "public.scores",
+			CreatedAt: at.Add(time.Duration(i) * time.Hour),
+			Payload:   `{"scoreid": 7}`,
+			PrevHash:  &p,
+		}
+		h := eventChainHash(p, l)
+		l.Hash = &h
+		prev = h
+		links[i] = l
+	}
+	return links
+}
+
+func walkChain(genesisID int64, links []*eventLink, archives ...*EventArchive) *EventChainReport {
+	w := &eventChainWalk{
+		report:      &EventChainReport{},
+		genesisID:   &genesisID,
+		archives:    archives,
+		checkpoints: map[int64][]*EventCheckpoint{},
+	}
+	for _, l := range links {
+		if w.step(l) != nil {
+			break
+		}
+	}
+	return w.report
+}
+
+// Every column, and the link to the event before, goes into the hash.
+func TestEventChainHash(t *testing.T) {
+	l := chainLinks(1, 1)[0]
+	h := eventChainHash(eventChainGenesis, l)
+	assert.Len(t, h, 64)
+	assert.Equal(t, h, eventChainHash(eventChainGenesis, l))
+
+	assert.NotEqual(t, h, eventChainHash(strings.Repeat("1", 64), l))
+	for name, alter := range map[string]func(*eventLink){
+		"eventid":   func(c *eventLink) { c.EventID++ },
+		"userid":    func(c *eventLink) { c.UserID = "00000000-0000-0000-0000-000000000002" },
+		"action":    func(c *eventLink) { c.Action = eventActionDeleted },
+		"resource":  func(c *eventLink) { c.Resource = "users" },
+		"createdat": func(c *eventLink) { c.CreatedAt = c.CreatedAt.Add(time.Microsecond) },
+		"payload":   func(c *eventLink) { c.Payload = `{"scoreid": 8}` },
+	} {
+		c := *l
+		alter(&c)
+		assert.NotEqual(t, h, eventChainHash(eventChainGenesis, &c), name)
+	}
+}
+
+func TestEventChainWalk(t *testing.T) {
+	t.Run("intact", func(t *testing.T) {
+		r := walkChain(1, chainLinks(1, 5))
+		assert.Nil(t, r.Break)
+		assert.Equal(t, int64(5), r.Checked)
+	})
+
+	t.Run("pre-chain events are counted, not checked", func(t *testing.T) {
+		pre := []*eventLink{{EventID: 1}, {EventID: 2}}
+		r := walkChain(3, append(pre, chainLinks(3, 2)...))
+		assert.Nil(t, r.Break)
+		assert.Equal(t, int64(2), r.PreChain)
+		assert.Equal(t, int64(2), r.Checked)
+	})
+
+	t.Run("altered", func(t *testing.T) {
+		links := chainLinks(1, 4)
+		links[2].Payload = `{"scoreid": 9}`
+		r := walkChain(1, links)
+		require.NotNil(t, r.Break)
+		assert.Equal(t, EventChainAltered, r.Break.Kind)
+		assert.Equal(t, int64(3), r.Break.EventID)
+		assert.Equal(t, int64(2), r.Checked)
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		links := chainLinks(1, 4)
+		r := walkChain(1, append(links[:1], links[2:]...))
+		require.NotNil(t, r.Break)
+		assert.Equal(t, EventChainUnlinked, r.Break.Kind)
+		assert.Equal(t, int64(3), r.Break.EventID)
+	})
+
+	t.Run("unchained", func(t *testing.T) {
+		links := chainLinks(1, 2)
+		r := walkChain(1, append(links, &eventLink{EventID: 3}))
+		require.NotNil(t, r.Break)
+		assert.Equal(t, EventChainUnchained, r.Break.Kind)
+	})
+
+	t.Run("an archived month is a gap", func(t *testing.T) {
+		links := chainLinks(1, 4)
+		start := links[1].CreatedAt.Add(-time.Minute)
+		archive := &EventArchive{RangeStart: start, RangeEnd: links[2].CreatedAt.Add(time.Minute)}
+		r := walkChain(1, append(links[:1], links[3:]...), archive)
+		assert.Nil(t, r.Break)
+		assert.Equal(t, 1, r.Gaps)
+	})
+}
+
+func TestEventCheckpointSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	cp := &EventCheckpoint{
+		EventID:   42,
+		Hash:      strings.Repeat("a", 64),
+		CreatedAt: time.Date(2026, 3, 1, 9, 0, 0, 123456000, time.UTC),
+		KeyID:     EventCheckpointKeyID(pub),
+	}
+	cp.Signature = signEventCheckpoint(cp, priv)
+	assert.True(t, cp.verify(pub))
+
+	// As read back from the database, in another zone.
+	read := *cp
+	read.CreatedAt = cp.CreatedAt.In(time.FixedZone("EST", -5*3600))
+	assert.True(t, read.verify(pub))
+
+	forged := *cp
+	forged.Hash = strings.Repeat("b", 64)
+	assert.False(t, forged.verify(pub))
+
+	other, _, _ := ed25519.GenerateKey(nil)
+	assert.False(t, cp.verify(other))
+	assert.Len(t, cp.KeyID, 16)
+}
+
+func TestEventChainWalkCheckpoints(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	links := chainLinks(1, 3)
+
+	walk := func(cp *EventCheckpoint) *EventChainReport {
+		one := int64(1)
+		w := &eventChainWalk{
+			report:      &EventChainReport{},
+			genesisID:   &one,
+			checkpoints: map[int64][]*EventCheckpoint{cp.EventID: {cp}},
+			key:         pub,
+			keyID:       EventCheckpointKeyID(pub),
+		}
+		for _, l := range links {
+			if w.step(l) != nil {
+				break
+			}
+		}
+		return w.report
+	}
+
+	cp := &EventCheckpoint{EventID: 2, Hash: *links[1].Hash, CreatedAt: time.Now().UTC(), KeyID: EventCheckpointKeyID(pub)}
+	cp.Signature = signEventCheckpoint(cp, priv)
+	r := walk(cp)
+	assert.Nil(t, r.Break)
+	assert.Equal(t, 1, r.Checkpoints)
+
+	// The chain rewritten from event 2 on still hashes, but not to what was
+	// signed.
+	rewritten := *cp
+	rewritten.Hash = *links[2].Hash
+	rewritten.Signature = cp.Signature
+	r = walk(&rewritten)
+	require.NotNil(t, r.Break)
+	assert.Equal(t, EventChainCheckpoint, r.Break.Kind)
+
+	other := *cp
+	other.KeyID = "0000000000000000"
+	r = walk(&other)
+	assert.Nil(t, r.Break)
+	assert.Equal(t, 1, r.CheckpointsSkipped)
+}
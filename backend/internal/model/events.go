@@ -2,21 +2,28 @@ package model
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
 	"github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
 	"github.com/lann/builder"
 )
 
 type Event struct {
-	EventID   int64       `json:"eventid"`   // identity column; the paging tiebreaker (migration 0058)
+	EventID   int64       `json:"eventid"`   // the paging tiebreaker (migration 0058) and the hash chain's order (0071)
 	UserID    string      `json:"userid"`    // who initiated the event
 	Action    string      `json:"action"`    // the action they took
 	Resource  string      `json:"type"`      // on what resource
 	CreatedAt *time.Time  `json:"createdat"` // at what date and time
 	Payload   interface{} `json:"payload"`   // incoming data
+	PrevHash  *string     `json:"prevhash"`  // hash of the event chained before this one; nil before the chain began
+	Hash      *string     `json:"hash"`      // SHA-256 over prevhash and this event's columns: its link in the chain
 }
 
 // Event actions. These are the complete set of values that may appear in
@@ -52,8 +59,8 @@ const (
 
 	// eventActionImported marks provenance for score data loaded outside the
 	// application - bulk imports and seed SQL, not a human answering a
-	// questionnaire. Nothing in this package writes it today; the value lives
-	// here so there is one authoritative home for it.
+	// questionnaire. ImportScores (scoreimport.go) is the Go writer; the seed
+	// data writes the same value as a SQL literal.
 	//
 	// Note which consumers actually depend on the exact spelling: the readers
 	// that exclude imported rows (0048's backfill, the seed status-sync, the
@@ -63,19 +70,32 @@ const (
 	// WRITERS and the assertions over them: the seed INSERT in
 	// _test_data_empire.sql and scoreprogress_integration_test.go, which read
 	// back `action='imported'` to prove imported history stays not_started.
-	//
-	// A future bulk importer must write this action rather than reusing the
-	// in-app create/update path - see Score.Save.
-	//
-	// Having no Go writer is the point, so silence the unused check rather than
-	// leaving the value defined only in SQL and prose. Staticcheck will NOT
-	// tell you when this directive goes stale - U1000 ignores are exempt from
-	// its unmatched-directive check - so delete it by hand if a Go writer ever
-	// appears.
-	//lint:ignore U1000 defined as the authoritative spelling for SQL readers; no Go writer by design
 	eventActionImported = "imported"
+
+	// eventActionRolloverRepaired is recorded once by each RepairRollover that
+	// wrote rows (rolloverrepair.go), with the new scoreids in the payload. The
+	// rows are carried-forward answers, not edits, so like 'imported' it stays
+	// outside the created/updated allowlists.
+	eventActionRolloverRepaired = "rollover_repaired"
+
+	// eventActionSubmitted, eventActionApproved and eventActionReturned record
+	// the review transitions (scorereview.go). A review is not an edit of the
+	// answer, so these stay outside the created/updated allowlists and out of
+	// a score's last-edited audit fields.
+	eventActionSubmitted = "submitted"
+	eventActionApproved  = "approved"
+	eventActionReturned  = "returned"
+
+	// eventActionRehydrated is recorded by RehydrateEvents (eventarchives.go),
+	// once per archived month put back, with its archiveid in the payload.
+	// Bringing audit history back is itself audited.
+	eventActionRehydrated = "rehydrated"
 )
 
+// reviewEventActions are the review transitions, bound by the queries that
+// tell a score's edits from its reviews.
+var reviewEventActions = []string{eventActionSubmitted, eventActionApproved, eventActionReturned}
+
 // json tags here are used when payload is marshaled into select Where argument (see FindEvents() )
 type payload struct {
 	UserID        *string `schema:"userid" json:"userid,omitempty"`
@@ -105,6 +125,10 @@ type FindEventsInput struct {
 	// decoder from ever trying.
 	From *time.Time `schema:"-" json:"from,omitempty"`
 	To   *time.Time `schema:"-" json:"to,omitempty"`
+	// Cursor continues from a previous page's NextCursor instead of skipping
+	// Offset rows, so a deep page costs what the first one does. It cannot
+	// be combined with Offset.
+	Cursor *string `schema:"cursor" json:"cursor,omitempty"`
 }
 
 // Paging bounds for FindEvents. GET /events returned the entire table before
@@ -139,19 +163,57 @@ func (i *FindEventsInput) offset() uint32 {
 // paging controls. Total counts every event matching the filters, not just this
 // page; Limit and Offset echo the values actually applied after defaulting and
 // clamping, so a client can trust them for page math without re-deriving the
-// rules.
+// rules. NextCursor fetches the page after this one and is null once a page
+// comes back short; a page that ends exactly on the last event still carries
+// one, which then yields an empty page.
 type EventsPage struct {
-	Events []*Event `json:"events"`
-	Total  int64    `json:"total"`
-	Limit  uint32   `json:"limit"`
-	Offset uint32   `json:"offset"`
+	Events     []*Event `json:"events"`
+	Total      int64    `json:"total"`
+	Limit      uint32   `json:"limit"`
+	Offset     uint32   `json:"offset"`
+	NextCursor *string  `json:"next_cursor"`
+}
+
+// eventsCursor is the position after an event in the createdat DESC, eventid
+// DESC order. createdat leads because eventid was backfilled in no particular
+// order (migration 0058) and only breaks ties. It travels as an opaque token:
+// the microseconds and eventid, base64url encoded.
+type eventsCursor struct {
+	createdAt time.Time
+	eventID   int64
+}
+
+func (c eventsCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d.%d", c.createdAt.UnixMicro(), c.eventID)))
+}
+
+func parseEventsCursor(token string) (eventsCursor, error) {
+	invalid := &InvalidInputError{data: map[string]any{"cursor": "not a cursor this endpoint issued"}}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return eventsCursor{}, invalid
+	}
+	micros, id, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return eventsCursor{}, invalid
+	}
+	us, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return eventsCursor{}, invalid
+	}
+	eventID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return eventsCursor{}, invalid
+	}
+	return eventsCursor{createdAt: time.UnixMicro(us).UTC(), eventID: eventID}, nil
 }
 
 // recordEvent uses the provided SqlBuilder to determin what write operation was performed (create, update, delete), and
 // records that along with current user ID, the resource being acted upon, and the payload for the event.
 // The event payload is essentially the row that was inserted or updated, but in this case stored as JSONB.
 //
-// Error handling: the inner queryRow call logs but does not return its
+// Error handling: the inner insertEvent call logs but does not return its
 // error to recordEvent's caller. The outer write that triggered this
 // hook (e.g. scores INSERT) has already succeeded by the time we get
 // here, so failing the response would lie about what is in the DB.
@@ -192,25 +254,35 @@ func recordEvent(ctx context.Context, sqlb SqlBuilder, res interface{}) {
 
 	// Fire-and-forget: the outer write already succeeded, so a failed event
 	// insert must not fail the response (see the doc comment above). The error
-	// is discarded here but logged inside queryRow.
+	// is discarded here but logged inside insertEvent.
 	insertEvent(ctx, user.UserID, e.Action, e.Resource, e.Payload)
 }
 
-// insertEvent appends a single row to the events audit log. It is the shared
-// write behind both recordEvent (the write-derived side-effect hook, which
-// discards the error) and RecordQuestionView (an explicit, purpose-built event,
-// which returns it). The insert flows through queryRow, whose recordEvent hook
-// short-circuits on resource == "events", so recording an event never recurses
-// into recording another.
+// insertEvent appends a single row to the events audit log, chained to the
+// event before it, in a transaction of its own. It is the shared write behind
+// both recordEvent (the write-derived side-effect hook, which discards the
+// error) and RecordQuestionView (an explicit, purpose-built event, which
+// returns it). A write that records its event in its own transaction calls
+// insertEventTx instead, so the two commit together.
 func insertEvent(ctx context.Context, userID, action, resource string, payload any) error {
-	sqlb := stmntBuilder.
-		Insert("events").
-		Columns("userid", "action", "resource", "payload").
-		Values(userID, action, resource, payload).
-		Suffix("Returning *")
-
-	_, err := queryRow(ctx, sqlb, pgx.RowToStructByName[Event])
-	return err
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return trapError(err)
+	}
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		conn.Release()
+		return trapError(err)
+	}
+	defer func() {
+		tx.Rollback(ctx)
+		conn.Release()
+	}()
+
+	if err := insertEventTx(ctx, tx, userID, action, resource, payload); err != nil {
+		return err
+	}
+	return trapError(tx.Commit(ctx))
 }
 
 // QuestionViewInput carries the client-supplied identifiers for a questionnaire
@@ -306,17 +378,18 @@ func RecordLogin(ctx context.Context, userID string) error {
 	return insertEvent(ctx, userID, eventActionCreated, "session", payload{UserID: &userID})
 }
 
-func FindEvents(ctx context.Context, input *FindEventsInput) (*EventsPage, error) {
-
-	if input.From != nil && input.To != nil && input.From.After(*input.To) {
+// where returns the filters shared by FindEvents and StreamEvents, applied
+// to a select on events.
+func (i *FindEventsInput) where() (func(squirrel.SelectBuilder) squirrel.SelectBuilder, error) {
+	if i.From != nil && i.To != nil && i.From.After(*i.To) {
 		return nil, &InvalidInputError{data: map[string]any{"from": "must not be after to"}}
 	}
 
 	// Marshaled once, ahead of the closure, so a marshal failure surfaces
 	// before any query is built.
 	var payloadJSON *string
-	if input.Payload != nil {
-		p, err := json.Marshal(input.Payload)
+	if i.Payload != nil {
+		p, err := json.Marshal(i.Payload)
 		if err != nil {
 			return nil, err
 		}
@@ -324,28 +397,47 @@ func FindEvents(ctx context.Context, input *FindEventsInput) (*EventsPage, error
 		payloadJSON = &s
 	}
 
-	// The page query and the count query must agree on the filters or Total
-	// lies to the client's pager; one closure keeps them from drifting.
-	where := func(sqlb squirrel.SelectBuilder) squirrel.SelectBuilder {
-		if input.UserID != nil {
-			sqlb = sqlb.Where("userid=?", input.UserID)
+	return func(sqlb squirrel.SelectBuilder) squirrel.SelectBuilder {
+		if i.UserID != nil {
+			sqlb = sqlb.Where("userid=?", i.UserID)
 		}
-		if input.Resource != nil {
-			sqlb = sqlb.Where("resource=?", input.Resource)
+		if i.Resource != nil {
+			sqlb = sqlb.Where("resource=?", i.Resource)
 		}
-		if input.Action != nil {
-			sqlb = sqlb.Where("action=?", input.Action)
+		if i.Action != nil {
+			sqlb = sqlb.Where("action=?", i.Action)
 		}
-		if input.From != nil {
-			sqlb = sqlb.Where("createdat >= ?", input.From)
+		if i.From != nil {
+			sqlb = sqlb.Where("createdat >= ?", i.From)
 		}
-		if input.To != nil {
-			sqlb = sqlb.Where("createdat <= ?", input.To)
+		if i.To != nil {
+			sqlb = sqlb.Where("createdat <= ?", i.To)
 		}
 		if payloadJSON != nil {
 			sqlb = sqlb.Where("payload @> ?", *payloadJSON)
 		}
 		return sqlb
+	}, nil
+}
+
+func FindEvents(ctx context.Context, input *FindEventsInput) (*EventsPage, error) {
+	// The page query and the count query must agree on the filters or Total
+	// lies to the client's pager; one closure keeps them from drifting.
+	where, err := input.where()
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor *eventsCursor
+	if input.Cursor != nil {
+		if input.offset() > 0 {
+			return nil, &InvalidInputError{data: map[string]any{"cursor": "cannot be combined with offset"}}
+		}
+		c, err := parseEventsCursor(*input.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		cursor = &c
 	}
 
 	limit, offset := input.limit(), input.offset()
@@ -357,6 +449,10 @@ func FindEvents(ctx context.Context, input *FindEventsInput) (*EventsPage, error
 		OrderBy("createdat DESC", "eventid DESC").
 		Limit(uint64(limit)).
 		Offset(uint64(offset))
+	if cursor != nil {
+		// The row comparison walks events_pagination_idx from the cursor.
+		sqlb = sqlb.Where("(createdat, eventid) < (?, ?)", cursor.createdAt, cursor.eventID)
+	}
 
 	events, err := query(ctx, sqlb, pgx.RowToAddrOfStructByName[Event])
 	if err != nil {
@@ -373,5 +469,61 @@ func FindEvents(ctx context.Context, input *FindEventsInput) (*EventsPage, error
 		return nil, err
 	}
 
-	return &EventsPage{Events: events, Total: *total, Limit: limit, Offset: offset}, nil
+	page := &EventsPage{Events: events, Total: *total, Limit: limit, Offset: offset}
+	if last := len(events) - 1; last >= 0 && len(events) == int(limit) && events[last].CreatedAt != nil {
+		next := eventsCursor{createdAt: *events[last].CreatedAt, eventID: events[last].EventID}.String()
+		page.NextCursor = &next
+	}
+	return page, nil
+}
+
+// Audit log export formats accepted by ExportEventsInput.Format.
+const (
+	EventsExportNDJSON = "ndjson"
+	EventsExportCSV    = "csv"
+)
+
+// ExportEventsInput is the whole of FindEventsInput's filtered audit trail in
+// a file. Paging does not apply to an export; Format defaults to NDJSON.
+type ExportEventsInput struct {
+	FindEventsInput
+	Format *string `schema:"format" json:"format,omitempty"`
+}
+
+// Validate rejects an export format the export does not write and the paging
+// fields, so both surface as a 400 before any response is started.
+func (i *ExportEventsInput) Validate() error {
+	err := InvalidInputError{data: map[string]any{}}
+	if i.Format != nil && *i.Format != EventsExportNDJSON && *i.Format != EventsExportCSV {
+		err.data["format"] = fmt.Sprintf("must be %s or %s", EventsExportNDJSON, EventsExportCSV)
+	}
+	for key, set := range map[string]bool{"limit": i.Limit != nil, "offset": i.Offset != nil, "cursor": i.Cursor != nil} {
+		if set {
+			err.data[key] = "does not apply to an export"
+		}
+	}
+	if len(err.data) > 0 {
+		return &err
+	}
+	return nil
+}
+
+// StreamEvents hands every event matching the filters to each, oldest first
+// in the same total order FindEvents pages newest first, as the rows arrive
+// from the database. Nothing is collected, so an export of years of audit
+// trail holds one event at a time. An error from each stops the scan and is
+// returned.
+func StreamEvents(ctx context.Context, input *ExportEventsInput, each func(*Event) error) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+	where, err := input.where()
+	if err != nil {
+		return err
+	}
+
+	sqlb := where(stmntBuilder.Select("*").From("events")).
+		OrderBy("createdat ASC", "eventid ASC")
+
+	return queryEach(ctx, sqlb, pgx.RowToAddrOfStructByName[Event], each)
 }
@@ -101,3 +101,66 @@ func TestFindEventsPaginationIntegration(t *testing.T) {
 	assert.NotNil(t, page.Events)
 	assert.Empty(t, page.Events)
 }
+
+// The cursor walk must cover the same rows in the same order as the offset
+// walk above, including across the tied timestamps, and the stream must
+// yield them all in the reverse order.
+func TestFindEventsCursorIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+
+	const resource = "cursor_test"
+	t.Cleanup(func() {
+		_, _ = conn.Exec(ctx, `DELETE FROM public.events WHERE resource=$1`, resource)
+		conn.Release()
+	})
+
+	var userID string
+	require.NoError(t, conn.QueryRow(ctx, `SELECT userid FROM public.users LIMIT 1`).Scan(&userID))
+
+	tied := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, ts := range []time.Time{tied, tied, tied, tied, tied, tied.Add(-time.Hour)} {
+		_, err := conn.Exec(ctx,
+			`INSERT INTO public.events (userid, action, resource, createdat, payload) VALUES ($1, 'created', $2, $3, '{}')`,
+			userID, resource, ts)
+		require.NoError(t, err)
+	}
+
+	res := resource
+	all, err := FindEvents(ctx, &FindEventsInput{Resource: &res})
+	require.NoError(t, err)
+	require.Len(t, all.Events, 6)
+	assert.Nil(t, all.NextCursor, "a short page is the last")
+
+	limit := uint32(4)
+	var walked []int64
+	var cursor *string
+	for range 3 {
+		page, err := FindEvents(ctx, &FindEventsInput{Resource: &res, Limit: &limit, Cursor: cursor})
+		require.NoError(t, err)
+		assert.EqualValues(t, 6, page.Total, "total ignores the cursor")
+		for _, e := range page.Events {
+			walked = append(walked, e.EventID)
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	var want []int64
+	for _, e := range all.Events {
+		want = append(want, e.EventID)
+	}
+	assert.Equal(t, want, walked)
+
+	var streamed []int64
+	require.NoError(t, StreamEvents(ctx, &ExportEventsInput{FindEventsInput: FindEventsInput{Resource: &res}}, func(e *Event) error {
+		streamed = append([]int64{e.EventID}, streamed...)
+		return nil
+	}))
+	assert.Equal(t, want, streamed, "the stream is the page order reversed")
+}
@@ -2,11 +2,13 @@ package model
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUserFromContext_NilUser(t *testing.T) {
@@ -146,3 +148,42 @@ func TestFindEventsRejectsInvertedRange(t *testing.T) {
 		assert.Contains(t, iie.Data(), "from")
 	}
 }
+
+func TestEventsCursor(t *testing.T) {
+	c := eventsCursor{createdAt: time.Date(2026, 3, 4, 5, 6, 7, 123456000, time.UTC), eventID: 98765}
+	got, err := parseEventsCursor(c.String())
+	require.NoError(t, err)
+	assert.Equal(t, c, got)
+
+	for _, bad := range []string{"", "!!", base64.RawURLEncoding.EncodeToString([]byte("12")), base64.RawURLEncoding.EncodeToString([]byte("a.1"))} {
+		_, err := parseEventsCursor(bad)
+		var iie *InvalidInputError
+		assert.ErrorAs(t, err, &iie, bad)
+	}
+}
+
+// A cursor and an offset are two answers to where a page starts; asking for
+// both is refused before any query runs.
+func TestFindEventsRejectsCursorWithOffset(t *testing.T) {
+	cursor := eventsCursor{createdAt: time.Now(), eventID: 1}.String()
+	offset := uint32(50)
+	_, err := FindEvents(context.Background(), &FindEventsInput{Cursor: &cursor, Offset: &offset})
+	iie, ok := err.(*InvalidInputError)
+	if assert.True(t, ok, "want *InvalidInputError, got %T", err) {
+		assert.Contains(t, iie.Data(), "cursor")
+	}
+}
+
+func TestExportEventsInputValidate(t *testing.T) {
+	csv, pdf := EventsExportCSV, "pdf"
+	limit := uint32(10)
+	assert.NoError(t, (&ExportEventsInput{}).Validate())
+	assert.NoError(t, (&ExportEventsInput{Format: &csv}).Validate())
+
+	err := (&ExportEventsInput{Format: &pdf, FindEventsInput: FindEventsInput{Limit: &limit}}).Validate()
+	iie, ok := err.(*InvalidInputError)
+	if assert.True(t, ok, "want *InvalidInputError, got %T", err) {
+		assert.Contains(t, iie.Data(), "format")
+		assert.Contains(t, iie.Data(), "limit")
+	}
+}
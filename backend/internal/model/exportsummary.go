@@ -0,0 +1,101 @@
+package model
+
+import (
+	"context"
+	"fmt"
+)
+
+// Export workbook layouts accepted by FindAnswersInput.Workbook.
+const (
+	ExportWorkbookAnswers   = "answers"
+	ExportWorkbookExecutive = "executive"
+)
+
+// ExportSummary is what the executive workbook reports beside a data call's
+// answers. Systems carries each scored system's score, tier and pillar scores
+// measured against its target; OpDivs rolls them up by OpDiv and HHS the
+// whole set, so every figure is the one the scores endpoints give for the
+// same scope. HHS is nil unless the export covers the whole department (see
+// FindExportSummary). OpDivCodes labels Systems' OpDivs.
+type ExportSummary struct {
+	Systems    []*ScoreGap
+	OpDivs     []*ScoreRollup
+	HHS        *ScoreRollup
+	OpDivCodes map[int32]string
+}
+
+// ValidateExportWorkbook rejects a workbook layout the export does not have.
+func ValidateExportWorkbook(workbook *string) error {
+	if workbook == nil || *workbook == ExportWorkbookAnswers || *workbook == ExportWorkbookExecutive {
+		return nil
+	}
+	return &InvalidInputError{data: map[string]any{
+		"workbook": fmt.Sprintf("must be %s or %s", ExportWorkbookAnswers, ExportWorkbookExecutive),
+	}}
+}
+
+// FindExportSummary gathers the executive figures for the data call and
+// systems FindAnswers exports for input, under the same scope.
+func FindExportSummary(ctx context.Context, input FindAnswersInput) (*ExportSummary, error) {
+	gaps, err := FindScoreGaps(ctx, FindScoreGapsInput{
+		DataCallID: &input.DataCallID,
+		UserID:     input.UserID,
+		OpDivScope: input.OpDivScope,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &ExportSummary{Systems: gaps, OpDivCodes: map[int32]string{}}
+	if len(input.FismaSystemIDs) > 0 {
+		wanted := map[int32]bool{}
+		for _, id := range input.FismaSystemIDs {
+			if id != nil {
+				wanted[*id] = true
+			}
+		}
+		summary.Systems = []*ScoreGap{}
+		for _, g := range gaps {
+			if wanted[g.FismaSystemID] {
+				summary.Systems = append(summary.Systems, g)
+			}
+		}
+	}
+
+	rollupInput := FindScoresInput{
+		DataCallID:     &input.DataCallID,
+		FismaSystemIDs: input.FismaSystemIDs,
+		UserID:         input.UserID,
+		OpDivScope:     input.OpDivScope,
+	}
+	groupBy := ScoreGroupOpDiv
+	rollupInput.GroupBy = &groupBy
+	if summary.OpDivs, err = FindScoresRollup(ctx, rollupInput); err != nil {
+		return nil, err
+	}
+	// Over a scoped or filtered export the department rollup would be the
+	// average of a subset labelled as HHS's, the figure guardScoreRollup
+	// refuses group_by=hhs for, so it is left out.
+	if input.UserID == nil && !input.RestrictToOpDivIDs && len(input.FismaSystemIDs) == 0 {
+		groupBy = ScoreGroupHHS
+		hhs, err := FindScoresRollup(ctx, rollupInput)
+		if err != nil {
+			return nil, err
+		}
+		if len(hhs) > 0 {
+			summary.HHS = hhs[0]
+		}
+	}
+
+	// A system can still sit in an OpDiv since deactivated.
+	activeOnly := false
+	opDivs, err := FindOpDivs(ctx, FindOpDivsInput{ActiveOnly: &activeOnly})
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range opDivs {
+		summary.OpDivCodes[o.OpDivID] = o.Code
+	}
+
+	return summary, nil
+}
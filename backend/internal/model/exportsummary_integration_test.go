@@ -0,0 +1,62 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFindExportSummaryIntegration checks the executive figures against the
+// scores endpoints they restate: every summarized system is one the aggregate
+// reports, at the same score, and the HHS rollup covers them all. A filtered
+// or OpDiv-scoped export has no HHS rollup.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestFindExportSummaryIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	var dataCallID int32
+	require.NoError(t, conn.QueryRow(ctx,
+		"SELECT datacallid FROM scores GROUP BY datacallid ORDER BY COUNT(*) DESC LIMIT 1").Scan(&dataCallID))
+
+	summary, err := FindExportSummary(ctx, FindAnswersInput{DataCallID: dataCallID})
+	require.NoError(t, err)
+	require.NotEmpty(t, summary.Systems)
+	require.NotNil(t, summary.HHS)
+	assert.Equal(t, len(summary.Systems), summary.HHS.SystemCount)
+
+	aggregates, err := FindScoresAggregate(ctx, FindScoresInput{DataCallID: &dataCallID})
+	require.NoError(t, err)
+	scores := map[int32]float64{}
+	for _, a := range aggregates {
+		scores[a.FismaSystemID] = a.SystemScore
+	}
+	for _, s := range summary.Systems {
+		assert.InDelta(t, scores[s.FismaSystemID], s.SystemScore, scoreAggregateTolerance, s.FismaAcronym)
+	}
+
+	// Filtering by system narrows every sheet's figures together, and drops
+	// the department figure a subset cannot stand for.
+	one := summary.Systems[0].FismaSystemID
+	narrowed, err := FindExportSummary(ctx, FindAnswersInput{DataCallID: dataCallID, FismaSystemIDs: []*int32{&one}})
+	require.NoError(t, err)
+	require.Len(t, narrowed.Systems, 1)
+	assert.Nil(t, narrowed.HHS)
+
+	scoped, err := FindExportSummary(ctx, FindAnswersInput{DataCallID: dataCallID,
+		OpDivScope: OpDivScope{RestrictToOpDivIDs: true, OpDivIDs: []int32{}}})
+	require.NoError(t, err)
+	assert.Empty(t, scoped.Systems)
+	assert.Nil(t, scoped.HHS)
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateExportWorkbook(t *testing.T) {
+	for _, ok := range []*string{nil, stringPtr(ExportWorkbookAnswers), stringPtr(ExportWorkbookExecutive)} {
+		assert.NoError(t, ValidateExportWorkbook(ok))
+	}
+
+	var invalid *InvalidInputError
+	assert.ErrorAs(t, ValidateExportWorkbook(stringPtr("Executive")), &invalid)
+}
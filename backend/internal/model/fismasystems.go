@@ -445,7 +445,17 @@ func (f *FismaSystem) Save(ctx context.Context, opts ...SaveOption) (*FismaSyste
 			Suffix("RETURNING " + strings.Join(fismaSystemColumns, ", "))
 	}
 
-	return queryRow(ctx, sqlb, pgx.RowToStructByName[FismaSystem])
+	saved, err := queryRow(ctx, sqlb, pgx.RowToStructByName[FismaSystem])
+	if err != nil {
+		return nil, err
+	}
+
+	// The datacenterenvironment decides which functions the system is
+	// scored on, in every cycle it has answers in. A new system has none.
+	if f.FismaSystemID != 0 {
+		refreshScoreAggregates(ctx, FindScoresInput{FismaSystemID: &saved.FismaSystemID})
+	}
+	return saved, nil
 }
 
 // DecommissionInput contains optional parameters for decommissioning a system
@@ -621,11 +631,8 @@ func ReactivateFismaSystem(ctx context.Context, input ReactivateInput) (*FismaSy
 	}
 
 	if actor := UserFromContext(ctx); actor != nil {
-		if _, err := tx.Exec(ctx,
-			"INSERT INTO events (userid, action, resource, payload) VALUES ($1, $2, $3, $4)",
-			actor.UserID, eventActionUpdated, "fismasystems", system,
-		); err != nil {
-			return nil, trapError(err)
+		if err := insertEventTx(ctx, tx, actor.UserID, eventActionUpdated, "fismasystems", system); err != nil {
+			return nil, err
 		}
 	}
 
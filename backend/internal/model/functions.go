@@ -3,6 +3,7 @@ package model
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/jackc/pgx/v5"
@@ -116,7 +117,13 @@ func (f *Function) Save(ctx context.Context) (*Function, error) {
 			Suffix("RETURNING " + strings.Join(functionsColumns, ", "))
 	}
 
-	return queryRow(ctx, sqlb, pgx.RowToStructByName[Function])
+	// Like a question edit, this publishes a new catalog version rather than
+	// changing how closed cycles read.
+	note := "function added"
+	if f.FunctionID != 0 {
+		note = fmt.Sprintf("function %d edited", f.FunctionID)
+	}
+	return saveCatalogRow(ctx, sqlb, note, pgx.RowToStructByName[Function])
 }
 
 func (f *Function) validate() error {
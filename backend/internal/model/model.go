@@ -67,6 +67,43 @@ func query[T any](ctx context.Context, sqlb SqlBuilder, fn pgx.RowToFunc[T]) ([]
 	return res, nil
 }
 
+// queryEach is query for results too large to collect: it hands each row to
+// each as it is read and keeps none. An error from each stops the scan and is
+// returned as is. Read-only, like query.
+func queryEach[T any](ctx context.Context, sqlb SqlBuilder, fn pgx.RowToFunc[T], each func(T) error) error {
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return trapError(err)
+	}
+	// Held for the whole scan; released with the rows on return.
+	defer conn.Release()
+
+	sql, args, _ := sqlb.ToSql()
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		log.Println(err, sql)
+		return trapError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		v, err := fn(rows)
+		if err != nil {
+			log.Println(err, sql)
+			return trapError(err)
+		}
+		if err := each(v); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Println(err, sql)
+		return trapError(err)
+	}
+	return nil
+}
+
 // query is a proxy to *pgx.Conn.Query and wrapper around pgx.CollectOneRow, enabling the centralizing of event tracking
 func queryRow[T any](ctx context.Context, sqlb SqlBuilder, fn pgx.RowToFunc[T]) (*T, error) {
 
@@ -0,0 +1,157 @@
+package model
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxPillarWeight is the largest value pillarweights.weight (NUMERIC(6,3))
+// stores.
+const maxPillarWeight = 999.999
+
+// PillarWeight is how much one pillar counts toward a data call's system
+// scores. See migration 0063.
+type PillarWeight struct {
+	DataCallID int32   `json:"datacallid"`
+	PillarID   int32   `json:"pillarid"`
+	Pillar     string  `json:"pillar"`
+	Weight     float64 `json:"weight"`
+}
+
+// pillarWeightsEvent is the payload recorded when a data call's weights are
+// replaced. Weights is empty when they were cleared back to equal weighting.
+type pillarWeightsEvent struct {
+	DataCallID int32           `json:"datacallid"`
+	Weights    []*PillarWeight `json:"weights"`
+}
+
+// FindPillarWeights lists a data call's weights in pillar order. An empty list
+// means the call uses equal weighting.
+func FindPillarWeights(ctx context.Context, dataCallID int32) ([]*PillarWeight, error) {
+	sqlb := stmntBuilder.
+		Select("pw.datacallid", "pw.pillarid", "p.pillar", "pw.weight::float8 AS weight").
+		From("pillarweights pw").
+		InnerJoin("pillars p ON p.pillarid = pw.pillarid").
+		Where("pw.datacallid=?", dataCallID).
+		OrderBy("p.ordr", "pw.pillarid")
+
+	return query(ctx, sqlb, pgx.RowToAddrOfStructByName[PillarWeight])
+}
+
+// validatePillarWeights requires weights to be empty, which clears them, or to
+// name every pillar exactly once. A partial set would leave the missing
+// pillars at the migration's fallback weight of 1 without anyone having chosen
+// it.
+func validatePillarWeights(weights []*PillarWeight, pillarIDs []int32) error {
+	if len(weights) == 0 {
+		return nil
+	}
+
+	err := InvalidInputError{data: map[string]any{}}
+
+	known := map[int32]bool{}
+	for _, id := range pillarIDs {
+		known[id] = true
+	}
+
+	seen := map[int32]bool{}
+	for _, w := range weights {
+		key := fmt.Sprintf("pillar %d", w.PillarID)
+		switch {
+		case !known[w.PillarID]:
+			err.data[key] = "no such pillar"
+		case seen[w.PillarID]:
+			err.data[key] = "listed more than once"
+		case w.Weight <= 0 || w.Weight > maxPillarWeight:
+			err.data[key] = fmt.Sprintf("weight must be greater than 0 and at most %g", maxPillarWeight)
+		}
+		seen[w.PillarID] = true
+	}
+	for _, id := range pillarIDs {
+		if !seen[id] {
+			err.data[fmt.Sprintf("pillar %d", id)] = "missing; weight every pillar, or send an empty list for equal weighting"
+		}
+	}
+
+	if len(err.data) > 0 {
+		return &err
+	}
+	return nil
+}
+
+// SavePillarWeights replaces a data call's weights. An empty list returns the
+// call to equal weighting. A closed or archived call keeps the weighting its
+// published scores were computed with, so only draft and open calls accept
+// changes; the call's row is locked for the write so a concurrent close
+// cannot slip between the check and the replace.
+func SavePillarWeights(ctx context.Context, dataCallID int32, weights []*PillarWeight) ([]*PillarWeight, error) {
+	user := UserFromContext(ctx)
+	if user == nil {
+		return nil, &InvalidInputError{data: map[string]any{"user": "required"}}
+	}
+
+	pillarIDs, err := query(ctx, stmntBuilder.Select("pillarid").From("pillars").OrderBy("pillarid"), pgx.RowTo[int32])
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePillarWeights(weights, pillarIDs); err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, trapError(err)
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		conn.Release()
+		return nil, trapError(err)
+	}
+	defer func() {
+		tx.Rollback(ctx)
+		conn.Release()
+	}()
+
+	var status string
+	err = tx.QueryRow(ctx, "SELECT status FROM datacalls WHERE datacallid = $1 FOR UPDATE", dataCallID).Scan(&status)
+	if err != nil {
+		return nil, trapError(err)
+	}
+	if status != DataCallDraft && status != DataCallOpen {
+		return nil, &InvalidInputError{data: map[string]any{
+			"status": fmt.Sprintf("a %s data call keeps the weighting it was scored with", status),
+		}}
+	}
+
+	if weights == nil {
+		weights = []*PillarWeight{}
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue("DELETE FROM pillarweights WHERE datacallid = $1", dataCallID)
+	for _, w := range weights {
+		w.DataCallID = dataCallID
+		batch.Queue("INSERT INTO pillarweights (datacallid, pillarid, weight) VALUES ($1, $2, $3)", dataCallID, w.PillarID, w.Weight)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return nil, trapError(err)
+	}
+	// In the transaction, like a rollover repair's audit row: the weighting
+	// behind a cycle's scores must not change without a record of who set it.
+	err = insertEventTx(ctx, tx, user.UserID, eventActionUpdated, "public.pillarweights", pillarWeightsEvent{DataCallID: dataCallID, Weights: weights})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, trapError(err)
+	}
+
+	refreshScoreAggregates(ctx, FindScoresInput{DataCallID: &dataCallID})
+
+	return FindPillarWeights(ctx, dataCallID)
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPillarWeightsAggregateIntegration pins that a data call scores with
+// equal weighting until weights are set, then with the weighted average of the
+// same pillar scores, and that a closed call refuses new weights.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestPillarWeightsAggregateIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	purgeIntegrationTestRows(t)
+	defer purgeIntegrationTestRows(t)
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	var dataCallID int32
+	err = conn.QueryRow(ctx, `
+		INSERT INTO datacalls (datacall, datecreated, deadline)
+		VALUES ($1, NOW(), NOW())
+		RETURNING datacallid
+	`, fmt.Sprintf("%sweights_%d", integrationTestPrefix, time.Now().UnixNano())).Scan(&dataCallID)
+	require.NoError(t, err)
+
+	// Any scored answer puts its system in the aggregate for the call.
+	var system int32
+	err = conn.QueryRow(ctx, `
+		INSERT INTO scores (fismasystemid, functionoptionid, datacallid)
+		SELECT fismasystemid, functionoptionid, $1 FROM scores LIMIT 1
+		RETURNING fismasystemid
+	`, dataCallID).Scan(&system)
+	require.NoError(t, err)
+
+	input := FindScoresInput{DataCallID: &dataCallID, FismaSystemID: &system, IncludePillars: boolPtr(true)}
+
+	before, err := FindScoresAggregate(ctx, input)
+	require.NoError(t, err)
+	require.Len(t, before, 1)
+	assert.Equal(t, ScoreWeightingEqual, before[0].Weighting)
+
+	var ownerID string
+	err = conn.QueryRow(ctx, `SELECT userid FROM users WHERE role = 'OWNER' LIMIT 1`).Scan(&ownerID)
+	require.NoError(t, err)
+	ownerCtx := UserToContext(ctx, &User{UserID: ownerID, Role: "OWNER"})
+
+	rows, err := conn.Query(ctx, `SELECT pillarid FROM pillars ORDER BY pillarid`)
+	require.NoError(t, err)
+	weights := []*PillarWeight{}
+	byPillar := map[int32]float64{}
+	for rows.Next() {
+		var id int32
+		require.NoError(t, rows.Scan(&id))
+		w := 1.0
+		if len(weights) == 0 {
+			w = 3
+		}
+		weights = append(weights, &PillarWeight{PillarID: id, Weight: w})
+		byPillar[id] = w
+	}
+	require.NoError(t, rows.Err())
+
+	saved, err := SavePillarWeights(ownerCtx, dataCallID, weights)
+	require.NoError(t, err)
+	assert.Len(t, saved, len(weights))
+
+	after, err := FindScoresAggregate(ctx, input)
+	require.NoError(t, err)
+	require.Len(t, after, 1)
+	assert.Equal(t, ScoreWeightingPillar, after[0].Weighting)
+
+	var sum, total float64
+	for _, p := range after[0].PillarScores {
+		assert.Equal(t, byPillar[p.PillarID], p.Weight)
+		sum += p.Score * p.Weight
+		total += p.Weight
+	}
+	assert.InDelta(t, sum/total, after[0].SystemScore, 1e-9)
+
+	_, err = conn.Exec(ctx, `UPDATE datacalls SET status = 'closed' WHERE datacallid = $1`, dataCallID)
+	require.NoError(t, err)
+	_, err = SavePillarWeights(ownerCtx, dataCallID, []*PillarWeight{})
+	var invalid *InvalidInputError
+	assert.ErrorAs(t, err, &invalid, "a closed call keeps its weighting")
+}
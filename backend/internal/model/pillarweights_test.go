@@ -0,0 +1,41 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePillarWeights(t *testing.T) {
+	pillars := []int32{1, 2, 3}
+	weights := func(ws ...float64) []*PillarWeight {
+		out := []*PillarWeight{}
+		for i, w := range ws {
+			out = append(out, &PillarWeight{PillarID: int32(i + 1), Weight: w})
+		}
+		return out
+	}
+
+	assert.NoError(t, validatePillarWeights(nil, pillars), "an empty set clears the weights")
+	assert.NoError(t, validatePillarWeights(weights(2, 1, 0.5), pillars))
+
+	tests := map[string]struct {
+		weights []*PillarWeight
+		key     string
+	}{
+		"missing pillar": {weights(2, 1), "pillar 3"},
+		"zero weight":    {weights(2, 0, 1), "pillar 2"},
+		"too large":      {weights(2, 1, 1000), "pillar 3"},
+		"unknown pillar": {append(weights(1, 1, 1), &PillarWeight{PillarID: 9, Weight: 1}), "pillar 9"},
+		"duplicate":      {append(weights(1, 1, 1), &PillarWeight{PillarID: 1, Weight: 2}), "pillar 1"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := validatePillarWeights(tc.weights, pillars)
+			var invalid *InvalidInputError
+			if assert.ErrorAs(t, err, &invalid) {
+				assert.Contains(t, invalid.Data(), tc.key)
+			}
+		})
+	}
+}
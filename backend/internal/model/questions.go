@@ -2,6 +2,7 @@ package model
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"github.com/jackc/pgx/v5"
@@ -50,7 +51,13 @@ func (q *Question) Save(ctx context.Context) (*Question, error) {
 			Suffix("RETURNING " + strings.Join(questionsColumns, ", "))
 	}
 
-	return queryRow(ctx, sqlb, pgx.RowToStructByNameLax[Question])
+	// The edit becomes a new catalog version; the cycles that closed under the
+	// old wording keep reading it (catalogversions.go).
+	note := "question added"
+	if q.QuestionID != 0 {
+		note = fmt.Sprintf("question %d edited", q.QuestionID)
+	}
+	return saveCatalogRow(ctx, sqlb, note, pgx.RowToStructByNameLax[Question])
 
 }
 
@@ -95,10 +102,24 @@ func FindQuestionsByFismaSystem(ctx context.Context, fismaSystemID int32, input
 	// on that key. This is the same indirection the scoring aggregate uses, so the
 	// answer form an ISSO sees matches the functions a system is scored against.
 	sqlb := stmntBuilder.
-		Select("questions.questionid, question, notesprompt, questions.ordr, pillars.pillarid, pillars.pillar, pillars.ordr, functionid, function, description").
-		From("questions").
-		InnerJoin("pillars ON pillars.pillarid=questions.pillarid").
-		InnerJoin("functions ON functions.questionid=questions.questionid").
+		Select("questions.questionid, question, notesprompt, questions.ordr, pillars.pillarid, pillars.pillar, pillars.ordr, functionid, function, description")
+
+	// For a data call, the questionnaire is the catalog version it pins, so a
+	// closed cycle reads back as it was answered; without one, the live catalog.
+	if input.DataCallID != nil {
+		sqlb = sqlb.
+			From("catalogquestions questions").
+			InnerJoin("pillars ON pillars.pillarid=questions.pillarid").
+			InnerJoin("catalogfunctions functions ON functions.catalogversionid=questions.catalogversionid AND functions.questionid=questions.questionid").
+			Where("questions.catalogversionid="+pinnedCatalogSQL("?"), *input.DataCallID)
+	} else {
+		sqlb = sqlb.
+			From("questions").
+			InnerJoin("pillars ON pillars.pillarid=questions.pillarid").
+			InnerJoin("functions ON functions.questionid=questions.questionid")
+	}
+
+	sqlb = sqlb.
 		InnerJoin("datacenterenvironments dce ON dce.scoring_key=functions.datacenterenvironment").
 		InnerJoin("fismasystems ON fismasystems.datacenterenvironment=dce.datacenterenvironment AND fismasystems.fismasystemid=?", fismaSystemID).
 		// questionid breaks ties so questions sharing an ordr (0 wherever
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Report file formats the report endpoints accept as format=; without one
+// they answer in JSON.
+const (
+	ReportFormatCSV  = "csv"
+	ReportFormatXLSX = "xlsx"
+)
+
+// ValidateReportFormat rejects a report format the endpoints do not write.
+func ValidateReportFormat(format *string) error {
+	if format == nil || *format == ReportFormatCSV || *format == ReportFormatXLSX {
+		return nil
+	}
+	return &InvalidInputError{data: map[string]any{
+		"format": fmt.Sprintf("must be %s or %s", ReportFormatXLSX, ReportFormatCSV),
+	}}
+}
+
+// ReportSystem labels a FISMA system in an exported report, so a file read
+// outside the app still says which system each row is and whose it is.
+// ISSOName is resolved as the system GET resolves it.
+type ReportSystem struct {
+	FismaSystemID int32   `db:"fismasystemid"`
+	FismaAcronym  string  `db:"fismaacronym"`
+	FismaName     string  `db:"fismaname"`
+	OpDiv         *string `db:"opdiv"`
+	ISSOName      *string `db:"isso_name"`
+}
+
+// FindReportSystems returns the labels of the given systems by id. It applies
+// no scope: the ids come from a report already scoped to the caller.
+func FindReportSystems(ctx context.Context, ids []int32) (map[int32]*ReportSystem, error) {
+	systems := map[int32]*ReportSystem{}
+	if len(ids) == 0 {
+		return systems, nil
+	}
+
+	c := []string{"fismasystems.fismasystemid", "fismaacronym", "fismaname", "opdivs.code AS opdiv", "isso_name"}
+	resolveISSONameColumn(c)
+	sqlb := stmntBuilder.
+		Select(c...).
+		From("fismasystems").
+		LeftJoin("opdivs ON opdivs.opdiv_id = fismasystems.opdiv_id").
+		Where("fismasystems.fismasystemid = ANY(?)", ids)
+
+	rows, err := query(ctx, sqlb, pgx.RowToAddrOfStructByName[ReportSystem])
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range rows {
+		systems[s.FismaSystemID] = s
+	}
+	return systems, nil
+}
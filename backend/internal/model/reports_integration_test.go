@@ -0,0 +1,52 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFindReportSystemsIntegration labels a system with an OpDiv as the
+// system GET would: the same acronym, the OpDiv's code and the resolved ISSO
+// name. An id with no system is simply absent.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestFindReportSystemsIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	var (
+		id   int32
+		code string
+	)
+	require.NoError(t, conn.QueryRow(ctx, `
+SELECT fs.fismasystemid, o.code
+  FROM fismasystems fs
+  JOIN opdivs o ON o.opdiv_id = fs.opdiv_id
+ ORDER BY fs.fismasystemid LIMIT 1`).Scan(&id, &code))
+
+	systems, err := FindReportSystems(ctx, []int32{id, -1})
+	require.NoError(t, err)
+	require.Len(t, systems, 1)
+
+	sys, err := FindFismaSystem(ctx, FindFismaSystemsInput{FismaSystemID: &id, ResolveISSOName: true})
+	require.NoError(t, err)
+	got := systems[id]
+	require.NotNil(t, got)
+	assert.Equal(t, sys.FismaAcronym, got.FismaAcronym)
+	assert.Equal(t, sys.FismaName, got.FismaName)
+	assert.Equal(t, sys.ISSOName, got.ISSOName)
+	if assert.NotNil(t, got.OpDiv) {
+		assert.Equal(t, code, *got.OpDiv)
+	}
+}
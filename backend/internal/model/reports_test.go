@@ -0,0 +1,18 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateReportFormat(t *testing.T) {
+	for _, ok := range []*string{nil, stringPtr(ReportFormatCSV), stringPtr(ReportFormatXLSX)} {
+		assert.NoError(t, ValidateReportFormat(ok))
+	}
+
+	var invalid *InvalidInputError
+	for _, bad := range []string{"json", "XLSX", ""} {
+		assert.ErrorAs(t, ValidateReportFormat(stringPtr(bad)), &invalid, bad)
+	}
+}
@@ -0,0 +1,301 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// rolloverLockClass is the first key of the advisory lock that serializes
+// copyPreviousScores and RepairRollover on one data call (the second key is
+// the datacallid). The value is arbitrary; it only has to be unique among the
+// application's two-key advisory locks.
+const rolloverLockClass = 411
+
+// RolloverItem is one question a rollover carries forward: the answer a system
+// gave in its source cycle, and whether the target already holds an answer to
+// the same function.
+type RolloverItem struct {
+	FismaSystemID    int32 `json:"fismasystemid"`
+	FunctionID       int32 `json:"functionid"`
+	SourceDataCallID int32 `json:"source_datacallid"`
+	SourceScoreID    int32 `json:"source_scoreid"`
+	FunctionOptionID int32 `json:"functionoptionid"`
+	Present          bool  `json:"present"`
+}
+
+// RolloverReport is what RolloverPreview and RepairRollover return. Missing
+// counts the items with Present false; Repaired is how many of them a repair
+// wrote, and is always zero for a preview.
+type RolloverReport struct {
+	DataCallID         int32           `json:"datacallid"`
+	DryRun             bool            `json:"dry_run"`
+	FallbackDataCallID *int32          `json:"fallback_datacallid"`
+	Expected           int             `json:"expected"`
+	Present            int             `json:"present"`
+	Missing            int             `json:"missing"`
+	Repaired           int64           `json:"repaired"`
+	Items              []*RolloverItem `json:"items"`
+}
+
+// rolloverRepairEvent is the payload of the event a repair records.
+type rolloverRepairEvent struct {
+	DataCallID         int32   `json:"datacallid"`
+	FallbackDataCallID *int32  `json:"fallback_datacallid"`
+	Repaired           int64   `json:"repaired"`
+	ScoreIDs           []int32 `json:"scoreids"`
+}
+
+// rolloverPlanSQL lists, per system and function, the answer the rollover
+// carries forward into $1, using the same source assignment and the same
+// DISTINCT ON / functionoptions join as copyPreviousScoresByRules. $2 is the
+// fallback cycle. For a target without rules every system falls back, which
+// is the legacy single-source copy - except that path does not collapse a
+// source's duplicate answers, so it may have written more rows than listed.
+func rolloverPlanSQL() string {
+	return fmt.Sprintf(`
+		WITH assignment AS (%s)
+		SELECT DISTINCT ON (s.fismasystemid, fo.functionid)
+		       s.fismasystemid, fo.functionid, a.source_datacallid, s.scoreid, s.functionoptionid,
+		       EXISTS (
+		           SELECT 1
+		             FROM scores t
+		             JOIN functionoptions tfo ON tfo.functionoptionid = t.functionoptionid
+		            WHERE t.datacallid = $1::int
+		              AND t.fismasystemid = s.fismasystemid
+		              AND tfo.functionid = fo.functionid) AS present
+		  FROM assignment a
+		  JOIN scores s           ON s.fismasystemid    = a.fismasystemid
+		                         AND s.datacallid       = a.source_datacallid
+		  JOIN functionoptions fo ON fo.functionoptionid = s.functionoptionid
+		 ORDER BY s.fismasystemid, fo.functionid, s.scoreid DESC`,
+		rolloverAssignmentSQL("$1::int", "$2::int"))
+}
+
+// RolloverPreview reports what copyPreviousScores would carry forward into the
+// data call, and from which source, without writing anything. On a draft it is
+// what opening will copy; on an open call, the Present false items are what a
+// partial rollover left out.
+func RolloverPreview(ctx context.Context, dataCallID int32) (*RolloverReport, error) {
+	if _, err := FindDataCallByID(ctx, dataCallID); err != nil {
+		return nil, err
+	}
+
+	fallback, err := rolloverFallback(ctx, dataCallID)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, trapError(err)
+	}
+	defer conn.Release()
+
+	items, err := rolloverPlan(ctx, conn, dataCallID, fallback)
+	if err != nil {
+		return nil, err
+	}
+
+	return newRolloverReport(dataCallID, true, fallback, items), nil
+}
+
+// RepairRollover inserts the carried-forward answers a rollover should have
+// written but did not, and nothing else. A question the data call already
+// holds any answer to is left alone - whether copied, imported or answered
+// this cycle - so running it twice, or after a complete rollover, writes
+// nothing: the duplicate-answer hazard of re-running the copy (ztmf#411) does
+// not arise.
+//
+// Only an open data call can be repaired. A draft has not rolled over yet,
+// and repairing it would have opening copy every row a second time; closed and
+// archived cycles are no longer being answered.
+func RepairRollover(ctx context.Context, dataCallID int32) (*RolloverReport, error) {
+	user := UserFromContext(ctx)
+	if user == nil {
+		return nil, &InvalidInputError{data: map[string]any{"user": "required"}}
+	}
+
+	dataCall, err := FindDataCallByID(ctx, dataCallID)
+	if err != nil {
+		return nil, err
+	}
+	if dataCall.Status != DataCallOpen {
+		return nil, &InvalidInputError{data: map[string]any{
+			"status": fmt.Sprintf("only an open data call can be repaired; this one is %s", dataCall.Status),
+		}}
+	}
+
+	fallback, err := rolloverFallback(ctx, dataCallID)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, trapError(err)
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		conn.Release()
+		return nil, trapError(err)
+	}
+	defer func() {
+		tx.Rollback(ctx)
+		conn.Release()
+	}()
+
+	// Two repairs, or a repair and the copy on first open, would each see the
+	// other's rows as missing.
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1, $2)", rolloverLockClass, dataCallID); err != nil {
+		return nil, trapError(err)
+	}
+
+	items, err := rolloverPlan(ctx, tx, dataCallID, fallback)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, fmt.Sprintf(`
+		WITH plan AS (%s)
+		INSERT INTO scores (fismasystemid, datecalculated, notes, notes_is_ai_summary, functionoptionid, datacallid, status)
+		SELECT s.fismasystemid, s.datecalculated, s.notes, s.notes_is_ai_summary, s.functionoptionid, $1::int, '%s'
+		  FROM plan p
+		  JOIN scores s ON s.scoreid = p.scoreid
+		 WHERE NOT p.present
+		RETURNING scoreid`,
+		rolloverPlanSQL(), scoreStatusNotStarted), dataCallID, fallback)
+	if err != nil {
+		return nil, trapError(err)
+	}
+	scoreIDs, err := pgx.CollectRows(rows, pgx.RowTo[int32])
+	if err != nil {
+		return nil, trapError(err)
+	}
+
+	// Repaired answers get their attachments as the copy on open would have
+	// given them; answers that already had theirs are left alone.
+	if _, err := carryForwardAttachments(ctx, tx, dataCallID, fallback); err != nil {
+		return nil, trapError(err)
+	}
+
+	// Recorded in the transaction, like an import's provenance, so the repair
+	// and its audit row commit together.
+	if len(scoreIDs) > 0 {
+		err = insertEventTx(ctx, tx,
+			user.UserID, eventActionRolloverRepaired, "public.scores", rolloverRepairEvent{
+				DataCallID:         dataCallID,
+				FallbackDataCallID: fallback,
+				Repaired:           int64(len(scoreIDs)),
+				ScoreIDs:           scoreIDs,
+			})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, trapError(err)
+	}
+
+	if len(scoreIDs) > 0 {
+		refreshScoreAggregates(ctx, FindScoresInput{DataCallID: &dataCallID})
+	}
+
+	report := newRolloverReport(dataCallID, false, fallback, items)
+	report.Repaired = int64(len(scoreIDs))
+
+	log.Printf("ROLLOVER_REPAIR datacall=%d user=%s fallback=%s expected=%d missing=%d repaired=%d",
+		dataCallID, user.UserID, fmtDataCallID(fallback), report.Expected, report.Missing, report.Repaired)
+
+	return report, nil
+}
+
+// rolloverFallback is the cycle systems no rule matches roll forward from, or
+// nil when there is no earlier cycle.
+func rolloverFallback(ctx context.Context, dataCallID int32) (*int32, error) {
+	prev, err := findPreviousDataCall(ctx, dataCallID)
+	if errors.Is(err, ErrNoData) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &prev.DataCallID, nil
+}
+
+// rolloverQuerier is satisfied by both a pooled connection and a transaction.
+type rolloverQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func rolloverPlan(ctx context.Context, q rolloverQuerier, dataCallID int32, fallback *int32) ([]*RolloverItem, error) {
+	rows, err := q.Query(ctx, rolloverPlanSQL(), dataCallID, fallback)
+	if err != nil {
+		return nil, trapError(err)
+	}
+
+	items, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*RolloverItem, error) {
+		var i RolloverItem
+		err := row.Scan(&i.FismaSystemID, &i.FunctionID, &i.SourceDataCallID, &i.SourceScoreID, &i.FunctionOptionID, &i.Present)
+		return &i, err
+	})
+	if err != nil {
+		return nil, trapError(err)
+	}
+	return items, nil
+}
+
+func newRolloverReport(dataCallID int32, dryRun bool, fallback *int32, items []*RolloverItem) *RolloverReport {
+	report := &RolloverReport{
+		DataCallID:         dataCallID,
+		DryRun:             dryRun,
+		FallbackDataCallID: fallback,
+		Expected:           len(items),
+		Items:              items,
+	}
+	for _, i := range items {
+		if i.Present {
+			report.Present++
+		} else {
+			report.Missing++
+		}
+	}
+	if report.Items == nil {
+		report.Items = []*RolloverItem{}
+	}
+	return report
+}
+
+// lockRollover takes the rollover advisory lock for dataCallID on a dedicated
+// connection and returns the function that releases it. copyPreviousScores
+// runs its copy on other connections, so the lock is session-level rather
+// than tied to a transaction.
+func lockRollover(ctx context.Context, dataCallID int32) (func(), error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1, $2)", rolloverLockClass, dataCallID); err != nil {
+		conn.Release()
+		return nil, err
+	}
+	return func() {
+		// Unlocked on a fresh context: a cancelled request must not return
+		// the connection to the pool still holding the lock.
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1, $2)", rolloverLockClass, dataCallID); err != nil {
+			log.Printf("ROLLOVER_ANOMALY datacall=%d err=%v reason=unlock", dataCallID, err)
+			// The session may still hold the lock. Ending it is the only sure
+			// release; pooled, it would block every later rollover of this
+			// call until the connection happened to be recycled. The pool
+			// discards a closed connection on Release.
+			conn.Conn().Close(context.Background())
+		}
+		conn.Release()
+	}, nil
+}
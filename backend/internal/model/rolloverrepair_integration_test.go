@@ -0,0 +1,104 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRepairRolloverIntegration pins the repair contract against the real
+// schema: the preview reports a question the rollover missed, a repair writes
+// exactly that question as not_started, and a second repair writes nothing -
+// the re-run never duplicates an answer (ztmf#411).
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestRepairRolloverIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	purgeIntegrationTestRows(t)
+	defer purgeIntegrationTestRows(t)
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	// Deadlines beat every seed call, so previous is findPreviousDataCall's
+	// answer for target. Inserted rows default to open, which a repair needs.
+	suffix := time.Now().UnixNano()
+	insertDataCall := func(name, deadline string) int32 {
+		var id int32
+		err := conn.QueryRow(ctx, `
+			INSERT INTO datacalls (datacall, datecreated, deadline, opened_at)
+			VALUES ($1, NOW(), $2::timestamptz, NOW())
+			RETURNING datacallid
+		`, fmt.Sprintf("%srepair_%s_%d", integrationTestPrefix, name, suffix), deadline).Scan(&id)
+		require.NoError(t, err)
+		return id
+	}
+	previous := insertDataCall("previous", "2100-06-01T00:00:00Z")
+	target := insertDataCall("target", "2101-01-01T00:00:00Z")
+
+	// One system with options for two different functions.
+	var system, copiedOption, missedOption int32
+	err = conn.QueryRow(ctx, `
+		SELECT s1.fismasystemid, s1.functionoptionid, fo2.functionoptionid
+		  FROM scores s1
+		  JOIN functionoptions fo1 ON fo1.functionoptionid = s1.functionoptionid
+		  JOIN scores s2 ON s2.fismasystemid = s1.fismasystemid
+		  JOIN functionoptions fo2 ON fo2.functionoptionid = s2.functionoptionid
+		 WHERE fo2.functionid <> fo1.functionid
+		 LIMIT 1
+	`).Scan(&system, &copiedOption, &missedOption)
+	require.NoError(t, err, "need a system scored on two functions")
+
+	insertScore := func(dataCallID, option int32) {
+		_, err := conn.Exec(ctx, `
+			INSERT INTO scores (fismasystemid, functionoptionid, datacallid, status)
+			VALUES ($1, $2, $3, 'not_started')
+		`, system, option, dataCallID)
+		require.NoError(t, err)
+	}
+	insertScore(previous, copiedOption)
+	insertScore(previous, missedOption)
+	// A partial rollover: only the first question made it across.
+	insertScore(target, copiedOption)
+
+	preview, err := RolloverPreview(ctx, target)
+	require.NoError(t, err)
+	assert.Equal(t, &previous, preview.FallbackDataCallID)
+	assert.Equal(t, 2, preview.Expected)
+	assert.Equal(t, 1, preview.Present)
+	assert.Equal(t, 1, preview.Missing)
+
+	var ownerID string
+	err = conn.QueryRow(ctx, `SELECT userid FROM users WHERE role = 'OWNER' LIMIT 1`).Scan(&ownerID)
+	require.NoError(t, err)
+	ownerCtx := UserToContext(ctx, &User{UserID: ownerID, Role: "OWNER"})
+
+	report, err := RepairRollover(ownerCtx, target)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), report.Repaired)
+
+	var rows, missed int
+	err = conn.QueryRow(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE functionoptionid = $2 AND status = 'not_started')
+		  FROM scores WHERE datacallid = $1
+	`, target, missedOption).Scan(&rows, &missed)
+	require.NoError(t, err)
+	assert.Equal(t, 2, rows)
+	assert.Equal(t, 1, missed, "the missed question is written as carried forward")
+
+	again, err := RepairRollover(ownerCtx, target)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), again.Repaired, "a second repair must not duplicate anything")
+	assert.Equal(t, 0, again.Missing)
+}
@@ -0,0 +1,313 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+var rolloverRuleColumns = []string{"ruleid", "target_datacallid", "opdiv_id", "scoring_key", "source_datacallid"}
+
+// RolloverRule names the cycle a set of systems rolls forward from when the
+// target data call first opens. At most one of OpDivID and ScoringKey is set;
+// with neither, the rule is the target's default for systems no keyed rule
+// matches. See migration 0061 for the matching order.
+type RolloverRule struct {
+	RuleID           int32   `json:"ruleid"`
+	TargetDataCallID int32   `json:"target_datacallid" db:"target_datacallid"`
+	OpDivID          *int32  `json:"opdiv_id" db:"opdiv_id"`
+	ScoringKey       *string `json:"scoring_key" db:"scoring_key"`
+	SourceDataCallID int32   `json:"source_datacallid" db:"source_datacallid"`
+}
+
+// validate checks the rule against its two data calls. The source must be a
+// cycle that has gone live and whose deadline precedes the target's - the
+// ztmf#448 invariant findPreviousDataCall enforces for the default path, so a
+// rule cannot roll a later cycle's answers backward either.
+func (r *RolloverRule) validate(target, source *DataCall) error {
+	err := InvalidInputError{data: map[string]any{}}
+
+	if r.OpDivID != nil && r.ScoringKey != nil {
+		err.data["key"] = "set at most one of opdiv_id or scoring_key"
+	}
+	if r.ScoringKey != nil && strings.TrimSpace(*r.ScoringKey) == "" {
+		err.data["scoring_key"] = "must not be blank"
+	}
+
+	switch {
+	case source == nil:
+		err.data["source_datacallid"] = "no such data call"
+	case source.DataCallID == target.DataCallID:
+		err.data["source_datacallid"] = "a data call cannot roll over from itself"
+	case source.Status == DataCallDraft:
+		err.data["source_datacallid"] = "a draft data call has no answers to roll forward"
+	case !source.Deadline.Before(target.Deadline):
+		err.data["source_datacallid"] = "source deadline must be before the target's"
+	}
+
+	if len(err.data) > 0 {
+		return &err
+	}
+	return nil
+}
+
+// Save creates the rule, or updates it when RuleID is set. A rule only takes
+// effect when its target first opens; changing one afterwards changes nothing
+// already copied.
+func (r *RolloverRule) Save(ctx context.Context) (*RolloverRule, error) {
+	target, err := FindDataCallByID(ctx, r.TargetDataCallID)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := FindDataCallByID(ctx, r.SourceDataCallID)
+	if err != nil && !errors.Is(err, ErrNoData) {
+		return nil, err
+	}
+
+	if err := r.validate(target, source); err != nil {
+		return nil, err
+	}
+
+	if r.ScoringKey != nil {
+		key := strings.TrimSpace(*r.ScoringKey)
+		r.ScoringKey = &key
+		if ok, err := scoringKeyExists(ctx, key); err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, &InvalidInputError{data: map[string]any{"scoring_key": "no data center environment scores under this key"}}
+		}
+	}
+
+	var sqlb SqlBuilder
+	if r.RuleID == 0 {
+		sqlb = stmntBuilder.
+			Insert("rolloverrules").
+			Columns("target_datacallid", "opdiv_id", "scoring_key", "source_datacallid").
+			Values(r.TargetDataCallID, r.OpDivID, r.ScoringKey, r.SourceDataCallID).
+			Suffix("RETURNING " + strings.Join(rolloverRuleColumns, ", "))
+	} else {
+		sqlb = stmntBuilder.
+			Update("rolloverrules").
+			Set("opdiv_id", r.OpDivID).
+			Set("scoring_key", r.ScoringKey).
+			Set("source_datacallid", r.SourceDataCallID).
+			Where("ruleid=? AND target_datacallid=?", r.RuleID, r.TargetDataCallID).
+			Suffix("RETURNING " + strings.Join(rolloverRuleColumns, ", "))
+	}
+
+	return queryRow(ctx, sqlb, pgx.RowToStructByName[RolloverRule])
+}
+
+func scoringKeyExists(ctx context.Context, key string) (bool, error) {
+	sqlb := stmntBuilder.
+		Select().
+		Column(squirrel.Expr("EXISTS (SELECT 1 FROM datacenterenvironments WHERE scoring_key = ?)", key))
+
+	exists, err := queryRow(ctx, sqlb, pgx.RowTo[bool])
+	if err != nil {
+		return false, err
+	}
+	return *exists, nil
+}
+
+// FindRolloverRules lists the rules for one target in matching order: OpDiv
+// rules, then scoring-key rules, then the default.
+func FindRolloverRules(ctx context.Context, targetDataCallID int32) ([]*RolloverRule, error) {
+	sqlb := stmntBuilder.
+		Select(rolloverRuleColumns...).
+		From("rolloverrules").
+		Where("target_datacallid=?", targetDataCallID).
+		OrderBy("opdiv_id NULLS LAST", "scoring_key NULLS LAST", "ruleid")
+
+	return query(ctx, sqlb, pgx.RowToAddrOfStructByName[RolloverRule])
+}
+
+func DeleteRolloverRule(ctx context.Context, targetDataCallID, ruleID int32) error {
+	sqlb := stmntBuilder.
+		Delete("rolloverrules").
+		Where("ruleid=? AND target_datacallid=?", ruleID, targetDataCallID).
+		Suffix("RETURNING " + strings.Join(rolloverRuleColumns, ", "))
+
+	_, err := queryRow(ctx, sqlb, pgx.RowToStructByName[RolloverRule])
+	return err
+}
+
+// requireRolloverRules refuses the first open of a call flagged
+// requires_rollover_rules while it has no rollover rules, and raises
+// ROLLOVER_ANOMALY so a scheduled open that stalls on it is seen.
+func requireRolloverRules(ctx context.Context, target *DataCall) error {
+	if !target.requiresRolloverRules() {
+		return nil
+	}
+
+	rules, err := FindRolloverRules(ctx, target.DataCallID)
+	if err != nil {
+		return err
+	}
+	if len(rules) > 0 {
+		return nil
+	}
+
+	log.Printf("ROLLOVER_ANOMALY datacall=%d expected=? copied=0 err=<none> reason=rules_required_missing",
+		target.DataCallID)
+	return &InvalidInputError{data: map[string]any{
+		"status": "add this data call's rollover rules before opening it, or clear requires_rollover_rules",
+	}}
+}
+
+// rolloverAssignmentSQL selects every system with the cycle it rolls forward
+// from into the target: its OpDiv's rule, else its scoring key's, else the
+// target's default rule, else fallbackExpr - the previous cycle, or NULL when
+// there is none, which assigns the system nothing. Arguments are SQL
+// expressions valid in the caller's scope.
+//
+// Exclusion, not preference: each system reads exactly one source. Where two
+// lineages overlap, the rule decides which is the record, and a system with no
+// rows in its assigned source rolls forward empty (reported as stranded).
+func rolloverAssignmentSQL(targetExpr, fallbackExpr string) string {
+	return fmt.Sprintf(`
+    SELECT fs.fismasystemid,
+           COALESCE(
+               (SELECT r.source_datacallid FROM rolloverrules r
+                 WHERE r.target_datacallid = %[1]s AND r.opdiv_id = fs.opdiv_id),
+               (SELECT r.source_datacallid FROM rolloverrules r
+                 WHERE r.target_datacallid = %[1]s AND r.scoring_key = dce.scoring_key),
+               (SELECT r.source_datacallid FROM rolloverrules r
+                 WHERE r.target_datacallid = %[1]s AND r.opdiv_id IS NULL AND r.scoring_key IS NULL),
+               %[2]s
+           ) AS source_datacallid
+      FROM fismasystems fs
+      LEFT JOIN datacenterenvironments dce ON dce.datacenterenvironment = fs.datacenterenvironment`,
+		targetExpr, fallbackExpr)
+}
+
+// copyPreviousScoresByRules performs the rollover for a target that has rules.
+// handled is false when it has none, and the caller takes the single-source
+// path unchanged. Otherwise it carries the same ROLLOVER_ANOMALY alarms that
+// path raises, plus the ones only a keyed rollover can trip.
+func copyPreviousScoresByRules(ctx context.Context, dataCallID int32) (copied int64, handled bool, err error) {
+	rules, err := FindRolloverRules(ctx, dataCallID)
+	if err != nil {
+		log.Printf("ROLLOVER_ANOMALY datacall=%d expected=? copied=0 err=%v reason=rules_lookup", dataCallID, err)
+		return 0, true, err
+	}
+	if len(rules) == 0 {
+		return 0, false, nil
+	}
+
+	// Systems no rule matches keep the default path's source.
+	var fallback *int32
+	if prev, err := findPreviousDataCall(ctx, dataCallID); err == nil {
+		fallback = &prev.DataCallID
+	} else if !errors.Is(err, ErrNoData) {
+		log.Printf("ROLLOVER_ANOMALY datacall=%d expected=? copied=0 err=%v", dataCallID, err)
+		return 0, true, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		log.Printf("ROLLOVER_ANOMALY datacall=%d expected=? copied=0 err=%v", dataCallID, err)
+		return 0, true, err
+	}
+	defer conn.Release()
+
+	assignment := rolloverAssignmentSQL("$1::int", "$2::int")
+
+	// DISTINCT ON (fismasystemid, functionid) keeps one answer per question -
+	// scores has no uniqueness constraint, so a source may hold duplicates.
+	// The functionoptions join drops rows whose option no longer resolves
+	// rather than failing the batch (ztmf#411). $1 is cast because an untyped
+	// parameter in an INSERT...SELECT list resolves to text.
+	copySQL := fmt.Sprintf(`
+		WITH assignment AS (%s)
+		INSERT INTO scores (fismasystemid, datecalculated, notes, notes_is_ai_summary, functionoptionid, datacallid, status)
+		SELECT DISTINCT ON (s.fismasystemid, fo.functionid)
+		       s.fismasystemid, s.datecalculated, s.notes, s.notes_is_ai_summary,
+		       s.functionoptionid, $1::int, '%s'
+		  FROM assignment a
+		  JOIN scores s           ON s.fismasystemid    = a.fismasystemid
+		                         AND s.datacallid       = a.source_datacallid
+		  JOIN functionoptions fo ON fo.functionoptionid = s.functionoptionid
+		 ORDER BY s.fismasystemid, fo.functionid, s.scoreid DESC`,
+		assignment, scoreStatusNotStarted)
+
+	tag, err := conn.Exec(ctx, copySQL, dataCallID, fallback)
+	if err != nil {
+		log.Printf("ROLLOVER_ANOMALY datacall=%d expected=? copied=0 err=%v", dataCallID, err)
+		return 0, true, err
+	}
+	copied = tag.RowsAffected()
+
+	// src_rows counts every row the assignment points at; selected_rows only
+	// those whose option resolves, so src_rows - selected_rows is what the copy
+	// had to drop and selected_rows - copied is duplicate collapse. stranded
+	// counts systems that had rows in their assigned source yet received none.
+	var srcRows, selectedRows, stranded int64
+	diagErr := conn.QueryRow(ctx, fmt.Sprintf(`
+		WITH assignment AS (%s),
+		src AS (
+			SELECT s.fismasystemid, fo.functionoptionid IS NOT NULL AS resolvable
+			  FROM assignment a
+			  JOIN scores s ON s.fismasystemid = a.fismasystemid AND s.datacallid = a.source_datacallid
+			  LEFT JOIN functionoptions fo ON fo.functionoptionid = s.functionoptionid
+		)
+		SELECT (SELECT COUNT(*) FROM src),
+		       (SELECT COUNT(*) FROM src WHERE resolvable),
+		       (SELECT COUNT(DISTINCT fismasystemid) FROM src
+		         WHERE fismasystemid NOT IN (SELECT fismasystemid FROM scores WHERE datacallid = $1))`,
+		assignment), dataCallID, fallback).Scan(&srcRows, &selectedRows, &stranded)
+	if diagErr != nil {
+		log.Printf("ROLLOVER_RULES datacall=%d status=active copied=%d diagnostics_err=%v", dataCallID, copied, diagErr)
+		return copied, true, nil
+	}
+
+	log.Printf("ROLLOVER_RULES datacall=%d status=active rules=%d fallback=%s src_rows=%d selected_rows=%d copied=%d deduped=%d dropped_unresolvable=%d stranded=%d",
+		dataCallID, len(rules), fmtDataCallID(fallback), srcRows, selectedRows, copied, selectedRows-copied, srcRows-selectedRows, stranded)
+
+	if srcRows > selectedRows {
+		log.Printf("ROLLOVER_ANOMALY datacall=%d expected=%d copied=%d err=<none> reason=rules_unresolvable_rows dropped=%d",
+			dataCallID, srcRows, copied, srcRows-selectedRows)
+	}
+	if copied == 0 && selectedRows > 0 {
+		log.Printf("ROLLOVER_ANOMALY datacall=%d expected=%d copied=0 err=<none> reason=rules_empty_copy", dataCallID, selectedRows)
+	}
+	if stranded > 0 {
+		log.Printf("ROLLOVER_ANOMALY datacall=%d expected=>0 copied=%d err=<none> reason=rules_stranded_systems stranded=%d",
+			dataCallID, copied, stranded)
+	}
+
+	// A keyed rule that matches no system is almost always a typo'd key or
+	// the wrong OpDiv: every system it was meant for silently took the
+	// default instead, and copied is still nonzero, so nothing above fires.
+	rows, err := conn.Query(ctx, `
+		SELECT r.ruleid FROM rolloverrules r
+		 WHERE r.target_datacallid = $1
+		   AND (r.opdiv_id IS NOT NULL OR r.scoring_key IS NOT NULL)
+		   AND NOT EXISTS (
+		       SELECT 1 FROM fismasystems fs
+		         LEFT JOIN datacenterenvironments dce ON dce.datacenterenvironment = fs.datacenterenvironment
+		        WHERE fs.opdiv_id = r.opdiv_id OR dce.scoring_key = r.scoring_key)`, dataCallID)
+	if err == nil {
+		unmatched, err := pgx.CollectRows(rows, pgx.RowTo[int32])
+		if err == nil && len(unmatched) > 0 {
+			log.Printf("ROLLOVER_ANOMALY datacall=%d expected=>0 copied=%d err=<none> reason=rule_matched_no_systems rules=%v",
+				dataCallID, copied, unmatched)
+		}
+	}
+
+	return copied, true, nil
+}
+
+func fmtDataCallID(id *int32) string {
+	if id == nil {
+		return "none"
+	}
+	return fmt.Sprint(*id)
+}
@@ -0,0 +1,187 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCopyPreviousScoresByRulesIntegration pins the keyed rollover against the
+// real schema: a system whose OpDiv has a rule reads only that rule's source,
+// even where the previous cycle also answered it, and a system no rule matches
+// still rolls forward from the previous cycle.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestCopyPreviousScoresByRulesIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	purgeIntegrationTestRows(t)
+	defer purgeIntegrationTestRows(t)
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	// Deadlines beat every seed call, so ruleSource < previous < target and
+	// previous is findPreviousDataCall's answer for target.
+	suffix := time.Now().UnixNano()
+	insertDataCall := func(name, deadline string) int32 {
+		var id int32
+		err := conn.QueryRow(ctx, `
+			INSERT INTO datacalls (datacall, datecreated, deadline)
+			VALUES ($1, NOW(), $2::timestamptz)
+			RETURNING datacallid
+		`, fmt.Sprintf("%srules_%s_%d", integrationTestPrefix, name, suffix), deadline).Scan(&id)
+		require.NoError(t, err)
+		return id
+	}
+	ruleSource := insertDataCall("source", "2100-01-01T00:00:00Z")
+	previous := insertDataCall("previous", "2100-06-01T00:00:00Z")
+	target := insertDataCall("target", "2101-01-01T00:00:00Z")
+
+	// Two scored systems in different OpDivs.
+	rows, err := conn.Query(ctx, `
+		SELECT DISTINCT ON (fs.opdiv_id) s.fismasystemid, s.functionoptionid, fs.opdiv_id
+		FROM scores s
+		JOIN fismasystems fs ON fs.fismasystemid = s.fismasystemid
+		WHERE fs.opdiv_id IS NOT NULL
+		ORDER BY fs.opdiv_id, s.scoreid
+		LIMIT 2
+	`)
+	require.NoError(t, err)
+	type pick struct{ system, option, opdiv int32 }
+	var picks []pick
+	for rows.Next() {
+		var p pick
+		require.NoError(t, rows.Scan(&p.system, &p.option, &p.opdiv))
+		picks = append(picks, p)
+	}
+	require.NoError(t, rows.Err())
+	if len(picks) < 2 {
+		t.Skip("need scored systems in two OpDivs")
+	}
+	ruled, unruled := picks[0], picks[1]
+
+	for _, p := range picks {
+		for dc, note := range map[int32]string{ruleSource: "from rule source", previous: "from previous"} {
+			_, err := conn.Exec(ctx, `
+				INSERT INTO scores (fismasystemid, functionoptionid, datacallid, notes)
+				VALUES ($1, $2, $3, $4)
+			`, p.system, p.option, dc, note)
+			require.NoError(t, err)
+		}
+	}
+
+	_, err = conn.Exec(ctx, `
+		INSERT INTO rolloverrules (target_datacallid, opdiv_id, source_datacallid)
+		VALUES ($1, $2, $3)
+	`, target, ruled.opdiv, ruleSource)
+	require.NoError(t, err)
+
+	copied, err := copyPreviousScores(ctx, target)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, copied, int64(2))
+
+	notesFor := func(system int32) []string {
+		rows, err := conn.Query(ctx, `
+			SELECT notes FROM scores WHERE datacallid = $1 AND fismasystemid = $2
+		`, target, system)
+		require.NoError(t, err)
+		defer rows.Close()
+		var notes []string
+		for rows.Next() {
+			var n string
+			require.NoError(t, rows.Scan(&n))
+			notes = append(notes, n)
+		}
+		return notes
+	}
+
+	assert.Equal(t, []string{"from rule source"}, notesFor(ruled.system),
+		"the OpDiv's rule excludes the previous cycle for its systems")
+	assert.Equal(t, []string{"from previous"}, notesFor(unruled.system),
+		"a system no rule matches rolls forward from the previous cycle")
+}
+
+// TestRequireRolloverRulesIntegration pins the rules requirement end to end:
+// creating a call that requires rules leaves a draft whatever status the
+// client implied, its open is refused until it has a rule and changes nothing
+// meanwhile, the scheduler logs the refusal rather than taking it for a lost
+// race, and one rule is enough to lift it.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestRequireRolloverRulesIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	required := true
+	created, err := (&DataCall{
+		DataCall:              fmt.Sprintf("%srequired_%d", integrationTestPrefix, time.Now().UnixNano()),
+		Deadline:              time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+		RequiresRolloverRules: &required,
+	}).Save(ctx)
+	require.NoError(t, err)
+	target := created.DataCallID
+	t.Cleanup(func() {
+		c, err := db.Conn(context.Background())
+		if err != nil {
+			return
+		}
+		defer c.Release()
+		_, _ = c.Exec(context.Background(), `DELETE FROM datacalls WHERE datacallid = $1`, target)
+	})
+	assert.Equal(t, DataCallDraft, created.Status, "a call requiring rules is created as a draft")
+	require.NotNil(t, created.RequiresRolloverRules)
+	assert.True(t, *created.RequiresRolloverRules)
+
+	_, err = SetDataCallStatus(ctx, target, DataCallOpen)
+	var invalid *InvalidInputError
+	require.ErrorAs(t, err, &invalid)
+	assert.Contains(t, invalid.Data()["status"], "rollover rules")
+
+	current, err := FindDataCallByID(ctx, target)
+	require.NoError(t, err)
+	assert.Equal(t, DataCallDraft, current.Status, "a refused open changes nothing")
+	assert.Nil(t, current.OpenedAt)
+
+	_, err = conn.Exec(ctx, `UPDATE datacalls SET opens_at = deadline - INTERVAL '1 day' WHERE datacallid = $1`, target)
+	require.NoError(t, err)
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	opened, err := OpenScheduledDataCalls(ctx)
+	log.SetOutput(os.Stderr)
+	require.NoError(t, err)
+	assert.NotContains(t, opened, target)
+	assert.Contains(t, logged.String(), fmt.Sprintf("DATACALL_OPEN_REFUSED datacall=%d ", target))
+
+	// Any other call will do as the source; the guard only asks that a rule
+	// exists, and Save's checks are not under test here.
+	var source int32
+	err = conn.QueryRow(ctx, `SELECT datacallid FROM datacalls WHERE datacallid <> $1 LIMIT 1`, target).Scan(&source)
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, `
+		INSERT INTO rolloverrules (target_datacallid, source_datacallid) VALUES ($1, $2)
+	`, target, source)
+	require.NoError(t, err)
+
+	assert.NoError(t, requireRolloverRules(ctx, current))
+}
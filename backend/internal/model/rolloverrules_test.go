@@ -0,0 +1,73 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRolloverRuleValidate pins the source rules. The deadline check is the
+// ztmf#448 invariant carried over from findPreviousDataCall: a rule must not
+// roll a later cycle's answers backward into a backfill.
+func TestRolloverRuleValidate(t *testing.T) {
+	target := &DataCall{DataCallID: 9, Status: DataCallDraft, Deadline: time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)}
+	earlier := &DataCall{DataCallID: 7, Status: DataCallClosed, Deadline: time.Date(2025, 9, 30, 0, 0, 0, 0, time.UTC)}
+	later := &DataCall{DataCallID: 8, Status: DataCallClosed, Deadline: time.Date(2027, 9, 30, 0, 0, 0, 0, time.UTC)}
+	draft := &DataCall{DataCallID: 6, Status: DataCallDraft, Deadline: earlier.Deadline}
+	key := "SaaS"
+
+	message := func(err error, field string) string {
+		var invalid *InvalidInputError
+		if !errors.As(err, &invalid) {
+			return ""
+		}
+		s, _ := invalid.Data()[field].(string)
+		return s
+	}
+
+	assert.NoError(t, (&RolloverRule{OpDivID: int32Ptr(1)}).validate(target, earlier))
+	assert.NoError(t, (&RolloverRule{ScoringKey: &key}).validate(target, earlier))
+	assert.NoError(t, (&RolloverRule{}).validate(target, earlier), "neither key is the target's default rule")
+
+	assert.NotEmpty(t, message((&RolloverRule{OpDivID: int32Ptr(1), ScoringKey: &key}).validate(target, earlier), "key"))
+	assert.Contains(t, message((&RolloverRule{}).validate(target, later), "source_datacallid"), "before the target")
+	assert.Contains(t, message((&RolloverRule{}).validate(target, target), "source_datacallid"), "itself")
+	assert.Contains(t, message((&RolloverRule{}).validate(target, draft), "source_datacallid"), "draft")
+	assert.Contains(t, message((&RolloverRule{}).validate(target, nil), "source_datacallid"), "no such")
+}
+
+// TestRolloverAssignmentSQL pins the matching order - OpDiv, then scoring
+// key, then the default, then the previous cycle - since COALESCE takes the
+// first non-NULL and reordering its arguments silently changes which lineage
+// wins for a system both an OpDiv and a scoring-key rule match.
+func TestRolloverAssignmentSQL(t *testing.T) {
+	sql := rolloverAssignmentSQL("$1::int", "$2::int")
+
+	opdiv := strings.Index(sql, "r.opdiv_id = fs.opdiv_id")
+	scoring := strings.Index(sql, "r.scoring_key = dce.scoring_key")
+	fallback := strings.Index(sql, "r.opdiv_id IS NULL AND r.scoring_key IS NULL")
+	previous := strings.Index(sql, "$2::int")
+
+	assert.True(t, opdiv > 0 && opdiv < scoring && scoring < fallback && fallback < previous,
+		"COALESCE order must be opdiv, scoring key, default, previous cycle")
+	assert.NotContains(t, sql, "FY", "no cycle name may be hardcoded; rules are rows")
+}
+
+func TestNewRolloverReportCounts(t *testing.T) {
+	fallback := int32(7)
+	report := newRolloverReport(9, true, &fallback, []*RolloverItem{
+		{FismaSystemID: 1, FunctionID: 10, Present: true},
+		{FismaSystemID: 1, FunctionID: 11},
+		{FismaSystemID: 2, FunctionID: 10},
+	})
+	assert.Equal(t, 3, report.Expected)
+	assert.Equal(t, 1, report.Present)
+	assert.Equal(t, 2, report.Missing)
+	assert.Zero(t, report.Repaired)
+
+	empty := newRolloverReport(9, true, nil, nil)
+	assert.NotNil(t, empty.Items, "an empty plan encodes as [], not null")
+}
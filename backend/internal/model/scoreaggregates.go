@@ -0,0 +1,348 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// scoreAggregatesLockClass keys the advisory locks that serialize writes to
+// scoreaggregates (see rolloverLockClass for the key space). A refresh deletes
+// and recomputes its rows; two over the same rows at once could otherwise
+// interleave so the one that read older answers commits last. A refresh of
+// one data call locks (class, datacallid), holding (class, 0) shared; one
+// across data calls holds (class, 0) alone, which waits out the rest.
+const scoreAggregatesLockClass = 602
+
+// scoreAggregatesSystemLockClass keys the lock a refresh of one system in one
+// data call takes, under (scoreAggregatesLockClass, datacallid) held shared,
+// so answers saved to different systems of an open cycle refresh side by
+// side. The second key hashes the pair; a collision only makes two such
+// refreshes take turns.
+const scoreAggregatesSystemLockClass = 603
+
+// scoreAggregateTolerance is how far a stored score may sit from the live one
+// and still agree. Both come out of the same SQL, but a float AVG may sum in a
+// different order from one run to the next.
+const scoreAggregateTolerance = 1e-9
+
+// scoreAggregateColumns are the columns of scoreaggregates that carry a
+// pillarScoreRow, in the order buildPillarScoresSQL selects them.
+const scoreAggregateColumns = "datacallid, fismasystemid, pillarid, pillar, score, weight, weighted, system_score"
+
+// buildScoreAggregatesSQL reads the materialized pillar rows under input's
+// filters and scope, in the order and shape of buildPillarScoresSQL. It is
+// what the aggregate, rollup and gap reads run; the live query only refreshes
+// and checks the table.
+func buildScoreAggregatesSQL(input FindScoresInput) (string, []any) {
+	conds, userJoin, args := scoreScopeSQL(input)
+
+	sql := fmt.Sprintf(`
+SELECT sa.datacallid, sa.fismasystemid, sa.pillarid, sa.pillar, sa.score, sa.weight, sa.weighted, sa.system_score
+FROM scoreaggregates sa
+INNER JOIN fismasystems fs ON fs.fismasystemid = sa.fismasystemid
+INNER JOIN datacalls dc    ON dc.datacallid    = sa.datacallid
+%s
+WHERE %s
+ORDER BY sa.datacallid, sa.fismasystemid, sa.pillarid
+`, userJoin, strings.Join(conds, " AND "))
+
+	return sql, args
+}
+
+// refreshScoreAggregates recomputes the materialized rows in scope - a data
+// call, a system, or both - after a write that can move them has committed.
+// The write stands either way, so a failed refresh is not returned: it is
+// logged under SCORE_AGGREGATES_STALE, and the dirty mark the write left
+// (migration 0072) keeps reads of the scope on the live query until the
+// API's sweep (RefreshDirtyScoreAggregates) refreshes it.
+func refreshScoreAggregates(ctx context.Context, scope FindScoresInput) {
+	if err := rebuildScoreAggregates(ctx, scope); err != nil {
+		log.Printf("SCORE_AGGREGATES_STALE datacall=%s fismasystem=%s err=%v",
+			fmtScopeID(scope.DataCallID), fmtScopeID(scope.FismaSystemID), err)
+	}
+}
+
+// fmtScopeID renders one side of a refresh scope for the log; nil is every
+// data call or system.
+func fmtScopeID(id *int32) string {
+	if id == nil {
+		return "all"
+	}
+	return fmt.Sprint(*id)
+}
+
+// refreshOpenScoreAggregates refreshes every draft and open data call, the
+// ones a catalog edit re-pins (publishCatalogVersion).
+func refreshOpenScoreAggregates(ctx context.Context) {
+	sqlb := stmntBuilder.
+		Select("datacallid").
+		From("datacalls").
+		Where("status IN (?, ?)", DataCallDraft, DataCallOpen)
+
+	ids, err := query(ctx, sqlb, pgx.RowTo[int32])
+	if err != nil {
+		log.Printf("SCORE_AGGREGATES_STALE datacall=open fismasystem=all err=%v", err)
+		return
+	}
+	for _, id := range ids {
+		refreshScoreAggregates(ctx, FindScoresInput{DataCallID: &id})
+	}
+}
+
+// rebuildScoreAggregates replaces the materialized rows in scope with what
+// the live query returns now, in one transaction.
+func rebuildScoreAggregates(ctx context.Context, scope FindScoresInput) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return trapError(err)
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		conn.Release()
+		return trapError(err)
+	}
+	defer func() {
+		tx.Rollback(ctx)
+		conn.Release()
+	}()
+
+	if err := lockScoreAggregates(ctx, tx, scope); err != nil {
+		return err
+	}
+
+	if err := replaceScoreAggregates(ctx, tx, scope); err != nil {
+		return err
+	}
+
+	return trapError(tx.Commit(ctx))
+}
+
+// lockScoreAggregates takes the advisory locks for refreshing scope, for the
+// rest of tx, widest first so no two refreshes wait on each other in a cycle.
+func lockScoreAggregates(ctx context.Context, tx pgx.Tx, scope FindScoresInput) error {
+	var err error
+	switch {
+	case scope.DataCallID == nil:
+		_, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1, 0)", scoreAggregatesLockClass)
+	case scope.FismaSystemID == nil:
+		_, err = tx.Exec(ctx,
+			"SELECT pg_advisory_xact_lock_shared($1, 0), pg_advisory_xact_lock($1, $2)",
+			scoreAggregatesLockClass, *scope.DataCallID)
+	default:
+		_, err = tx.Exec(ctx, `
+SELECT pg_advisory_xact_lock_shared($1, 0),
+       pg_advisory_xact_lock_shared($1, $3),
+       pg_advisory_xact_lock($2, hashtext($3::text || ':' || $4::text))`,
+			scoreAggregatesLockClass, scoreAggregatesSystemLockClass, *scope.DataCallID, *scope.FismaSystemID)
+	}
+	return trapError(err)
+}
+
+// replaceScoreAggregates deletes the rows in scope and inserts the live
+// query's, both bound to one set of scope args, and clears the dirty marks
+// the rows answer for. The marks go first: a write committing meanwhile is
+// either seen by the insert or leaves a mark of its own. The caller holds
+// the lock.
+func replaceScoreAggregates(ctx context.Context, tx pgx.Tx, scope FindScoresInput) error {
+	_, err := tx.Exec(ctx, `
+DELETE FROM scoreaggregatesdirty
+WHERE ($1::int IS NULL OR datacallid = $1)
+  AND ($2::int IS NULL OR fismasystemid = $2)`, scope.DataCallID, scope.FismaSystemID)
+	if err != nil {
+		return trapError(err)
+	}
+
+	conds, _, args := scoreScopeSQL(scope)
+
+	_, err = tx.Exec(ctx, fmt.Sprintf(`
+DELETE FROM scoreaggregates sa
+USING fismasystems fs, datacalls dc
+WHERE fs.fismasystemid = sa.fismasystemid
+  AND dc.datacallid = sa.datacallid
+  AND %s`, strings.Join(conds, " AND ")), args...)
+	if err != nil {
+		return trapError(err)
+	}
+
+	sql, args := buildPillarScoresSQL(scope)
+	_, err = tx.Exec(ctx, "INSERT INTO scoreaggregates ("+scoreAggregateColumns+")"+sql, args...)
+	return trapError(err)
+}
+
+// scoreAggregatesDirty reports whether a write has marked rows in scope
+// that no refresh has recomputed since. Only the data call and system of
+// scope narrow it; a mark on either side of one counts.
+func scoreAggregatesDirty(ctx context.Context, scope FindScoresInput) (bool, error) {
+	dirty, err := queryRow(ctx, rawQuery{sql: `
+SELECT EXISTS (
+    SELECT 1 FROM scoreaggregatesdirty
+    WHERE ($1::int IS NULL OR datacallid IS NULL OR datacallid = $1)
+      AND ($2::int IS NULL OR fismasystemid IS NULL OR fismasystemid = $2)
+)`, args: []any{scope.DataCallID, scope.FismaSystemID}}, pgx.RowTo[bool])
+	if err != nil {
+		return false, err
+	}
+	return *dirty, nil
+}
+
+// pillarRowsSQL is the pillar rows under input: read from scoreaggregates,
+// or computed live when live is set.
+func pillarRowsSQL(input FindScoresInput, live bool) (string, []any) {
+	if live {
+		return buildPillarScoresSQL(input)
+	}
+	return buildScoreAggregatesSQL(input)
+}
+
+// RefreshDirtyScoreAggregates refreshes every scope a write has marked dirty
+// and no refresh has cleared: those whose refresh failed, and those written
+// outside the model. It returns how many it refreshed; a scope that fails is
+// logged and left marked for the next sweep.
+func RefreshDirtyScoreAggregates(ctx context.Context) (int, error) {
+	sqlb := stmntBuilder.
+		Select("datacallid", "fismasystemid").
+		From("scoreaggregatesdirty").
+		OrderBy("markedat")
+
+	scopes, err := query(ctx, sqlb, func(row pgx.CollectableRow) (FindScoresInput, error) {
+		scope := FindScoresInput{}
+		err := row.Scan(&scope.DataCallID, &scope.FismaSystemID)
+		return scope, err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	refreshed := 0
+	for _, scope := range scopes {
+		if err := rebuildScoreAggregates(ctx, scope); err != nil {
+			log.Printf("SCORE_AGGREGATES_STALE datacall=%s fismasystem=%s err=%v",
+				fmtScopeID(scope.DataCallID), fmtScopeID(scope.FismaSystemID), err)
+			continue
+		}
+		refreshed++
+	}
+	return refreshed, nil
+}
+
+// RebuildScoreAggregates recomputes the whole table from the live query.
+func RebuildScoreAggregates(ctx context.Context) error {
+	return rebuildScoreAggregates(ctx, FindScoresInput{})
+}
+
+// SeedScoreAggregates builds the table when it is empty but there are scores
+// to aggregate: once after the migration that creates it, and again only if
+// it has been emptied. It reports whether it built.
+func SeedScoreAggregates(ctx context.Context) (bool, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return false, trapError(err)
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		conn.Release()
+		return false, trapError(err)
+	}
+	defer func() {
+		tx.Rollback(ctx)
+		conn.Release()
+	}()
+
+	// Under the lock, so of several API tasks starting together one builds
+	// and the rest find the table full.
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1, 0)", scoreAggregatesLockClass); err != nil {
+		return false, trapError(err)
+	}
+
+	var empty bool
+	err = tx.QueryRow(ctx,
+		"SELECT NOT EXISTS (SELECT 1 FROM scoreaggregates) AND EXISTS (SELECT 1 FROM scores)").Scan(&empty)
+	if err != nil {
+		return false, trapError(err)
+	}
+	if !empty {
+		return false, nil
+	}
+
+	if err := replaceScoreAggregates(ctx, tx, FindScoresInput{}); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, trapError(err)
+	}
+	return true, nil
+}
+
+// Kinds of ScoreAggregateDrift.
+const (
+	ScoreAggregateMissing = "missing" // the live query has the row, the table does not
+	ScoreAggregateExtra   = "extra"   // the table has a row the live query does not
+	ScoreAggregateDiffers = "differs" // both have it, with different values
+)
+
+// ScoreAggregateDrift is one (data call, system, pillar) row on which
+// scoreaggregates and the live query disagree. Stored and Live are nil on
+// the side that lacks the row.
+type ScoreAggregateDrift struct {
+	DataCallID        int32    `json:"datacallid" db:"datacallid"`
+	FismaSystemID     int32    `json:"fismasystemid" db:"fismasystemid"`
+	PillarID          int32    `json:"pillarid" db:"pillarid"`
+	Kind              string   `json:"kind" db:"kind"`
+	StoredScore       *float64 `json:"stored_score" db:"stored_score"`
+	LiveScore         *float64 `json:"live_score" db:"live_score"`
+	StoredSystemScore *float64 `json:"stored_system_score" db:"stored_system_score"`
+	LiveSystemScore   *float64 `json:"live_system_score" db:"live_system_score"`
+}
+
+// buildScoreAggregateDriftSQL compares every materialized row with the live
+// query, keeping the rows that are missing, extra, or differ in any column.
+func buildScoreAggregateDriftSQL() (string, []any) {
+	liveSQL, args := buildPillarScoresSQL(FindScoresInput{})
+
+	sql := fmt.Sprintf(`
+WITH live AS (%s)
+SELECT
+    COALESCE(l.datacallid, sa.datacallid)       AS datacallid,
+    COALESCE(l.fismasystemid, sa.fismasystemid) AS fismasystemid,
+    COALESCE(l.pillarid, sa.pillarid)           AS pillarid,
+    CASE WHEN sa.datacallid IS NULL THEN '%s'
+         WHEN l.datacallid IS NULL  THEN '%s'
+         ELSE '%s'
+    END AS kind,
+    sa.score        AS stored_score,
+    l.score         AS live_score,
+    sa.system_score AS stored_system_score,
+    l.system_score  AS live_system_score
+FROM live l
+FULL OUTER JOIN scoreaggregates sa
+  ON sa.datacallid = l.datacallid
+ AND sa.fismasystemid = l.fismasystemid
+ AND sa.pillarid = l.pillarid
+WHERE sa.datacallid IS NULL
+   OR l.datacallid IS NULL
+   OR sa.pillar <> l.pillar
+   OR sa.weighted <> l.weighted
+   OR ABS(sa.score - l.score) > %[5]g
+   OR ABS(sa.weight - l.weight) > %[5]g
+   OR ABS(sa.system_score - l.system_score) > %[5]g
+ORDER BY 1, 2, 3
+`, liveSQL, ScoreAggregateMissing, ScoreAggregateExtra, ScoreAggregateDiffers, scoreAggregateTolerance)
+
+	return sql, args
+}
+
+// CheckScoreAggregates returns every row on which scoreaggregates disagrees
+// with the live query; none means the table is consistent. A score written
+// while the check runs can show up until its own refresh commits, so drift
+// that does not repeat on a second check is not a fault.
+func CheckScoreAggregates(ctx context.Context) ([]*ScoreAggregateDrift, error) {
+	sql, args := buildScoreAggregateDriftSQL()
+	return query(ctx, rawQuery{sql: sql, args: args}, pgx.RowToAddrOfStructByName[ScoreAggregateDrift])
+}
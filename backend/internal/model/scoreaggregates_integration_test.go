@@ -0,0 +1,171 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScoreAggregatesIntegration checks the seeded table agrees with the live
+// query, that the check finds a row removed behind its back, and that a
+// refresh of the row's data call and system puts it back.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestScoreAggregatesIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	require.NoError(t, RebuildScoreAggregates(ctx))
+	drift, err := CheckScoreAggregates(ctx)
+	require.NoError(t, err)
+	require.Empty(t, drift, "a rebuilt table matches the live query")
+
+	var dataCallID, fismaSystemID, pillarID int32
+	err = conn.QueryRow(ctx,
+		"SELECT datacallid, fismasystemid, pillarid FROM scoreaggregates ORDER BY 1, 2, 3 LIMIT 1").
+		Scan(&dataCallID, &fismaSystemID, &pillarID)
+	require.NoError(t, err, "the seed has scores to aggregate")
+
+	_, err = conn.Exec(ctx,
+		"DELETE FROM scoreaggregates WHERE datacallid = $1 AND fismasystemid = $2 AND pillarid = $3",
+		dataCallID, fismaSystemID, pillarID)
+	require.NoError(t, err)
+
+	drift, err = CheckScoreAggregates(ctx)
+	require.NoError(t, err)
+	require.Len(t, drift, 1)
+	assert.Equal(t, ScoreAggregateMissing, drift[0].Kind)
+	assert.Equal(t, pillarID, drift[0].PillarID)
+	assert.Nil(t, drift[0].StoredScore)
+	assert.NotNil(t, drift[0].LiveScore)
+
+	refreshScoreAggregates(ctx, FindScoresInput{DataCallID: &dataCallID, FismaSystemID: &fismaSystemID})
+
+	drift, err = CheckScoreAggregates(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, drift)
+
+	seeded, err := SeedScoreAggregates(ctx)
+	require.NoError(t, err)
+	assert.False(t, seeded, "a full table is not reseeded")
+}
+
+// TestScoreAggregatesDirtyIntegration writes a score around the model, as a
+// migration or a console session would, and checks the write's mark keeps
+// reads of its scope live until the sweep refreshes and clears it.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestScoreAggregatesDirtyIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	require.NoError(t, RebuildScoreAggregates(ctx))
+
+	var dataCallID, fismaSystemID, pillarID int32
+	err = conn.QueryRow(ctx,
+		"SELECT datacallid, fismasystemid, pillarid FROM scoreaggregates ORDER BY 1, 2, 3 LIMIT 1").
+		Scan(&dataCallID, &fismaSystemID, &pillarID)
+	require.NoError(t, err, "the seed has scores to aggregate")
+	scope := FindScoresInput{DataCallID: &dataCallID, FismaSystemID: &fismaSystemID}
+
+	dirty, err := scoreAggregatesDirty(ctx, scope)
+	require.NoError(t, err)
+	require.False(t, dirty, "a rebuild clears every mark")
+
+	// The row goes missing from the table and the scores under it are
+	// rewritten; nothing refreshes.
+	_, err = conn.Exec(ctx,
+		"DELETE FROM scoreaggregates WHERE datacallid = $1 AND fismasystemid = $2 AND pillarid = $3",
+		dataCallID, fismaSystemID, pillarID)
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx,
+		"UPDATE scores SET notes = notes WHERE datacallid = $1 AND fismasystemid = $2",
+		dataCallID, fismaSystemID)
+	require.NoError(t, err)
+
+	dirty, err = scoreAggregatesDirty(ctx, scope)
+	require.NoError(t, err)
+	require.True(t, dirty, "the trigger marks the scope in the writing statement")
+
+	aggregates, err := FindScoresAggregate(ctx, scope)
+	require.NoError(t, err)
+	require.Len(t, aggregates, 1)
+	pillars := []int32{}
+	for _, p := range aggregates[0].PillarScores {
+		pillars = append(pillars, p.PillarID)
+	}
+	assert.Contains(t, pillars, pillarID, "a dirty scope is read live, not from the table")
+
+	refreshed, err := RefreshDirtyScoreAggregates(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, refreshed, 1)
+
+	dirty, err = scoreAggregatesDirty(ctx, scope)
+	require.NoError(t, err)
+	assert.False(t, dirty, "the sweep clears the marks it refreshes")
+
+	drift, err := CheckScoreAggregates(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, drift)
+}
+
+// TestLockScoreAggregatesIntegration pins the refresh locks: refreshes of two
+// systems in one data call do not wait on each other, while a refresh of the
+// whole data call waits for both.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestLockScoreAggregatesIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	ctx := context.Background()
+	begin := func() pgx.Tx {
+		conn, err := db.Conn(ctx)
+		require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+		tx, err := conn.Begin(ctx)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			tx.Rollback(context.Background())
+			conn.Release()
+		})
+		return tx
+	}
+
+	dataCallID, one, two := int32(-1), int32(-1), int32(-2)
+	require.NoError(t, lockScoreAggregates(ctx, begin(),
+		FindScoresInput{DataCallID: &dataCallID, FismaSystemID: &one}))
+
+	// Would block until the first transaction ends if the system locks
+	// conflicted.
+	quick, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	require.NoError(t, lockScoreAggregates(quick, begin(),
+		FindScoresInput{DataCallID: &dataCallID, FismaSystemID: &two}),
+		"a second system's refresh does not wait on the first")
+
+	var got bool
+	require.NoError(t, begin().QueryRow(ctx,
+		"SELECT pg_try_advisory_xact_lock($1, $2)", scoreAggregatesLockClass, dataCallID).Scan(&got))
+	assert.False(t, got, "a data call refresh waits for its systems' refreshes")
+}
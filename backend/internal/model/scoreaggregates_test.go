@@ -0,0 +1,45 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBuildScoreAggregatesSQL pins that the aggregate reads come from the
+// materialized table under the same scope the live query applies, in the
+// order aggregatePillarRows expects.
+func TestBuildScoreAggregatesSQL(t *testing.T) {
+	userID := "u-1"
+	dataCallID := int32(5)
+	input := FindScoresInput{
+		DataCallID: &dataCallID,
+		UserID:     &userID,
+		OpDivScope: OpDivScope{RestrictToOpDivIDs: true, OpDivIDs: []int32{7}},
+	}
+
+	sql, args := buildScoreAggregatesSQL(input)
+	_, liveArgs := buildPillarScoresSQL(input)
+
+	assert.Contains(t, sql, "FROM scoreaggregates sa")
+	assert.NotContains(t, sql, "expected", "the read does not enumerate expected functions")
+	assert.Contains(t, sql, "dc.datacallid = $1")
+	assert.Contains(t, sql, "fs.opdiv_id = ANY($2)")
+	assert.Contains(t, sql, "INNER JOIN users_fismasystems ufs ON ufs.fismasystemid = fs.fismasystemid AND ufs.userid = $3")
+	assert.Contains(t, sql, "ORDER BY sa.datacallid, sa.fismasystemid, sa.pillarid")
+	assert.Equal(t, liveArgs, args, "the read binds the same scope as the live query")
+}
+
+// TestBuildScoreAggregateDriftSQL verifies the check joins the table to the
+// full live query both ways and flags values beyond the float tolerance only.
+func TestBuildScoreAggregateDriftSQL(t *testing.T) {
+	sql, args := buildScoreAggregateDriftSQL()
+	liveSQL, _ := buildPillarScoresSQL(FindScoresInput{})
+
+	assert.Contains(t, sql, "WITH live AS ("+liveSQL+")")
+	assert.Contains(t, sql, "FULL OUTER JOIN scoreaggregates sa")
+	assert.Contains(t, sql, "THEN 'missing'")
+	assert.Contains(t, sql, "THEN 'extra'")
+	assert.Contains(t, sql, "ABS(sa.system_score - l.system_score) > 1e-09")
+	assert.Empty(t, args, "an unscoped check binds nothing")
+}
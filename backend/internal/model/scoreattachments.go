@@ -0,0 +1,349 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/attachments"
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// MaxAttachmentBytes is the largest evidence file accepted on an answer.
+const MaxAttachmentBytes = 25 << 20
+
+// attachmentContentTypes maps each content type an attachment may declare to
+// the type http.DetectContentType must find in its first bytes. The check
+// keeps a declared PDF from being an executable; the Office formats are zip
+// containers and sniff as such.
+var attachmentContentTypes = map[string]string{
+	"application/pdf": "application/pdf",
+	"image/png":       "image/png",
+	"image/jpeg":      "image/jpeg",
+	"text/plain":      "text/plain",
+	"text/csv":        "text/plain",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         "application/zip",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   "application/zip",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": "application/zip",
+}
+
+// ScoreAttachment is an evidence file on an answer. The bytes are in the
+// attachment store under StorageKey, which stays server-side. CarriedFrom is
+// the attachment on the previous cycle's answer this one was rolled forward
+// from; carried attachments share the stored file.
+type ScoreAttachment struct {
+	AttachmentID int32     `json:"attachmentid"`
+	ScoreID      int32     `json:"scoreid"`
+	FileName     string    `json:"filename"`
+	ContentType  string    `json:"contenttype"`
+	SizeBytes    int64     `json:"sizebytes"`
+	SHA256       string    `json:"sha256"`
+	StorageKey   string    `json:"-"`
+	CarriedFrom  *int32    `json:"carriedfrom"`
+	CreatedAt    time.Time `json:"createdat"`
+	UploadedBy   *AuditRef `json:"uploaded_by"`
+}
+
+var scoreAttachmentColumns = []string{"attachmentid", "scoreid", "filename", "contenttype", "sizebytes", "sha256", "storagekey", "carriedfrom", "createdat"}
+
+func scanScoreAttachment(row pgx.CollectableRow) (*ScoreAttachment, error) {
+	a := ScoreAttachment{}
+	err := row.Scan(&a.AttachmentID, &a.ScoreID, &a.FileName, &a.ContentType, &a.SizeBytes, &a.SHA256, &a.StorageKey, &a.CarriedFrom, &a.CreatedAt)
+	return &a, err
+}
+
+func scanScoreAttachmentWithUploader(row pgx.CollectableRow) (*ScoreAttachment, error) {
+	a := ScoreAttachment{}
+	var (
+		uploaderUserID *string
+		uploaderName   *string
+		uploaderEmail  *string
+		uploaderRole   *string
+	)
+	err := row.Scan(&a.AttachmentID, &a.ScoreID, &a.FileName, &a.ContentType, &a.SizeBytes, &a.SHA256, &a.StorageKey, &a.CarriedFrom, &a.CreatedAt,
+		&uploaderUserID, &uploaderName, &uploaderEmail, &uploaderRole,
+	)
+	if err != nil {
+		return &a, err
+	}
+	if uploaderUserID != nil {
+		a.UploadedBy = &AuditRef{
+			UserID: *uploaderUserID,
+			Name:   derefString(uploaderName),
+			Email:  derefString(uploaderEmail),
+			Role:   derefString(uploaderRole),
+		}
+	}
+	return &a, nil
+}
+
+func findScoreAttachments(ctx context.Context, scoreID int32, attachmentID *int32) ([]*ScoreAttachment, error) {
+	cols := make([]string, 0, len(scoreAttachmentColumns)+4)
+	for _, c := range scoreAttachmentColumns {
+		cols = append(cols, "sa."+c)
+	}
+	sqlb := stmntBuilder.
+		Select(append(cols, "u.userid", "u.fullname", "u.email", "u.role")...).
+		From("scoreattachments sa").
+		LeftJoin("users u ON u.userid = sa.uploadedby").
+		Where("sa.scoreid=?", scoreID).
+		OrderBy("sa.createdat", "sa.attachmentid")
+
+	if attachmentID != nil {
+		sqlb = sqlb.Where("sa.attachmentid=?", *attachmentID)
+	}
+
+	return query(ctx, sqlb, scanScoreAttachmentWithUploader)
+}
+
+// FindScoreAttachments lists an answer's attachments, oldest first. The
+// caller authorizes the answer; this does not.
+func FindScoreAttachments(ctx context.Context, scoreID int32) ([]*ScoreAttachment, error) {
+	list, err := findScoreAttachments(ctx, scoreID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []*ScoreAttachment{}
+	}
+	return list, nil
+}
+
+// OpenScoreAttachment returns one attachment of an answer and a reader over
+// its file, which the caller must close.
+func OpenScoreAttachment(ctx context.Context, store attachments.Store, scoreID, attachmentID int32) (*ScoreAttachment, io.ReadCloser, error) {
+	list, err := findScoreAttachments(ctx, scoreID, &attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(list) == 0 {
+		return nil, nil, ErrNoData
+	}
+
+	body, err := store.Get(ctx, list[0].StorageKey)
+	if errors.Is(err, attachments.ErrNotFound) {
+		log.Printf("ATTACHMENT_MISSING attachmentid=%d scoreid=%d key=%s", attachmentID, scoreID, list[0].StorageKey)
+		return nil, nil, ErrNoData
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return list[0], body, nil
+}
+
+// validateScoreAttachment checks the upload against the type and size limits.
+// It returns the file name reduced to its last path element, since browsers
+// differ on whether they send the client's full path.
+func validateScoreAttachment(fileName, contentType string, content []byte) (string, error) {
+	err := InvalidInputError{data: map[string]any{}}
+
+	name := path.Base(strings.ReplaceAll(fileName, `\`, "/"))
+	if name == "" || name == "." || name == "/" {
+		err.data["filename"] = "required"
+	} else if len(name) > 255 {
+		err.data["filename"] = "must be at most 255 characters"
+	}
+
+	switch {
+	case len(content) == 0:
+		err.data["file"] = "must not be empty"
+	case len(content) > MaxAttachmentBytes:
+		err.data["file"] = fmt.Sprintf("must be at most %d bytes", MaxAttachmentBytes)
+	}
+
+	if sniffed, ok := attachmentContentTypes[contentType]; !ok {
+		err.data["contenttype"] = fmt.Sprintf("%q is not an accepted attachment type", contentType)
+	} else if len(content) > 0 {
+		detected, _, _ := mime.ParseMediaType(http.DetectContentType(content))
+		if detected != sniffed {
+			err.data["contenttype"] = fmt.Sprintf("file content does not match %s", contentType)
+		}
+	}
+
+	if len(err.data) > 0 {
+		return "", &err
+	}
+	return name, nil
+}
+
+// CreateScoreAttachment stores content and attaches it to the answer. The
+// answer's data call must still be writable by the caller, as for saving the
+// answer itself. The file is stored before the row is written, and removed
+// again if the row cannot be, so a row never names a file that is not there.
+func CreateScoreAttachment(ctx context.Context, store attachments.Store, scoreID int32, fileName, contentType string, content []byte) (*ScoreAttachment, error) {
+	user := UserFromContext(ctx)
+	if user == nil {
+		return nil, &InvalidInputError{data: map[string]any{"user": "required"}}
+	}
+
+	name, err := validateScoreAttachment(fileName, contentType, content)
+	if err != nil {
+		return nil, err
+	}
+
+	score, err := FindScoreByID(ctx, scoreID)
+	if err != nil {
+		return nil, err
+	}
+	if err := score.validateDeadline(ctx); err != nil {
+		return nil, err
+	}
+
+	key, err := newAttachmentKey(scoreID)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(content)
+
+	if err := store.Put(ctx, key, bytes.NewReader(content), int64(len(content)), contentType); err != nil {
+		return nil, err
+	}
+
+	sqlb := stmntBuilder.
+		Insert("public.scoreattachments").
+		Columns("scoreid", "filename", "contenttype", "sizebytes", "sha256", "storagekey", "uploadedby").
+		Values(scoreID, name, contentType, len(content), hex.EncodeToString(sum[:]), key, user.UserID).
+		Suffix("RETURNING " + strings.Join(scoreAttachmentColumns, ", "))
+
+	created, err := queryRow(ctx, sqlb, scanScoreAttachment)
+	if err != nil {
+		if delErr := store.Delete(ctx, key); delErr != nil {
+			log.Printf("ATTACHMENT_ORPHAN key=%s err=%v", key, delErr)
+		}
+		return nil, err
+	}
+
+	a := *created
+	a.UploadedBy = &AuditRef{UserID: user.UserID, Name: user.FullName, Email: user.Email, Role: user.Role}
+	return a, nil
+}
+
+// DeleteScoreAttachment removes an attachment from an answer, under the same
+// deadline rule as CreateScoreAttachment. The stored file is removed only
+// when no other answer still names it, which is the case for a file carried
+// forward to a later cycle. A file that cannot be removed is logged and left;
+// the attachment is gone either way.
+func DeleteScoreAttachment(ctx context.Context, store attachments.Store, scoreID, attachmentID int32) error {
+	score, err := FindScoreByID(ctx, scoreID)
+	if err != nil {
+		return err
+	}
+	if err := score.validateDeadline(ctx); err != nil {
+		return err
+	}
+
+	sqlb := stmntBuilder.
+		Delete("public.scoreattachments").
+		Where("scoreid=?", scoreID).
+		Where("attachmentid=?", attachmentID).
+		Suffix("RETURNING " + strings.Join(scoreAttachmentColumns, ", "))
+
+	row, err := queryRow(ctx, sqlb, scanScoreAttachment)
+	if err != nil {
+		return err
+	}
+	deleted := *row
+
+	shared, err := query(ctx, rawQuery{
+		sql:  "SELECT EXISTS (SELECT 1 FROM scoreattachments WHERE storagekey = $1)",
+		args: []any{deleted.StorageKey},
+	}, pgx.RowTo[bool])
+	if err != nil || len(shared) == 0 {
+		log.Printf("ATTACHMENT_ORPHAN key=%s err=%v reason=reference_check", deleted.StorageKey, err)
+		return nil
+	}
+	if shared[0] {
+		return nil
+	}
+
+	if err := store.Delete(ctx, deleted.StorageKey); err != nil && !errors.Is(err, attachments.ErrNotFound) {
+		log.Printf("ATTACHMENT_ORPHAN key=%s err=%v", deleted.StorageKey, err)
+	}
+	return nil
+}
+
+// newAttachmentKey names a stored file. The random part keeps keys
+// unguessable and unique; the score id only groups a score's files together.
+func newAttachmentKey(scoreID int32) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("scores/%d/%s", scoreID, hex.EncodeToString(b)), nil
+}
+
+// attachmentExecer is satisfied by both a pooled connection and a transaction.
+type attachmentExecer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// carryForwardAttachments gives each answer in dataCallID the attachments of
+// the same system's answer to the same function in the cycle it was rolled
+// over from, by reference: new rows, same stored files. The source cycle is
+// the rollover's own (rolloverAssignmentSQL, fallback for systems no rule
+// matches). Attachments the answer already holds are skipped, so running it
+// again - as a rollover repair does - adds only what is missing.
+func carryForwardAttachments(ctx context.Context, e attachmentExecer, dataCallID int32, fallback *int32) (int64, error) {
+	sql := fmt.Sprintf(`
+		WITH assignment AS (%s)
+		INSERT INTO scoreattachments (scoreid, filename, contenttype, sizebytes, sha256, storagekey, uploadedby, createdat, carriedfrom)
+		SELECT DISTINCT ON (dst.scoreid, sa.storagekey)
+		       dst.scoreid, sa.filename, sa.contenttype, sa.sizebytes, sa.sha256, sa.storagekey, sa.uploadedby, sa.createdat, sa.attachmentid
+		  FROM assignment a
+		  JOIN scores dst           ON dst.fismasystemid = a.fismasystemid AND dst.datacallid = $1::int
+		  JOIN functionoptions dfo  ON dfo.functionoptionid = dst.functionoptionid
+		  JOIN scores src           ON src.fismasystemid = a.fismasystemid AND src.datacallid = a.source_datacallid
+		  JOIN functionoptions sfo  ON sfo.functionoptionid = src.functionoptionid AND sfo.functionid = dfo.functionid
+		  JOIN scoreattachments sa  ON sa.scoreid = src.scoreid
+		 WHERE NOT EXISTS (
+		       SELECT 1 FROM scoreattachments held
+		        WHERE held.scoreid = dst.scoreid AND held.storagekey = sa.storagekey)
+		 ORDER BY dst.scoreid, sa.storagekey, sa.attachmentid`,
+		rolloverAssignmentSQL("$1::int", "$2::int"))
+
+	tag, err := e.Exec(ctx, sql, dataCallID, fallback)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// carryForwardRolloverAttachments runs carryForwardAttachments after the
+// rollover copy. Like the copy it is best-effort: the answers are already in
+// place, so a failure is raised as an anomaly rather than failing the open.
+func carryForwardRolloverAttachments(ctx context.Context, dataCallID int32) {
+	fallback, err := rolloverFallback(ctx, dataCallID)
+	if err != nil {
+		log.Printf("ROLLOVER_ANOMALY datacall=%d err=%v reason=attachments", dataCallID, err)
+		return
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		log.Printf("ROLLOVER_ANOMALY datacall=%d err=%v reason=attachments", dataCallID, err)
+		return
+	}
+	defer conn.Release()
+
+	carried, err := carryForwardAttachments(ctx, conn, dataCallID, fallback)
+	if err != nil {
+		log.Printf("ROLLOVER_ANOMALY datacall=%d err=%v reason=attachments", dataCallID, err)
+		return
+	}
+	if carried > 0 {
+		log.Printf("ROLLOVER_ATTACHMENTS datacall=%d carried=%d", dataCallID, carried)
+	}
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/attachments"
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScoreAttachmentsIntegration attaches a file to a score, reads it back
+// through the store, and removes it, checking the file goes with the last
+// reference.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestScoreAttachmentsIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	var userID string
+	var scoreID int32
+	require.NoError(t, conn.QueryRow(ctx, `
+SELECT (SELECT userid FROM users WHERE role='OWNER' AND deleted=false LIMIT 1),
+       (SELECT scoreid FROM scores ORDER BY scoreid LIMIT 1)`).Scan(&userID, &scoreID))
+
+	user, err := FindUserByID(ctx, userID)
+	require.NoError(t, err)
+	ctx = UserToContext(ctx, user)
+
+	store, err := attachments.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	created, err := CreateScoreAttachment(ctx, store, scoreID, "evidence.pdf", "application/pdf", pdfContent)
+	require.NoError(t, err)
+	assert.Equal(t, scoreID, created.ScoreID)
+	assert.Equal(t, int64(len(pdfContent)), created.SizeBytes)
+	require.NotNil(t, created.UploadedBy)
+
+	list, err := FindScoreAttachments(ctx, scoreID)
+	require.NoError(t, err)
+	require.NotEmpty(t, list)
+	assert.Equal(t, created.AttachmentID, list[len(list)-1].AttachmentID)
+
+	_, body, err := OpenScoreAttachment(ctx, store, scoreID, created.AttachmentID)
+	require.NoError(t, err)
+	got, err := io.ReadAll(body)
+	body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, pdfContent, got)
+
+	require.NoError(t, DeleteScoreAttachment(ctx, store, scoreID, created.AttachmentID))
+	_, err = store.Get(ctx, created.StorageKey)
+	assert.ErrorIs(t, err, attachments.ErrNotFound)
+
+	_, _, err = OpenScoreAttachment(ctx, store, scoreID, created.AttachmentID)
+	assert.ErrorIs(t, err, ErrNoData)
+}
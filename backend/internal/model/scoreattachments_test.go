@@ -0,0 +1,77 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pdfContent = []byte("%PDF-1.7\n1 0 obj\n<<>>\nendobj\n")
+
+func TestValidateScoreAttachment(t *testing.T) {
+	name, err := validateScoreAttachment(`C:\Users\isso\evidence.pdf`, "application/pdf", pdfContent)
+	require.NoError(t, err)
+	assert.Equal(t, "evidence.pdf", name, "the client's path is dropped")
+
+	tests := []struct {
+		name        string
+		fileName    string
+		contentType string
+		content     []byte
+		wantField   string
+	}{
+		{"no name", "", "application/pdf", pdfContent, "filename"},
+		{"name too long", strings.Repeat("a", 256), "application/pdf", pdfContent, "filename"},
+		{"empty", "e.pdf", "application/pdf", nil, "file"},
+		{"too large", "e.pdf", "application/pdf", append(append([]byte{}, pdfContent...), make([]byte, MaxAttachmentBytes)...), "file"},
+		{"unaccepted type", "e.exe", "application/x-msdownload", pdfContent, "contenttype"},
+		{"content does not match", "e.pdf", "application/pdf", []byte{0x4d, 0x5a, 0x90, 0x00, 0x03}, "contenttype"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validateScoreAttachment(tt.fileName, tt.contentType, tt.content)
+			var invalid *InvalidInputError
+			require.ErrorAs(t, err, &invalid)
+			assert.Contains(t, invalid.Data(), tt.wantField)
+		})
+	}
+}
+
+func TestValidateScoreAttachment_OfficeSniffsAsZip(t *testing.T) {
+	zip := append([]byte("PK\x03\x04"), bytes.Repeat([]byte{0}, 26)...)
+	_, err := validateScoreAttachment("evidence.xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", zip)
+	assert.NoError(t, err)
+}
+
+type recordingExecer struct {
+	sql  string
+	args []any
+}
+
+func (e *recordingExecer) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	e.sql, e.args = sql, args
+	return pgconn.NewCommandTag("INSERT 0 2"), nil
+}
+
+// TestCarryForwardAttachments_SQL pins the carry-forward to the rollover's own
+// source assignment and to reference semantics: the stored key is copied, not
+// re-stored, and keys the answer already holds are skipped.
+func TestCarryForwardAttachments_SQL(t *testing.T) {
+	e := &recordingExecer{}
+	fallback := int32(4)
+
+	n, err := carryForwardAttachments(context.Background(), e, 5, &fallback)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	assert.Equal(t, []any{int32(5), &fallback}, e.args)
+	assert.Contains(t, e.sql, rolloverAssignmentSQL("$1::int", "$2::int"))
+	assert.Contains(t, e.sql, "sa.storagekey, sa.uploadedby, sa.createdat, sa.attachmentid")
+	assert.Contains(t, e.sql, "held.scoreid = dst.scoreid AND held.storagekey = sa.storagekey")
+	assert.Contains(t, e.sql, "sfo.functionid = dfo.functionid", "attachments follow the function, not the option")
+}
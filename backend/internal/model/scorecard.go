@@ -0,0 +1,164 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// scorecardLowestFunctions is how many of the lowest-scoring functions a
+// scorecard lists.
+const scorecardLowestFunctions = 5
+
+type FindScorecardInput struct {
+	FismaSystemID int32 `schema:"-"`
+	// DataCallID defaults to the latest data call (FindLatestDataCall).
+	DataCallID *int32 `schema:"datacallid"`
+}
+
+// Scorecard is one system's standing in one data call, as the PDF scorecard
+// reports it. Score is nil when the system has no scores in the data call,
+// and PreviousScore likewise for the previous cycle. Previous is nil for the
+// first cycle, which has no changes to report.
+type Scorecard struct {
+	System              *FismaSystem
+	DataCall            *DataCall
+	Score               *ScoreAggregate
+	TargetTier          string
+	TargetTierDefaulted bool
+	Previous            *DataCall
+	PreviousScore       *ScoreAggregate
+	Changes             []*ScoreDiff
+	LowestFunctions     []*ScorecardFunction
+}
+
+// ScorecardFunction is one of a system's lowest-scoring functions, labelled
+// with the pillar it counts toward.
+type ScorecardFunction struct {
+	PillarID int32
+	Pillar   string
+	GapDriver
+}
+
+// FindScorecard gathers a system's scorecard for a data call: its pillar
+// scores from FindScoresAggregate, its target maturity, what changed since
+// the previous cycle from FindScoreDiff, and its lowest-scoring functions as
+// the gap analysis ranks them. It applies no scope of its own; the caller
+// decides whether the user may see the system.
+func FindScorecard(ctx context.Context, input FindScorecardInput) (*Scorecard, error) {
+	system, err := FindFismaSystem(ctx, FindFismaSystemsInput{FismaSystemID: &input.FismaSystemID, ResolveISSOName: true})
+	if err != nil {
+		return nil, err
+	}
+	if system == nil {
+		return nil, ErrNoData
+	}
+
+	var dataCall *DataCall
+	if input.DataCallID == nil {
+		dataCall, err = FindLatestDataCall(ctx)
+	} else {
+		dataCall, err = FindDataCallByID(ctx, *input.DataCallID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	card := &Scorecard{System: system, DataCall: dataCall, Changes: []*ScoreDiff{}, LowestFunctions: []*ScorecardFunction{}}
+	card.TargetTier, card.TargetTierDefaulted = targetTier(system.TargetMaturityTier)
+
+	if card.Score, err = findScorecardAggregate(ctx, system.FismaSystemID, dataCall.DataCallID); err != nil {
+		return nil, err
+	}
+
+	if card.Score != nil {
+		scoresInput := scoreGapsScoresInput(FindScoreGapsInput{
+			DataCallID:    &dataCall.DataCallID,
+			FismaSystemID: &system.FismaSystemID,
+		})
+		sql, args := buildScoreGapDriversSQL(scoresInput, scorecardLowestFunctions)
+		rows, err := query(ctx, rawQuery{sql: sql, args: args}, pgx.RowToAddrOfStructByName[scoreGapDriverRow])
+		if err != nil {
+			return nil, err
+		}
+		card.LowestFunctions = lowestScorecardFunctions(card.Score, rows, scorecardLowestFunctions)
+	}
+
+	card.Previous, err = findPreviousDataCall(ctx, dataCall.DataCallID)
+	if errors.Is(err, ErrNoData) {
+		return card, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if card.PreviousScore, err = findScorecardAggregate(ctx, system.FismaSystemID, card.Previous.DataCallID); err != nil {
+		return nil, err
+	}
+	if card.Changes, err = FindScoreDiff(ctx, FindScoreDiffInput{
+		FismaSystemID:  &system.FismaSystemID,
+		FromDataCallID: &card.Previous.DataCallID,
+		ToDataCallID:   &dataCall.DataCallID,
+	}); err != nil {
+		return nil, err
+	}
+
+	return card, nil
+}
+
+// findScorecardAggregate is the system's aggregate with pillar scores in one
+// data call, or nil when it has none.
+func findScorecardAggregate(ctx context.Context, fismaSystemID, dataCallID int32) (*ScoreAggregate, error) {
+	includePillars := true
+	aggregates, err := FindScoresAggregate(ctx, FindScoresInput{
+		FismaSystemID:  &fismaSystemID,
+		DataCallID:     &dataCallID,
+		IncludePillars: &includePillars,
+	})
+	if err != nil || len(aggregates) == 0 {
+		return nil, err
+	}
+	return aggregates[0], nil
+}
+
+// lowestScorecardFunctions merges the per-pillar ranking of
+// buildScoreGapDriversSQL into the limit lowest-scoring functions of the
+// system, lowest first. Equal scores keep pillar order, then the order the
+// pillar ranked them in.
+func lowestScorecardFunctions(agg *ScoreAggregate, rows []*scoreGapDriverRow, limit int) []*ScorecardFunction {
+	pillars := map[int32]string{}
+	for _, ps := range agg.PillarScores {
+		pillars[ps.PillarID] = ps.Pillar
+	}
+
+	functions := make([]*ScorecardFunction, 0, len(rows))
+	for _, r := range rows {
+		if r.DataCallID != agg.DataCallID || r.FismaSystemID != agg.FismaSystemID {
+			continue
+		}
+		functions = append(functions, &ScorecardFunction{
+			PillarID: r.PillarID,
+			Pillar:   pillars[r.PillarID],
+			GapDriver: GapDriver{
+				FunctionID:  r.FunctionID,
+				Function:    r.Function,
+				Description: r.Description,
+				Score:       r.Score,
+				OptionName:  r.OptionName,
+			},
+		})
+	}
+
+	sort.SliceStable(functions, func(i, j int) bool {
+		if functions[i].Score != functions[j].Score {
+			return functions[i].Score < functions[j].Score
+		}
+		return functions[i].PillarID < functions[j].PillarID
+	})
+	if len(functions) > limit {
+		functions = functions[:limit]
+	}
+	return functions
+}
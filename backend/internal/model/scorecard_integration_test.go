@@ -0,0 +1,57 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFindScorecardIntegration gathers the scorecard of a scored system and
+// checks it restates the aggregate and the diff for the same data calls.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestFindScorecardIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	var fismaSystemID, dataCallID int32
+	require.NoError(t, conn.QueryRow(ctx,
+		"SELECT fismasystemid, datacallid FROM scores GROUP BY fismasystemid, datacallid ORDER BY COUNT(*) DESC LIMIT 1").
+		Scan(&fismaSystemID, &dataCallID))
+
+	card, err := FindScorecard(ctx, FindScorecardInput{FismaSystemID: fismaSystemID, DataCallID: &dataCallID})
+	require.NoError(t, err)
+	assert.Equal(t, fismaSystemID, card.System.FismaSystemID)
+	assert.Equal(t, dataCallID, card.DataCall.DataCallID)
+
+	aggregates, err := FindScoresAggregate(ctx, FindScoresInput{FismaSystemID: &fismaSystemID, DataCallID: &dataCallID})
+	require.NoError(t, err)
+	require.Len(t, aggregates, 1)
+	require.NotNil(t, card.Score)
+	assert.InDelta(t, aggregates[0].SystemScore, card.Score.SystemScore, scoreAggregateTolerance)
+	assert.NotEmpty(t, card.Score.PillarScores)
+	assert.LessOrEqual(t, len(card.LowestFunctions), scorecardLowestFunctions)
+
+	if card.Previous != nil {
+		diff, err := FindScoreDiff(ctx, FindScoreDiffInput{
+			FismaSystemID:  &fismaSystemID,
+			FromDataCallID: &card.Previous.DataCallID,
+			ToDataCallID:   &dataCallID,
+		})
+		require.NoError(t, err)
+		assert.Len(t, card.Changes, len(diff))
+	}
+
+	_, err = FindScorecard(ctx, FindScorecardInput{FismaSystemID: -1})
+	assert.ErrorIs(t, err, ErrNoData)
+}
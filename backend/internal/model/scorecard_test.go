@@ -0,0 +1,59 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargetTier(t *testing.T) {
+	tests := []struct {
+		name          string
+		stored        *string
+		want          string
+		wantDefaulted bool
+	}{
+		{"unset", nil, "Advanced", true},
+		{"set", stringPtr("Optimal"), "Optimal", false},
+		{"outside the vocabulary", stringPtr("Traditional"), "Advanced", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tier, defaulted := targetTier(tt.stored)
+			assert.Equal(t, tt.want, tier)
+			assert.Equal(t, tt.wantDefaulted, defaulted)
+		})
+	}
+}
+
+// TestLowestScorecardFunctions merges two pillars' rankings: lowest score
+// first, pillar order on a tie, the limit applied across pillars, and rows
+// for another system or data call ignored.
+func TestLowestScorecardFunctions(t *testing.T) {
+	agg := &ScoreAggregate{DataCallID: 4, FismaSystemID: 9, PillarScores: []*PillarScore{
+		{PillarID: 1, Pillar: "Identity"},
+		{PillarID: 2, Pillar: "Devices"},
+	}}
+	row := func(system, pillar, function int32, score float64) *scoreGapDriverRow {
+		name := "f"
+		return &scoreGapDriverRow{DataCallID: 4, FismaSystemID: system, PillarID: pillar, FunctionID: function, Function: &name, Score: score}
+	}
+	rows := []*scoreGapDriverRow{
+		row(9, 1, 10, 2), row(9, 1, 11, 3), row(9, 1, 12, 4),
+		row(9, 2, 20, 1), row(9, 2, 21, 2), row(9, 2, 22, 5),
+		row(8, 2, 30, 1),
+	}
+
+	got := lowestScorecardFunctions(agg, rows, 4)
+	require.Len(t, got, 4)
+	var ids []int32
+	for _, f := range got {
+		ids = append(ids, f.FunctionID)
+	}
+	assert.Equal(t, []int32{20, 10, 21, 11}, ids)
+	assert.Equal(t, "Devices", got[0].Pillar)
+	assert.Equal(t, "Identity", got[1].Pillar)
+
+	assert.Empty(t, lowestScorecardFunctions(agg, nil, 4))
+}
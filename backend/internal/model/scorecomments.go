@@ -0,0 +1,331 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxCommentLength bounds a comment body. Generous next to an answer's notes:
+// review threads quote the answer back.
+const maxCommentLength = 4000
+
+// ScoreComment is a review comment on one system's answer to one function in
+// one data call. Threads are one level deep: a top comment and its replies,
+// in the order written. Only the top comment carries the thread's resolved
+// state. Author.Role is the author's role when the comment was written.
+type ScoreComment struct {
+	CommentID     int32           `json:"commentid"`
+	FismaSystemID int32           `json:"fismasystemid"`
+	FunctionID    int32           `json:"functionid"`
+	DataCallID    int32           `json:"datacallid"`
+	ParentID      *int32          `json:"parentid"`
+	Body          string          `json:"body"`
+	Author        *AuditRef       `json:"author"`
+	CreatedAt     time.Time       `json:"createdat"`
+	Resolved      bool            `json:"resolved"`
+	ResolvedAt    *time.Time      `json:"resolvedat"`
+	ResolvedBy    *AuditRef       `json:"resolvedby"`
+	Replies       []*ScoreComment `json:"replies,omitempty"`
+}
+
+type FindScoreCommentsInput struct {
+	// ScoreID selects the comments anchored where the score is: its system,
+	// its answer's function, its data call.
+	ScoreID       *int32 `schema:"scoreid"`
+	FismaSystemID *int32 `schema:"fismasystemid"`
+	FunctionID    *int32 `schema:"functionid"`
+	DataCallID    *int32 `schema:"datacallid"`
+	// Resolved filters whole threads by their top comment's state.
+	Resolved *bool `schema:"resolved"`
+	// UserID restricts the comments to the requesting user's assigned systems
+	// (ISSO/ISSM tiers); set by the controller, schema:"-" so it is not
+	// bindable from the query.
+	UserID *string `schema:"-"`
+	OpDivScope
+}
+
+// ScoreCommentInput is the body that starts a thread or replies to one. A
+// thread is anchored by ScoreID or by FismaSystemID, FunctionID and
+// DataCallID together; a reply names ParentID only and takes its anchor from
+// the thread.
+type ScoreCommentInput struct {
+	ScoreID       *int32 `json:"scoreid"`
+	FismaSystemID *int32 `json:"fismasystemid"`
+	FunctionID    *int32 `json:"functionid"`
+	DataCallID    *int32 `json:"datacallid"`
+	ParentID      *int32 `json:"parentid"`
+	Body          string `json:"body"`
+}
+
+// ScoreCommentAnchor is where a new comment goes, resolved from its input by
+// ResolveScoreCommentAnchor so the caller can authorize it before writing.
+// ParentID is the thread's top comment, even when the input replied to a
+// reply.
+type ScoreCommentAnchor struct {
+	FismaSystemID int32
+	FunctionID    int32
+	DataCallID    int32
+	ParentID      *int32
+}
+
+var scoreCommentColumns = []string{
+	"c.commentid", "c.fismasystemid", "c.functionid", "c.datacallid", "c.parentid", "c.body", "c.createdat", "c.resolvedat",
+	"c.authorid", "c.authorrole", "author.fullname", "author.email",
+	"c.resolvedby", "resolver.fullname", "resolver.email", "resolver.role",
+}
+
+func scanScoreComment(row pgx.CollectableRow) (*ScoreComment, error) {
+	c := ScoreComment{}
+	var (
+		author                                                AuditRef
+		authorName, authorEmail                               *string
+		resolverID, resolverName, resolverEmail, resolverRole *string
+	)
+	err := row.Scan(&c.CommentID, &c.FismaSystemID, &c.FunctionID, &c.DataCallID, &c.ParentID, &c.Body, &c.CreatedAt, &c.ResolvedAt,
+		&author.UserID, &author.Role, &authorName, &authorEmail,
+		&resolverID, &resolverName, &resolverEmail, &resolverRole,
+	)
+	if err != nil {
+		return &c, err
+	}
+	author.Name = derefString(authorName)
+	author.Email = derefString(authorEmail)
+	c.Author = &author
+	c.Resolved = c.ResolvedAt != nil
+	if resolverID != nil {
+		c.ResolvedBy = &AuditRef{
+			UserID: *resolverID,
+			Name:   derefString(resolverName),
+			Email:  derefString(resolverEmail),
+			Role:   derefString(resolverRole),
+		}
+	}
+	return &c, nil
+}
+
+func scoreCommentsSelect() squirrel.SelectBuilder {
+	return stmntBuilder.
+		Select(scoreCommentColumns...).
+		From("scorecomments c").
+		LeftJoin("users author ON author.userid = c.authorid").
+		LeftJoin("users resolver ON resolver.userid = c.resolvedby")
+}
+
+// FindScoreComments returns the threads matching input, oldest first, each
+// top comment carrying its replies. Scoped like FindScores.
+func FindScoreComments(ctx context.Context, input FindScoreCommentsInput) ([]*ScoreComment, error) {
+	sqlb := scoreCommentsSelect().
+		// The thread's top comment: itself, or the reply's parent.
+		InnerJoin("scorecomments root ON root.commentid = COALESCE(c.parentid, c.commentid)").
+		OrderBy("c.createdat", "c.commentid")
+
+	if input.ScoreID != nil {
+		sqlb = sqlb.Where(`(c.fismasystemid, c.functionid, c.datacallid) IN (
+			SELECT s.fismasystemid, fo.functionid, s.datacallid
+			  FROM scores s
+			  JOIN functionoptions fo ON fo.functionoptionid = s.functionoptionid
+			 WHERE s.scoreid = ?)`, *input.ScoreID)
+	}
+	if input.FismaSystemID != nil {
+		sqlb = sqlb.Where("c.fismasystemid=?", *input.FismaSystemID)
+	}
+	if input.FunctionID != nil {
+		sqlb = sqlb.Where("c.functionid=?", *input.FunctionID)
+	}
+	if input.DataCallID != nil {
+		sqlb = sqlb.Where("c.datacallid=?", *input.DataCallID)
+	}
+	if input.Resolved != nil {
+		if *input.Resolved {
+			sqlb = sqlb.Where("root.resolvedat IS NOT NULL")
+		} else {
+			sqlb = sqlb.Where("root.resolvedat IS NULL")
+		}
+	}
+
+	if input.UserID != nil {
+		sqlb = sqlb.Where("c.fismasystemid IN (SELECT fismasystemid FROM users_fismasystems WHERE userid=?)", *input.UserID)
+	}
+	if f := input.OpDivWhere(squirrel.Expr("c.fismasystemid IN (SELECT fismasystemid FROM fismasystems WHERE opdiv_id = ANY(?))", input.OpDivIDs)); f != nil {
+		sqlb = sqlb.Where(f)
+	}
+
+	comments, err := query(ctx, sqlb, scanScoreComment)
+	if err != nil {
+		return nil, err
+	}
+	return threadScoreComments(comments), nil
+}
+
+// threadScoreComments nests replies under their top comments, keeping both
+// levels in the order given.
+func threadScoreComments(comments []*ScoreComment) []*ScoreComment {
+	threads := []*ScoreComment{}
+	roots := map[int32]*ScoreComment{}
+	for _, c := range comments {
+		if c.ParentID == nil {
+			roots[c.CommentID] = c
+			threads = append(threads, c)
+		}
+	}
+	for _, c := range comments {
+		if c.ParentID == nil {
+			continue
+		}
+		if root, ok := roots[*c.ParentID]; ok {
+			root.Replies = append(root.Replies, c)
+		}
+	}
+	return threads
+}
+
+// FindScoreCommentByID returns one comment without its replies. The caller
+// authorizes it.
+func FindScoreCommentByID(ctx context.Context, commentID int32) (*ScoreComment, error) {
+	comments, err := query(ctx, scoreCommentsSelect().Where("c.commentid=?", commentID), scanScoreComment)
+	if err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return nil, ErrNoData
+	}
+	return comments[0], nil
+}
+
+func (i ScoreCommentInput) validate() error {
+	err := InvalidInputError{data: map[string]any{}}
+
+	body := strings.TrimSpace(i.Body)
+	if body == "" {
+		err.data["body"] = "required"
+	} else if utf8.RuneCountInString(body) > maxCommentLength {
+		err.data["body"] = fmt.Sprintf("must be at most %d characters", maxCommentLength)
+	}
+
+	triple := i.FismaSystemID != nil || i.FunctionID != nil || i.DataCallID != nil
+	switch {
+	case i.ParentID != nil && (i.ScoreID != nil || triple):
+		err.data["parentid"] = "a reply takes its thread's anchor; do not also give scoreid, fismasystemid, functionid or datacallid"
+	case i.ParentID != nil:
+	case i.ScoreID != nil && triple:
+		err.data["scoreid"] = "give either scoreid or fismasystemid, functionid and datacallid, not both"
+	case i.ScoreID != nil:
+	case i.FismaSystemID == nil || i.FunctionID == nil || i.DataCallID == nil:
+		err.data["scoreid"] = "a new thread needs scoreid, or fismasystemid, functionid and datacallid"
+	}
+
+	if len(err.data) > 0 {
+		return &err
+	}
+	return nil
+}
+
+// ResolveScoreCommentAnchor validates input and works out where the comment
+// goes. A reply to a reply joins the same thread.
+func ResolveScoreCommentAnchor(ctx context.Context, input ScoreCommentInput) (*ScoreCommentAnchor, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case input.ParentID != nil:
+		parent, err := FindScoreCommentByID(ctx, *input.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		root := parent.CommentID
+		if parent.ParentID != nil {
+			root = *parent.ParentID
+		}
+		return &ScoreCommentAnchor{
+			FismaSystemID: parent.FismaSystemID,
+			FunctionID:    parent.FunctionID,
+			DataCallID:    parent.DataCallID,
+			ParentID:      &root,
+		}, nil
+
+	case input.ScoreID != nil:
+		anchors, err := query(ctx, stmntBuilder.
+			Select("s.fismasystemid", "fo.functionid", "s.datacallid").
+			From("scores s").
+			InnerJoin("functionoptions fo ON fo.functionoptionid = s.functionoptionid").
+			Where("s.scoreid=?", *input.ScoreID), pgx.RowToAddrOfStructByNameLax[ScoreCommentAnchor])
+		if err != nil {
+			return nil, err
+		}
+		if len(anchors) == 0 {
+			return nil, ErrNoData
+		}
+		return anchors[0], nil
+	}
+
+	return &ScoreCommentAnchor{
+		FismaSystemID: *input.FismaSystemID,
+		FunctionID:    *input.FunctionID,
+		DataCallID:    *input.DataCallID,
+	}, nil
+}
+
+// CreateScoreComment writes a comment at anchor as the current user, with
+// the role they hold now. A system, function or data call that does not
+// exist is ErrNoReference.
+func CreateScoreComment(ctx context.Context, anchor *ScoreCommentAnchor, body string) (*ScoreComment, error) {
+	user := UserFromContext(ctx)
+	if user == nil {
+		return nil, &InvalidInputError{data: map[string]any{"user": "required"}}
+	}
+
+	sqlb := stmntBuilder.
+		Insert("public.scorecomments").
+		Columns("fismasystemid", "functionid", "datacallid", "parentid", "body", "authorid", "authorrole").
+		Values(anchor.FismaSystemID, anchor.FunctionID, anchor.DataCallID, anchor.ParentID, strings.TrimSpace(body), user.UserID, user.Role).
+		Suffix("RETURNING commentid")
+
+	created, err := queryRow(ctx, sqlb, pgx.RowTo[int32])
+	if err != nil {
+		return nil, err
+	}
+	return FindScoreCommentByID(ctx, *created)
+}
+
+// SetScoreCommentResolved resolves or reopens the thread commentID starts.
+// Replies are not resolved on their own. Setting the state a thread already
+// has changes nothing, so the first resolver stays on record.
+func SetScoreCommentResolved(ctx context.Context, commentID int32, resolved bool) (*ScoreComment, error) {
+	user := UserFromContext(ctx)
+	if user == nil {
+		return nil, &InvalidInputError{data: map[string]any{"user": "required"}}
+	}
+
+	current, err := FindScoreCommentByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if current.ParentID != nil {
+		return nil, &InvalidInputError{data: map[string]any{
+			"commentid": "only the first comment of a thread is resolved",
+		}}
+	}
+	if current.Resolved == resolved {
+		return current, nil
+	}
+
+	sqlb := stmntBuilder.Update("public.scorecomments").Where("commentid=? AND parentid IS NULL", commentID)
+	if resolved {
+		sqlb = sqlb.Set("resolvedat", squirrel.Expr("NOW()")).Set("resolvedby", user.UserID)
+	} else {
+		sqlb = sqlb.Set("resolvedat", nil).Set("resolvedby", nil)
+	}
+	sqlb = sqlb.Suffix("RETURNING commentid")
+
+	if _, err := queryRow(ctx, sqlb, pgx.RowTo[int32]); err != nil {
+		return nil, err
+	}
+	return FindScoreCommentByID(ctx, commentID)
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScoreCommentsIntegration opens a thread on a score, replies to it and to
+// the reply, and resolves and reopens it, checking the thread stays one level
+// deep and the progress count follows its state.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestScoreCommentsIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	var userID string
+	var scoreID int32
+	require.NoError(t, conn.QueryRow(ctx, `
+SELECT (SELECT userid FROM users WHERE role='OWNER' AND deleted=false LIMIT 1),
+       (SELECT scoreid FROM scores ORDER BY scoreid LIMIT 1)`).Scan(&userID, &scoreID))
+
+	user, err := FindUserByID(ctx, userID)
+	require.NoError(t, err)
+	ctx = UserToContext(ctx, user)
+
+	anchor, err := ResolveScoreCommentAnchor(ctx, ScoreCommentInput{ScoreID: &scoreID, Body: "why a 3?"})
+	require.NoError(t, err)
+	require.Nil(t, anchor.ParentID)
+
+	top, err := CreateScoreComment(ctx, anchor, "  why a 3?  ")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Exec(context.Background(), "DELETE FROM scorecomments WHERE commentid = $1", top.CommentID)
+	})
+	assert.Equal(t, "why a 3?", top.Body, "bodies are trimmed")
+	assert.Equal(t, user.Role, top.Author.Role)
+
+	progressOpen := func() int32 {
+		t.Helper()
+		rows, err := FindScoreProgress(ctx, FindScoreProgressInput{DataCallID: &top.DataCallID, FismaSystemID: &top.FismaSystemID})
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		return rows[0].UnresolvedComments
+	}
+	open := progressOpen()
+	assert.GreaterOrEqual(t, open, int32(1))
+
+	reply := func(parentID int32) *ScoreComment {
+		t.Helper()
+		anchor, err := ResolveScoreCommentAnchor(ctx, ScoreCommentInput{ParentID: &parentID, Body: "see the evidence"})
+		require.NoError(t, err)
+		c, err := CreateScoreComment(ctx, anchor, "see the evidence")
+		require.NoError(t, err)
+		return c
+	}
+	first := reply(top.CommentID)
+	second := reply(first.CommentID)
+	require.NotNil(t, second.ParentID)
+	assert.Equal(t, top.CommentID, *second.ParentID, "a reply to a reply joins the thread")
+
+	threads, err := FindScoreComments(ctx, FindScoreCommentsInput{ScoreID: &scoreID})
+	require.NoError(t, err)
+	var found *ScoreComment
+	for _, th := range threads {
+		if th.CommentID == top.CommentID {
+			found = th
+		}
+	}
+	require.NotNil(t, found)
+	assert.Len(t, found.Replies, 2)
+
+	_, err = SetScoreCommentResolved(ctx, first.CommentID, true)
+	var invalid *InvalidInputError
+	require.ErrorAs(t, err, &invalid, "replies are not resolved on their own")
+
+	resolved, err := SetScoreCommentResolved(ctx, top.CommentID, true)
+	require.NoError(t, err)
+	assert.True(t, resolved.Resolved)
+	require.NotNil(t, resolved.ResolvedBy)
+	assert.Equal(t, userID, resolved.ResolvedBy.UserID)
+	assert.Equal(t, open-1, progressOpen())
+
+	reopened, err := SetScoreCommentResolved(ctx, top.CommentID, false)
+	require.NoError(t, err)
+	assert.False(t, reopened.Resolved)
+	assert.Nil(t, reopened.ResolvedBy)
+}
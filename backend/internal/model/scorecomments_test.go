@@ -0,0 +1,62 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreCommentInputValidate(t *testing.T) {
+	id := int32Ptr(1)
+	tests := []struct {
+		name      string
+		input     ScoreCommentInput
+		wantField string
+	}{
+		{"ok by score", ScoreCommentInput{ScoreID: id, Body: "why a 3?"}, ""},
+		{"ok by anchor", ScoreCommentInput{FismaSystemID: id, FunctionID: id, DataCallID: id, Body: "why a 3?"}, ""},
+		{"ok reply", ScoreCommentInput{ParentID: id, Body: "see the attachment"}, ""},
+		{"blank body", ScoreCommentInput{ScoreID: id, Body: "  "}, "body"},
+		{"long body", ScoreCommentInput{ScoreID: id, Body: strings.Repeat("x", maxCommentLength+1)}, "body"},
+		{"multibyte body at the limit", ScoreCommentInput{ScoreID: id, Body: strings.Repeat("é", maxCommentLength)}, ""},
+		{"no anchor", ScoreCommentInput{Body: "why?"}, "scoreid"},
+		{"partial anchor", ScoreCommentInput{FismaSystemID: id, DataCallID: id, Body: "why?"}, "scoreid"},
+		{"score and anchor", ScoreCommentInput{ScoreID: id, FunctionID: id, Body: "why?"}, "scoreid"},
+		{"reply with anchor", ScoreCommentInput{ParentID: id, ScoreID: id, Body: "why?"}, "parentid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.validate()
+			if tt.wantField == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var invalid *InvalidInputError
+			require.ErrorAs(t, err, &invalid)
+			assert.Contains(t, invalid.Data(), tt.wantField)
+		})
+	}
+}
+
+func TestThreadScoreComments(t *testing.T) {
+	comments := []*ScoreComment{
+		{CommentID: 1},
+		{CommentID: 2},
+		{CommentID: 3, ParentID: int32Ptr(1)},
+		{CommentID: 4, ParentID: int32Ptr(2)},
+		{CommentID: 5, ParentID: int32Ptr(1)},
+		// A reply whose thread was filtered out is dropped, not promoted.
+		{CommentID: 6, ParentID: int32Ptr(9)},
+	}
+
+	threads := threadScoreComments(comments)
+	require.Len(t, threads, 2)
+	assert.Equal(t, int32(1), threads[0].CommentID)
+	require.Len(t, threads[0].Replies, 2)
+	assert.Equal(t, int32(3), threads[0].Replies[0].CommentID)
+	assert.Equal(t, int32(5), threads[0].Replies[1].CommentID)
+	require.Len(t, threads[1].Replies, 1)
+	assert.Equal(t, int32(4), threads[1].Replies[0].CommentID)
+}
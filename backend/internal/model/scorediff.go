@@ -45,6 +45,9 @@ type FindScoreDiffInput struct {
 	FismaSystemID  *int32 `schema:"fismasystemid"`
 	FromDataCallID *int32 `schema:"from"`
 	ToDataCallID   *int32 `schema:"to"`
+	// Format asks for the report as a file (ReportFormatCSV or
+	// ReportFormatXLSX) rather than JSON; the controller writes it.
+	Format *string `schema:"format"`
 	// UserID restricts the diff to the requesting user's assigned systems
 	// (ISSO/ISSM tiers); set by the controller, schema:"-" so it is not bindable
 	// from the query.
@@ -129,6 +132,10 @@ func buildScoreDiffSQL(input FindScoreDiffInput) (string, []any) {
 	// questions.ordr - the ranks migration 0056 populates - costs one LEFT JOIN
 	// and matches FindAnswers and FindQuestionsByFismaSystem. functionid stays
 	// last as the tiebreaker for rows the migration could not rank.
+	//
+	// Labels and order come from the catalog version the To cycle pins, or the
+	// From cycle's when the function was answered only there, so rewording a
+	// question after both cycles closed does not change the diff between them.
 	sql := fmt.Sprintf(`
 WITH from_scores AS (%s),
      to_scores AS (%s)
@@ -144,8 +151,9 @@ SELECT
 FROM from_scores f
 FULL OUTER JOIN to_scores t
   ON f.fismasystemid = t.fismasystemid AND f.functionid = t.functionid
-LEFT JOIN functions fn ON fn.functionid = COALESCE(f.functionid, t.functionid)
-LEFT JOIN questions q  ON q.questionid  = fn.questionid
+LEFT JOIN catalogfunctions fn ON fn.catalogversionid = COALESCE(t.catalogversionid, f.catalogversionid)
+                             AND fn.functionid = COALESCE(f.functionid, t.functionid)
+LEFT JOIN catalogquestions q  ON q.catalogversionid = fn.catalogversionid AND q.questionid = fn.questionid
 LEFT JOIN pillars p    ON p.pillarid    = q.pillarid
 LEFT JOIN LATERAL (
     SELECT createdat, userid
@@ -198,9 +206,11 @@ func scoreCycleCTE(input FindScoreDiffInput, dataCallID int32, args *[]any, argN
 
 	return fmt.Sprintf(`
     SELECT s.scoreid, s.fismasystemid, fo.functionid, s.functionoptionid,
-           fo.score AS option_score, fo.optionname, s.notes, s.notes_is_ai_summary
+           fo.score AS option_score, fo.optionname, s.notes, s.notes_is_ai_summary,
+           dc.catalogversionid
     FROM scores s
     INNER JOIN functionoptions fo ON fo.functionoptionid = s.functionoptionid
+    INNER JOIN datacalls dc ON dc.datacallid = s.datacallid
     %s
     WHERE %s`, userJoin, strings.Join(conds, " AND "))
 }
@@ -70,6 +70,7 @@ func TestBuildScoreDiffSQL_Shape(t *testing.T) {
 	assert.Contains(t, sql, `regexp_replace(COALESCE(f.notes, ''), '\s+', ' ', 'g')`, "notes must be whitespace-normalized before comparison (nil/empty equal, spacing-only differences ignored)")
 	assert.Contains(t, sql, "resource = 'public.scores'", "attribution lateral must read score events")
 	assert.Contains(t, sql, "(payload->>'scoreid')::int = t.scoreid", "attribution must key on the later (To) write")
+	assert.Contains(t, sql, "fn.catalogversionid = COALESCE(t.catalogversionid, f.catalogversionid)", "labels come from the To cycle's pinned catalog, else the From cycle's")
 
 	// Two cycle filters, no scope: from=3 then to=4, in that order.
 	if assert.Len(t, args, 2, "expected one datacallid arg per cycle") {
@@ -0,0 +1,346 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// defaultTargetMaturityTier is the goal a system is measured against while its
+// target_maturity_tier is NULL, the same default the UI presents (#398).
+const defaultTargetMaturityTier = "Advanced"
+
+const (
+	defaultGapDrivers = 3
+	maxGapDrivers     = 20
+)
+
+// tierLadder lists the tiers in order with the lowest score, in hundredths,
+// that Tier() places in each. It must move with Tier(); the gap arithmetic
+// below is done in the same hundredths so a score Tier() calls Advanced is
+// never reported as short of Advanced.
+var tierLadder = []struct {
+	name  string
+	floor int
+}{
+	{"Not Assessed", 0},
+	{"Traditional", 101},
+	{"Initial", 210},
+	{"Advanced", 310},
+	{"Optimal", 410},
+}
+
+// Tiers returns the labels Tier can return, lowest first.
+func Tiers() []string {
+	names := make([]string, len(tierLadder))
+	for i, t := range tierLadder {
+		names[i] = t.name
+	}
+	return names
+}
+
+// tierStep returns the rung of tier on tierLadder, or -1 for an unknown name.
+func tierStep(tier string) int {
+	for i, t := range tierLadder {
+		if t.name == tier {
+			return i
+		}
+	}
+	return -1
+}
+
+type FindScoreGapsInput struct {
+	// DataCallID defaults to the latest data call (FindLatestDataCall).
+	DataCallID    *int32 `schema:"datacallid"`
+	FismaSystemID *int32 `schema:"fismasystemid"`
+	OpDivID       *int32 `schema:"opdiv_id"`
+	// Drivers is how many of the lowest-scoring functions to report per
+	// pillar short of target; defaultGapDrivers when omitted.
+	Drivers *int `schema:"drivers"`
+	// UserID restricts the analysis to the requesting user's assigned systems
+	// (ISSO/ISSM tiers); set by the controller, schema:"-" so it is not
+	// bindable from the query.
+	UserID *string `schema:"-"`
+	OpDivScope
+}
+
+func (i FindScoreGapsInput) validate() error {
+	if i.Drivers != nil && (*i.Drivers < 1 || *i.Drivers > maxGapDrivers) {
+		return &InvalidInputError{data: map[string]any{
+			"drivers": fmt.Sprintf("must be between 1 and %d", maxGapDrivers),
+		}}
+	}
+	return nil
+}
+
+// ScoreGap is how far one system sits from its target maturity tier in one
+// data call. TierGap counts tiers still to climb and PointsGap the score still
+// to gain, both 0 once the target is met. TargetTierDefaulted is true when the
+// system has no target of its own and is measured against Advanced.
+type ScoreGap struct {
+	DataCallID          int32        `json:"datacallid"`
+	FismaSystemID       int32        `json:"fismasystemid"`
+	FismaAcronym        string       `json:"fismaacronym"`
+	FismaName           string       `json:"fismaname"`
+	OpDivID             *int32       `json:"opdiv_id"`
+	TargetTier          string       `json:"target_tier"`
+	TargetTierDefaulted bool         `json:"target_tier_defaulted"`
+	SystemScore         float64      `json:"systemscore"`
+	SystemTier          string       `json:"systemtier"`
+	TierGap             int          `json:"tier_gap"`
+	PointsGap           float64      `json:"points_gap"`
+	Weighting           string       `json:"weighting"`
+	Pillars             []*PillarGap `json:"pillars"`
+}
+
+// PillarGap measures one pillar against the system's target tier. Drivers is
+// empty for a pillar already at or above it.
+type PillarGap struct {
+	PillarScore
+	TierGap   int          `json:"tier_gap"`
+	PointsGap float64      `json:"points_gap"`
+	Drivers   []*GapDriver `json:"drivers"`
+}
+
+// GapDriver is one of the lowest-scoring functions in a pillar, on the same
+// 1.0-5.0 scale as the pillar score. OptionName is nil for a function that has
+// not been answered, which scores the floor.
+type GapDriver struct {
+	FunctionID  int32   `json:"functionid"`
+	Function    *string `json:"function"`
+	Description *string `json:"description"`
+	Score       float64 `json:"score"`
+	OptionName  *string `json:"optionname"`
+}
+
+// scoreGapSystem is the fismasystems row a gap is labelled and targeted from.
+type scoreGapSystem struct {
+	FismaSystemID      int32   `db:"fismasystemid"`
+	FismaAcronym       string  `db:"fismaacronym"`
+	FismaName          string  `db:"fismaname"`
+	OpDivID            *int32  `db:"opdiv_id"`
+	TargetMaturityTier *string `db:"target_maturity_tier"`
+}
+
+// scoreGapDriverRow is one ranked function from buildScoreGapDriversSQL.
+type scoreGapDriverRow struct {
+	DataCallID    int32   `db:"datacallid"`
+	FismaSystemID int32   `db:"fismasystemid"`
+	PillarID      int32   `db:"pillarid"`
+	FunctionID    int32   `db:"functionid"`
+	Function      *string `db:"function"`
+	Description   *string `db:"description"`
+	Score         float64 `db:"score"`
+	OptionName    *string `db:"optionname"`
+}
+
+// FindScoreGaps reports, per system and pillar, the distance to the system's
+// target maturity tier in one data call. Scores come from the same query as
+// /scores/aggregate, under the same scope and pillar weighting, so a system's
+// current tier here always matches the one the aggregate shows.
+func FindScoreGaps(ctx context.Context, input FindScoreGapsInput) ([]*ScoreGap, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	drivers := defaultGapDrivers
+	if input.Drivers != nil {
+		drivers = *input.Drivers
+	}
+
+	if input.DataCallID == nil {
+		latest, err := FindLatestDataCall(ctx)
+		if errors.Is(err, ErrNoData) {
+			return []*ScoreGap{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		input.DataCallID = &latest.DataCallID
+	}
+
+	scoresInput := scoreGapsScoresInput(input)
+
+	pillarRows, err := findPillarScoresAll(ctx, scoresInput)
+	if err != nil {
+		return nil, err
+	}
+	aggregates := aggregatePillarRows(pillarRows, true)
+	if len(aggregates) == 0 {
+		return []*ScoreGap{}, nil
+	}
+
+	ids := make([]int32, 0, len(aggregates))
+	for _, agg := range aggregates {
+		ids = append(ids, agg.FismaSystemID)
+	}
+	systems, err := query(ctx, stmntBuilder.
+		Select("fismasystemid", "fismaacronym", "fismaname", "opdiv_id", "target_maturity_tier").
+		From("fismasystems").
+		Where(squirrel.Eq{"fismasystemid": ids}), pgx.RowToAddrOfStructByName[scoreGapSystem])
+	if err != nil {
+		return nil, err
+	}
+
+	sql, args := buildScoreGapDriversSQL(scoresInput, drivers)
+	driverRows, err := query(ctx, rawQuery{sql: sql, args: args}, pgx.RowToAddrOfStructByName[scoreGapDriverRow])
+	if err != nil {
+		return nil, err
+	}
+
+	return buildScoreGaps(aggregates, systems, driverRows), nil
+}
+
+// scoreGapsScoresInput carries the gap filters and scope over to the
+// FindScoresInput the pillar queries are built from, in the single-system
+// shape FindScoresAggregate uses.
+func scoreGapsScoresInput(input FindScoreGapsInput) FindScoresInput {
+	scoresInput := FindScoresInput{
+		DataCallID:    input.DataCallID,
+		FismaSystemID: input.FismaSystemID,
+		UserID:        input.UserID,
+		OpDivID:       input.OpDivID,
+		OpDivScope:    input.OpDivScope,
+	}
+	if scoresInput.FismaSystemID != nil {
+		scoresInput.FismaSystemIDs = []*int32{scoresInput.FismaSystemID}
+	}
+	return scoresInput
+}
+
+// buildScoreGapDriversSQL ranks each pillar's expected functions from lowest
+// score up and keeps the first limit of them. Functions are scored as the
+// pillar aggregation scores them - unanswered is 0, shifted to 1.0 - so the
+// drivers are exactly the terms pulling the pillar average down. Ties break
+// on questionnaire order. Names come from the data call's pinned catalog.
+func buildScoreGapDriversSQL(input FindScoresInput, limit int) (string, []any) {
+	expectedCTE, args := buildExpectedFunctionsCTE(input)
+	args = append(args, limit)
+
+	sql := fmt.Sprintf(`
+WITH %s,
+answers AS (
+    SELECT s.fismasystemid, s.datacallid, fo.functionid, fo.score, fo.optionname
+    FROM scores s
+    INNER JOIN functionoptions fo ON fo.functionoptionid = s.functionoptionid
+),
+ranked AS (
+    SELECT
+        e.datacallid,
+        e.fismasystemid,
+        e.pillarid,
+        e.functionid,
+        cf.function,
+        cf.description,
+        (COALESCE(a.score, 0) + 1.0)::float8 AS score,
+        a.optionname,
+        ROW_NUMBER() OVER (
+            PARTITION BY e.datacallid, e.fismasystemid, e.pillarid
+            ORDER BY COALESCE(a.score, 0), cf.ordr, e.functionid
+        ) AS rn
+    FROM expected e
+    INNER JOIN catalogfunctions cf ON cf.catalogversionid = e.catalogversionid AND cf.functionid = e.functionid
+    LEFT JOIN answers a
+      ON a.fismasystemid = e.fismasystemid
+     AND a.datacallid    = e.datacallid
+     AND a.functionid    = e.functionid
+)
+SELECT datacallid, fismasystemid, pillarid, functionid, function, description, score, optionname
+FROM ranked
+WHERE rn <= $%d
+ORDER BY datacallid, fismasystemid, pillarid, rn
+`, expectedCTE, len(args))
+
+	return sql, args
+}
+
+// buildScoreGaps measures each aggregate against its system's target and
+// attaches the drivers of every pillar that falls short. It does no score
+// math beyond the distance to a tier floor; the scores themselves are read
+// as the SQL computed them.
+func buildScoreGaps(aggregates []*ScoreAggregate, systems []*scoreGapSystem, drivers []*scoreGapDriverRow) []*ScoreGap {
+	systemByID := map[int32]*scoreGapSystem{}
+	for _, s := range systems {
+		systemByID[s.FismaSystemID] = s
+	}
+
+	type pillarKey struct {
+		dataCallID, fismaSystemID, pillarID int32
+	}
+	driversByPillar := map[pillarKey][]*GapDriver{}
+	for _, d := range drivers {
+		k := pillarKey{d.DataCallID, d.FismaSystemID, d.PillarID}
+		driversByPillar[k] = append(driversByPillar[k], &GapDriver{
+			FunctionID:  d.FunctionID,
+			Function:    d.Function,
+			Description: d.Description,
+			Score:       d.Score,
+			OptionName:  d.OptionName,
+		})
+	}
+
+	gaps := make([]*ScoreGap, 0, len(aggregates))
+	for _, agg := range aggregates {
+		gap := &ScoreGap{
+			DataCallID:          agg.DataCallID,
+			FismaSystemID:       agg.FismaSystemID,
+			TargetTier:          defaultTargetMaturityTier,
+			TargetTierDefaulted: true,
+			SystemScore:         agg.SystemScore,
+			SystemTier:          agg.SystemTier,
+			Weighting:           agg.Weighting,
+			Pillars:             []*PillarGap{},
+		}
+		if s, ok := systemByID[agg.FismaSystemID]; ok {
+			gap.FismaAcronym = s.FismaAcronym
+			gap.FismaName = s.FismaName
+			gap.OpDivID = s.OpDivID
+			gap.TargetTier, gap.TargetTierDefaulted = targetTier(s.TargetMaturityTier)
+		}
+		gap.TierGap, gap.PointsGap = tierGap(agg.SystemScore, gap.TargetTier)
+
+		for _, ps := range agg.PillarScores {
+			pg := &PillarGap{PillarScore: *ps, Drivers: []*GapDriver{}}
+			pg.TierGap, pg.PointsGap = tierGap(ps.Score, gap.TargetTier)
+			if pg.TierGap > 0 {
+				if d := driversByPillar[pillarKey{agg.DataCallID, agg.FismaSystemID, ps.PillarID}]; d != nil {
+					pg.Drivers = d
+				}
+			}
+			gap.Pillars = append(gap.Pillars, pg)
+		}
+
+		gaps = append(gaps, gap)
+	}
+	return gaps
+}
+
+// targetTier is the tier a system with the stored target maturity tier is
+// measured against, and whether that is the default. A value outside the
+// selectable vocabulary cannot be measured against, so it falls back to the
+// default like a NULL does.
+func targetTier(stored *string) (string, bool) {
+	if stored != nil && validTargetMaturityTiers[*stored] {
+		return *stored, false
+	}
+	return defaultTargetMaturityTier, true
+}
+
+// tierGap returns how many tiers score sits below target and how many points
+// it needs to reach target's floor, both 0 when it is already there.
+func tierGap(score float64, target string) (int, float64) {
+	step := tierStep(target)
+	if step < 0 {
+		return 0, 0
+	}
+	tiers := step - tierStep(Tier(score))
+	if tiers <= 0 {
+		return 0, 0
+	}
+	short := tierLadder[step].floor - int(math.Round(score*100))
+	return tiers, float64(short) / 100
+}
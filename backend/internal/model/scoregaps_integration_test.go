@@ -0,0 +1,66 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFindScoreGapsIntegration checks the gaps against the aggregate they are
+// measured from: every system the aggregate scores appears with the same
+// score and tier, and drivers appear only on pillars short of target.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestFindScoreGapsIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	var dataCallID int32
+	require.NoError(t, conn.QueryRow(ctx, `SELECT datacallid FROM scores LIMIT 1`).Scan(&dataCallID))
+
+	systems, err := FindScoresAggregate(ctx, FindScoresInput{DataCallID: &dataCallID})
+	require.NoError(t, err)
+	require.NotEmpty(t, systems)
+
+	gaps, err := FindScoreGaps(ctx, FindScoreGapsInput{DataCallID: &dataCallID})
+	require.NoError(t, err)
+	require.Len(t, gaps, len(systems))
+
+	for i, g := range gaps {
+		assert.Equal(t, systems[i].FismaSystemID, g.FismaSystemID)
+		assert.InDelta(t, systems[i].SystemScore, g.SystemScore, 1e-9)
+		assert.Equal(t, systems[i].SystemTier, g.SystemTier)
+		for _, p := range g.Pillars {
+			if p.TierGap == 0 {
+				assert.Empty(t, p.Drivers)
+				continue
+			}
+			assert.NotEmpty(t, p.Drivers, "system %d pillar %d is short of target", g.FismaSystemID, p.PillarID)
+			assert.LessOrEqual(t, len(p.Drivers), defaultGapDrivers)
+		}
+	}
+
+	// opdiv_id narrows to a subset of the same systems.
+	var opDivID int32
+	require.NoError(t, conn.QueryRow(ctx, `
+SELECT fs.opdiv_id FROM scores s
+INNER JOIN fismasystems fs ON fs.fismasystemid = s.fismasystemid
+WHERE s.datacallid = $1 AND fs.opdiv_id IS NOT NULL LIMIT 1`, dataCallID).Scan(&opDivID))
+	narrowed, err := FindScoreGaps(ctx, FindScoreGapsInput{DataCallID: &dataCallID, OpDivID: &opDivID})
+	require.NoError(t, err)
+	require.NotEmpty(t, narrowed)
+	for _, g := range narrowed {
+		require.NotNil(t, g.OpDivID)
+		assert.Equal(t, opDivID, *g.OpDivID)
+	}
+}
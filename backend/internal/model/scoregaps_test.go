@@ -0,0 +1,143 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTierLadder_MatchesTier pins tierLadder to Tier(): each floor is the
+// lowest score Tier() places in that tier, and one hundredth less falls below.
+func TestTierLadder_MatchesTier(t *testing.T) {
+	for i, rung := range tierLadder {
+		assert.Equal(t, rung.name, Tier(float64(rung.floor)/100), "floor of %s", rung.name)
+		if i > 0 {
+			assert.Equal(t, tierLadder[i-1].name, Tier(float64(rung.floor-1)/100), "just below %s", rung.name)
+		}
+	}
+}
+
+func TestTierGap(t *testing.T) {
+	tests := []struct {
+		name       string
+		score      float64
+		target     string
+		wantTiers  int
+		wantPoints float64
+	}{
+		{"at floor of target", 3.10, "Advanced", 0, 0},
+		{"above target", 4.50, "Advanced", 0, 0},
+		{"one tier short", 2.50, "Advanced", 1, 0.60},
+		{"two tiers short", 2.50, "Optimal", 2, 1.60},
+		{"not assessed to initial", 1.00, "Initial", 2, 1.10},
+		{"rounds like Tier", 3.095, "Advanced", 0, 0},
+		{"unknown target", 1.00, "Stellar", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiers, points := tierGap(tt.score, tt.target)
+			assert.Equal(t, tt.wantTiers, tiers)
+			assert.InDelta(t, tt.wantPoints, points, 1e-9)
+		})
+	}
+}
+
+func TestFindScoreGapsInput_Validate(t *testing.T) {
+	for _, n := range []int{0, -1, maxGapDrivers + 1} {
+		err := FindScoreGapsInput{Drivers: &n}.validate()
+		var invalid *InvalidInputError
+		require.ErrorAs(t, err, &invalid, "drivers=%d", n)
+		assert.Contains(t, invalid.Data(), "drivers")
+	}
+	for _, n := range []int{1, maxGapDrivers} {
+		assert.NoError(t, FindScoreGapsInput{Drivers: &n}.validate(), "drivers=%d", n)
+	}
+	assert.NoError(t, FindScoreGapsInput{}.validate())
+}
+
+func TestBuildScoreGaps(t *testing.T) {
+	aggregates := []*ScoreAggregate{
+		{
+			DataCallID: 5, FismaSystemID: 1, SystemScore: 2.60, SystemTier: "Initial", Weighting: ScoreWeightingEqual,
+			PillarScores: []*PillarScore{
+				{PillarID: 1, Pillar: "Identity", Score: 3.40, Tier: "Advanced"},
+				{PillarID: 2, Pillar: "Devices", Score: 1.80, Tier: "Traditional"},
+			},
+		},
+		{
+			DataCallID: 5, FismaSystemID: 2, SystemScore: 3.50, SystemTier: "Advanced", Weighting: ScoreWeightingEqual,
+			PillarScores: []*PillarScore{
+				{PillarID: 1, Pillar: "Identity", Score: 3.50, Tier: "Advanced"},
+			},
+		},
+	}
+	systems := []*scoreGapSystem{
+		{FismaSystemID: 1, FismaAcronym: "ONE", FismaName: "System One"},
+		{FismaSystemID: 2, FismaAcronym: "TWO", FismaName: "System Two", TargetMaturityTier: stringPtr("Optimal")},
+	}
+	drivers := []*scoreGapDriverRow{
+		{DataCallID: 5, FismaSystemID: 1, PillarID: 1, FunctionID: 10, Score: 2.0},
+		{DataCallID: 5, FismaSystemID: 1, PillarID: 2, FunctionID: 20, Score: 1.0},
+		{DataCallID: 5, FismaSystemID: 1, PillarID: 2, FunctionID: 21, Score: 2.0, OptionName: stringPtr("Traditional")},
+		{DataCallID: 5, FismaSystemID: 2, PillarID: 1, FunctionID: 10, Score: 3.0},
+	}
+
+	gaps := buildScoreGaps(aggregates, systems, drivers)
+	require.Len(t, gaps, 2)
+
+	one := gaps[0]
+	assert.Equal(t, "ONE", one.FismaAcronym)
+	assert.Equal(t, "Advanced", one.TargetTier)
+	assert.True(t, one.TargetTierDefaulted, "no stored target falls back to Advanced")
+	assert.Equal(t, 1, one.TierGap)
+	assert.InDelta(t, 0.50, one.PointsGap, 1e-9)
+	require.Len(t, one.Pillars, 2)
+	assert.Equal(t, 0, one.Pillars[0].TierGap)
+	assert.Empty(t, one.Pillars[0].Drivers, "a pillar at target has no drivers")
+	assert.NotNil(t, one.Pillars[0].Drivers)
+	assert.Equal(t, 2, one.Pillars[1].TierGap)
+	assert.InDelta(t, 1.30, one.Pillars[1].PointsGap, 1e-9)
+	require.Len(t, one.Pillars[1].Drivers, 2)
+	assert.Equal(t, int32(20), one.Pillars[1].Drivers[0].FunctionID)
+	assert.Nil(t, one.Pillars[1].Drivers[0].OptionName)
+
+	two := gaps[1]
+	assert.Equal(t, "Optimal", two.TargetTier)
+	assert.False(t, two.TargetTierDefaulted)
+	assert.Equal(t, 1, two.TierGap)
+	assert.InDelta(t, 0.60, two.PointsGap, 1e-9)
+	require.Len(t, two.Pillars[0].Drivers, 1)
+}
+
+func TestBuildScoreGapDriversSQL(t *testing.T) {
+	opDiv := int32(7)
+	dataCall := int32(5)
+	sql, args := buildScoreGapDriversSQL(FindScoresInput{DataCallID: &dataCall, OpDivID: &opDiv}, 3)
+
+	assert.Contains(t, sql, "fs.opdiv_id = $", "opdiv_id should narrow the expected CTE")
+	assert.Contains(t, args, opDiv)
+	assert.Contains(t, sql, "cf.catalogversionid = e.catalogversionid", "names come from the pinned catalog")
+	assert.Contains(t, sql, "ORDER BY COALESCE(a.score, 0), cf.ordr, e.functionid")
+	require.NotEmpty(t, args)
+	assert.Equal(t, 3, args[len(args)-1], "the driver limit binds last")
+	assert.Contains(t, sql, fmt.Sprintf("WHERE rn <= $%d", len(args)))
+}
+
+func TestScoreGapsScoresInput(t *testing.T) {
+	system := int32(1001)
+	opDiv := int32(7)
+	user := "u-1"
+	in := scoreGapsScoresInput(FindScoreGapsInput{
+		FismaSystemID: &system,
+		OpDivID:       &opDiv,
+		UserID:        &user,
+		OpDivScope:    OpDivScope{RestrictToOpDivIDs: true, OpDivIDs: []int32{7}},
+	})
+
+	assert.Equal(t, []*int32{&system}, in.FismaSystemIDs)
+	assert.Equal(t, &opDiv, in.OpDivID)
+	assert.Equal(t, &user, in.UserID)
+	assert.True(t, in.RestrictToOpDivIDs, "caller scope carries over")
+}
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ScoreHistory is one answer and every revision the events log records for it,
+// oldest first.
+type ScoreHistory struct {
+	Score     *Score           `json:"score"`
+	Revisions []*ScoreRevision `json:"revisions"`
+}
+
+// ScoreRevision is the answer as one write left it, read back from that write's
+// event payload. Changes lists the fields that differ from the previous
+// revision; the first revision has no predecessor and so no changes.
+//
+// Status is nil where the event did not record it - imports, and writes from
+// before migration 0048 added the column - and a nil status is never reported
+// as a change. ReviewNote is the reviewer's reason on a returned revision; a
+// later edit clears it.
+type ScoreRevision struct {
+	Action           string             `json:"action"`
+	CreatedAt        time.Time          `json:"createdat"`
+	EditedBy         *AuditRef          `json:"edited_by"`
+	FunctionOptionID *int32             `json:"functionoptionid"`
+	OptionName       *string            `json:"optionname"`
+	Notes            *string            `json:"notes"`
+	Status           *string            `json:"status"`
+	ReviewNote       *string            `json:"reviewnote"`
+	Changes          []ScoreFieldChange `json:"changes"`
+}
+
+// ScoreFieldChange is one field that moved between consecutive revisions.
+type ScoreFieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// FindScoreHistory returns the revisions of one score. The score itself is
+// read through FindScores with the caller's scope, so an answer the caller
+// could not list is ErrNoData here too rather than a history without a score.
+//
+// Revisions come from the write events Save and ImportScores record against
+// 'public.scores', matching the lateral join FindScores uses for the latest
+// edit, and the submitted, approved and returned events Review records. An
+// answer carried forward by the rollover has no event of its own until
+// someone touches it, so its history starts at that first edit.
+func FindScoreHistory(ctx context.Context, input FindScoresInput) (*ScoreHistory, error) {
+	scores, err := FindScores(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if len(scores) == 0 {
+		return nil, ErrNoData
+	}
+
+	revisions, err := query(ctx, rawQuery{sql: scoreHistorySQL, args: []any{
+		scores[0].ScoreID,
+		append([]string{eventActionCreated, eventActionUpdated, eventActionImported}, reviewEventActions...),
+	}}, scanScoreRevision)
+	if err != nil {
+		return nil, err
+	}
+	if revisions == nil {
+		revisions = []*ScoreRevision{}
+	}
+
+	return &ScoreHistory{Score: scores[0], Revisions: diffScoreRevisions(revisions)}, nil
+}
+
+// scoreHistorySQL lists the write and review events for $1 in the order they
+// happened. eventid breaks ties between events stamped in the same instant.
+// The option label is resolved from the live functionoptions table, which is
+// not versioned (see migration 0062).
+const scoreHistorySQL = `
+SELECT e.action, e.createdat,
+       (e.payload->>'functionoptionid')::int, fo.optionname,
+       e.payload->>'notes', e.payload->>'status', e.payload->>'reviewnote',
+       u.userid, u.fullname, u.email, u.role
+FROM events e
+LEFT JOIN functionoptions fo ON fo.functionoptionid = (e.payload->>'functionoptionid')::int
+LEFT JOIN users u ON u.userid = e.userid
+WHERE e.resource = 'public.scores'
+  AND (e.payload->>'scoreid')::int = $1
+  AND e.action = ANY($2)
+ORDER BY e.createdat, e.eventid
+`
+
+func scanScoreRevision(row pgx.CollectableRow) (*ScoreRevision, error) {
+	rev := ScoreRevision{}
+	var (
+		editorUserID *string
+		editorName   *string
+		editorEmail  *string
+		editorRole   *string
+	)
+	err := row.Scan(&rev.Action, &rev.CreatedAt,
+		&rev.FunctionOptionID, &rev.OptionName,
+		&rev.Notes, &rev.Status, &rev.ReviewNote,
+		&editorUserID, &editorName, &editorEmail, &editorRole,
+	)
+	if err != nil {
+		return &rev, err
+	}
+	// As in FindScores: an editor that no longer resolves through users is
+	// reported as absent rather than as a bare id.
+	if editorUserID != nil {
+		rev.EditedBy = &AuditRef{
+			UserID: *editorUserID,
+			Name:   derefString(editorName),
+			Email:  derefString(editorEmail),
+			Role:   derefString(editorRole),
+		}
+	}
+	return &rev, nil
+}
+
+// diffScoreRevisions fills in Changes on each revision against the one before
+// it. Notes compare with nil and "" as the same value, the rule
+// scoresEqualForUpdate applies to writes, so a revision never reports a change
+// the no-op guard would not have recorded.
+func diffScoreRevisions(revisions []*ScoreRevision) []*ScoreRevision {
+	for i, rev := range revisions {
+		rev.Changes = []ScoreFieldChange{}
+		if i == 0 {
+			continue
+		}
+		prev := revisions[i-1]
+
+		if derefInt32(prev.FunctionOptionID) != derefInt32(rev.FunctionOptionID) {
+			rev.Changes = append(rev.Changes, ScoreFieldChange{Field: "functionoptionid", From: prev.FunctionOptionID, To: rev.FunctionOptionID})
+		}
+		if derefString(prev.Notes) != derefString(rev.Notes) {
+			rev.Changes = append(rev.Changes, ScoreFieldChange{Field: "notes", From: prev.Notes, To: rev.Notes})
+		}
+		if prev.Status != nil && rev.Status != nil && *prev.Status != *rev.Status {
+			rev.Changes = append(rev.Changes, ScoreFieldChange{Field: "status", From: prev.Status, To: rev.Status})
+		}
+		if derefString(prev.ReviewNote) != derefString(rev.ReviewNote) {
+			rev.Changes = append(rev.Changes, ScoreFieldChange{Field: "reviewnote", From: prev.ReviewNote, To: rev.ReviewNote})
+		}
+	}
+	return revisions
+}
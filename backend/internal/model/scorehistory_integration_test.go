@@ -0,0 +1,120 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFindScoreHistoryIntegration pins the revision read: write events for a
+// score come back oldest first with the option, notes and editor each one
+// recorded, review events appear among them with the reviewer's note,
+// consecutive revisions report what changed, and a caller outside
+// the score's scope gets ErrNoData rather than its history.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestFindScoreHistoryIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	purgeIntegrationTestRows(t)
+	defer purgeIntegrationTestRows(t)
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	var dataCallID int32
+	err = conn.QueryRow(ctx, `
+		INSERT INTO datacalls (datacall, datecreated, deadline)
+		VALUES ($1, NOW(), NOW())
+		RETURNING datacallid
+	`, fmt.Sprintf("%shistory_%d", integrationTestPrefix, time.Now().UnixNano())).Scan(&dataCallID)
+	require.NoError(t, err)
+
+	// Two options of the same function, so the edit is a realistic re-answer.
+	var system, firstOption, secondOption int32
+	err = conn.QueryRow(ctx, `
+		SELECT fs.fismasystemid, fo1.functionoptionid, fo2.functionoptionid
+		  FROM fismasystems fs
+		  CROSS JOIN functionoptions fo1
+		  JOIN functionoptions fo2 ON fo2.functionid = fo1.functionid AND fo2.functionoptionid <> fo1.functionoptionid
+		 LIMIT 1
+	`).Scan(&system, &firstOption, &secondOption)
+	require.NoError(t, err, "need a function with two options")
+
+	var scoreID int32
+	err = conn.QueryRow(ctx, `
+		INSERT INTO scores (fismasystemid, functionoptionid, datacallid, status, notes)
+		VALUES ($1, $2, $3, 'done', 'second')
+		RETURNING scoreid
+	`, system, secondOption, dataCallID).Scan(&scoreID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		c, err := db.Conn(context.Background())
+		if err != nil {
+			return
+		}
+		defer c.Release()
+		_, _ = c.Exec(context.Background(),
+			`DELETE FROM events WHERE resource = 'public.scores' AND (payload->>'scoreid')::int = $1`, scoreID)
+	})
+
+	var ownerID string
+	err = conn.QueryRow(ctx, `SELECT userid FROM users WHERE role = 'OWNER' LIMIT 1`).Scan(&ownerID)
+	require.NoError(t, err)
+
+	insertEvent := func(action string, at time.Time, option int32, notes *string, status string, reviewNote *string) {
+		_, err := conn.Exec(ctx, `
+			INSERT INTO events (userid, action, resource, createdat, payload)
+			VALUES ($1, $2, 'public.scores', $3, $4)
+		`, ownerID, action, at, Score{
+			ScoreID: scoreID, FismaSystemID: system, DataCallID: dataCallID,
+			FunctionOptionID: option, Notes: notes, Status: status, ReviewNote: reviewNote,
+		})
+		require.NoError(t, err)
+	}
+	second := "second"
+	created := time.Now().Add(-time.Hour)
+	reason := "cite the ATO"
+	insertEvent(eventActionCreated, created, firstOption, nil, scoreStatusDone, nil)
+	insertEvent(eventActionUpdated, created.Add(time.Minute), secondOption, &second, scoreStatusDone, nil)
+	insertEvent(eventActionSubmitted, created.Add(2*time.Minute), secondOption, &second, scoreStatusSubmitted, nil)
+	insertEvent(eventActionReturned, created.Add(3*time.Minute), secondOption, &second, scoreStatusReturned, &reason)
+
+	history, err := FindScoreHistory(ctx, FindScoresInput{ScoreID: &scoreID})
+	require.NoError(t, err)
+	require.Equal(t, scoreID, history.Score.ScoreID)
+	require.Len(t, history.Revisions, 4)
+
+	first, edited := history.Revisions[0], history.Revisions[1]
+	assert.Equal(t, eventActionCreated, first.Action)
+	assert.Empty(t, first.Changes)
+	if assert.NotNil(t, first.EditedBy) {
+		assert.Equal(t, ownerID, first.EditedBy.UserID)
+	}
+	assert.Equal(t, eventActionUpdated, edited.Action)
+	assert.Equal(t, []string{"functionoptionid", "notes"}, changedFields(edited.Changes))
+
+	submitted, returned := history.Revisions[2], history.Revisions[3]
+	assert.Equal(t, eventActionSubmitted, submitted.Action)
+	assert.Equal(t, []string{"status"}, changedFields(submitted.Changes))
+	assert.Equal(t, eventActionReturned, returned.Action)
+	assert.Equal(t, []string{"status", "reviewnote"}, changedFields(returned.Changes))
+	if assert.NotNil(t, returned.ReviewNote) {
+		assert.Equal(t, reason, *returned.ReviewNote)
+	}
+
+	// An ISSO with no assignment to the system cannot read it.
+	unassigned := "00000000-0000-0000-0000-000000000000"
+	_, err = FindScoreHistory(ctx, FindScoresInput{ScoreID: &scoreID, UserID: &unassigned})
+	assert.ErrorIs(t, err, ErrNoData)
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func changedFields(changes []ScoreFieldChange) []string {
+	fields := []string{}
+	for _, c := range changes {
+		fields = append(fields, c.Field)
+	}
+	return fields
+}
+
+// TestDiffScoreRevisions pins which field movements a revision reports, in
+// particular the ones it must not: nil and "" notes are the same answer, and a
+// revision whose event did not record status cannot claim status changed.
+// A return and the edit that answers it move the review note.
+func TestDiffScoreRevisions(t *testing.T) {
+	revisions := diffScoreRevisions([]*ScoreRevision{
+		{Action: eventActionImported, FunctionOptionID: int32Ptr(1)},
+		{Action: eventActionUpdated, FunctionOptionID: int32Ptr(1), Notes: stringPtr(""), Status: stringPtr(scoreStatusDone)},
+		{Action: eventActionUpdated, FunctionOptionID: int32Ptr(2), Notes: stringPtr("why"), Status: stringPtr(scoreStatusDone)},
+		{Action: eventActionUpdated, FunctionOptionID: int32Ptr(2), Notes: stringPtr("why"), Status: stringPtr(scoreStatusNotStarted)},
+		{Action: eventActionReturned, FunctionOptionID: int32Ptr(2), Notes: stringPtr("why"), Status: stringPtr(scoreStatusReturned), ReviewNote: stringPtr("cite the ATO")},
+		{Action: eventActionUpdated, FunctionOptionID: int32Ptr(2), Notes: stringPtr("why"), Status: stringPtr(scoreStatusDone)},
+	})
+
+	assert.Empty(t, revisions[0].Changes, "the first revision has nothing to compare against")
+	assert.NotNil(t, revisions[0].Changes, "changes encode as [] rather than null")
+	assert.Empty(t, revisions[1].Changes, "empty notes over nil notes and a newly recorded status are not changes")
+	assert.Equal(t, []string{"functionoptionid", "notes"}, changedFields(revisions[2].Changes))
+	assert.Equal(t, []string{"status"}, changedFields(revisions[3].Changes))
+	assert.Equal(t, []string{"status", "reviewnote"}, changedFields(revisions[4].Changes), "a return reports its reason")
+	assert.Equal(t, []string{"status", "reviewnote"}, changedFields(revisions[5].Changes), "the edit after a return clears it")
+
+	change := revisions[2].Changes[0]
+	assert.Equal(t, int32(1), *change.From.(*int32))
+	assert.Equal(t, int32(2), *change.To.(*int32))
+}
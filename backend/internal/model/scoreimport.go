@@ -0,0 +1,359 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxScoreImportRows caps a single import. The largest historical load to date
+// (one full cycle for every CMS system) is a few thousand answers; the cap
+// exists so a mis-exported workbook cannot tie up a transaction for minutes.
+const maxScoreImportRows = 20000
+
+// ScoreImportRow is one answer to load. Row is the 1-based line or sheet row
+// it came from, carried through so every finding in the error report and every
+// provenance event points back at the source the operator can open.
+type ScoreImportRow struct {
+	Row              int     `json:"row"`
+	FismaSystemID    int32   `json:"fismasystemid"`
+	DataCallID       int32   `json:"datacallid"`
+	FunctionOptionID int32   `json:"functionoptionid"`
+	Notes            *string `json:"notes"`
+}
+
+// ScoreImportSource describes where an import came from. It is stamped onto
+// every 'imported' event so the audit log can answer "which load wrote this
+// row" without a side table.
+type ScoreImportSource struct {
+	Filename string `json:"filename"`
+	Format   string `json:"format"`
+}
+
+// ScoreImportRowError is one finding in the per-row report. Field names the
+// column at fault, or is empty when the finding is about the row as a whole
+// (a duplicate, an already-answered question).
+type ScoreImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ScoreImportInput is everything ImportScores needs. ParseErrors carries the
+// rows the file parser could not read at all (a non-numeric id, say); they are
+// reported alongside the validation findings so the caller gets one report
+// rather than fixing the file in two rounds.
+type ScoreImportInput struct {
+	Source      ScoreImportSource
+	Rows        []ScoreImportRow
+	ParseErrors []ScoreImportRowError
+}
+
+// ScoreImportResult is returned on a successful import.
+type ScoreImportResult struct {
+	Imported int               `json:"imported"`
+	Source   ScoreImportSource `json:"source"`
+}
+
+// scoreImportEvent is the payload of an 'imported' event. The answer fields
+// match the shape the seed data writes for its archived cycle
+// (_test_data_empire.sql), so lookupScoreAudit and the analyst queries read
+// both the same way; Source is what distinguishes one load from another.
+type scoreImportEvent struct {
+	ScoreID          int32                  `json:"scoreid"`
+	FismaSystemID    int32                  `json:"fismasystemid"`
+	FunctionOptionID int32                  `json:"functionoptionid"`
+	DataCallID       int32                  `json:"datacallid"`
+	Notes            *string                `json:"notes"`
+	Source           scoreImportEventSource `json:"source"`
+}
+
+type scoreImportEventSource struct {
+	ScoreImportSource
+	Row int `json:"row"`
+}
+
+// ImportScores bulk-loads answers recorded outside the application - history
+// from before the app existed, or answers collected out of band - as the
+// importer contract on Score.Save requires (ztmf#445): rows are inserted as
+// not_started and each one is attributed with an 'imported' event rather than
+// a 'created' one. An import is not a human answering the questionnaire this
+// cycle, so it must not move QuestionsUpdated or last-updated on the progress
+// endpoint, and it must not read as an edit on the diff.
+//
+// The load is all-or-nothing. Every row is checked first - ids present, notes
+// within the questionnaire's limit, each system, data call and function option
+// resolving, the option's function applying to the system's environment, and
+// the question not already answered in that cycle (scores has no uniqueness
+// constraint, so nothing else would stop a re-run from doubling the cycle) -
+// and any finding fails the whole file with an InvalidInputError whose "rows"
+// entry is the per-row report. Only a clean file is written, in one
+// transaction, with its events in the same transaction so a row can never land
+// without its provenance.
+//
+// The deadline is deliberately not checked: loading a closed cycle's history is
+// the main use, and the endpoint is restricted to unscoped write admins.
+func ImportScores(ctx context.Context, input ScoreImportInput) (*ScoreImportResult, error) {
+	user := UserFromContext(ctx)
+	if user == nil {
+		// Provenance is the point of this path; an import nobody can be held
+		// to is refused rather than written unattributed.
+		return nil, &InvalidInputError{data: map[string]any{"user": "required"}}
+	}
+
+	findings := append([]ScoreImportRowError{}, input.ParseErrors...)
+	findings = append(findings, validateScoreImportRows(input.Rows)...)
+	if len(input.Rows) == 0 && len(findings) == 0 {
+		return nil, &InvalidInputError{data: map[string]any{"file": "contains no answers"}}
+	}
+	if len(input.Rows) > maxScoreImportRows {
+		return nil, &InvalidInputError{data: map[string]any{"file": fmt.Sprintf("at most %d rows per import", maxScoreImportRows)}}
+	}
+	if len(findings) > 0 {
+		return nil, scoreImportReport(findings)
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, trapError(err)
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		conn.Release()
+		return nil, trapError(err)
+	}
+	// Same single-defer shape as ReactivateFismaSystem: Rollback is a no-op
+	// after Commit, and the connection is released after the tx resolves.
+	defer func() {
+		tx.Rollback(ctx)
+		conn.Release()
+	}()
+
+	checks, err := checkScoreImportReferences(ctx, tx, input.Rows)
+	if err != nil {
+		return nil, err
+	}
+	if findings := scoreImportReferenceErrors(checks); len(findings) > 0 {
+		return nil, scoreImportReport(findings)
+	}
+
+	batch := &pgx.Batch{}
+	for _, r := range input.Rows {
+		batch.Queue(`
+			INSERT INTO public.scores (fismasystemid, notes, functionoptionid, datacallid, status)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING scoreid`,
+			r.FismaSystemID, r.Notes, r.FunctionOptionID, r.DataCallID, scoreStatusNotStarted)
+	}
+	results := tx.SendBatch(ctx, batch)
+	scoreIDs := make([]int32, len(input.Rows))
+	for i := range input.Rows {
+		if err := results.QueryRow().Scan(&scoreIDs[i]); err != nil {
+			results.Close()
+			return nil, trapError(err)
+		}
+	}
+	if err := results.Close(); err != nil {
+		return nil, trapError(err)
+	}
+
+	// In this transaction rather than through insertEvent, which commits on
+	// its own connection: a provenance row that can commit without its score
+	// (or vice versa) defeats the purpose. Appended in one go, so the chain's
+	// lock is held for a few round trips however large the import.
+	events := make([]pendingEvent, len(input.Rows))
+	for i, r := range input.Rows {
+		events[i] = pendingEvent{user.UserID, eventActionImported, "public.scores", scoreImportEvent{
+			ScoreID:          scoreIDs[i],
+			FismaSystemID:    r.FismaSystemID,
+			FunctionOptionID: r.FunctionOptionID,
+			DataCallID:       r.DataCallID,
+			Notes:            r.Notes,
+			Source:           scoreImportEventSource{ScoreImportSource: input.Source, Row: r.Row},
+		}}
+	}
+	if err := insertEventsTx(ctx, tx, events); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, trapError(err)
+	}
+
+	log.Printf("SCORE_IMPORT user=%s file=%q format=%s rows=%d", user.UserID, input.Source.Filename, input.Source.Format, len(input.Rows))
+
+	refreshed := map[int32]bool{}
+	for _, r := range input.Rows {
+		if !refreshed[r.DataCallID] {
+			refreshed[r.DataCallID] = true
+			refreshScoreAggregates(ctx, FindScoresInput{DataCallID: &r.DataCallID})
+		}
+	}
+
+	return &ScoreImportResult{Imported: len(input.Rows), Source: input.Source}, nil
+}
+
+// scoreImportReport wraps findings as the 400 the controller returns, sorted by
+// row so the report reads top to bottom like the file.
+func scoreImportReport(findings []ScoreImportRowError) error {
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Row < findings[j].Row })
+	return &InvalidInputError{data: map[string]any{"rows": findings}}
+}
+
+// validateScoreImportRows runs the checks that need no database: required ids
+// and the notes limit Score.validate enforces on the questionnaire.
+func validateScoreImportRows(rows []ScoreImportRow) []ScoreImportRowError {
+	var findings []ScoreImportRowError
+	for _, r := range rows {
+		if !isValidIntID(r.FismaSystemID) {
+			findings = append(findings, ScoreImportRowError{Row: r.Row, Field: "fismasystemid", Message: "required"})
+		}
+		if !isValidIntID(r.DataCallID) {
+			findings = append(findings, ScoreImportRowError{Row: r.Row, Field: "datacallid", Message: "required"})
+		}
+		if !isValidIntID(r.FunctionOptionID) {
+			findings = append(findings, ScoreImportRowError{Row: r.Row, Field: "functionoptionid", Message: "required"})
+		}
+		if r.Notes != nil && utf8.RuneCountInString(*r.Notes) > 2000 {
+			findings = append(findings, ScoreImportRowError{Row: r.Row, Field: "notes", Message: ErrNotesTooLong.Error()})
+		}
+	}
+	return findings
+}
+
+// scoreImportCheck is what the database knows about one incoming row.
+// FunctionID is nil when the function option does not resolve.
+type scoreImportCheck struct {
+	Row           int
+	FismaSystemID int32
+	DataCallID    int32
+	SystemFound   bool
+	DataCallFound bool
+	DataCallDraft bool
+	OptionFound   bool
+	FunctionID    *int32
+	Applies       bool
+	Answered      bool
+}
+
+// checkScoreImportReferences resolves every row against the reference tables in
+// one round trip. Applies uses the same environment-to-function mapping the
+// progress and aggregate queries count against (datacenterenvironments.
+// scoring_key), in the catalog version the target data call pins, so an
+// answer that loads here is one those numbers will see.
+// Answered looks for an existing answer to the same function - not the same
+// option - because a question has one answer per system per cycle.
+func checkScoreImportReferences(ctx context.Context, tx pgx.Tx, rows []ScoreImportRow) ([]scoreImportCheck, error) {
+	nums := make([]int32, len(rows))
+	systems := make([]int32, len(rows))
+	dataCalls := make([]int32, len(rows))
+	options := make([]int32, len(rows))
+	for i, r := range rows {
+		nums[i] = int32(r.Row)
+		systems[i] = r.FismaSystemID
+		dataCalls[i] = r.DataCallID
+		options[i] = r.FunctionOptionID
+	}
+
+	dbRows, err := tx.Query(ctx, `
+		WITH incoming AS (
+			SELECT * FROM unnest($1::int[], $2::int[], $3::int[], $4::int[])
+			       AS t(rownum, fismasystemid, datacallid, functionoptionid)
+		)
+		SELECT i.rownum, i.fismasystemid, i.datacallid,
+		       fs.fismasystemid IS NOT NULL,
+		       dc.datacallid IS NOT NULL,
+		       COALESCE(dc.status = $5, FALSE),
+		       fo.functionoptionid IS NOT NULL,
+		       fo.functionid,
+		       f.functionid IS NOT NULL,
+		       EXISTS (
+		           SELECT 1
+		             FROM scores s
+		             JOIN functionoptions so ON so.functionoptionid = s.functionoptionid
+		            WHERE s.fismasystemid = i.fismasystemid
+		              AND s.datacallid = i.datacallid
+		              AND so.functionid = fo.functionid)
+		  FROM incoming i
+		  LEFT JOIN fismasystems fs             ON fs.fismasystemid = i.fismasystemid
+		  LEFT JOIN datacalls dc                ON dc.datacallid = i.datacallid
+		  LEFT JOIN functionoptions fo          ON fo.functionoptionid = i.functionoptionid
+		  LEFT JOIN datacenterenvironments dce  ON dce.datacenterenvironment = fs.datacenterenvironment
+		  LEFT JOIN catalogfunctions f          ON f.catalogversionid = dc.catalogversionid
+		                                       AND f.functionid = fo.functionid
+		                                       AND f.datacenterenvironment = dce.scoring_key
+		 ORDER BY i.rownum`,
+		nums, systems, dataCalls, options, DataCallDraft)
+	if err != nil {
+		return nil, trapError(err)
+	}
+
+	checks, err := pgx.CollectRows(dbRows, func(row pgx.CollectableRow) (scoreImportCheck, error) {
+		var c scoreImportCheck
+		var rownum int32
+		err := row.Scan(&rownum, &c.FismaSystemID, &c.DataCallID, &c.SystemFound, &c.DataCallFound,
+			&c.DataCallDraft, &c.OptionFound, &c.FunctionID, &c.Applies, &c.Answered)
+		c.Row = int(rownum)
+		return c, err
+	})
+	if err != nil {
+		return nil, trapError(err)
+	}
+	if len(checks) != len(rows) {
+		return nil, trapError(errors.New("score import: reference check returned a different row count"))
+	}
+	return checks, nil
+}
+
+// scoreImportReferenceErrors turns the reference checks into findings. Kept
+// pure so the rules - including the in-file duplicate check, which has to key
+// on the resolved function - are unit-testable without a database.
+func scoreImportReferenceErrors(checks []scoreImportCheck) []ScoreImportRowError {
+	type question struct {
+		fismaSystemID, dataCallID, functionID int32
+	}
+	firstRow := map[question]int{}
+
+	var findings []ScoreImportRowError
+	for _, c := range checks {
+		if !c.SystemFound {
+			findings = append(findings, ScoreImportRowError{Row: c.Row, Field: "fismasystemid", Message: "no such fisma system"})
+		}
+		if !c.DataCallFound {
+			findings = append(findings, ScoreImportRowError{Row: c.Row, Field: "datacallid", Message: "no such data call"})
+		}
+		// A draft has not been rolled over yet. Loading into it first would
+		// let the rollover that runs on open copy a second answer on top of
+		// the imported one.
+		if c.DataCallDraft {
+			findings = append(findings, ScoreImportRowError{Row: c.Row, Field: "datacallid", Message: "data call is a draft; open it before importing"})
+		}
+		if !c.OptionFound || c.FunctionID == nil {
+			findings = append(findings, ScoreImportRowError{Row: c.Row, Field: "functionoptionid", Message: "no such function option"})
+			continue
+		}
+		if !c.SystemFound || !c.DataCallFound {
+			continue
+		}
+		if !c.Applies {
+			findings = append(findings, ScoreImportRowError{Row: c.Row, Field: "functionoptionid", Message: "function does not apply to the system's data center environment"})
+		}
+		if c.Answered {
+			findings = append(findings, ScoreImportRowError{Row: c.Row, Message: "question already answered for this system in this data call"})
+		}
+		q := question{c.FismaSystemID, c.DataCallID, *c.FunctionID}
+		if first, ok := firstRow[q]; ok {
+			findings = append(findings, ScoreImportRowError{Row: c.Row, Message: fmt.Sprintf("duplicates row %d (same system, data call and function)", first)})
+			continue
+		}
+		firstRow[q] = c.Row
+	}
+	return findings
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestImportScoresIntegration pins the importer contract (ztmf#445) against
+// the real schema: an imported answer lands not_started, carries exactly one
+// 'imported' event naming its source file and row, counts as answered but not
+// updated on the progress endpoint, and a second load of the same question is
+// refused as already answered rather than doubling the cycle.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestImportScoresIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	purgeIntegrationTestRows(t)
+	defer purgeIntegrationTestRows(t)
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	var dataCallID int32
+	err = conn.QueryRow(ctx, `
+		INSERT INTO datacalls (datacall, datecreated, deadline)
+		VALUES ($1, NOW(), $2::timestamptz)
+		RETURNING datacallid
+	`, fmt.Sprintf("%simport_%d", integrationTestPrefix, time.Now().UnixNano()), time.Now().Add(-24*time.Hour)).Scan(&dataCallID)
+	require.NoError(t, err)
+
+	// An active system and an option whose function applies to its
+	// environment, so the importer's applicability check passes.
+	var fismaSystemID, functionOptionID int32
+	err = conn.QueryRow(ctx, `
+		SELECT fs.fismasystemid, fo.functionoptionid
+		FROM fismasystems fs
+		JOIN datacenterenvironments dce ON dce.datacenterenvironment = fs.datacenterenvironment
+		JOIN functions f ON f.datacenterenvironment = dce.scoring_key
+		JOIN questions q ON q.questionid = f.questionid
+		JOIN functionoptions fo ON fo.functionid = f.functionid
+		WHERE fs.decommissioned = FALSE
+		LIMIT 1
+	`).Scan(&fismaSystemID, &functionOptionID)
+	require.NoError(t, err, "need one active system with an applicable function option")
+
+	// Events carry an FK to users, so attribute the load to a real account.
+	var importerID string
+	err = conn.QueryRow(ctx, `SELECT userid FROM users WHERE role = 'OWNER' LIMIT 1`).Scan(&importerID)
+	require.NoError(t, err)
+	importerCtx := UserToContext(ctx, &User{UserID: importerID, Role: "OWNER"})
+
+	notes := "loaded from the archived workbook"
+	input := ScoreImportInput{
+		Source: ScoreImportSource{Filename: "fy2019.xlsx", Format: "xlsx"},
+		Rows: []ScoreImportRow{{
+			Row: 2, FismaSystemID: fismaSystemID, DataCallID: dataCallID,
+			FunctionOptionID: functionOptionID, Notes: &notes,
+		}},
+	}
+
+	result, err := ImportScores(importerCtx, input)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Imported)
+
+	var status, action, filename string
+	var row, events int
+	err = conn.QueryRow(ctx, `
+		SELECT s.status, e.action, e.payload->'source'->>'filename', (e.payload->'source'->>'row')::int,
+		       (SELECT COUNT(*) FROM events e2
+		         WHERE e2.resource = 'public.scores' AND (e2.payload->>'scoreid')::int = s.scoreid)
+		FROM scores s
+		JOIN events e ON e.resource = 'public.scores' AND (e.payload->>'scoreid')::int = s.scoreid
+		WHERE s.datacallid = $1 AND s.fismasystemid = $2
+	`, dataCallID, fismaSystemID).Scan(&status, &action, &filename, &row, &events)
+	require.NoError(t, err)
+	assert.Equal(t, "not_started", status, "an import is not a human answering this cycle")
+	assert.Equal(t, "imported", action)
+	assert.Equal(t, "fy2019.xlsx", filename)
+	assert.Equal(t, 2, row)
+	assert.Equal(t, 1, events, "one provenance event per imported row, and no created event")
+
+	progress, err := FindScoreProgress(ctx, FindScoreProgressInput{DataCallID: &dataCallID, FismaSystemID: &fismaSystemID})
+	require.NoError(t, err)
+	require.Len(t, progress, 1)
+	assert.Equal(t, int32(1), progress[0].QuestionsAnswered)
+	assert.Equal(t, int32(0), progress[0].QuestionsUpdated)
+	assert.Nil(t, progress[0].LastUpdatedAt, "imported provenance must not read as last-updated")
+
+	_, err = ImportScores(importerCtx, input)
+	var invalid *InvalidInputError
+	require.ErrorAs(t, err, &invalid, "re-running the same load must be refused")
+	assert.Equal(t, []ScoreImportRowError{
+		{Row: 2, Message: "question already answered for this system in this data call"},
+	}, invalid.Data()["rows"])
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValidateScoreImportRows pins the database-free checks: every id is
+// required and notes obey the questionnaire's 2000-character limit, so an
+// import cannot store a justification the UI would refuse to save.
+func TestValidateScoreImportRows(t *testing.T) {
+	long := strings.Repeat("x", 2001)
+	findings := validateScoreImportRows([]ScoreImportRow{
+		{Row: 2, FismaSystemID: 1, DataCallID: 1, FunctionOptionID: 1},
+		{Row: 3, FismaSystemID: 0, DataCallID: 1, FunctionOptionID: 0},
+		{Row: 4, FismaSystemID: 1, DataCallID: 1, FunctionOptionID: 1, Notes: &long},
+	})
+
+	assert.Equal(t, []ScoreImportRowError{
+		{Row: 3, Field: "fismasystemid", Message: "required"},
+		{Row: 3, Field: "functionoptionid", Message: "required"},
+		{Row: 4, Field: "notes", Message: ErrNotesTooLong.Error()},
+	}, findings)
+}
+
+// TestScoreImportReferenceErrors covers the reference rules. The duplicate
+// check keys on the resolved FUNCTION, not the option: two rows choosing
+// different options for the same question are still two answers to one
+// question, which is exactly the double-counting the importer must refuse.
+func TestScoreImportReferenceErrors(t *testing.T) {
+	fn := func(id int32) *int32 { return &id }
+	ok := scoreImportCheck{SystemFound: true, DataCallFound: true, OptionFound: true, Applies: true}
+
+	with := func(row int, fsid int32, functionID int32, mutate func(*scoreImportCheck)) scoreImportCheck {
+		c := ok
+		c.Row, c.FismaSystemID, c.DataCallID, c.FunctionID = row, fsid, 9, fn(functionID)
+		if mutate != nil {
+			mutate(&c)
+		}
+		return c
+	}
+
+	findings := scoreImportReferenceErrors([]scoreImportCheck{
+		with(2, 1, 100, nil),
+		with(3, 1, 100, nil), // same system, call and function as row 2
+		with(4, 2, 100, nil), // same function, different system: fine
+		with(5, 1, 101, func(c *scoreImportCheck) { c.SystemFound = false }),
+		with(6, 1, 102, func(c *scoreImportCheck) { c.OptionFound = false; c.FunctionID = nil }),
+		with(7, 1, 103, func(c *scoreImportCheck) { c.Applies = false }),
+		with(8, 1, 104, func(c *scoreImportCheck) { c.Answered = true }),
+		with(9, 1, 105, func(c *scoreImportCheck) { c.DataCallFound = false }),
+		with(10, 1, 106, func(c *scoreImportCheck) { c.DataCallDraft = true }),
+	})
+
+	assert.Equal(t, []ScoreImportRowError{
+		{Row: 3, Message: "duplicates row 2 (same system, data call and function)"},
+		{Row: 5, Field: "fismasystemid", Message: "no such fisma system"},
+		{Row: 6, Field: "functionoptionid", Message: "no such function option"},
+		{Row: 7, Field: "functionoptionid", Message: "function does not apply to the system's data center environment"},
+		{Row: 8, Message: "question already answered for this system in this data call"},
+		{Row: 9, Field: "datacallid", Message: "no such data call"},
+		{Row: 10, Field: "datacallid", Message: "data call is a draft; open it before importing"},
+	}, findings)
+}
+
+// TestScoreImportReportSortsByRow pins that parse findings (which arrive
+// first) and validation findings interleave by row in the report.
+func TestScoreImportReportSortsByRow(t *testing.T) {
+	err := scoreImportReport([]ScoreImportRowError{
+		{Row: 7, Field: "datacallid", Message: "not a whole number"},
+		{Row: 3, Field: "fismasystemid", Message: "required"},
+	})
+
+	var invalid *InvalidInputError
+	if assert.ErrorAs(t, err, &invalid) {
+		rows := invalid.Data()["rows"].([]ScoreImportRowError)
+		assert.Equal(t, 3, rows[0].Row)
+		assert.Equal(t, 7, rows[1].Row)
+	}
+}
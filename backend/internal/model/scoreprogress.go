@@ -45,12 +45,23 @@ type ScoreProgress struct {
 	// count here even though they do not count as QuestionsUpdated.
 	QuestionsAnswered int32 `json:"questionsanswered"`
 	// QuestionsUpdated is the number of distinct functions whose answer in
-	// this data call reached status = 'done' (genuinely saved this cycle) AND
-	// is still applicable to the system's current environment. Counted from
-	// the same applicable-function set as QuestionsExpected, so it can never
-	// exceed it. Read from the persisted scores.status column, not from the
-	// events audit log.
+	// this data call has left 'not_started' (genuinely saved this cycle, and
+	// possibly since taken into review) AND is still applicable to the
+	// system's current environment. Counted from the same applicable-function
+	// set as QuestionsExpected, so it can never exceed it. Read from the
+	// persisted scores.status column, not from the events audit log.
 	QuestionsUpdated int32 `json:"questionsupdated"`
+	// The answered functions by review state (scorereview.go). They partition
+	// QuestionsAnswered; everything but QuestionsNotStarted sums to
+	// QuestionsUpdated.
+	QuestionsNotStarted int32 `json:"questionsnotstarted"`
+	QuestionsDone       int32 `json:"questionsdone"`
+	QuestionsSubmitted  int32 `json:"questionssubmitted"`
+	QuestionsApproved   int32 `json:"questionsapproved"`
+	QuestionsReturned   int32 `json:"questionsreturned"`
+	// UnresolvedComments is the number of open review comment threads on the
+	// system in this data call (scorecomments.go).
+	UnresolvedComments int32 `json:"unresolvedcomments"`
 	// LastUpdatedAt is the most recent edit event across the system's answers
 	// in this data call; nil when nothing has been touched this cycle. Only
 	// in-app edit actions count, the same ones the status backfill treats as a
@@ -66,11 +77,18 @@ type ScoreProgress struct {
 	// since the start of the data call" - by name in the response, so a
 	// consumer rendering a boolean chip never touches the numeric fields.
 	UpdatedSinceStart bool `json:"updatedsincestart"`
+	// EffectiveDeadline is the deadline this system's ISSOs are held to: the
+	// data call's, moved later by an extension for the system or its OpDiv.
+	// Nil only when the data call does not exist.
+	EffectiveDeadline *time.Time `json:"effectivedeadline,omitempty"`
 }
 
 type FindScoreProgressInput struct {
 	DataCallID    *int32 `schema:"datacallid"`
 	FismaSystemID *int32 `schema:"fismasystemid"`
+	// Format asks for the report as a file (ReportFormatCSV or
+	// ReportFormatXLSX) rather than JSON; the controller writes it.
+	Format *string `schema:"format"`
 	// UserID restricts progress to the requesting user's assigned systems
 	// (ISSO/ISSM tiers); set by the controller, schema:"-" so it is not bindable
 	// from the query.
@@ -163,13 +181,16 @@ func buildScoreProgressSQL(input FindScoreProgressInput) (string, []any) {
 	// excluded), a valid pillar, matched to the system's environment through
 	// the datacenterenvironments scoring vocabulary. answered's and updated's
 	// sets are that same set further restricted to answered functions (and, for
-	// updated, to status = 'done'), so both are subsets of expected's - neither
+	// updated, to status <> 'not_started'), so both are subsets of expected's - neither
 	// questionsanswered nor questionsupdated can exceed questionsexpected even
 	// when a carried-over answer references a function that is no longer
 	// applicable after an environment change (that answer simply fails the
 	// applicability join). COUNT(DISTINCT) on all guards against fan-out from
 	// the environment mapping.
 	//
+	// The set is drawn from the catalog version the data call pins, so a
+	// closed call's denominator does not move when the live catalog grows.
+	//
 	// The reduced pillar scope belongs to that shared set, so it is rendered once
 	// and interpolated into BOTH CTEs. In expected's alone it would leave a
 	// numerator of 40 against a denominator of 25, reporting a false "Complete".
@@ -177,18 +198,19 @@ func buildScoreProgressSQL(input FindScoreProgressInput) (string, []any) {
 
 	sql := fmt.Sprintf(`
 WITH scoped_systems AS (
-    SELECT fs.fismasystemid, fs.datacenterenvironment
+    SELECT fs.fismasystemid, fs.datacenterenvironment, fs.opdiv_id
     FROM fismasystems fs
-    WHERE %s
+    WHERE %[1]s
 ),
 expected AS (
     SELECT ss.fismasystemid, COUNT(DISTINCT f.functionid) AS questionsexpected
     FROM scoped_systems ss
     INNER JOIN datacenterenvironments dce ON dce.datacenterenvironment = ss.datacenterenvironment
-    INNER JOIN functions f ON f.datacenterenvironment = dce.scoring_key
-    INNER JOIN questions q ON q.questionid = f.questionid
+    INNER JOIN catalogfunctions f ON f.catalogversionid = %[3]s
+                                 AND f.datacenterenvironment = dce.scoring_key
+    INNER JOIN catalogquestions q ON q.questionid = f.questionid AND q.catalogversionid = f.catalogversionid
     INNER JOIN pillars p ON p.pillarid = q.pillarid
-      AND %s
+      AND %[2]s
     GROUP BY ss.fismasystemid
 ),
 updated AS (
@@ -200,18 +222,24 @@ updated AS (
            -- fact written in the same statement as the answer, so a
            -- pre-populated row copied by copyPreviousScores (status =
            -- 'not_started') is excluded without consulting the events log.
-           COUNT(DISTINCT f.functionid) FILTER (WHERE s.status = 'done') AS questionsupdated,
+           -- Every review state was reached through 'done', so all count.
+           COUNT(DISTINCT f.functionid) FILTER (WHERE s.status <> 'not_started') AS questionsupdated,
+           COUNT(DISTINCT f.functionid) FILTER (WHERE s.status = 'not_started') AS questionsnotstarted,
+           COUNT(DISTINCT f.functionid) FILTER (WHERE s.status = 'done') AS questionsdone,
+           COUNT(DISTINCT f.functionid) FILTER (WHERE s.status = 'submitted') AS questionssubmitted,
+           COUNT(DISTINCT f.functionid) FILTER (WHERE s.status = 'approved') AS questionsapproved,
+           COUNT(DISTINCT f.functionid) FILTER (WHERE s.status = 'returned_for_revision') AS questionsreturned,
            MAX(le.createdat) AS lastupdatedat -- newest across the system's rows; the lateral below is per-row
     FROM scoped_systems ss
-    INNER JOIN scores s ON s.fismasystemid = ss.fismasystemid AND s.datacallid = $%d
+    INNER JOIN scores s ON s.fismasystemid = ss.fismasystemid AND s.datacallid = $%[4]d
     INNER JOIN functionoptions fo ON fo.functionoptionid = s.functionoptionid
-    INNER JOIN functions f ON f.functionid = fo.functionid
+    INNER JOIN catalogfunctions f ON f.catalogversionid = %[3]s AND f.functionid = fo.functionid
     INNER JOIN datacenterenvironments dce ON dce.datacenterenvironment = ss.datacenterenvironment
                                          AND dce.scoring_key = f.datacenterenvironment
-    INNER JOIN questions q ON q.questionid = f.questionid
+    INNER JOIN catalogquestions q ON q.questionid = f.questionid AND q.catalogversionid = f.catalogversionid
     INNER JOIN pillars p ON p.pillarid = q.pillarid
       -- Identical to expected's; see the note above the query.
-      AND %s
+      AND %[2]s
     -- One newest in-app edit per score row (LIMIT 1 keeps the lateral on the
     -- index fast path); the outer MAX then picks the newest across the
     -- system's rows. LEFT now (not the old filtering INNER): it feeds only
@@ -231,17 +259,33 @@ updated AS (
         LIMIT 1
     ) le ON TRUE
     GROUP BY ss.fismasystemid
+),
+comments AS (
+    SELECT c.fismasystemid, COUNT(*) AS unresolvedcomments
+    FROM scoped_systems ss
+    INNER JOIN scorecomments c ON c.fismasystemid = ss.fismasystemid
+    WHERE c.datacallid = $%[4]d AND c.parentid IS NULL AND c.resolvedat IS NULL
+    GROUP BY c.fismasystemid
 )
 SELECT ss.fismasystemid,
        COALESCE(ex.questionsexpected, 0) AS questionsexpected,
        COALESCE(u.questionsanswered, 0) AS questionsanswered,
        COALESCE(u.questionsupdated, 0) AS questionsupdated,
-       u.lastupdatedat
+       COALESCE(u.questionsnotstarted, 0) AS questionsnotstarted,
+       COALESCE(u.questionsdone, 0) AS questionsdone,
+       COALESCE(u.questionssubmitted, 0) AS questionssubmitted,
+       COALESCE(u.questionsapproved, 0) AS questionsapproved,
+       COALESCE(u.questionsreturned, 0) AS questionsreturned,
+       COALESCE(cm.unresolvedcomments, 0) AS unresolvedcomments,
+       u.lastupdatedat,
+       %[5]s AS effectivedeadline
 FROM scoped_systems ss
 LEFT JOIN expected ex ON ex.fismasystemid = ss.fismasystemid
 LEFT JOIN updated u ON u.fismasystemid = ss.fismasystemid
+LEFT JOIN comments cm ON cm.fismasystemid = ss.fismasystemid
 ORDER BY ss.fismasystemid
-`, strings.Join(conds, " AND "), pillarScope, dataCallArg, pillarScope)
+`, strings.Join(conds, " AND "), pillarScope, pinnedCatalogSQL(fmt.Sprintf("$%d", dataCallArg)), dataCallArg,
+		effectiveDeadlineSQL(fmt.Sprintf("$%d", dataCallArg), "ss.fismasystemid", "ss.opdiv_id"))
 
 	return sql, args
 }
@@ -249,7 +293,10 @@ ORDER BY ss.fismasystemid
 func scanScoreProgress(row pgx.CollectableRow) (*ScoreProgress, error) {
 	var p ScoreProgress
 
-	if err := row.Scan(&p.FismaSystemID, &p.QuestionsExpected, &p.QuestionsAnswered, &p.QuestionsUpdated, &p.LastUpdatedAt); err != nil {
+	if err := row.Scan(&p.FismaSystemID, &p.QuestionsExpected, &p.QuestionsAnswered, &p.QuestionsUpdated,
+		&p.QuestionsNotStarted, &p.QuestionsDone, &p.QuestionsSubmitted, &p.QuestionsApproved, &p.QuestionsReturned,
+		&p.UnresolvedComments,
+		&p.LastUpdatedAt, &p.EffectiveDeadline); err != nil {
 		return nil, err
 	}
 
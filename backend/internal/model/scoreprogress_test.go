@@ -42,7 +42,7 @@ func TestFindScoreProgressInputValidate(t *testing.T) {
 //     a carried-over answer to a now-inapplicable function from inflating the
 //     numerator past 100%;
 //   - the updated count filters on the persisted scores.status column
-//     (status = 'done'), excluding pre-populated rows, rather than requiring
+//     (status <> 'not_started'), excluding pre-populated rows, rather than requiring
 //     an edit event; the events lateral is now LEFT and feeds only
 //     lastupdatedat;
 //   - the SaaS pillar scope appears in both halves, never one;
@@ -57,12 +57,16 @@ func TestBuildScoreProgressSQL_Shape(t *testing.T) {
 	// Both halves resolve applicability through the same canonical join chain.
 	assert.Contains(t, sql, "dce.datacenterenvironment = ss.datacenterenvironment", "environment maps into the scoring vocabulary")
 	assert.Contains(t, sql, "f.datacenterenvironment = dce.scoring_key", "functions match on the scoring key")
-	assert.Contains(t, sql, "INNER JOIN questions q ON q.questionid = f.questionid", "orphan functions (no question) must be excluded, matching the questionnaire")
+	assert.Contains(t, sql, "INNER JOIN catalogquestions q ON q.questionid = f.questionid", "orphan functions (no question) must be excluded, matching the questionnaire")
+	assert.Equal(t, 2, strings.Count(sql, "f.catalogversionid = (SELECT catalogversionid FROM datacalls WHERE datacallid = $1)"), "both halves read the catalog version the data call pins")
 	assert.Contains(t, sql, "INNER JOIN pillars p ON p.pillarid = q.pillarid", "applicability mirrors FindQuestionsByFismaSystem")
 	// updated re-checks applicability against the system's CURRENT environment.
 	assert.Contains(t, sql, "dce.scoring_key = f.datacenterenvironment", "an answered function must still be applicable to the system's current environment")
-	assert.Equal(t, 3, strings.Count(sql, "COUNT(DISTINCT f.functionid)"), "expected, answered, and updated all count distinct applicable functions from the same set")
-	assert.Contains(t, sql, "FILTER (WHERE s.status = 'done')", "updated is the answered set filtered to genuinely-saved rows via the persisted status column")
+	assert.Equal(t, 8, strings.Count(sql, "COUNT(DISTINCT f.functionid)"), "expected, answered, updated and the per-state counts all count distinct applicable functions from the same set")
+	assert.Contains(t, sql, "FILTER (WHERE s.status <> 'not_started') AS questionsupdated", "updated is the answered set filtered to genuinely-saved rows via the persisted status column; review states were all reached through done")
+	for _, status := range []string{scoreStatusNotStarted, scoreStatusDone, scoreStatusSubmitted, scoreStatusApproved, scoreStatusReturned} {
+		assert.Contains(t, sql, "FILTER (WHERE s.status = '"+status+"')", "each review state is counted")
+	}
 	assert.Contains(t, sql, "LEFT JOIN LATERAL", "the events lateral is now LEFT (audit timeline only), not the filter for the count")
 	assert.Contains(t, sql, "resource = 'public.scores'", "the lateral must read score events for lastupdatedat")
 	assert.Contains(t, sql, "action IN ('created', 'updated')", "lastupdatedat reads only in-app edits, the same actions the status backfill (0048) counts - imported provenance must not surface as an update")
@@ -71,6 +75,8 @@ func TestBuildScoreProgressSQL_Shape(t *testing.T) {
 	assert.Contains(t, sql, "COALESCE(u.questionsanswered, 0)", "zero-activity systems report 0 answered, not NULL")
 	assert.Contains(t, sql, "COALESCE(u.questionsupdated, 0)", "zero-activity systems report 0 updated, not NULL")
 	assert.Contains(t, sql, "COALESCE(ex.questionsexpected, 0)", "unmapped-environment systems report 0 expected, not NULL")
+	assert.Contains(t, sql, "c.parentid IS NULL AND c.resolvedat IS NULL", "open threads are counted once, by their first comment")
+	assert.Contains(t, sql, "COALESCE(cm.unresolvedcomments, 0)", "systems without comments report 0 open threads, not NULL")
 
 	// Both count halves, never one: in expected's alone a SaaS denominator of 25
 	// would face a numerator of 40, reporting a false "Complete" on a closed call.
@@ -83,7 +89,11 @@ func TestBuildScoreProgressSQL_Shape(t *testing.T) {
 	assert.NotContains(t, sql, "tdc.datacallid >=",
 		"the cycle comparison must be on deadline, not datacallid - ids are not chronological")
 
-	// The pillar scope adds no bind parameters.
+	// Each row carries the system's effective deadline, resolved against both
+	// the system's own extension and its OpDiv's.
+	assert.Contains(t, sql, "ede.fismasystemid = ss.fismasystemid OR ede.opdiv_id = ss.opdiv_id")
+
+	// Neither the pillar scope nor the deadline adds bind parameters.
 	assert.Equal(t, []any{int32(4)}, args)
 }
 
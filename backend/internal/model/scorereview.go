@@ -0,0 +1,183 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// Review actions, as named in the transition endpoints.
+const (
+	ScoreReviewSubmit  = "submit"
+	ScoreReviewApprove = "approve"
+	ScoreReviewReturn  = "return"
+)
+
+// maxReviewNoteLength matches the limit on an answer's own notes.
+const maxReviewNoteLength = 2000
+
+// scoreReviewTransition is one action of the review workflow: the states it
+// may start from, the state it leaves, and the event that records it.
+type scoreReviewTransition struct {
+	from  []string
+	to    string
+	event string
+}
+
+// scoreReviewTransitions is the review state machine. A returned answer is not
+// resubmitted directly: it is saved or confirmed back to 'done' first, so the
+// revision the reviewer asked for is an attributable write of its own.
+var scoreReviewTransitions = map[string]scoreReviewTransition{
+	ScoreReviewSubmit:  {from: []string{scoreStatusDone}, to: scoreStatusSubmitted, event: eventActionSubmitted},
+	ScoreReviewApprove: {from: []string{scoreStatusSubmitted}, to: scoreStatusApproved, event: eventActionApproved},
+	ScoreReviewReturn:  {from: []string{scoreStatusSubmitted, scoreStatusApproved}, to: scoreStatusReturned, event: eventActionReturned},
+}
+
+// ScoreReviewInput is the body of a review transition. Reason is required to
+// return an answer and not accepted otherwise.
+type ScoreReviewInput struct {
+	Reason *string `json:"reason"`
+}
+
+// validateScoreReview checks action against the answer's current status and
+// returns the transition and the note to store.
+func validateScoreReview(action, status string, input ScoreReviewInput) (scoreReviewTransition, *string, error) {
+	err := InvalidInputError{data: map[string]any{}}
+
+	t, ok := scoreReviewTransitions[action]
+	if !ok {
+		err.data["action"] = fmt.Sprintf("%q is not a review action", action)
+		return t, nil, &err
+	}
+
+	var note *string
+	if input.Reason != nil {
+		reason := strings.TrimSpace(*input.Reason)
+		if reason != "" {
+			note = &reason
+		}
+	}
+	switch {
+	case action == ScoreReviewReturn && note == nil:
+		err.data["reason"] = "required to return an answer"
+	case action != ScoreReviewReturn && note != nil:
+		err.data["reason"] = "only accepted when returning an answer"
+	case note != nil && utf8.RuneCountInString(*note) > maxReviewNoteLength:
+		err.data["reason"] = fmt.Sprintf("must be at most %d characters", maxReviewNoteLength)
+	}
+
+	if !t.allows(status) {
+		err.data["status"] = fmt.Sprintf("cannot %s an answer that is %s", action, status)
+	}
+
+	if len(err.data) > 0 {
+		return t, nil, &err
+	}
+	return t, note, nil
+}
+
+func (t scoreReviewTransition) allows(status string) bool {
+	for _, from := range t.from {
+		if status == from {
+			return true
+		}
+	}
+	return false
+}
+
+// Review moves the answer through the review workflow. Submitting is an
+// answer write and keeps to the data call's deadline; approving and returning
+// happen after it, so only the call's lifecycle state applies (a closed call
+// takes reviews from admins only).
+//
+// The status change and its event commit together. The event carries the
+// answer as reviewed, with the reviewer's note on a return, under its own
+// action, so the review does not displace the answer's last editor.
+//
+// As with Confirm, the receiver must be a row loaded by FindScoreByID, and
+// the caller authorizes the reviewer.
+func (s *Score) Review(ctx context.Context, action string, input ScoreReviewInput) (*Score, error) {
+	user := UserFromContext(ctx)
+	if user == nil {
+		return nil, &InvalidInputError{data: map[string]any{"user": "required"}}
+	}
+
+	t, note, err := validateScoreReview(action, s.Status, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if action == ScoreReviewSubmit {
+		err = s.validateDeadline(ctx)
+	} else {
+		var dataCall *DataCall
+		if dataCall, err = FindDataCallByID(ctx, s.DataCallID); err == nil {
+			err = dataCall.checkState(user)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, trapError(err)
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		conn.Release()
+		return nil, trapError(err)
+	}
+	defer func() {
+		tx.Rollback(ctx)
+		conn.Release()
+	}()
+
+	// The status predicate makes the transition compare-and-set: a concurrent
+	// review that moved the row first leaves nothing to update.
+	rows, err := tx.Query(ctx, `
+		UPDATE scores SET status = $2, reviewnote = $3
+		 WHERE scoreid = $1 AND status = ANY($4)
+		RETURNING scoreid, fismasystemid, EXTRACT(EPOCH FROM datecalculated) as datecalculated, notes, notes_is_ai_summary, functionoptionid, datacallid, status, reviewnote`,
+		s.ScoreID, t.to, note, t.from)
+	if err != nil {
+		return nil, trapError(err)
+	}
+	reviewed, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByNameLax[Score])
+	if errors.Is(err, pgx.ErrNoRows) {
+		current, findErr := FindScoreByID(ctx, s.ScoreID)
+		if findErr != nil {
+			return nil, findErr
+		}
+		_, _, err = validateScoreReview(action, current.Status, input)
+		if err == nil {
+			err = ErrNoData
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, trapError(err)
+	}
+
+	err = insertEventTx(ctx, tx,
+		user.UserID, t.event, "public.scores", reviewed)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, trapError(err)
+	}
+
+	if at, by := lookupScoreAudit(ctx, reviewed.ScoreID); at != nil && by != nil {
+		reviewed.LastEditedAt = at
+		reviewed.LastEditedBy = by
+	}
+	return reviewed, nil
+}
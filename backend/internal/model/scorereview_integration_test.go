@@ -0,0 +1,81 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScoreReviewIntegration walks one answer through submit, approve, return
+// and confirm, checking each step's event and that the review never displaces
+// the answer's last editor.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestScoreReviewIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	var userID string
+	var scoreID int32
+	require.NoError(t, conn.QueryRow(ctx, `
+SELECT (SELECT userid FROM users WHERE role='OWNER' AND deleted=false LIMIT 1),
+       (SELECT s.scoreid FROM scores s JOIN datacalls dc ON dc.datacallid = s.datacallid
+         WHERE s.status = 'done' AND dc.status IN ('open', 'closed') ORDER BY s.scoreid LIMIT 1)`).Scan(&userID, &scoreID))
+	t.Cleanup(func() {
+		conn.Exec(context.Background(), "UPDATE scores SET status = 'done', reviewnote = NULL WHERE scoreid = $1", scoreID)
+	})
+
+	user, err := FindUserByID(ctx, userID)
+	require.NoError(t, err)
+	ctx = UserToContext(ctx, user)
+
+	before, err := FindScores(ctx, FindScoresInput{ScoreID: &scoreID})
+	require.NoError(t, err)
+	require.Len(t, before, 1)
+
+	step := func(action string, input ScoreReviewInput, want string) *Score {
+		t.Helper()
+		score, err := FindScoreByID(ctx, scoreID)
+		require.NoError(t, err)
+		reviewed, err := score.Review(ctx, action, input)
+		require.NoError(t, err, action)
+		assert.Equal(t, want, reviewed.Status)
+		assert.Equal(t, before[0].LastEditedAt, reviewed.LastEditedAt, "a review is not an edit")
+		return reviewed
+	}
+
+	step(ScoreReviewSubmit, ScoreReviewInput{}, scoreStatusSubmitted)
+	step(ScoreReviewApprove, ScoreReviewInput{}, scoreStatusApproved)
+	returned := step(ScoreReviewReturn, ScoreReviewInput{Reason: stringPtr("attach this cycle's evidence")}, scoreStatusReturned)
+	require.NotNil(t, returned.ReviewNote)
+	assert.Equal(t, "attach this cycle's evidence", *returned.ReviewNote)
+
+	var events int
+	require.NoError(t, conn.QueryRow(ctx, `
+SELECT COUNT(*) FROM events
+ WHERE resource = 'public.scores' AND (payload->>'scoreid')::int = $1
+   AND action IN ('submitted', 'approved', 'returned')`, scoreID).Scan(&events))
+	assert.GreaterOrEqual(t, events, 3)
+
+	// A second submit of a returned answer is refused until it is revised.
+	score, err := FindScoreByID(ctx, scoreID)
+	require.NoError(t, err)
+	_, err = score.Review(ctx, ScoreReviewSubmit, ScoreReviewInput{})
+	var invalid *InvalidInputError
+	require.ErrorAs(t, err, &invalid)
+
+	confirmed, err := score.Confirm(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, scoreStatusDone, confirmed.Status)
+	assert.Nil(t, confirmed.ReviewNote, "confirming clears the reviewer's note")
+}
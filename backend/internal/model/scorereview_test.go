@@ -0,0 +1,76 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateScoreReview_Transitions(t *testing.T) {
+	reason := ScoreReviewInput{Reason: stringPtr("evidence is from last year")}
+	tests := []struct {
+		action string
+		from   string
+		input  ScoreReviewInput
+		to     string
+	}{
+		{ScoreReviewSubmit, scoreStatusDone, ScoreReviewInput{}, scoreStatusSubmitted},
+		{ScoreReviewApprove, scoreStatusSubmitted, ScoreReviewInput{}, scoreStatusApproved},
+		{ScoreReviewReturn, scoreStatusSubmitted, reason, scoreStatusReturned},
+		{ScoreReviewReturn, scoreStatusApproved, reason, scoreStatusReturned},
+		// The limit counts characters, not bytes.
+		{ScoreReviewReturn, scoreStatusSubmitted, ScoreReviewInput{Reason: stringPtr(strings.Repeat("é", maxReviewNoteLength))}, scoreStatusReturned},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action+" from "+tt.from, func(t *testing.T) {
+			transition, note, err := validateScoreReview(tt.action, tt.from, tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.to, transition.to)
+			assert.Equal(t, tt.input.Reason, note)
+		})
+	}
+}
+
+func TestValidateScoreReview_Rejections(t *testing.T) {
+	reason := ScoreReviewInput{Reason: stringPtr("why")}
+	tests := []struct {
+		name      string
+		action    string
+		from      string
+		input     ScoreReviewInput
+		wantField string
+	}{
+		{"unknown action", "reopen", scoreStatusDone, ScoreReviewInput{}, "action"},
+		{"submit untouched carry-forward", ScoreReviewSubmit, scoreStatusNotStarted, ScoreReviewInput{}, "status"},
+		{"submit returned without revising", ScoreReviewSubmit, scoreStatusReturned, ScoreReviewInput{}, "status"},
+		{"approve unsubmitted", ScoreReviewApprove, scoreStatusDone, ScoreReviewInput{}, "status"},
+		{"approve twice", ScoreReviewApprove, scoreStatusApproved, ScoreReviewInput{}, "status"},
+		{"return unsubmitted", ScoreReviewReturn, scoreStatusDone, reason, "status"},
+		{"return without reason", ScoreReviewReturn, scoreStatusSubmitted, ScoreReviewInput{}, "reason"},
+		{"return with blank reason", ScoreReviewReturn, scoreStatusSubmitted, ScoreReviewInput{Reason: stringPtr("  ")}, "reason"},
+		{"return with long reason", ScoreReviewReturn, scoreStatusSubmitted, ScoreReviewInput{Reason: stringPtr(strings.Repeat("x", maxReviewNoteLength+1))}, "reason"},
+		{"approve with reason", ScoreReviewApprove, scoreStatusSubmitted, reason, "reason"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := validateScoreReview(tt.action, tt.from, tt.input)
+			var invalid *InvalidInputError
+			require.ErrorAs(t, err, &invalid)
+			assert.Contains(t, invalid.Data(), tt.wantField)
+		})
+	}
+}
+
+func TestScoreConfirmable(t *testing.T) {
+	for status, want := range map[string]bool{
+		scoreStatusNotStarted: true,
+		scoreStatusReturned:   true,
+		scoreStatusDone:       false,
+		scoreStatusSubmitted:  false,
+		scoreStatusApproved:   false,
+	} {
+		assert.Equal(t, want, (&Score{Status: status}).confirmable(), status)
+	}
+}
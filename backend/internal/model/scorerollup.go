@@ -0,0 +1,180 @@
+package model
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Groupings accepted by FindScoresInput.GroupBy.
+const (
+	ScoreGroupOpDiv              = "opdiv"
+	ScoreGroupHHS                = "hhs"
+	ScoreGroupDataCenterCategory = "datacenter_category"
+)
+
+// scoreGroupKeySQL maps each grouping to the key and display name its groups
+// are built on, as expressions over the fismasystems (fs), opdivs (o) and
+// datacenterenvironments (dce) joins in buildScoreRollupSQL. A system with no
+// OpDiv is grouped on its own rather than dropped.
+var scoreGroupKeySQL = map[string][2]string{
+	ScoreGroupOpDiv:              {"COALESCE(o.code, 'UNASSIGNED')", "COALESCE(o.name, 'No OpDiv')"},
+	ScoreGroupHHS:                {"'HHS'", "'HHS'"},
+	ScoreGroupDataCenterCategory: {"dce.category", "dce.category"},
+}
+
+// ScoreRollup averages the system aggregates of one group - an OpDiv, a data
+// center category, or the whole department - for one data call. SystemScore
+// is the mean of the group's system scores, each computed exactly as
+// /scores/aggregate computes it, so Weighting carries over from the data call.
+type ScoreRollup struct {
+	DataCallID   int32           `json:"datacallid"`
+	GroupBy      string          `json:"group_by"`
+	GroupKey     string          `json:"group_key"`
+	GroupName    string          `json:"group_name"`
+	SystemCount  int             `json:"system_count"`
+	SystemScore  float64         `json:"systemscore"`
+	SystemTier   string          `json:"systemtier"`
+	Weighting    string          `json:"weighting"`
+	PillarScores []*PillarRollup `json:"pillarscores"`
+}
+
+// PillarRollup is a pillar's mean score across the group's systems.
+// SystemCount is how many of them are scored on the pillar, which is fewer
+// than the group's where reduced pillar scope applies.
+type PillarRollup struct {
+	PillarScore
+	SystemCount int `json:"system_count"`
+}
+
+// scoreRollupRow is one (datacall, group, pillar) row of buildScoreRollupSQL,
+// with the group's system-level figures carried on every row as
+// pillarScoreRow carries system_score.
+type scoreRollupRow struct {
+	DataCallID        int32   `db:"datacallid"`
+	GroupKey          string  `db:"group_key"`
+	GroupName         string  `db:"group_name"`
+	SystemCount       int     `db:"system_count"`
+	SystemScore       float64 `db:"system_score"`
+	Weighted          bool    `db:"weighted"`
+	PillarID          int32   `db:"pillarid"`
+	Pillar            string  `db:"pillar"`
+	Score             float64 `db:"score"`
+	Weight            float64 `db:"weight"`
+	PillarSystemCount int     `db:"pillar_system_count"`
+}
+
+// FindScoresRollup groups the per-system aggregates FindScoresAggregate would
+// return for the same input by input.GroupBy. Scope is inherited rather than
+// reapplied: the per-system rows are already limited to the caller's OpDivs
+// or systems, so an OpDiv-scoped admin's rollup covers only their OpDivs.
+func FindScoresRollup(ctx context.Context, input FindScoresInput) ([]*ScoreRollup, error) {
+	if input.GroupBy == nil {
+		return nil, &InvalidInputError{data: map[string]any{"group_by": "required"}}
+	}
+	if _, ok := scoreGroupKeySQL[*input.GroupBy]; !ok {
+		return nil, &InvalidInputError{data: map[string]any{
+			"group_by": fmt.Sprintf("must be one of %s, %s or %s", ScoreGroupOpDiv, ScoreGroupHHS, ScoreGroupDataCenterCategory),
+		}}
+	}
+
+	if input.FismaSystemID != nil && len(input.FismaSystemIDs) == 0 {
+		input.FismaSystemIDs = []*int32{input.FismaSystemID}
+	}
+
+	dirty, err := scoreAggregatesDirty(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	sql, args := buildScoreRollupSQL(input, dirty)
+	rows, err := query(ctx, rawQuery{sql: sql, args: args}, pgx.RowToAddrOfStructByName[scoreRollupRow])
+	if err != nil {
+		return nil, err
+	}
+	return rollupScoreRows(rows, *input.GroupBy), nil
+}
+
+// buildScoreRollupSQL wraps pillarRowsSQL, so a group's systems are scored
+// from the same rows, under the same scope, as /scores/aggregate; the
+// averages over them are taken in Postgres for the same float-consistency
+// reason. input.GroupBy must be a key of scoreGroupKeySQL.
+func buildScoreRollupSQL(input FindScoresInput, live bool) (string, []any) {
+	pillarSQL, args := pillarRowsSQL(input, live)
+	key := scoreGroupKeySQL[*input.GroupBy]
+
+	sql := fmt.Sprintf(`
+WITH pillar_rows AS (%s),
+grouped AS (
+    SELECT pr.*, %s AS group_key, %s AS group_name
+    FROM pillar_rows pr
+    INNER JOIN fismasystems fs ON fs.fismasystemid = pr.fismasystemid
+    LEFT JOIN opdivs o ON o.opdiv_id = fs.opdiv_id
+    LEFT JOIN datacenterenvironments dce ON dce.datacenterenvironment = fs.datacenterenvironment
+),
+group_systems AS (
+    SELECT datacallid, group_key, MIN(group_name) AS group_name,
+           COUNT(*) AS system_count,
+           AVG(system_score)::float8 AS system_score,
+           bool_or(weighted) AS weighted
+    FROM (SELECT DISTINCT datacallid, fismasystemid, group_key, group_name, system_score, weighted FROM grouped) s
+    GROUP BY datacallid, group_key
+),
+group_pillars AS (
+    SELECT datacallid, group_key, pillarid, pillar,
+           AVG(score)::float8 AS score,
+           MAX(weight)::float8 AS weight,
+           COUNT(*) AS pillar_system_count
+    FROM grouped
+    GROUP BY datacallid, group_key, pillarid, pillar
+)
+SELECT
+    gs.datacallid, gs.group_key, gs.group_name, gs.system_count::int AS system_count,
+    gs.system_score, gs.weighted,
+    gp.pillarid, gp.pillar, gp.score, gp.weight, gp.pillar_system_count::int AS pillar_system_count
+FROM group_systems gs
+INNER JOIN group_pillars gp ON gp.datacallid = gs.datacallid AND gp.group_key = gs.group_key
+ORDER BY gs.datacallid, gs.group_key, gp.pillarid
+`, pillarSQL, key[0], key[1])
+
+	return sql, args
+}
+
+// rollupScoreRows collapses the per-pillar rows into one ScoreRollup per
+// (datacall, group), reading every figure from the SQL as aggregatePillarRows
+// does. rows must be ordered by (datacallid, group_key, pillarid).
+func rollupScoreRows(rows []*scoreRollupRow, groupBy string) []*ScoreRollup {
+	rollups := []*ScoreRollup{}
+	var current *ScoreRollup
+
+	for _, r := range rows {
+		if current == nil || current.DataCallID != r.DataCallID || current.GroupKey != r.GroupKey {
+			current = &ScoreRollup{
+				DataCallID:   r.DataCallID,
+				GroupBy:      groupBy,
+				GroupKey:     r.GroupKey,
+				GroupName:    r.GroupName,
+				SystemCount:  r.SystemCount,
+				SystemScore:  r.SystemScore,
+				SystemTier:   Tier(r.SystemScore),
+				Weighting:    ScoreWeightingEqual,
+				PillarScores: []*PillarRollup{},
+			}
+			if r.Weighted {
+				current.Weighting = ScoreWeightingPillar
+			}
+			rollups = append(rollups, current)
+		}
+		current.PillarScores = append(current.PillarScores, &PillarRollup{
+			PillarScore: PillarScore{
+				PillarID: r.PillarID,
+				Pillar:   r.Pillar,
+				Score:    r.Score,
+				Tier:     Tier(r.Score),
+				Weight:   r.Weight,
+			},
+			SystemCount: r.PillarSystemCount,
+		})
+	}
+	return rollups
+}
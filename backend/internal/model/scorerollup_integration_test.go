@@ -0,0 +1,118 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFindScoresRollupIntegration checks the rollups against the per-system
+// aggregate they are built from: the department rollup counts and averages
+// every system, and the OpDiv rollups partition the same systems.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestFindScoresRollupIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	var dataCallID int32
+	require.NoError(t, conn.QueryRow(ctx, `SELECT datacallid FROM scores LIMIT 1`).Scan(&dataCallID))
+
+	systems, err := FindScoresAggregate(ctx, FindScoresInput{DataCallID: &dataCallID})
+	require.NoError(t, err)
+	require.NotEmpty(t, systems)
+
+	var sum float64
+	for _, s := range systems {
+		sum += s.SystemScore
+	}
+
+	hhs := ScoreGroupHHS
+	dept, err := FindScoresRollup(ctx, FindScoresInput{DataCallID: &dataCallID, GroupBy: &hhs})
+	require.NoError(t, err)
+	require.Len(t, dept, 1)
+	assert.Equal(t, len(systems), dept[0].SystemCount)
+	assert.InDelta(t, sum/float64(len(systems)), dept[0].SystemScore, 1e-9)
+
+	opdiv := ScoreGroupOpDiv
+	byOpDiv, err := FindScoresRollup(ctx, FindScoresInput{DataCallID: &dataCallID, GroupBy: &opdiv})
+	require.NoError(t, err)
+	counted := 0
+	for _, g := range byOpDiv {
+		counted += g.SystemCount
+	}
+	assert.Equal(t, len(systems), counted, "every system falls in exactly one OpDiv group")
+}
+
+// TestFindScoresRollupOpDivAdminIntegration scopes a group_by=opdiv rollup
+// the way GetScoresAggregate does for an OPDIV_ADMIN and checks it returns
+// their OpDiv's group alone, with the same systems the unscoped rollup
+// counts for it, and that an OpDiv admin with no grants gets no groups.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestFindScoresRollupOpDivAdminIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	// A data call scoring systems in more than one OpDiv, so there is a group
+	// the admin must not see.
+	var dataCallID, opdivID int32
+	var opdivCode string
+	err = conn.QueryRow(ctx, `
+		SELECT s.datacallid, MIN(fs.opdiv_id)
+		  FROM scores s
+		  JOIN fismasystems fs ON fs.fismasystemid = s.fismasystemid
+		 GROUP BY s.datacallid
+		HAVING COUNT(DISTINCT fs.opdiv_id) > 1
+		 LIMIT 1
+	`).Scan(&dataCallID, &opdivID)
+	if err != nil {
+		t.Skip("need a data call scoring systems in two OpDivs")
+	}
+	require.NoError(t, conn.QueryRow(ctx, `SELECT code FROM opdivs WHERE opdiv_id = $1`, opdivID).Scan(&opdivCode))
+
+	groupBy := ScoreGroupOpDiv
+	all, err := FindScoresRollup(ctx, FindScoresInput{DataCallID: &dataCallID, GroupBy: &groupBy})
+	require.NoError(t, err)
+	require.Greater(t, len(all), 1)
+	want := 0
+	for _, g := range all {
+		if g.GroupKey == opdivCode {
+			want = g.SystemCount
+		}
+	}
+	require.NotZero(t, want)
+
+	admin := &User{Role: "OPDIV_ADMIN", AssignedOpDivIDs: []*int32{&opdivID}}
+	input := FindScoresInput{DataCallID: &dataCallID, GroupBy: &groupBy}
+	require.False(t, input.ApplyTier(admin), "the OpDiv tier needs no self-scope")
+
+	scoped, err := FindScoresRollup(ctx, input)
+	require.NoError(t, err)
+	require.Len(t, scoped, 1, "only the admin's OpDiv")
+	assert.Equal(t, opdivCode, scoped[0].GroupKey)
+	assert.Equal(t, want, scoped[0].SystemCount)
+
+	ungranted := FindScoresInput{DataCallID: &dataCallID, GroupBy: &groupBy}
+	ungranted.ApplyTier(&User{Role: "OPDIV_ADMIN"})
+	none, err := FindScoresRollup(ctx, ungranted)
+	require.NoError(t, err)
+	assert.Empty(t, none, "an OpDiv admin without grants fails closed")
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBuildScoreRollupSQL pins that a rollup is built over the per-system
+// aggregate rows rather than beside them, so it inherits that query's OpDiv
+// scope and score math, and that each grouping keys on its own column.
+func TestBuildScoreRollupSQL(t *testing.T) {
+	groupBy := ScoreGroupOpDiv
+	input := FindScoresInput{
+		GroupBy:    &groupBy,
+		OpDivScope: OpDivScope{RestrictToOpDivIDs: true, OpDivIDs: []int32{7}},
+	}
+
+	sql, args := buildScoreRollupSQL(input, false)
+	pillarSQL, pillarArgs := buildScoreAggregatesSQL(input)
+
+	assert.Contains(t, sql, "WITH pillar_rows AS ("+pillarSQL+")")
+	assert.Equal(t, pillarArgs, args, "the rollup binds nothing of its own")
+
+	// With a write in scope not yet refreshed, over the live rows instead.
+	liveSQL, liveArgs := buildScoreRollupSQL(input, true)
+	pillarSQL, pillarArgs = buildPillarScoresSQL(input)
+	assert.Contains(t, liveSQL, "WITH pillar_rows AS ("+pillarSQL+")")
+	assert.Equal(t, pillarArgs, liveArgs)
+	assert.Contains(t, sql, "fs.opdiv_id = ANY($")
+	assert.Contains(t, sql, "COALESCE(o.code, 'UNASSIGNED') AS group_key")
+
+	groupBy = ScoreGroupDataCenterCategory
+	sql, _ = buildScoreRollupSQL(input, false)
+	assert.Contains(t, sql, "dce.category AS group_key")
+}
+
+func TestRollupScoreRows(t *testing.T) {
+	rows := []*scoreRollupRow{
+		{DataCallID: 5, GroupKey: "CMS", GroupName: "Centers", SystemCount: 3, SystemScore: 3.2, PillarID: 1, Pillar: "Identity", Score: 4.2, Weight: 1, PillarSystemCount: 3},
+		{DataCallID: 5, GroupKey: "CMS", GroupName: "Centers", SystemCount: 3, SystemScore: 3.2, PillarID: 2, Pillar: "Devices", Score: 2.0, Weight: 1, PillarSystemCount: 2},
+		{DataCallID: 5, GroupKey: "FDA", GroupName: "Food", SystemCount: 1, SystemScore: 1.0, Weighted: true, PillarID: 1, Pillar: "Identity", Score: 1.0, Weight: 2, PillarSystemCount: 1},
+	}
+
+	rollups := rollupScoreRows(rows, ScoreGroupOpDiv)
+	if assert.Len(t, rollups, 2) {
+		cms := rollups[0]
+		assert.Equal(t, ScoreGroupOpDiv, cms.GroupBy)
+		assert.Equal(t, 3, cms.SystemCount)
+		assert.Equal(t, "Advanced", cms.SystemTier)
+		assert.Equal(t, ScoreWeightingEqual, cms.Weighting)
+		if assert.Len(t, cms.PillarScores, 2) {
+			assert.Equal(t, "Optimal", cms.PillarScores[0].Tier)
+			assert.Equal(t, 2, cms.PillarScores[1].SystemCount, "a reduced-scope system is not counted on a pillar it lacks")
+		}
+
+		assert.Equal(t, "FDA", rollups[1].GroupKey)
+		assert.Equal(t, ScoreWeightingPillar, rollups[1].Weighting)
+	}
+
+	assert.NotNil(t, rollupScoreRows(nil, ScoreGroupHHS), "no rows encode as []")
+}
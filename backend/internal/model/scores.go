@@ -13,7 +13,6 @@ import (
 	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
 	"github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // rawQuery wraps a hand-built SQL string and its arguments so it can flow
@@ -34,8 +33,8 @@ func (r rawQuery) ToSql() (string, []any, error) {
 
 // Score review states, as stored in scores.status. Like the event actions in
 // events.go these are stored data rather than an internal enum: migration
-// 0048 pins the exact set with a CHECK constraint
-// (`status IN ('not_started', 'done')`), the seed data's status-sync writes
+// 0048 pins the exact set with a CHECK constraint, widened by 0065 for the
+// review workflow (scorereview.go), the seed data's status-sync writes
 // 'done' while the column DEFAULT set in 0048 supplies 'not_started', and the
 // progress query in scoreprogress.go filters on them as inline
 // SQL literals. Changing a VALUE here is a data migration - a new CHECK, a
@@ -49,6 +48,11 @@ const (
 	// copyPreviousScores seeds onto a carried-forward answer: the answer value
 	// survives the rollover, its review state for the new cycle does not.
 	scoreStatusNotStarted = "not_started"
+	// scoreStatusSubmitted, scoreStatusApproved and scoreStatusReturned are
+	// the review states; only the transitions in scorereview.go write them.
+	scoreStatusSubmitted = "submitted"
+	scoreStatusApproved  = "approved"
+	scoreStatusReturned  = "returned_for_revision"
 )
 
 type Score struct {
@@ -65,7 +69,10 @@ type Score struct {
 	// migration 0048's CHECK). Exposed on the read path so the questionnaire
 	// marks carried-forward answers from the SAME fact the Data Call Progress
 	// count reads, instead of inferring it from the absence of an edit event.
-	Status         string          `json:"status"`
+	Status string `json:"status"`
+	// ReviewNote is the reviewer's reason for returning the answer; set only
+	// while Status is returned_for_revision.
+	ReviewNote     *string         `json:"reviewnote"`
 	FunctionOption *FunctionOption `json:"functionoption,omitempty"`
 	LastEditedAt   *time.Time      `json:"last_edited_at,omitempty"`
 	LastEditedBy   *AuditRef       `json:"last_edited_by,omitempty"`
@@ -81,7 +88,8 @@ func (s *Score) AuditInfo() (*time.Time, *AuditRef) {
 // scoreSaveConfig carries request-shape context that is not part of the
 // persisted entity.
 type scoreSaveConfig struct {
-	current *Score
+	current         *Score
+	deferAggregates bool
 }
 
 // ScoreSaveOption configures a Score.Save call.
@@ -100,6 +108,13 @@ func WithCurrentScore(current *Score) ScoreSaveOption {
 	return func(c *scoreSaveConfig) { c.current = current }
 }
 
+// withDeferredAggregates has Save leave its scoreaggregates refresh to the
+// caller, which saves a run of answers and refreshes their scope once. The
+// write's dirty mark keeps reads correct in between.
+func withDeferredAggregates() ScoreSaveOption {
+	return func(c *scoreSaveConfig) { c.deferAggregates = true }
+}
+
 // Save writes one answer for the current data call, as an interactive human
 // edit. It is the questionnaire's write path and it hardcodes that meaning:
 // every successful write sets status to scoreStatusDone, and the event it
@@ -107,22 +122,14 @@ func WithCurrentScore(current *Score) ScoreSaveOption {
 // actions that migration 0048's backfill and the seed status-sync treat as
 // "a human answered this cycle".
 //
-// Importer contract (ztmf#445): a future bulk importer must NOT reuse Save as
-// it stands. Loading history through it would flip every imported row to done
-// and attribute it as an in-app edit, which is precisely the distinction the
-// status column exists to preserve - imported rows are supposed to stay
-// not_started and report no last-updated. An importer should either
-//
-//   - take an explicit status/provenance parameter added to Save (a
-//     ScoreSaveOption alongside WithCurrentScore is the natural shape), so the
-//     caller states the status to write and the action to record; or
-//   - write through its own path that inserts the rows and records action
-//     'imported' (eventActionImported in events.go), mirroring the provenance
-//     the 0048 backfill and the seed data deliberately exclude.
-//
-// Adding the parameter is the larger change of the two and was deliberately
-// left undone here rather than designed speculatively without an importer to
-// shape it.
+// Importer contract (ztmf#445): bulk loads must NOT go through Save. Loading
+// history through it would flip every imported row to done and attribute it as
+// an in-app edit, which is precisely the distinction the status column exists
+// to preserve - imported rows are supposed to stay not_started and report no
+// last-updated. ImportScores (scoreimport.go) is that separate path: it inserts
+// not_started rows and records action 'imported' (eventActionImported in
+// events.go), mirroring the provenance the 0048 backfill and the seed data
+// deliberately exclude.
 func (s *Score) Save(ctx context.Context, opts ...ScoreSaveOption) (*Score, error) {
 	var sqlb SqlBuilder
 
@@ -185,7 +192,7 @@ func (s *Score) Save(ctx context.Context, opts ...ScoreSaveOption) (*Score, erro
 			Insert("public.scores").
 			Columns("fismasystemid", "notes", "notes_is_ai_summary", "functionoptionid", "datacallid", "status").
 			Values(s.FismaSystemID, s.Notes, derefBool(s.NotesIsAISummary), s.FunctionOptionID, s.DataCallID, scoreStatusDone).
-			Suffix("RETURNING scoreid, fismasystemid, EXTRACT(EPOCH FROM datecalculated) as datecalculated, notes, notes_is_ai_summary, functionoptionid, datacallid, status")
+			Suffix("RETURNING scoreid, fismasystemid, EXTRACT(EPOCH FROM datecalculated) as datecalculated, notes, notes_is_ai_summary, functionoptionid, datacallid, status, reviewnote")
 	} else {
 		// fismasystemid and datacallid are deliberately NOT in the SET list.
 		// Saving an answer must never move the row to another system or
@@ -198,6 +205,7 @@ func (s *Score) Save(ctx context.Context, opts ...ScoreSaveOption) (*Score, erro
 			"notes":            s.Notes,
 			"functionoptionid": s.FunctionOptionID,
 			"status":           scoreStatusDone,
+			"reviewnote":       nil,
 		}
 		if s.NotesIsAISummary != nil {
 			setCols["notes_is_ai_summary"] = *s.NotesIsAISummary
@@ -212,7 +220,7 @@ func (s *Score) Save(ctx context.Context, opts ...ScoreSaveOption) (*Score, erro
 			Update("public.scores").
 			SetMap(setCols).
 			Where("scoreid=? AND fismasystemid=? AND datacallid=?", s.ScoreID, s.FismaSystemID, s.DataCallID).
-			Suffix("RETURNING scoreid, fismasystemid, EXTRACT(EPOCH FROM datecalculated) as datecalculated, notes, notes_is_ai_summary, functionoptionid, datacallid, status")
+			Suffix("RETURNING scoreid, fismasystemid, EXTRACT(EPOCH FROM datecalculated) as datecalculated, notes, notes_is_ai_summary, functionoptionid, datacallid, status, reviewnote")
 	}
 
 	saved, err := queryRow(ctx, sqlb, pgx.RowToStructByNameLax[Score])
@@ -220,6 +228,12 @@ func (s *Score) Save(ctx context.Context, opts ...ScoreSaveOption) (*Score, erro
 		return saved, err
 	}
 
+	// The answer moves its system's pillar scores in this cycle, and only
+	// those. A caller saving many answers refreshes once after them instead.
+	if !cfg.deferAggregates {
+		refreshScoreAggregates(ctx, FindScoresInput{DataCallID: &saved.DataCallID, FismaSystemID: &saved.FismaSystemID})
+	}
+
 	// Stamp the just-performed edit onto the response so the POST/PUT body
 	// is consistent with what a subsequent GET will return. We read back
 	// the canonical row that recordEvent (fired from queryRow above) just
@@ -254,7 +268,10 @@ func (s *Score) Save(ctx context.Context, opts ...ScoreSaveOption) (*Score, erro
 // Deliberately narrow: sets ONLY status - notably not notes_is_ai_summary,
 // since agreeing with an AI-drafted justification is not authoring it. Runs
 // through queryRow so recordEvent stamps the confirming user as the editor.
-// Idempotent on an already-'done' row.
+// A returned_for_revision answer is confirmable too - the user keeps it as it
+// is - and confirming clears the reviewer's note. Any other state is returned
+// unchanged: idempotent on a 'done' row, and never a way to pull an answer
+// back out of review.
 //
 // The receiver must be a row loaded by FindScoreByID, not a client-supplied
 // body: DataCallID drives the deadline check, and the controller authorizes
@@ -271,7 +288,7 @@ func (s *Score) Confirm(ctx context.Context) (*Score, error) {
 	// the current row with the same audit projection used after a real write,
 	// preserving the endpoint's idempotent 200 response without recording a
 	// second event.
-	if s.Status == scoreStatusDone {
+	if !s.confirmable() {
 		if at, by := lookupScoreAudit(ctx, s.ScoreID); at != nil && by != nil {
 			s.LastEditedAt = at
 			s.LastEditedBy = by
@@ -282,11 +299,12 @@ func (s *Score) Confirm(ctx context.Context) (*Score, error) {
 	sqlb := stmntBuilder.
 		Update("public.scores").
 		Set("status", scoreStatusDone).
+		Set("reviewnote", nil).
 		// Keep the no-op guard in the write predicate as well as above. Two
 		// requests can both load not_started before either reaches Confirm; only
 		// the first must be allowed to update and create an audit event.
-		Where("scoreid=? AND status <> ?", s.ScoreID, scoreStatusDone).
-		Suffix("RETURNING scoreid, fismasystemid, EXTRACT(EPOCH FROM datecalculated) as datecalculated, notes, notes_is_ai_summary, functionoptionid, datacallid, status")
+		Where(squirrel.Eq{"scoreid": s.ScoreID, "status": []string{scoreStatusNotStarted, scoreStatusReturned}}).
+		Suffix("RETURNING scoreid, fismasystemid, EXTRACT(EPOCH FROM datecalculated) as datecalculated, notes, notes_is_ai_summary, functionoptionid, datacallid, status, reviewnote")
 
 	confirmed, err := queryRow(ctx, sqlb, pgx.RowToStructByNameLax[Score])
 	if err != nil {
@@ -298,7 +316,7 @@ func (s *Score) Confirm(ctx context.Context) (*Score, error) {
 			if findErr != nil {
 				return nil, findErr
 			}
-			if current.Status == scoreStatusDone {
+			if !current.confirmable() {
 				if at, by := lookupScoreAudit(ctx, current.ScoreID); at != nil && by != nil {
 					current.LastEditedAt = at
 					current.LastEditedBy = by
@@ -321,6 +339,11 @@ func (s *Score) Confirm(ctx context.Context) (*Score, error) {
 	return confirmed, nil
 }
 
+// confirmable reports whether Confirm may move the answer to 'done'.
+func (s *Score) confirmable() bool {
+	return s.Status == scoreStatusNotStarted || s.Status == scoreStatusReturned
+}
+
 // scoreUpdateIsNoOp reports whether the incoming Score's answer fields
 // match the existing row exactly. Used by Save to short-circuit the
 // "Next click without editing" path so the prior editor is not
@@ -385,12 +408,12 @@ func FindScoreByID(ctx context.Context, scoreID int32) (*Score, error) {
 	current := &Score{}
 	err = conn.QueryRow(ctx, `
 		SELECT scoreid, fismasystemid, EXTRACT(EPOCH FROM datecalculated) AS datecalculated,
-		       notes, notes_is_ai_summary, functionoptionid, datacallid, status
+		       notes, notes_is_ai_summary, functionoptionid, datacallid, status, reviewnote
 		FROM scores WHERE scoreid = $1
 	`, scoreID).Scan(
 		&current.ScoreID, &current.FismaSystemID, &current.DateCalculated,
 		&current.Notes, &current.NotesIsAISummary, &current.FunctionOptionID, &current.DataCallID,
-		&current.Status,
+		&current.Status, &current.ReviewNote,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -433,7 +456,7 @@ func scoresEqualForUpdate(current, incoming *Score) bool {
 	return derefString(current.Notes) == derefString(incoming.Notes)
 }
 
-// lookupScoreAudit fetches the most recent event for the given scoreid
+// lookupScoreAudit fetches the most recent edit event for the given scoreid
 // and resolves the editor identity from users. Returns (nil, nil) when
 // no event row exists -- the caller MUST treat that as the "do not
 // stamp" signal rather than substituting synthetic values, otherwise
@@ -456,6 +479,7 @@ func lookupScoreAudit(ctx context.Context, scoreID int32) (*time.Time, *AuditRef
 		LEFT JOIN users u ON u.userid = e.userid
 		WHERE e.resource = 'public.scores'
 		  AND (e.payload->>'scoreid')::int = $1
+		  AND e.action <> ALL($2)
 		ORDER BY e.createdat DESC
 		LIMIT 1
 	`
@@ -466,7 +490,7 @@ func lookupScoreAudit(ctx context.Context, scoreID int32) (*time.Time, *AuditRef
 		email *string
 		role  *string
 	)
-	if err := conn.QueryRow(ctx, q, scoreID).Scan(&at, &uid, &name, &email, &role); err != nil {
+	if err := conn.QueryRow(ctx, q, scoreID, reviewEventActions).Scan(&at, &uid, &name, &email, &role); err != nil {
 		// No row is the expected outcome when recordEvent skipped/failed.
 		// Anything else is genuinely unexpected; log it for forensics but
 		// stay on the "do not stamp" path so the response cannot lie.
@@ -495,9 +519,13 @@ func (s *Score) validate(ctx context.Context) error {
 	return s.validateDeadline(ctx)
 }
 
-// validateDeadline rejects a write to a closed data call for anyone but an
-// admin. Extracted from validate so Confirm enforces the same rule: confirming
-// a carried-forward answer is a write to the cycle like any other, and must not
+// validateDeadline rejects a write the data call does not accept: one outside
+// its open state (a draft, an archived call, or a closed call for anyone but an
+// admin) or, for non-admins, one after its deadline - see DataCall.CheckWritable.
+// The deadline is the system's effective one, so an OpDiv or system extension
+// lets its ISSOs keep answering rather than routing the work through an admin.
+// Extracted from validate so Confirm enforces the same rule: confirming a
+// carried-forward answer is a write to the cycle like any other, and must not
 // become a post-deadline loophole for the tiers that cannot save.
 func (s *Score) validateDeadline(ctx context.Context) error {
 	dataCall, err := FindDataCallByID(ctx, s.DataCallID)
@@ -505,27 +533,39 @@ func (s *Score) validateDeadline(ctx context.Context) error {
 		return err
 	}
 
-	user := UserFromContext(ctx)
-	if time.Now().UTC().After(dataCall.Deadline) && (user == nil || !user.IsAdmin()) {
-		return ErrPastDeadline
+	if err := dataCall.ApplyDeadlineExtension(ctx, s.FismaSystemID); err != nil {
+		return err
 	}
 
-	return nil
+	return dataCall.CheckWritable(UserFromContext(ctx), time.Now().UTC())
 }
 
 type ScoreAggregate struct {
-	DataCallID    int32          `json:"datacallid"`
-	FismaSystemID int32          `json:"fismasystemid"`
-	SystemScore   float64        `json:"systemscore"`
-	SystemTier    string         `json:"systemtier"`
-	PillarScores  []*PillarScore `json:"pillarscores,omitempty" db:"-"`
+	DataCallID    int32   `json:"datacallid"`
+	FismaSystemID int32   `json:"fismasystemid"`
+	SystemScore   float64 `json:"systemscore"`
+	SystemTier    string  `json:"systemtier"`
+	// Weighting is how SystemScore combines the pillar scores: "equal" for
+	// the plain average, "pillar_weights" where the data call sets weights.
+	// Scores under different weightings are not comparable.
+	Weighting    string         `json:"weighting"`
+	PillarScores []*PillarScore `json:"pillarscores,omitempty" db:"-"`
 }
 
+// Score weightings reported on ScoreAggregate.Weighting.
+const (
+	ScoreWeightingEqual  = "equal"
+	ScoreWeightingPillar = "pillar_weights"
+)
+
 type PillarScore struct {
 	PillarID int32   `json:"pillarid"`
 	Pillar   string  `json:"pillar"`
 	Score    float64 `json:"score"`
 	Tier     string  `json:"tier"`
+	// Weight is the pillar's weight in the system score; 1 under equal
+	// weighting.
+	Weight float64 `json:"weight"`
 }
 
 // Tier returns the HHS-aligned maturity tier label for a 1.0-5.0 score.
@@ -564,18 +604,24 @@ func Tier(score float64) string {
 
 type FindScoresInput struct {
 	input
+	ScoreID        *int32   `schema:"-"`
 	FismaSystemID  *int32   `schema:"fismasystemid"`
 	FismaSystemIDs []*int32 `schema:"-"`
 	DataCallID     *int32   `schema:"datacallid"`
 	UserID         *string  `schema:"-"`
 	IncludePillars *bool    `schema:"include_pillars"`
+	// GroupBy switches /scores/aggregate to group rollups (FindScoresRollup).
+	GroupBy *string `schema:"group_by"`
+	// OpDivID narrows the pillar queries to one OpDiv within the caller's
+	// scope. Server-set: the gap analysis exposes it as its own filter.
+	OpDivID *int32 `schema:"-"`
 	OpDivScope
 }
 
 func FindScores(ctx context.Context, input FindScoresInput) ([]*Score, error) {
 
 	sqlb := stmntBuilder.
-		Select("scoreid, scores.fismasystemid, EXTRACT(EPOCH FROM datecalculated) as datecalculated, notes, scores.notes_is_ai_summary, scores.functionoptionid, scores.datacallid, scores.status").
+		Select("scoreid, scores.fismasystemid, EXTRACT(EPOCH FROM datecalculated) as datecalculated, notes, scores.notes_is_ai_summary, scores.functionoptionid, scores.datacallid, scores.status, scores.reviewnote").
 		From("scores")
 
 	if input.contains("functionoption") {
@@ -588,6 +634,10 @@ func FindScores(ctx context.Context, input FindScoresInput) ([]*Score, error) {
 		sqlb = sqlb.InnerJoin("users_fismasystems ON users_fismasystems.fismasystemid=scores.fismasystemid AND users_fismasystems.userid=?", *input.UserID)
 	}
 
+	if input.ScoreID != nil {
+		sqlb = sqlb.Where("scores.scoreid=?", *input.ScoreID)
+	}
+
 	if input.FismaSystemID != nil {
 		sqlb = sqlb.Where("scores.fismasystemid=?", *input.FismaSystemID)
 	}
@@ -607,17 +657,19 @@ func FindScores(ctx context.Context, input FindScoresInput) ([]*Score, error) {
 	// Attach last-edit audit info. The lateral subquery picks the most recent
 	// write event for the row; the outer left join resolves the editor's
 	// identity. Both joins are LEFT so a score row missing an event (seed
-	// data) still returns. The 'public.scores' literal matches the value
-	// recordEvent writes from scores.Save; see follow-up to normalize.
+	// data) still returns. Review transitions are not edits and are skipped.
+	// The 'public.scores' literal matches the value recordEvent writes from
+	// scores.Save; see follow-up to normalize.
 	sqlb = sqlb.
 		JoinClause(`LEFT JOIN LATERAL (
 			SELECT createdat, userid
 			FROM events
 			WHERE resource = 'public.scores'
 			  AND (payload->>'scoreid')::int = scores.scoreid
+			  AND action <> ALL(?)
 			ORDER BY createdat DESC
 			LIMIT 1
-		) last_edit ON TRUE`).
+		) last_edit ON TRUE`, reviewEventActions).
 		LeftJoin("users last_edited_user ON last_edited_user.userid = last_edit.userid").
 		Columns(
 			"last_edit.createdat AS last_edited_at",
@@ -629,7 +681,7 @@ func FindScores(ctx context.Context, input FindScoresInput) ([]*Score, error) {
 
 	return query(ctx, sqlb, func(row pgx.CollectableRow) (*Score, error) {
 		score := Score{}
-		fields := []any{&score.ScoreID, &score.FismaSystemID, &score.DateCalculated, &score.Notes, &score.NotesIsAISummary, &score.FunctionOptionID, &score.DataCallID, &score.Status}
+		fields := []any{&score.ScoreID, &score.FismaSystemID, &score.DateCalculated, &score.Notes, &score.NotesIsAISummary, &score.FunctionOptionID, &score.DataCallID, &score.Status, &score.ReviewNote}
 		if input.contains("functionoption") {
 			score.FunctionOption = &FunctionOption{}
 			fields = append(fields, &score.FunctionOption.FunctionOptionID, &score.FunctionOption.FunctionID, &score.FunctionOption.Score, &score.FunctionOption.OptionName, &score.FunctionOption.Description)
@@ -705,6 +757,8 @@ type pillarScoreRow struct {
 	PillarID      int32   `db:"pillarid"`
 	Pillar        string  `db:"pillar"`
 	Score         float64 `db:"score"`
+	Weight        float64 `db:"weight"`
+	Weighted      bool    `db:"weighted"`
 	SystemScore   float64 `db:"system_score"`
 }
 
@@ -712,10 +766,11 @@ type pillarScoreRow struct {
 // matching the input filters. Both the system score and the per-pillar scores
 // are computed on the HHS-aligned 1.0-5.0 scale via the +1 shift aggregation
 // described in ztmf-misc#175. The system score is the simple AVG of pillar
-// scores; the divisor follows the actual pillar count rather than being
-// hardcoded, so adding or removing a pillar does not silently change the
-// math. Pillar scores are populated on the aggregate when IncludePillars is
-// true.
+// scores, or their weighted average where the data call sets pillar weights;
+// Weighting on each aggregate says which. Either way the divisor follows the
+// pillars actually present rather than being hardcoded, so adding or removing
+// a pillar does not silently change the math. Pillar scores are populated on
+// the aggregate when IncludePillars is true.
 func FindScoresAggregate(ctx context.Context, input FindScoresInput) ([]*ScoreAggregate, error) {
 	// Convert single FismaSystemID to FismaSystemIDs array if needed so the
 	// scoping rules below see a consistent shape. This mirrors prior behavior
@@ -768,6 +823,10 @@ func aggregatePillarRows(rows []*pillarScoreRow, includePillars bool) []*ScoreAg
 				FismaSystemID: r.FismaSystemID,
 				SystemScore:   r.SystemScore,
 				SystemTier:    Tier(r.SystemScore),
+				Weighting:     ScoreWeightingEqual,
+			}
+			if r.Weighted {
+				agg.Weighting = ScoreWeightingPillar
 			}
 			aggByKey[k] = agg
 			order = append(order, k)
@@ -778,6 +837,7 @@ func aggregatePillarRows(rows []*pillarScoreRow, includePillars bool) []*ScoreAg
 				Pillar:   r.Pillar,
 				Score:    r.Score,
 				Tier:     Tier(r.Score),
+				Weight:   r.Weight,
 			})
 		}
 	}
@@ -790,24 +850,130 @@ func aggregatePillarRows(rows []*pillarScoreRow, includePillars bool) []*ScoreAg
 }
 
 // findPillarScoresAll returns one row per (datacall, system, pillar) for every
-// combination matching the input filters. Pillar scores are computed by
-// enumerating every expected question for each (system, datacall, pillar)
-// triple, LEFT JOINing to existing answers, COALESCEing missing rows to 0,
-// applying the +1 shift, and averaging.
+// combination matching the input filters. The rows are read from
+// scoreaggregates (scoreaggregates.go), which holds what buildPillarScoresSQL
+// computes and is refreshed by the writes that move it, or computed live
+// while a write in scope has yet to be refreshed.
+func findPillarScoresAll(ctx context.Context, input FindScoresInput) ([]*pillarScoreRow, error) {
+	dirty, err := scoreAggregatesDirty(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	sql, args := pillarRowsSQL(input, dirty)
+	return query(ctx, rawQuery{sql: sql, args: args}, pgx.RowToAddrOfStructByName[pillarScoreRow])
+}
+
+// buildPillarScoresSQL assembles the parameterized SQL for the pillar
+// aggregation. Pillar scores are computed by enumerating every expected
+// question for each (system, datacall, pillar) triple, LEFT JOINing to
+// existing answers, COALESCEing missing rows to 0, applying the +1 shift, and
+// averaging. It is the live query scoreaggregates materializes.
 //
 // The query is built as parameterized raw SQL because the derived subqueries
 // and conditional joins exceed what squirrel expresses cleanly. Filters are
 // pushed into the `expected` subquery so the LEFT JOIN only operates on the
 // scoped set.
-func findPillarScoresAll(ctx context.Context, input FindScoresInput) ([]*pillarScoreRow, error) {
-	sql, args := buildPillarScoresSQL(input)
-	return query(ctx, rawQuery{sql: sql, args: args}, pgx.RowToAddrOfStructByName[pillarScoreRow])
+//
+// Data calls frozen at close (datacallsnapshots.go) are read from their
+// snapshot under the same scope; only the rest are computed live.
+func buildPillarScoresSQL(input FindScoresInput) (string, []any) {
+	expectedCTE, args := buildExpectedFunctionsCTE(input)
+	conds, userJoin, _ := scoreScopeSQL(input)
+	return pillarScoresSQL(expectedCTE, storedAnswersCTE, frozenPillarScoresSQL(conds, userJoin)), args
 }
 
-// buildPillarScoresSQL assembles the parameterized SQL for the pillar
-// aggregation. Extracted so unit tests can verify the filter and scope
-// shaping without a database connection.
-func buildPillarScoresSQL(input FindScoresInput) (string, []any) {
+// storedAnswersCTE is the answers the pillar math scores: every saved answer,
+// by system, data call and function. A simulation substitutes its own.
+const storedAnswersCTE = `answers AS (
+    SELECT s.fismasystemid, s.datacallid, fo.functionid, fo.score
+    FROM scores s
+    INNER JOIN functionoptions fo ON fo.functionoptionid = s.functionoptionid
+)`
+
+// pillarScoresSQL is the pillar and system score query over an expected
+// CTE from buildExpectedFunctionsCTE and an answers CTE with the columns of
+// storedAnswersCTE. frozenSQL, when not empty, selects the same columns for
+// the frozen data calls: they are left out of the live computation and its
+// rows are taken instead.
+func pillarScoresSQL(expectedCTE, answersCTE, frozenSQL string) string {
+	// Both pillar score and system score are computed in Postgres so the
+	// float math is consistent. System score is AVG of pillar scores
+	// (equal weighting per the locked plan); the divisor follows the
+	// actual pillar count via the inner AVG, so adding or removing a
+	// pillar does not silently shift the math. system_score is carried on
+	// every pillar row via a window function so callers that want only
+	// the system roll-up read it from any row without a second query.
+	//
+	// A data call with rows in pillarweights (migration 0063) takes the
+	// weighted average instead, dividing by the weights of the pillars the
+	// system actually has. The equal branch stays the bare AVG rather than a
+	// weighted average with every weight 1, so a cycle without weights
+	// produces the same float it always did. weighted is carried on every
+	// row so the response can say which of the two it used.
+	var liveWhere string
+	if frozenSQL != "" {
+		liveWhere = "WHERE NOT EXISTS (SELECT 1 FROM datacallsnapshots ds WHERE ds.datacallid = e.datacallid)"
+	}
+
+	return fmt.Sprintf(`
+WITH %s,
+%s,
+pillar_scores AS (
+    SELECT
+        e.datacallid,
+        e.fismasystemid,
+        e.pillarid,
+        e.pillar,
+        AVG(COALESCE(a.score, 0) + 1.0)::float8 AS pillar_score
+    FROM expected e
+    LEFT JOIN answers a
+      ON a.fismasystemid = e.fismasystemid
+     AND a.datacallid    = e.datacallid
+     AND a.functionid    = e.functionid
+    %s
+    GROUP BY e.datacallid, e.fismasystemid, e.pillarid, e.pillar
+)
+SELECT
+    ps.datacallid,
+    ps.fismasystemid,
+    ps.pillarid,
+    ps.pillar,
+    ps.pillar_score AS score,
+    COALESCE(pw.weight, 1)::float8 AS weight,
+    wc.datacallid IS NOT NULL AS weighted,
+    CASE WHEN wc.datacallid IS NULL
+         THEN AVG(ps.pillar_score) OVER sys
+         ELSE SUM(ps.pillar_score * COALESCE(pw.weight, 1)::float8) OVER sys
+              / SUM(COALESCE(pw.weight, 1)::float8) OVER sys
+    END::float8 AS system_score
+FROM pillar_scores ps
+LEFT JOIN (SELECT DISTINCT datacallid FROM pillarweights) wc ON wc.datacallid = ps.datacallid
+LEFT JOIN pillarweights pw ON pw.datacallid = ps.datacallid AND pw.pillarid = ps.pillarid
+WINDOW sys AS (PARTITION BY ps.datacallid, ps.fismasystemid)
+%s
+ORDER BY datacallid, fismasystemid, pillarid
+`, expectedCTE, answersCTE, liveWhere, frozenSQL)
+}
+
+// frozenPillarScoresSQL selects the snapshot rows of the frozen data calls
+// under the scope conds and userJoin render (scoreScopeSQL), as the UNION ALL
+// branch of pillarScoresSQL.
+func frozenPillarScoresSQL(conds []string, userJoin string) string {
+	return fmt.Sprintf(`UNION ALL
+SELECT sn.datacallid, sn.fismasystemid, sn.pillarid, sn.pillar, sn.score, sn.weight, sn.weighted, sn.system_score
+FROM snapshotpillarscores sn
+INNER JOIN fismasystems fs ON fs.fismasystemid = sn.fismasystemid
+INNER JOIN datacalls dc    ON dc.datacallid    = sn.datacallid
+%s
+WHERE %s`, userJoin, strings.Join(conds, " AND "))
+}
+
+// scoreScopeSQL renders input's filters and scope as predicates over
+// fismasystems fs and datacalls dc, plus a users_fismasystems join for the
+// per-user scope. The placeholders bind from $1 in the order of the returned
+// args, so a query may render the scope more than once against one set of
+// args, as buildPillarScoresSQL does.
+func scoreScopeSQL(input FindScoresInput) ([]string, string, []any) {
 	var conds []string
 	var args []any
 	argN := 1
@@ -841,12 +1007,36 @@ func buildPillarScoresSQL(input FindScoresInput) (string, []any) {
 		conds = append(conds, fmt.Sprintf("fs.fismasystemid IN (%s)", strings.Join(placeholders, ",")))
 	}
 
+	if input.OpDivID != nil {
+		conds = append(conds, fmt.Sprintf("fs.opdiv_id = $%d", argN))
+		args = append(args, *input.OpDivID)
+		argN++
+	}
+
 	// OpDiv scope (fail-closed) on the expected CTE, which already joins
 	// fismasystems as fs.
 	input.AppendRawFilter(&conds, &args, &argN, func(n int) string {
 		return fmt.Sprintf("fs.opdiv_id = ANY($%d)", n)
 	})
 
+	var userJoin string
+	if input.UserID != nil {
+		userJoin = fmt.Sprintf("INNER JOIN users_fismasystems ufs ON ufs.fismasystemid = fs.fismasystemid AND ufs.userid = $%d", argN)
+		args = append(args, *input.UserID)
+		argN++
+	}
+
+	return conds, userJoin, args
+}
+
+// buildExpectedFunctionsCTE builds the scored_pairs and expected CTEs shared by
+// the pillar aggregation and the gap analysis: one row per (system, datacall,
+// pillar, function) the system is expected to answer, under the input's
+// filters and scope. The fragment is meant to follow WITH; the returned args
+// bind from $1.
+func buildExpectedFunctionsCTE(input FindScoresInput) (string, []any) {
+	conds, userJoin, args := scoreScopeSQL(input)
+
 	// Reduced pillar scope (ztmf#545): pillars a seeded rule excludes never
 	// enter the expected set, so a reduced system's pillar_scores rows simply
 	// don't exist for them and the system score's window AVG divides by the
@@ -856,13 +1046,6 @@ func buildPillarScoresSQL(input FindScoresInput) (string, []any) {
 	// pillar, so closed calls are untouched.
 	conds = append(conds, reducedPillarScopeSQL("dce.scoring_key", "p.pillar", "sp.datacallid"))
 
-	var userJoin string
-	if input.UserID != nil {
-		userJoin = fmt.Sprintf("INNER JOIN users_fismasystems ufs ON ufs.fismasystemid = fs.fismasystemid AND ufs.userid = $%d", argN)
-		args = append(args, *input.UserID)
-		argN++
-	}
-
 	// The (system, datacall) universe is the set of pairs that appear at
 	// least once in the scores table. This preserves the legacy aggregate
 	// contract: a system that was never scored for a given data call did
@@ -884,27 +1067,16 @@ func buildPillarScoresSQL(input FindScoresInput) (string, []any) {
 	// junction is empty for live data calls that have thousands of score
 	// rows. Using it as the universe filter returns nothing.
 	//
-	// Question catalog drift is intentionally not handled. Functions are
-	// keyed by datacenterenvironment, not by datacall. A retroactive
-	// recompute of a closed historical cycle uses the current question set
-	// for that environment, which matches the legacy AVG-over-scored-rows
-	// behavior (it only averaged whatever functionoptions were referenced
-	// in the scores table). If question versioning becomes a real
-	// requirement, both paths would need the same treatment.
-	//
-	// Both pillar score and system score are computed in Postgres so the
-	// float math is consistent. System score is AVG of pillar scores
-	// (equal weighting per the locked plan); the divisor follows the
-	// actual pillar count via the inner AVG, so adding or removing a
-	// pillar does not silently shift the math. system_score is carried on
-	// every pillar row via a window function so callers that want only
-	// the system roll-up read it from any row without a second query.
-	sql := fmt.Sprintf(`
-WITH scored_pairs AS (
+	// Question catalog drift is handled by the data call's pinned catalog
+	// version (migration 0062): the expected set is that version's functions
+	// for the system's environment, so adding a function or moving a
+	// question to another pillar today does not recompute a closed cycle.
+	// FindScoreProgress counts against the same pinned set.
+	cte := fmt.Sprintf(`scored_pairs AS (
     SELECT DISTINCT fismasystemid, datacallid FROM scores
 ),
 expected AS (
-    SELECT sp.fismasystemid, sp.datacallid, p.pillarid, p.pillar, f.functionid
+    SELECT sp.fismasystemid, sp.datacallid, p.pillarid, p.pillar, f.functionid, f.catalogversionid
     FROM scored_pairs sp
     INNER JOIN fismasystems fs ON fs.fismasystemid = sp.fismasystemid
     INNER JOIN datacalls dc    ON dc.datacallid    = sp.datacallid
@@ -914,364 +1086,19 @@ expected AS (
     -- DECOMMISSIONED marker), matches no functions and is excluded from scoring,
     -- exactly as an unrecognized value was under the old direct join.
     INNER JOIN datacenterenvironments dce ON dce.datacenterenvironment = fs.datacenterenvironment
-    INNER JOIN functions f ON f.datacenterenvironment = dce.scoring_key
-    INNER JOIN questions q ON q.questionid = f.questionid
+    -- The functions and questions are the catalog version the data call pins,
+    -- so a closed cycle scores against the questionnaire it was answered on.
+    INNER JOIN catalogfunctions f ON f.catalogversionid = dc.catalogversionid
+                                 AND f.datacenterenvironment = dce.scoring_key
+    INNER JOIN catalogquestions q ON q.catalogversionid = f.catalogversionid AND q.questionid = f.questionid
     INNER JOIN pillars p   ON p.pillarid   = q.pillarid
     %s
     WHERE %s
-),
-answers AS (
-    SELECT s.fismasystemid, s.datacallid, fo.functionid, fo.score
-    FROM scores s
-    INNER JOIN functionoptions fo ON fo.functionoptionid = s.functionoptionid
-),
-pillar_scores AS (
-    SELECT
-        e.datacallid,
-        e.fismasystemid,
-        e.pillarid,
-        e.pillar,
-        AVG(COALESCE(a.score, 0) + 1.0)::float8 AS pillar_score
-    FROM expected e
-    LEFT JOIN answers a
-      ON a.fismasystemid = e.fismasystemid
-     AND a.datacallid    = e.datacallid
-     AND a.functionid    = e.functionid
-    GROUP BY e.datacallid, e.fismasystemid, e.pillarid, e.pillar
-)
-SELECT
-    ps.datacallid,
-    ps.fismasystemid,
-    ps.pillarid,
-    ps.pillar,
-    ps.pillar_score AS score,
-    AVG(ps.pillar_score) OVER (PARTITION BY ps.datacallid, ps.fismasystemid)::float8 AS system_score
-FROM pillar_scores ps
-ORDER BY ps.datacallid, ps.fismasystemid, ps.pillarid
-`, userJoin, strings.Join(conds, " AND "))
-
-	return sql, args
-}
-
-// ---------------------------------------------------------------------------
-// TEMPORARY HARD-CODE - ztmf#500. DELETE THIS ENTIRE BLOCK after the FY2026
-// data call has been created, along with the call site in copyPreviousScores.
-//
-// Rule: CMS systems roll forward from CMS's own hand-entered cycle; every other
-// OpDiv rolls forward from the HHS import cycle. Replaces the single global
-// predecessor (findPreviousDataCall) for the FY2026 cycle only.
-//
-// EXCLUSION, not preference: a system's OpDiv selects exactly one source cycle
-// and the other is not read at all. The two cycles are separate lineages, and
-// where they overlap the CMS entry is the record. A system with no rows in its
-// assigned cycle therefore rolls forward EMPTY - see cms_stranded below.
-//
-// Sources resolve by NAME, not id: datacalls.datacall is UNIQUE (migration 0013)
-// and ids differ per environment. If either name is missing the override is inert
-// and the caller falls through to the global path unchanged.
-const (
-	rolloverHardcodeCMSSource   = "FY2025 Q3" // CMS's own hand-entered cycle
-	rolloverHardcodeOtherSource = "FY25 ZTM"  // HHS import
-	// Compared case-insensitively via UPPER() at every site. opdivs.code is
-	// editable through PUT /opdivs/{id} (OpDiv.Save updates it unconditionally,
-	// validate only trims) and migration 0026's uniqueness index is on
-	// LOWER(code), so renaming CMS to "cms" saves cleanly. An exact-case compare
-	// would then match no rows, drop every system to the ELSE branch, and source
-	// the whole estate from the HHS cycle - this PR's own bug, reproduced. Keep
-	// this constant uppercase.
-	rolloverHardcodeCMSOpDiv = "CMS" // opdivs.code seeded by migration 0026
-)
-
-// Gated to the FY2026 cycle on three independent conditions so it cannot leak
-// onto another data call before this block is deleted:
-//
-//  1. the target's name starts with one of these prefixes
-//  2. its deadline is later than both sources' - the ztmf#448 invariant, which
-//     this path must carry itself since it bypasses findPreviousDataCall
-//  3. it is the FIRST cycle matching these prefixes (see
-//     earlierRolloverHardcodeTargets) - without it a second same-year call
-//     re-sources from the 2025 cycles and discards everything FY2026 accrued
-//
-// BOTH year forms are accepted on purpose. FY2026 is the single unified cycle
-// covering all of HHS including CMS - merging the two 2025 lineages into one is
-// the entire point of this change, so there is no separate HHS import cycle to
-// keep out. The final name is not fixed yet and the two prior conventions
-// disagree ("FY2025 Q3" four-digit, "FY25 ZTM" two-digit), so excluding either
-// form risks the override silently declining on the real cycle - which ships the
-// P0 this exists to prevent. Gate 3 is what keeps the broad prefix safe: only the
-// first matching cycle is ever treated as the target.
-var rolloverHardcodeTargetPrefixes = []string{"FY2026", "FY26"}
-
-// earlierRolloverHardcodeTargets returns the names of FY26-prefixed data calls
-// that precede the target, ordered ahead of it by (deadline, datacallid) - the
-// same ordering findPreviousDataCall uses. A non-empty result means the target is
-// not the first FY26 cycle and the override must not apply.
-func earlierRolloverHardcodeTargets(ctx context.Context, conn *pgxpool.Conn, dataCallID int32, targetDeadline time.Time) ([]string, error) {
-	rows, err := conn.Query(ctx, `
-		SELECT datacall FROM datacalls
-		 WHERE datacallid <> $1
-		   AND (deadline < $2 OR (deadline = $2 AND datacallid < $1))`,
-		dataCallID, targetDeadline)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var earlier []string
-	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
-			return nil, err
-		}
-		if matchesRolloverHardcodeTarget(name) {
-			earlier = append(earlier, name)
-		}
-	}
-	return earlier, rows.Err()
-}
-
-func matchesRolloverHardcodeTarget(name string) bool {
-	upper := strings.ToUpper(strings.TrimSpace(name))
-	for _, prefix := range rolloverHardcodeTargetPrefixes {
-		if strings.HasPrefix(upper, prefix) {
-			return true
-		}
-	}
-	return false
-}
-
-// copyPreviousScoresOpDivHardcoded performs the OpDiv-keyed rollover. handled is
-// false when the override does not apply (missing source, target is a source, or
-// either gate rejects it), in which case the caller falls back to the global path.
-// Every decline logs a specific reason.
-func copyPreviousScoresOpDivHardcoded(ctx context.Context, dataCallID int32) (copied int64, handled bool, err error) {
-	conn, err := db.Conn(ctx)
-	if err != nil {
-		return 0, false, nil // fall through; the normal path reports the error
-	}
-	defer conn.Release()
-
-	var (
-		cmsSourceID, otherSourceID int32
-		latestSourceDeadline       time.Time
-		targetName                 string
-		targetDeadline             time.Time
-	)
-	// The id subqueries MUST stay ahead of the GREATEST: a missing source name
-	// yields NULL, which fails the scan into int32 and takes the inactive path.
-	// GREATEST alone would not catch it - it ignores NULL arguments.
-	err = conn.QueryRow(ctx, `
-		SELECT
-			(SELECT datacallid FROM datacalls WHERE datacall = $1),
-			(SELECT datacallid FROM datacalls WHERE datacall = $2),
-			GREATEST(
-				(SELECT deadline FROM datacalls WHERE datacall = $1),
-				(SELECT deadline FROM datacalls WHERE datacall = $2)),
-			(SELECT datacall FROM datacalls WHERE datacallid = $3),
-			(SELECT deadline FROM datacalls WHERE datacallid = $3)`,
-		rolloverHardcodeCMSSource, rolloverHardcodeOtherSource, dataCallID,
-	).Scan(&cmsSourceID, &otherSourceID, &latestSourceDeadline, &targetName, &targetDeadline)
-	if err != nil {
-		log.Printf("ROLLOVER_HARDCODE datacall=%d status=inactive reason=source_lookup err=%v", dataCallID, err)
-		return 0, false, nil
-	}
-	if dataCallID == cmsSourceID || dataCallID == otherSourceID {
-		log.Printf("ROLLOVER_HARDCODE datacall=%d status=inactive reason=target_is_a_source", dataCallID)
-		return 0, false, nil
-	}
-
-	if !matchesRolloverHardcodeTarget(targetName) {
-		log.Printf("ROLLOVER_HARDCODE datacall=%d status=inactive reason=target_name_not_fy2026 name=%q want_prefix=%v",
-			dataCallID, targetName, rolloverHardcodeTargetPrefixes)
-		return 0, false, nil
-	}
-
-	// ztmf#448: a call named FY26 but deadlined before either source is a backfill.
-	if !targetDeadline.After(latestSourceDeadline) {
-		log.Printf("ROLLOVER_HARDCODE datacall=%d status=inactive reason=target_deadline_not_after_sources name=%q target_deadline=%s latest_source_deadline=%s",
-			dataCallID, targetName, targetDeadline.Format(time.RFC3339), latestSourceDeadline.Format(time.RFC3339))
-		return 0, false, nil
-	}
-
-	// Gate 3: the target must be the FIRST FY26-prefixed cycle. Without this, a
-	// second same-year call (FY2026 Q4, a redo, a mid-year backfill) passes gates
-	// 1 and 2 and re-sources from the 2025 cycles, discarding everything the real
-	// FY26 cycle accumulated. This path REPLACES findPreviousDataCall, so it would
-	// not be missing the right answer - it would be overriding it. FY2025 Q3
-	// establishes quarter suffixes as the convention, so a second FY26 call is the
-	// likelier hazard, not FY2027.
-	//
-	// The candidate list is filtered in Go with the same matcher the name gate
-	// uses, so the two can never disagree. datacalls holds single-digit row counts.
-	earlier, err := earlierRolloverHardcodeTargets(ctx, conn, dataCallID, targetDeadline)
-	if err != nil {
-		log.Printf("ROLLOVER_HARDCODE datacall=%d status=inactive reason=predecessor_scan err=%v", dataCallID, err)
-		return 0, false, nil
-	}
-	if len(earlier) > 0 {
-		log.Printf("ROLLOVER_HARDCODE datacall=%d status=inactive reason=not_first_fy2026_cycle name=%q earlier=%v",
-			dataCallID, targetName, earlier)
-		return 0, false, nil
-	}
-
-	// The CASE in the WHERE clause IS the exclusion: a row survives only if its
-	// datacallid is the source assigned to that system's OpDiv.
-	//
-	// DISTINCT ON (fismasystemid, functionid) guarantees one answer per question -
-	// scores has no uniqueness constraint, so a cycle may hold duplicates.
-	//
-	// status is seeded 'not_started' as on the normal path (ztmf#435). Written
-	// as a SQL literal inside this const rather than as scoreStatusNotStarted:
-	// the statement is a compile-time constant string so it can be read whole,
-	// and interpolating would trade that for no behavioral gain.
-	//
-	// The ::int casts are REQUIRED, not decoration. Both CASE branches are bind
-	// parameters, so Postgres resolves the CASE to text and the comparison becomes
-	// integer = text, failing the copy at runtime with SQLSTATE 42883. Testing with
-	// literal ids, or PREPARE with declared types, will NOT catch this - both supply
-	// the type information pgx omits. Do not remove.
-	const copySQL = `
-		INSERT INTO scores (fismasystemid, datecalculated, notes, notes_is_ai_summary, functionoptionid, datacallid, status)
-		SELECT DISTINCT ON (s.fismasystemid, fo.functionid)
-		       s.fismasystemid, s.datecalculated, s.notes, s.notes_is_ai_summary,
-		       s.functionoptionid, $1, 'not_started'
-		  FROM scores s
-		  JOIN fismasystems fs     ON fs.fismasystemid    = s.fismasystemid
-		  JOIN functionoptions fo  ON fo.functionoptionid = s.functionoptionid
-		  JOIN opdivs o            ON o.opdiv_id          = fs.opdiv_id
-		 WHERE s.datacallid = CASE WHEN UPPER(o.code) = $4 THEN $2::int ELSE $3::int END
-		 ORDER BY s.fismasystemid, fo.functionid, s.scoreid DESC`
-
-	tag, err := conn.Exec(ctx, copySQL, dataCallID, cmsSourceID, otherSourceID, rolloverHardcodeCMSOpDiv)
-	if err != nil {
-		log.Printf("ROLLOVER_ANOMALY datacall=%d expected=? copied=0 err=%v", dataCallID, err)
-		return 0, true, err
-	}
-	copied = tag.RowsAffected()
-
-	// Diagnostics. Three of these are not self-explanatory:
-	//
-	//   selected_rows  rows AFTER the OpDiv exclusion, so selected_rows - copied is
-	//                  duplicate collapse and src_rows - selected_rows is what the
-	//                  exclusion discarded. Conflating the two would report excluded
-	//                  rows as deduplication.
-	//   misfiled_carried  restricted to systems that HAVE a scoring environment; one
-	//                  with an unmapped datacenterenvironment or a NULL scoring_key
-	//                  (DECOMMISSIONED, migration 0045) matches no function at all
-	//                  and would otherwise read as 100% mis-filed. Counted separately
-	//                  as unscored_env.
-	//   dropped_unresolvable  assigned rows the copy discards because
-	//                  functionoptionid resolves to nothing. The FK does NOT make
-	//                  this impossible: it reports convalidated = t but was created
-	//                  against an empty table, and bulk loads run under
-	//                  session_replication_role = replica, bypassing FK triggers.
-	//                  Real data contains such rows.
-	var fromCMS, fromOther, cmsSystems, otherSystems, cmsStranded, otherStranded, misfiled, unscoredEnv, srcRows, selectedRows, droppedUnresolvable int64
-	diagErr := conn.QueryRow(ctx, `
-		WITH assigned AS (
-			SELECT s.scoreid, s.fismasystemid, s.datacallid, fo.functionid, UPPER(o.code) AS opdiv_code
-			  FROM scores s
-			  JOIN fismasystems fs    ON fs.fismasystemid    = s.fismasystemid
-			  JOIN functionoptions fo ON fo.functionoptionid = s.functionoptionid
-			  JOIN opdivs o           ON o.opdiv_id          = fs.opdiv_id
-			 WHERE s.datacallid = CASE WHEN UPPER(o.code) = $3 THEN $1::int ELSE $2::int END
-		),
-		winners AS (
-			SELECT DISTINCT ON (fismasystemid, functionid) datacallid AS won_from
-			  FROM assigned
-			 ORDER BY fismasystemid, functionid, scoreid DESC
-		),
-		-- Answered in some cycle, landed nothing in the new call.
-		missing AS (
-			SELECT fs.fismasystemid, UPPER(o.code) AS opdiv_code
-			  FROM fismasystems fs
-			  JOIN opdivs o ON o.opdiv_id = fs.opdiv_id
-			 WHERE fs.fismasystemid IN (SELECT fismasystemid FROM scores WHERE datacallid IN ($1, $2))
-			   AND fs.fismasystemid NOT IN (SELECT fismasystemid FROM scores WHERE datacallid = $4)
-		)
-		SELECT
-			(SELECT COUNT(*) FROM winners WHERE won_from = $1),
-			(SELECT COUNT(*) FROM winners WHERE won_from = $2),
-			(SELECT COUNT(*) FROM fismasystems fsc
-			   JOIN opdivs oc ON oc.opdiv_id = fsc.opdiv_id
-			  WHERE UPPER(oc.code) = $3),
-			(SELECT COUNT(*) FROM fismasystems fso
-			   JOIN opdivs oo ON oo.opdiv_id = fso.opdiv_id
-			  WHERE UPPER(oo.code) IS DISTINCT FROM $3),
-			(SELECT COUNT(*) FROM missing WHERE opdiv_code = $3),
-			(SELECT COUNT(*) FROM missing WHERE opdiv_code IS DISTINCT FROM $3),
-			(SELECT COUNT(*)
-			   FROM scores s
-			   JOIN fismasystems fs2    ON fs2.fismasystemid    = s.fismasystemid
-			   JOIN functionoptions fo2 ON fo2.functionoptionid = s.functionoptionid
-			   LEFT JOIN datacenterenvironments dce ON dce.datacenterenvironment = fs2.datacenterenvironment
-			   LEFT JOIN functions f    ON f.functionid = fo2.functionid
-			                           AND f.datacenterenvironment = dce.scoring_key
-			  WHERE s.datacallid = $4
-			    AND dce.scoring_key IS NOT NULL
-			    AND f.functionid IS NULL),
-			(SELECT COUNT(*)
-			   FROM scores s
-			   JOIN fismasystems fs3 ON fs3.fismasystemid = s.fismasystemid
-			   LEFT JOIN datacenterenvironments dce2 ON dce2.datacenterenvironment = fs3.datacenterenvironment
-			  WHERE s.datacallid = $4
-			    AND dce2.scoring_key IS NULL),
-			(SELECT COUNT(*) FROM scores WHERE datacallid IN ($1, $2)),
-			(SELECT COUNT(*) FROM assigned),
-			(SELECT COUNT(*)
-			   FROM scores s
-			   JOIN fismasystems fs4 ON fs4.fismasystemid = s.fismasystemid
-			   JOIN opdivs o4        ON o4.opdiv_id       = fs4.opdiv_id
-			   LEFT JOIN functionoptions fo4 ON fo4.functionoptionid = s.functionoptionid
-			  WHERE s.datacallid = CASE WHEN UPPER(o4.code) = $3 THEN $1::int ELSE $2::int END
-			    AND fo4.functionoptionid IS NULL)`,
-		cmsSourceID, otherSourceID, rolloverHardcodeCMSOpDiv, dataCallID,
-	).Scan(&fromCMS, &fromOther, &cmsSystems, &otherSystems, &cmsStranded, &otherStranded, &misfiled, &unscoredEnv, &srcRows, &selectedRows, &droppedUnresolvable)
-	if diagErr != nil {
-		log.Printf("ROLLOVER_HARDCODE datacall=%d status=active copied=%d diagnostics_err=%v", dataCallID, copied, diagErr)
-		return copied, true, nil
-	}
-
-	log.Printf("ROLLOVER_HARDCODE datacall=%d status=active mode=exclusion cms_source=%d(%q) other_source=%d(%q) cms_systems=%d other_systems=%d src_rows=%d selected_rows=%d excluded_rows=%d copied=%d deduped=%d dropped_unresolvable=%d from_cms_source=%d from_other_source=%d cms_stranded=%d other_stranded=%d misfiled_carried=%d unscored_env=%d",
-		dataCallID, cmsSourceID, rolloverHardcodeCMSSource, otherSourceID, rolloverHardcodeOtherSource,
-		cmsSystems, otherSystems, srcRows, selectedRows, srcRows-selectedRows, copied, selectedRows-copied, droppedUnresolvable,
-		fromCMS, fromOther, cmsStranded, otherStranded, misfiled, unscoredEnv)
-
-	if droppedUnresolvable > 0 {
-		log.Printf("ROLLOVER_ANOMALY datacall=%d expected=%d copied=%d err=<none> reason=hardcode_unresolvable_rows dropped=%d",
-			dataCallID, selectedRows+droppedUnresolvable, copied, droppedUnresolvable)
-	}
+)`, userJoin, strings.Join(conds, " AND "))
 
-	// Zero-copy detection (ztmf#411). Against selected_rows, not src_rows: under
-	// exclusion a zero copy with nonzero src_rows is legitimate. Reuses the normal
-	// path's alarm token so it hits the existing CloudWatch metric.
-	if copied == 0 && selectedRows > 0 {
-		log.Printf("ROLLOVER_ANOMALY datacall=%d expected=%d copied=0 err=<none> reason=hardcode_empty_copy", dataCallID, selectedRows)
-	}
-
-	// These systems rolled forward EMPTY and the copy has no re-run path
-	// (ztmf#411). Investigate before anyone starts answering.
-	// cms_systems == 0 means the CMS OpDiv code did not match ANY system, so every
-	// system silently took the ELSE branch and sourced from the HHS cycle - this
-	// PR's own bug. None of the other alarms catch it: copied is nonzero because
-	// the other bucket copied fine. Promote it so it pages instead of sitting in
-	// an info line that a human has to read.
-	if cmsSystems == 0 {
-		log.Printf("ROLLOVER_ANOMALY datacall=%d expected=>0 copied=%d err=<none> reason=hardcode_no_cms_systems opdiv_code=%q",
-			dataCallID, copied, rolloverHardcodeCMSOpDiv)
-	}
-
-	if cmsStranded > 0 || otherStranded > 0 {
-		log.Printf("ROLLOVER_ANOMALY datacall=%d expected=>0 copied=%d err=<none> reason=hardcode_stranded_systems cms_stranded=%d other_stranded=%d",
-			dataCallID, copied, cmsStranded, otherStranded)
-	}
-
-	return copied, true, nil
+	return cte, args
 }
 
-// END TEMPORARY HARD-CODE - ztmf#500
-// ---------------------------------------------------------------------------
-
 // copyPreviousScores rolls the previous cycle's answers forward into the
 // newly created data call identified by dataCallID (the *latest* datacall; the
 // previous one is discovered via findPreviousDataCall). It returns the number
@@ -1282,12 +1109,22 @@ func copyPreviousScoresOpDivHardcoded(ctx context.Context, dataCallID int32) (co
 // ROLLOVER_ANOMALY log token (wired to a CloudWatch metric alarm) so an empty
 // cycle is detected rather than shipped silently. See ztmf#411.
 func copyPreviousScores(ctx context.Context, dataCallID int32) (int64, error) {
-	// TEMPORARY (ztmf#500): OpDiv-keyed rollover override for the FY26 cycle.
-	// Takes precedence over the global findPreviousDataCall path below, but only
-	// for the FY2026 call itself - every other data call, including a backfill or
-	// a future cycle, falls through to findPreviousDataCall unchanged. REMOVE
-	// after the FY2026 call is created.
-	if copied, handled, err := copyPreviousScoresOpDivHardcoded(ctx, dataCallID); handled {
+	// Held for the whole copy so a RepairRollover started meanwhile waits and
+	// then sees these rows as present, rather than writing them a second time.
+	unlock, err := lockRollover(ctx, dataCallID)
+	if err != nil {
+		log.Printf("ROLLOVER_ANOMALY datacall=%d expected=? copied=0 err=%v reason=lock", dataCallID, err)
+		return 0, err
+	}
+	defer unlock()
+
+	// A target with rollover rules (rolloverrules.go) takes each system's
+	// source from them; one without rules takes the single previous cycle
+	// below, unchanged.
+	if copied, handled, err := copyPreviousScoresByRules(ctx, dataCallID); handled {
+		if err == nil {
+			carryForwardRolloverAttachments(ctx, dataCallID)
+		}
 		return copied, err
 	}
 
@@ -1345,10 +1182,9 @@ func copyPreviousScores(ctx context.Context, dataCallID int32) (int64, error) {
 	// It stays a selected literal rather than becoming a bind parameter for two
 	// reasons. It keeps the emitted SQL byte-identical to what this statement
 	// has always produced; and an untyped $n in a SELECT list feeding an
-	// INSERT...SELECT walks into the same type-inference trap the sibling
-	// copySQL comment above documents at length for its ::int casts - Postgres
-	// resolves an unadorned placeholder to text and the column comparison
-	// fails at runtime.
+	// INSERT...SELECT walks into a type-inference trap (copyPreviousScoresByRules
+	// casts its $1 for the same reason) - Postgres resolves an unadorned
+	// placeholder to text and the column comparison fails at runtime.
 	prevScoresSqlb := squirrel.
 		Select("s.fismasystemid", "s.datecalculated", "s.notes", "s.notes_is_ai_summary", "s.functionoptionid", fmt.Sprintf("%d as latestdatacallid", dataCallID), fmt.Sprintf("'%s' as status", scoreStatusNotStarted)).
 		From("scores s").
@@ -1377,5 +1213,8 @@ func copyPreviousScores(ctx context.Context, dataCallID int32) (int64, error) {
 		log.Printf("ROLLOVER_ANOMALY datacall=%d expected=%d copied=%d err=<none>", dataCallID, expected, copied)
 	}
 
+	// Evidence follows the answers it was attached to, by reference.
+	carryForwardRolloverAttachments(ctx, dataCallID)
+
 	return copied, nil
 }
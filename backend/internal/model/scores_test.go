@@ -270,6 +270,18 @@ func TestFindScoresAggregate_ISSOwithSpecificSystem(t *testing.T) {
 	})
 }
 
+// TestBuildPillarScoresSQL_PinnedCatalog verifies the expected set is drawn
+// from the catalog version each data call pins, not the live tables, so a
+// closed cycle's aggregate does not move when the catalog is edited.
+func TestBuildPillarScoresSQL_PinnedCatalog(t *testing.T) {
+	sql, _ := buildPillarScoresSQL(normalizeInput(FindScoresInput{}))
+
+	assert.Contains(t, sql, "INNER JOIN catalogfunctions f ON f.catalogversionid = dc.catalogversionid")
+	assert.Contains(t, sql, "q.catalogversionid = f.catalogversionid")
+	assert.NotContains(t, sql, "JOIN functions f")
+	assert.NotContains(t, sql, "JOIN questions q")
+}
+
 // TestBuildPillarScoresSQL_OpDivScope verifies the OpDiv read-scope predicate
 // added for the OPDIV_ADMIN / OPDIV_READONLY_ADMIN tiers: a non-empty grant set
 // emits fs.opdiv_id = ANY($N) and binds the slice; a restricted-but-empty set
@@ -493,41 +505,31 @@ func TestDerefString(t *testing.T) {
 	})
 }
 
-// TestMatchesRolloverHardcodeTarget covers the name gate on the ztmf#500
-// rollover override. The gate is what scopes the hardcode to the FY2026 cycle
-// alone, so that deleting the block after FY26 is created is ordinary cleanup
-// rather than a deadline: until it is deleted, every non-FY26 data call must
-// fall through to the normal findPreviousDataCall path.
-//
-// Both year forms match: FY2026 is a single unified cycle for all of HHS
-// including CMS, its final name is not fixed, and the two prior conventions
-// disagree ("FY2025 Q3" vs "FY25 ZTM"). Declining the real cycle over a naming
-// guess is the costlier failure, so the first-cycle gate keeps the broad prefix
-// safe.
-//
-// DELETE THIS TEST with the override it covers.
-func TestMatchesRolloverHardcodeTarget(t *testing.T) {
-	for _, tc := range []struct {
-		name  string
-		want  bool
-		about string
-	}{
-		{"FY2026 Q1", true, "long-form convention, as FY2025 Q3 uses"},
-		{"FY26 ZTM", true, "two-digit form - the unified cycle may be named either way"},
-		{"FY2026", true, "bare year"},
-		{"fy2026 q1", true, "gate is case-insensitive - the operator types this"},
-		{"  FY2026 Q1  ", true, "surrounding whitespace is tolerated"},
-
-		{"FY2027 Q1", false, "the next cycle must NOT be hijacked if removal slips"},
-		{"FY27 ZTM", false, "next cycle, short form"},
-		{"FY2025 Q3", false, "a source cycle is never a target"},
-		{"FY25 ZTM", false, "a source cycle is never a target"},
-		{"FY2024 Backfill", false, "backfills roll forward normally (ztmf#448)"},
-		{"Audit Fields Smoke Cycle", false, "unrelated cycle"},
-		{"", false, "empty name"},
-	} {
-		t.Run(tc.name, func(t *testing.T) {
-			assert.Equal(t, tc.want, matchesRolloverHardcodeTarget(tc.name), tc.about)
-		})
+// TestBuildPillarScoresSQL_PillarWeights pins the two system score branches: a
+// data call without weights keeps the bare window AVG, so existing cycles
+// score exactly as before, and one with weights divides by the weights present.
+func TestBuildPillarScoresSQL_PillarWeights(t *testing.T) {
+	sql, _ := buildPillarScoresSQL(normalizeInput(FindScoresInput{}))
+
+	assert.Contains(t, sql, "THEN AVG(ps.pillar_score) OVER sys")
+	assert.Contains(t, sql, "/ SUM(COALESCE(pw.weight, 1)::float8) OVER sys")
+	assert.Contains(t, sql, "wc.datacallid IS NOT NULL AS weighted")
+	assert.Contains(t, sql, "WINDOW sys AS (PARTITION BY ps.datacallid, ps.fismasystemid)")
+}
+
+func TestAggregatePillarRows_Weighting(t *testing.T) {
+	rows := []*pillarScoreRow{
+		{DataCallID: 1, FismaSystemID: 1, PillarID: 1, Pillar: "Identity", Score: 4.0, Weight: 1, SystemScore: 3.0},
+		{DataCallID: 1, FismaSystemID: 1, PillarID: 2, Pillar: "Devices", Score: 2.0, Weight: 1, SystemScore: 3.0},
+		{DataCallID: 2, FismaSystemID: 1, PillarID: 1, Pillar: "Identity", Score: 4.0, Weight: 3, Weighted: true, SystemScore: 3.5},
+		{DataCallID: 2, FismaSystemID: 1, PillarID: 2, Pillar: "Devices", Score: 2.0, Weight: 1, Weighted: true, SystemScore: 3.5},
+	}
+
+	aggs := aggregatePillarRows(rows, true)
+	if assert.Len(t, aggs, 2) {
+		assert.Equal(t, ScoreWeightingEqual, aggs[0].Weighting)
+		assert.Equal(t, ScoreWeightingPillar, aggs[1].Weighting)
+		assert.Equal(t, 3.5, aggs[1].SystemScore, "the weighted score is read from SQL, not recomputed")
+		assert.Equal(t, 3.0, aggs[1].PillarScores[0].Weight)
 	}
 }
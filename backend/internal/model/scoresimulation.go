@@ -0,0 +1,200 @@
+package model
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ScoreSimulationInput is a what-if for one system in one data call: each
+// override stands in for the system's saved answer to the override option's
+// function, or answers it if the system has not.
+type ScoreSimulationInput struct {
+	FismaSystemID *int32           `json:"fismasystemid"`
+	DataCallID    *int32           `json:"datacallid"`
+	Overrides     []*ScoreOverride `json:"overrides"`
+	// UserID restricts the simulation to the requesting user's assigned
+	// systems (ISSO/ISSM tiers); set by the controller, never from the body.
+	UserID     *string `json:"-"`
+	OpDivScope `json:"-"`
+}
+
+// ScoreOverride is one hypothetical answer.
+type ScoreOverride struct {
+	FunctionOptionID int32 `json:"functionoptionid"`
+}
+
+// ScoreSimulation is the system's aggregate as saved and as it would be with
+// the overrides, both with pillar scores.
+type ScoreSimulation struct {
+	Current   *ScoreAggregate `json:"current"`
+	Projected *ScoreAggregate `json:"projected"`
+}
+
+func (i ScoreSimulationInput) validate() error {
+	err := InvalidInputError{data: map[string]any{}}
+
+	if i.FismaSystemID == nil {
+		err.data["fismasystemid"] = "required"
+	}
+	if i.DataCallID == nil {
+		err.data["datacallid"] = "required"
+	}
+
+	if len(err.data) > 0 {
+		return &err
+	}
+	return nil
+}
+
+// SimulateScores projects the scores a system would have with input's
+// overrides. The projection is the pillar query behind /scores/aggregate with
+// the overrides substituted into its answers, so reduced pillar scope, the
+// pinned catalog and pillar weights apply exactly as they would to saved
+// answers. Nothing is written: no score, no status, no event.
+//
+// The system must already have answers in the data call - the aggregate's
+// universe of scored pairs - and be in the caller's scope; otherwise the
+// result is ErrNoData.
+//
+// A data call frozen at close is refused: its current scores are the
+// snapshot, which a projection computed live could not be compared with.
+func SimulateScores(ctx context.Context, input ScoreSimulationInput) (*ScoreSimulation, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	frozen, err := isDataCallFrozen(ctx, *input.DataCallID)
+	if err != nil {
+		return nil, err
+	}
+	if frozen {
+		return nil, &InvalidInputError{data: map[string]any{
+			"datacallid": "the data call's scores were frozen when it closed; simulate against a call that is still open",
+		}}
+	}
+
+	includePillars := true
+	scoresInput := FindScoresInput{
+		FismaSystemID:  input.FismaSystemID,
+		FismaSystemIDs: []*int32{input.FismaSystemID},
+		DataCallID:     input.DataCallID,
+		IncludePillars: &includePillars,
+		UserID:         input.UserID,
+		OpDivScope:     input.OpDivScope,
+	}
+
+	expectedCTE, args := buildExpectedFunctionsCTE(scoresInput)
+	expected, err := query(ctx, rawQuery{
+		sql:  fmt.Sprintf("WITH %s\nSELECT DISTINCT functionid FROM expected", expectedCTE),
+		args: args,
+	}, pgx.RowTo[int32])
+	if err != nil {
+		return nil, err
+	}
+	if len(expected) == 0 {
+		return nil, ErrNoData
+	}
+
+	optionIDs := make([]int32, 0, len(input.Overrides))
+	for _, o := range input.Overrides {
+		optionIDs = append(optionIDs, o.FunctionOptionID)
+	}
+	options, err := query(ctx, stmntBuilder.
+		Select("functionoptionid", "functionid").
+		From("functionoptions").
+		Where("functionoptionid = ANY(?)", optionIDs), pgx.RowToAddrOfStructByName[simulatedOption])
+	if err != nil {
+		return nil, err
+	}
+	if err := validateScoreOverrides(input.Overrides, options, expected); err != nil {
+		return nil, err
+	}
+
+	// Current is computed live, as the projection is, rather than read from
+	// scoreaggregates, so the two differ by the overrides and nothing else.
+	sql, args := buildPillarScoresSQL(scoresInput)
+	currentRows, err := query(ctx, rawQuery{sql: sql, args: args}, pgx.RowToAddrOfStructByName[pillarScoreRow])
+	if err != nil {
+		return nil, err
+	}
+	current := aggregatePillarRows(currentRows, true)
+
+	sql, args = buildSimulatedPillarScoresSQL(scoresInput, optionIDs)
+	rows, err := query(ctx, rawQuery{sql: sql, args: args}, pgx.RowToAddrOfStructByName[pillarScoreRow])
+	if err != nil {
+		return nil, err
+	}
+	projected := aggregatePillarRows(rows, true)
+
+	if len(current) == 0 || len(projected) == 0 {
+		return nil, ErrNoData
+	}
+	return &ScoreSimulation{Current: current[0], Projected: projected[0]}, nil
+}
+
+// simulatedOption resolves an override's option to its function.
+type simulatedOption struct {
+	FunctionOptionID int32 `db:"functionoptionid"`
+	FunctionID       int32 `db:"functionid"`
+}
+
+// validateScoreOverrides requires every override to name an existing option
+// of a function the system answers in the data call, and no function to be
+// overridden twice. expected is the function ids from the expected CTE.
+func validateScoreOverrides(overrides []*ScoreOverride, options []*simulatedOption, expected []int32) error {
+	err := InvalidInputError{data: map[string]any{}}
+
+	functionOf := map[int32]int32{}
+	for _, o := range options {
+		functionOf[o.FunctionOptionID] = o.FunctionID
+	}
+	applicable := map[int32]bool{}
+	for _, id := range expected {
+		applicable[id] = true
+	}
+
+	seen := map[int32]bool{}
+	for _, o := range overrides {
+		key := fmt.Sprintf("functionoption %d", o.FunctionOptionID)
+		functionID, ok := functionOf[o.FunctionOptionID]
+		switch {
+		case !ok:
+			err.data[key] = "no such function option"
+		case !applicable[functionID]:
+			err.data[key] = "its function is not part of this system's questionnaire in this data call"
+		case seen[functionID]:
+			err.data[key] = fmt.Sprintf("function %d is overridden more than once", functionID)
+		}
+		seen[functionID] = true
+	}
+
+	if len(err.data) > 0 {
+		return &err
+	}
+	return nil
+}
+
+// buildSimulatedPillarScoresSQL is buildPillarScoresSQL with the overridden
+// functions' saved answers replaced by the override options. The overrides
+// are added once per scored pair in expected, which the caller limits to the
+// one system and data call.
+func buildSimulatedPillarScoresSQL(input FindScoresInput, optionIDs []int32) (string, []any) {
+	expectedCTE, args := buildExpectedFunctionsCTE(input)
+	args = append(args, optionIDs)
+
+	answersCTE := fmt.Sprintf(`answers AS (
+    SELECT s.fismasystemid, s.datacallid, fo.functionid, fo.score
+    FROM scores s
+    INNER JOIN functionoptions fo ON fo.functionoptionid = s.functionoptionid
+    WHERE fo.functionid NOT IN (SELECT functionid FROM functionoptions WHERE functionoptionid = ANY($%[1]d))
+    UNION ALL
+    SELECT pairs.fismasystemid, pairs.datacallid, fo.functionid, fo.score
+    FROM (SELECT DISTINCT fismasystemid, datacallid FROM expected) pairs
+    CROSS JOIN functionoptions fo
+    WHERE fo.functionoptionid = ANY($%[1]d)
+)`, len(args))
+
+	return pillarScoresSQL(expectedCTE, answersCTE, ""), args
+}
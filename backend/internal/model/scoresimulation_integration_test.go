@@ -0,0 +1,56 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSimulateScoresIntegration checks that a simulation with no overrides
+// projects exactly the saved scores, and that moving one answer to its
+// function's best option never lowers the projection - all without writing a
+// score or an event.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestSimulateScoresIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	var fismaSystemID, dataCallID, bestOptionID int32
+	require.NoError(t, conn.QueryRow(ctx, `
+SELECT s.fismasystemid, s.datacallid,
+       (SELECT best.functionoptionid FROM functionoptions best
+        WHERE best.functionid = fo.functionid ORDER BY best.score DESC LIMIT 1)
+FROM scores s
+INNER JOIN functionoptions fo ON fo.functionoptionid = s.functionoptionid
+LIMIT 1`).Scan(&fismaSystemID, &dataCallID, &bestOptionID))
+
+	var scoresBefore, eventsBefore int
+	require.NoError(t, conn.QueryRow(ctx, `SELECT (SELECT COUNT(*) FROM scores), (SELECT COUNT(*) FROM events)`).Scan(&scoresBefore, &eventsBefore))
+
+	input := ScoreSimulationInput{FismaSystemID: &fismaSystemID, DataCallID: &dataCallID}
+	same, err := SimulateScores(ctx, input)
+	require.NoError(t, err)
+	assert.InDelta(t, same.Current.SystemScore, same.Projected.SystemScore, 1e-9)
+	assert.Equal(t, same.Current.SystemTier, same.Projected.SystemTier)
+
+	input.Overrides = []*ScoreOverride{{FunctionOptionID: bestOptionID}}
+	better, err := SimulateScores(ctx, input)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, better.Projected.SystemScore, better.Current.SystemScore)
+
+	var scoresAfter, eventsAfter int
+	require.NoError(t, conn.QueryRow(ctx, `SELECT (SELECT COUNT(*) FROM scores), (SELECT COUNT(*) FROM events)`).Scan(&scoresAfter, &eventsAfter))
+	assert.Equal(t, scoresBefore, scoresAfter)
+	assert.Equal(t, eventsBefore, eventsAfter)
+}
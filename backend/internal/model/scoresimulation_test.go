@@ -0,0 +1,68 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreSimulationInput_Validate(t *testing.T) {
+	var invalid *InvalidInputError
+	require.ErrorAs(t, ScoreSimulationInput{}.validate(), &invalid)
+	assert.Contains(t, invalid.Data(), "fismasystemid")
+	assert.Contains(t, invalid.Data(), "datacallid")
+
+	assert.NoError(t, ScoreSimulationInput{FismaSystemID: int32Ptr(1001), DataCallID: int32Ptr(3)}.validate())
+}
+
+func TestValidateScoreOverrides(t *testing.T) {
+	options := []*simulatedOption{
+		{FunctionOptionID: 101, FunctionID: 10},
+		{FunctionOptionID: 102, FunctionID: 10},
+		{FunctionOptionID: 201, FunctionID: 20},
+		{FunctionOptionID: 901, FunctionID: 90},
+	}
+	expected := []int32{10, 20}
+
+	assert.NoError(t, validateScoreOverrides(nil, options, expected), "no overrides projects the saved answers")
+	assert.NoError(t, validateScoreOverrides([]*ScoreOverride{{101}, {201}}, options, expected))
+
+	err := validateScoreOverrides([]*ScoreOverride{{101}, {102}, {555}, {901}}, options, expected)
+	var invalid *InvalidInputError
+	require.ErrorAs(t, err, &invalid)
+	assert.NotContains(t, invalid.Data(), "functionoption 101")
+	assert.Contains(t, invalid.Data(), "functionoption 102", "second option for the same function")
+	assert.Contains(t, invalid.Data(), "functionoption 555", "unknown option")
+	assert.Contains(t, invalid.Data(), "functionoption 901", "function outside the system's questionnaire")
+}
+
+func TestBuildSimulatedPillarScoresSQL(t *testing.T) {
+	input := normalizeInput(FindScoresInput{FismaSystemID: int32Ptr(1001), DataCallID: int32Ptr(3)})
+	sql, args := buildSimulatedPillarScoresSQL(input, []int32{101, 201})
+
+	require.NotEmpty(t, args)
+	assert.Equal(t, []int32{101, 201}, args[len(args)-1], "the overrides bind last")
+	n := len(args)
+	assert.Contains(t, sql, fmt.Sprintf("WHERE fo.functionid NOT IN (SELECT functionid FROM functionoptions WHERE functionoptionid = ANY($%d))", n))
+	assert.Contains(t, sql, fmt.Sprintf("WHERE fo.functionoptionid = ANY($%d)", n))
+
+	// The rest of the query is the aggregate's: the same expected set,
+	// reduced pillar scope included, and the same pillar math.
+	expectedCTE, expectedArgs := buildExpectedFunctionsCTE(input)
+	assert.Equal(t, expectedArgs, args[:n-1])
+	assert.Contains(t, sql, expectedCTE)
+	assert.Contains(t, sql, reducedPillarScopeSQL("dce.scoring_key", "p.pillar", "sp.datacallid"))
+	assert.Equal(t, pillarScoresSQL(expectedCTE, "ANSWERS", ""), strings.Replace(sql, simulatedAnswers(t, sql), "ANSWERS", 1))
+}
+
+// simulatedAnswers cuts the answers CTE out of a simulated query.
+func simulatedAnswers(t *testing.T, sql string) string {
+	t.Helper()
+	start := strings.Index(sql, "answers AS (")
+	end := strings.Index(sql, ",\npillar_scores AS (")
+	require.True(t, start >= 0 && end > start)
+	return sql[start:end]
+}
@@ -0,0 +1,232 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// Directions reported on TierChange.
+const (
+	TierChangeUp   = "up"
+	TierChangeDown = "down"
+)
+
+type FindScoreTrendInput struct {
+	// Exactly one of FismaSystemID and OpDivID names the series.
+	FismaSystemID *int32 `schema:"fismasystemid"`
+	OpDivID       *int32 `schema:"opdiv_id"`
+	// FromDataCallID and ToDataCallID bound the range by deadline, inclusive;
+	// either end is open when omitted.
+	FromDataCallID *int32 `schema:"from"`
+	ToDataCallID   *int32 `schema:"to"`
+	// UserID restricts the trend to the requesting user's assigned systems
+	// (ISSO/ISSM tiers); set by the controller, schema:"-" so it is not
+	// bindable from the query.
+	UserID *string `schema:"-"`
+	OpDivScope
+}
+
+func (i FindScoreTrendInput) validate() error {
+	if (i.FismaSystemID == nil) == (i.OpDivID == nil) {
+		return &InvalidInputError{data: map[string]any{
+			"fismasystemid": "exactly one of fismasystemid or opdiv_id is required",
+		}}
+	}
+	return nil
+}
+
+// ScoreTrend is the score series of one system, or of one OpDiv's systems
+// averaged as the opdiv rollup averages them, with a point for every data call
+// in the range in deadline order.
+type ScoreTrend struct {
+	FismaSystemID *int32             `json:"fismasystemid"`
+	OpDivID       *int32             `json:"opdiv_id"`
+	Points        []*ScoreTrendPoint `json:"points"`
+}
+
+// ScoreTrendPoint is the series at one data call. Missing marks a cycle with
+// no scores for the subject; its score fields are nil and it has no pillars.
+// SystemCount is set on OpDiv series only.
+type ScoreTrendPoint struct {
+	DataCallID   int32               `json:"datacallid"`
+	DataCall     string              `json:"datacall"`
+	Deadline     time.Time           `json:"deadline"`
+	Missing      bool                `json:"missing"`
+	SystemCount  *int                `json:"system_count,omitempty"`
+	SystemScore  *float64            `json:"systemscore"`
+	SystemTier   *string             `json:"systemtier"`
+	Weighting    *string             `json:"weighting"`
+	TierChange   *TierChange         `json:"tier_change"`
+	PillarScores []*ScoreTrendPillar `json:"pillarscores"`
+}
+
+// ScoreTrendPillar is one pillar at one point, with its own tier change.
+type ScoreTrendPillar struct {
+	PillarScore
+	TierChange *TierChange `json:"tier_change"`
+}
+
+// TierChange records a tier that differs from the last scored cycle before
+// it. FromDataCallID names that cycle, which is not the previous point when
+// missing cycles lie between.
+type TierChange struct {
+	FromDataCallID int32  `json:"from_datacallid"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+	Direction      string `json:"direction"`
+}
+
+// scoreTrendSample is a subject's scores in one data call, from either an
+// aggregate or a rollup.
+type scoreTrendSample struct {
+	SystemScore  float64
+	Weighting    string
+	SystemCount  *int
+	PillarScores []*PillarScore
+}
+
+// FindScoreTrend returns the score series for one system or one OpDiv. Each
+// point is scored by the query behind /scores/aggregate (or its opdiv rollup),
+// under the same scope, so a point never disagrees with the aggregate for its
+// data call. Draft calls have no published scores and are not points.
+func FindScoreTrend(ctx context.Context, input FindScoreTrendInput) (*ScoreTrend, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	calls, err := findTrendDataCalls(ctx, input.FromDataCallID, input.ToDataCallID)
+	if err != nil {
+		return nil, err
+	}
+
+	scoresInput := FindScoresInput{UserID: input.UserID, OpDivScope: input.OpDivScope}
+	samples := map[int32]*scoreTrendSample{}
+
+	if input.FismaSystemID != nil {
+		scoresInput.FismaSystemID = input.FismaSystemID
+		scoresInput.FismaSystemIDs = []*int32{input.FismaSystemID}
+		includePillars := true
+		scoresInput.IncludePillars = &includePillars
+		aggregates, err := FindScoresAggregate(ctx, scoresInput)
+		if err != nil {
+			return nil, err
+		}
+		for _, agg := range aggregates {
+			samples[agg.DataCallID] = &scoreTrendSample{
+				SystemScore:  agg.SystemScore,
+				Weighting:    agg.Weighting,
+				PillarScores: agg.PillarScores,
+			}
+		}
+	} else {
+		groupBy := ScoreGroupOpDiv
+		scoresInput.GroupBy = &groupBy
+		scoresInput.OpDivID = input.OpDivID
+		rollups, err := FindScoresRollup(ctx, scoresInput)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rollups {
+			pillars := make([]*PillarScore, 0, len(r.PillarScores))
+			for _, p := range r.PillarScores {
+				pillars = append(pillars, &p.PillarScore)
+			}
+			samples[r.DataCallID] = &scoreTrendSample{
+				SystemScore:  r.SystemScore,
+				Weighting:    r.Weighting,
+				SystemCount:  &r.SystemCount,
+				PillarScores: pillars,
+			}
+		}
+	}
+
+	return &ScoreTrend{
+		FismaSystemID: input.FismaSystemID,
+		OpDivID:       input.OpDivID,
+		Points:        buildScoreTrendPoints(calls, samples),
+	}, nil
+}
+
+// findTrendDataCalls lists the non-draft data calls between from and to by
+// deadline, in the deadline-then-datacallid order findPreviousDataCall uses.
+func findTrendDataCalls(ctx context.Context, from, to *int32) ([]*DataCall, error) {
+	sqlb := stmntBuilder.
+		Select(dataCallColumns...).
+		From("datacalls").
+		Where(squirrel.NotEq{"status": DataCallDraft}).
+		OrderBy("deadline", "datacallid")
+
+	if from != nil {
+		sqlb = sqlb.Where("deadline >= (SELECT deadline FROM datacalls WHERE datacallid=?)", *from)
+	}
+	if to != nil {
+		sqlb = sqlb.Where("deadline <= (SELECT deadline FROM datacalls WHERE datacallid=?)", *to)
+	}
+
+	return query(ctx, sqlb, pgx.RowToAddrOfStructByName[DataCall])
+}
+
+// buildScoreTrendPoints lays the samples out over calls, marking the calls
+// with no sample as missing and annotating tier changes against the last
+// scored point. A pillar's change is against the last point that scored that
+// pillar, since reduced pillar scope can leave a pillar out of some cycles.
+func buildScoreTrendPoints(calls []*DataCall, samples map[int32]*scoreTrendSample) []*ScoreTrendPoint {
+	type scoredTier struct {
+		dataCallID int32
+		tier       string
+	}
+	var lastSystem *scoredTier
+	lastPillar := map[int32]*scoredTier{}
+
+	points := make([]*ScoreTrendPoint, 0, len(calls))
+	for _, dc := range calls {
+		point := &ScoreTrendPoint{
+			DataCallID:   dc.DataCallID,
+			DataCall:     dc.DataCall,
+			Deadline:     dc.Deadline,
+			PillarScores: []*ScoreTrendPillar{},
+		}
+		points = append(points, point)
+
+		sample, ok := samples[dc.DataCallID]
+		if !ok {
+			point.Missing = true
+			continue
+		}
+
+		tier := Tier(sample.SystemScore)
+		point.SystemScore = &sample.SystemScore
+		point.SystemTier = &tier
+		point.Weighting = &sample.Weighting
+		point.SystemCount = sample.SystemCount
+		if lastSystem != nil {
+			point.TierChange = tierChange(lastSystem.dataCallID, lastSystem.tier, tier)
+		}
+		lastSystem = &scoredTier{dc.DataCallID, tier}
+
+		for _, ps := range sample.PillarScores {
+			pillar := &ScoreTrendPillar{PillarScore: *ps}
+			if prev, ok := lastPillar[ps.PillarID]; ok {
+				pillar.TierChange = tierChange(prev.dataCallID, prev.tier, ps.Tier)
+			}
+			lastPillar[ps.PillarID] = &scoredTier{dc.DataCallID, ps.Tier}
+			point.PillarScores = append(point.PillarScores, pillar)
+		}
+	}
+	return points
+}
+
+// tierChange returns nil when from and to are the same tier.
+func tierChange(fromDataCallID int32, from, to string) *TierChange {
+	if from == to {
+		return nil
+	}
+	direction := TierChangeUp
+	if tierStep(to) < tierStep(from) {
+		direction = TierChangeDown
+	}
+	return &TierChange{FromDataCallID: fromDataCallID, From: from, To: to, Direction: direction}
+}
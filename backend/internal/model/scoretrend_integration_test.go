@@ -0,0 +1,54 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CMS-Enterprise/ztmf/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFindScoreTrendIntegration checks a system's series against the
+// aggregate: every cycle the aggregate scores is a point with the same score,
+// and every other point in the range is marked missing.
+//
+// Requires DB_* env vars pointing at a seeded ZTMF database. Skipped under
+// `go test -short`.
+func TestFindScoreTrendIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test")
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "DB connection required for integration test; ensure DB_* env vars are set")
+	defer conn.Release()
+
+	var fismaSystemID int32
+	require.NoError(t, conn.QueryRow(ctx, `SELECT fismasystemid FROM scores LIMIT 1`).Scan(&fismaSystemID))
+
+	aggregates, err := FindScoresAggregate(ctx, FindScoresInput{FismaSystemID: &fismaSystemID})
+	require.NoError(t, err)
+	require.NotEmpty(t, aggregates)
+	byCall := map[int32]*ScoreAggregate{}
+	for _, agg := range aggregates {
+		byCall[agg.DataCallID] = agg
+	}
+
+	trend, err := FindScoreTrend(ctx, FindScoreTrendInput{FismaSystemID: &fismaSystemID})
+	require.NoError(t, err)
+	require.NotEmpty(t, trend.Points)
+
+	for i, p := range trend.Points {
+		if i > 0 {
+			assert.False(t, p.Deadline.Before(trend.Points[i-1].Deadline), "points are in deadline order")
+		}
+		agg, scored := byCall[p.DataCallID]
+		assert.Equal(t, !scored, p.Missing, "datacall %d", p.DataCallID)
+		if scored {
+			require.NotNil(t, p.SystemScore)
+			assert.InDelta(t, agg.SystemScore, *p.SystemScore, 1e-9)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindScoreTrendInput_Validate(t *testing.T) {
+	id := int32(1)
+	assert.Error(t, FindScoreTrendInput{}.validate(), "a series needs a subject")
+	assert.Error(t, FindScoreTrendInput{FismaSystemID: &id, OpDivID: &id}.validate(), "and only one")
+	assert.NoError(t, FindScoreTrendInput{FismaSystemID: &id}.validate())
+	assert.NoError(t, FindScoreTrendInput{OpDivID: &id}.validate())
+}
+
+func TestBuildScoreTrendPoints(t *testing.T) {
+	deadline := time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC)
+	calls := []*DataCall{
+		{DataCallID: 1, DataCall: "FY23", Deadline: deadline.AddDate(-1, 0, 0)},
+		{DataCallID: 2, DataCall: "FY24", Deadline: deadline},
+		{DataCallID: 4, DataCall: "FY25", Deadline: deadline.AddDate(1, 0, 0)},
+		{DataCallID: 3, DataCall: "FY26", Deadline: deadline.AddDate(2, 0, 0)},
+	}
+	samples := map[int32]*scoreTrendSample{
+		1: {SystemScore: 2.50, Weighting: ScoreWeightingEqual, PillarScores: []*PillarScore{
+			{PillarID: 1, Pillar: "Identity", Score: 2.50, Tier: "Initial"},
+			{PillarID: 2, Pillar: "Devices", Score: 3.50, Tier: "Advanced"},
+		}},
+		4: {SystemScore: 3.20, Weighting: ScoreWeightingEqual, PillarScores: []*PillarScore{
+			{PillarID: 1, Pillar: "Identity", Score: 3.20, Tier: "Advanced"},
+			{PillarID: 2, Pillar: "Devices", Score: 3.20, Tier: "Advanced"},
+		}},
+		3: {SystemScore: 3.00, Weighting: ScoreWeightingEqual, PillarScores: []*PillarScore{
+			{PillarID: 2, Pillar: "Devices", Score: 3.00, Tier: "Initial"},
+		}},
+	}
+
+	points := buildScoreTrendPoints(calls, samples)
+	require.Len(t, points, 4)
+	for i, dc := range calls {
+		assert.Equal(t, dc.DataCallID, points[i].DataCallID, "points follow the calls' order")
+	}
+
+	assert.Nil(t, points[0].TierChange, "the first scored point has nothing to change from")
+	assert.Equal(t, "Initial", *points[0].SystemTier)
+
+	assert.True(t, points[1].Missing)
+	assert.Nil(t, points[1].SystemScore)
+	assert.NotNil(t, points[1].PillarScores)
+	assert.Empty(t, points[1].PillarScores)
+
+	require.NotNil(t, points[2].TierChange)
+	assert.Equal(t, TierChange{FromDataCallID: 1, From: "Initial", To: "Advanced", Direction: TierChangeUp}, *points[2].TierChange,
+		"a change across a missing cycle is against the last scored one")
+	require.Len(t, points[2].PillarScores, 2)
+	assert.NotNil(t, points[2].PillarScores[0].TierChange)
+	assert.Nil(t, points[2].PillarScores[1].TierChange, "Devices held Advanced")
+
+	require.NotNil(t, points[3].TierChange)
+	assert.Equal(t, TierChangeDown, points[3].TierChange.Direction)
+	require.Len(t, points[3].PillarScores, 1)
+	assert.Equal(t, int32(4), points[3].PillarScores[0].TierChange.FromDataCallID)
+}
@@ -608,11 +608,8 @@ func RestoreUser(ctx context.Context, userid string) (*User, error) {
 	}
 
 	if actor := UserFromContext(ctx); actor != nil {
-		if _, err := tx.Exec(ctx,
-			"INSERT INTO events (userid, action, resource, payload) VALUES ($1, $2, $3, $4)",
-			actor.UserID, eventActionUpdated, "users", restored,
-		); err != nil {
-			return nil, trapError(err)
+		if err := insertEventTx(ctx, tx, actor.UserID, eventActionUpdated, "users", restored); err != nil {
+			return nil, err
 		}
 	}
 
@@ -877,11 +874,8 @@ func AddSystemDelegate(ctx context.Context, sys *FismaSystem, opdiv *OpDiv, acto
 		{"users_opdivs", uo},
 		{"users_fismasystems", uf},
 	} {
-		if _, err = tx.Exec(ctx,
-			"INSERT INTO events (userid, action, resource, payload) VALUES ($1, $2, $3, $4)",
-			actorID, eventActionCreated, ev.resource, ev.payload,
-		); err != nil {
-			return nil, trapError(err)
+		if err = insertEventTx(ctx, tx, actorID, eventActionCreated, ev.resource, ev.payload); err != nil {
+			return nil, err
 		}
 	}
 
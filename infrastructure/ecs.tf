@@ -144,7 +144,7 @@ resource "aws_ecs_task_definition" "ztmf_api" {
           name  = "SMTP_CA_INT_SECRET_ID"
           value = local.smtp_intermediate_arn
         }
-      ], local.entra_api_env)
+      ], local.entra_api_env, local.events_archive_env)
       secrets = local.entra_api_secrets
       logConfiguration = {
         logDriver = "awslogs"
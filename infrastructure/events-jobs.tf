@@ -0,0 +1,382 @@
+# Scheduled jobs for the events audit log (backend cmd/eventsretention and
+# cmd/eventchain). Both ship in the API image and run as one-off Fargate tasks
+# from EventBridge, under their own task definition so the checkpoint signing
+# key is never handed to the API service.
+#
+# - eventsretention, daily: creates the monthly partitions ahead, archives
+#   expired months to the events archive bucket and drops them. The API also
+#   creates partitions ahead on its own daily ticker, so a missed run does not
+#   leave new events in events_default.
+# - eventchain -checkpoint, hourly: verifies the hash chain and publishes a
+#   signed checkpoint of its head to the same bucket. Gated by
+#   var.events_checkpoint_enabled until ztmf_events_checkpoint_signing_key
+#   holds a real key; ECS cannot start a task whose secret has no value.
+
+# Archived months (events/) and published checkpoints (checkpoints/). Versioned
+# so an overwritten or deleted object can still be recovered; the checkpoints
+# are only worth anything if someone able to rewrite the database cannot
+# quietly rewrite them too.
+resource "aws_s3_bucket" "ztmf_events_archive" {
+  bucket = "ztmf-events-archive-${var.environment}"
+
+  tags = {
+    Name        = "ZTMF Events Archive"
+    Environment = var.environment
+    Purpose     = "Archived audit events and signed chain checkpoints"
+  }
+}
+
+resource "aws_s3_bucket_public_access_block" "ztmf_events_archive" {
+  bucket = aws_s3_bucket.ztmf_events_archive.id
+
+  block_public_acls       = true
+  block_public_policy     = true
+  ignore_public_acls      = true
+  restrict_public_buckets = true
+}
+
+resource "aws_s3_bucket_versioning" "ztmf_events_archive" {
+  bucket = aws_s3_bucket.ztmf_events_archive.id
+  versioning_configuration {
+    status = "Enabled"
+  }
+}
+
+resource "aws_s3_bucket_server_side_encryption_configuration" "ztmf_events_archive" {
+  bucket = aws_s3_bucket.ztmf_events_archive.id
+
+  rule {
+    apply_server_side_encryption_by_default {
+      sse_algorithm = "AES256"
+    }
+  }
+}
+
+// Ed25519 seed, base64, that signs the chain checkpoints. Same operator-seed
+// bootstrap as the session signing key: terraform creates the empty secret,
+// the operator seeds it out of band, and the matching public key goes in
+// var.events_checkpoint_public_key before the schedule is enabled.
+resource "aws_secretsmanager_secret" "ztmf_events_checkpoint_signing_key" {
+  name = "ztmf_events_checkpoint_signing_key${local.underscore_sfx}"
+
+  lifecycle {
+    prevent_destroy = true
+  }
+}
+
+locals {
+  ztmf_events_jobs_name      = "ztmf-events-jobs${local.name_suffix}"
+  ztmf_events_jobs_log_group = "ztmf_events_jobs${local.underscore_sfx}"
+
+  // Read by the API (rehydrate, verify) and the jobs alike.
+  events_archive_env = concat([
+    { name = "EVENTS_ARCHIVE_BACKEND", value = "s3" },
+    { name = "EVENTS_ARCHIVE_S3_BUCKET", value = aws_s3_bucket.ztmf_events_archive.id },
+    ], var.events_checkpoint_public_key == "" ? [] : [
+    { name = "EVENTS_CHECKPOINT_PUBLIC_KEY", value = var.events_checkpoint_public_key },
+  ])
+}
+
+// The API rehydrates archived months and reads checkpoints; it never writes
+// the bucket.
+resource "aws_iam_role_policy" "ztmf_api_task_events_archive" {
+  name = "eventsArchiveRead"
+  role = module.api_task.role_id
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Action   = ["s3:GetObject"]
+        Effect   = "Allow"
+        Resource = ["${aws_s3_bucket.ztmf_events_archive.arn}/*"]
+      },
+      {
+        Action   = ["s3:ListBucket"]
+        Effect   = "Allow"
+        Resource = [aws_s3_bucket.ztmf_events_archive.arn]
+      },
+    ]
+  })
+}
+
+module "events_jobs_task_execution" {
+  name                = "ztmf_events_jobs_task_execution${local.underscore_sfx}"
+  source              = "./modules/role"
+  principal           = { Service = "ecs-tasks.amazonaws.com" }
+  managed_policy_arns = ["arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy"]
+}
+
+// ECS resolves the container's signing key secret with the execution role.
+resource "aws_iam_role_policy" "ztmf_events_jobs_task_execution" {
+  name = "eventsCheckpointSigningKey"
+  role = module.events_jobs_task_execution.role_id
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Action   = ["secretsmanager:GetSecretValue", "secretsmanager:DescribeSecret"]
+        Effect   = "Allow"
+        Resource = [aws_secretsmanager_secret.ztmf_events_checkpoint_signing_key.arn]
+      },
+    ]
+  })
+}
+
+module "events_jobs_task" {
+  name      = "ztmf_events_jobs_task${local.underscore_sfx}"
+  source    = "./modules/role"
+  principal = { Service = "ecs-tasks.amazonaws.com" }
+}
+
+resource "aws_iam_role_policy" "ztmf_events_jobs_task" {
+  name = "eventsJobsPermissions"
+  role = module.events_jobs_task.role_id
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Action   = ["secretsmanager:GetSecretValue", "secretsmanager:DescribeSecret"]
+        Effect   = "Allow"
+        Resource = [local.db_cred_secret]
+      },
+      {
+        Action   = ["s3:GetObject", "s3:PutObject"]
+        Effect   = "Allow"
+        Resource = ["${aws_s3_bucket.ztmf_events_archive.arn}/*"]
+      },
+      {
+        Action   = ["s3:ListBucket"]
+        Effect   = "Allow"
+        Resource = [aws_s3_bucket.ztmf_events_archive.arn]
+      },
+    ]
+  })
+}
+
+resource "aws_cloudwatch_log_group" "ztmf_events_jobs" {
+  name = local.ztmf_events_jobs_log_group
+  # Same reset as the API log group: the CMS retention lambda forces 731.
+  retention_in_days = 731
+}
+
+# The command is set per schedule by the EventBridge target's override; the
+# default here is the read-only verification, safe to run by hand.
+resource "aws_ecs_task_definition" "ztmf_events_jobs" {
+  execution_role_arn       = module.events_jobs_task_execution.role_arn
+  task_role_arn            = module.events_jobs_task.role_arn
+  family                   = "events-jobs${local.name_suffix}"
+  requires_compatibilities = ["FARGATE"]
+  network_mode             = "awsvpc"
+  cpu                      = 256
+  memory                   = 512
+  container_definitions = jsonencode([
+    {
+      name             = "ztmfeventsjobs"
+      command          = ["/usr/local/bin/ztmfeventchain"]
+      workingDirectory = "/api"
+      image            = "${local.ecr_api_repo_url}:${data.aws_ssm_parameter.ztmf_api_tag.insecure_value}"
+      essential        = true
+
+      environment = concat([
+        { name = "ENVIRONMENT", value = var.environment },
+        { name = "AWS_REGION", value = "us-east-1" },
+        { name = "DB_NAME", value = "ztmf" },
+        { name = "DB_ENDPOINT", value = aws_rds_cluster.ztmf.endpoint },
+        { name = "DB_PORT", value = "5432" },
+        { name = "DB_SECRET_ID", value = local.db_cred_secret },
+      ], local.events_archive_env)
+
+      secrets = var.events_checkpoint_enabled ? [
+        { name = "EVENTS_CHECKPOINT_SIGNING_KEY", valueFrom = aws_secretsmanager_secret.ztmf_events_checkpoint_signing_key.arn },
+      ] : []
+
+      logConfiguration = {
+        logDriver = "awslogs"
+        options = {
+          "awslogs-group"         = aws_cloudwatch_log_group.ztmf_events_jobs.name
+          "awslogs-region"        = "us-east-1"
+          "awslogs-stream-prefix" = "events"
+        }
+      }
+    }
+  ])
+}
+
+# EventBridge launches the tasks with this role.
+module "events_jobs_scheduler" {
+  name      = "ztmf_events_jobs_scheduler${local.underscore_sfx}"
+  source    = "./modules/role"
+  principal = { Service = "events.amazonaws.com" }
+}
+
+resource "aws_iam_role_policy" "ztmf_events_jobs_scheduler" {
+  name = "runEventsJobs"
+  role = module.events_jobs_scheduler.role_id
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Action   = ["ecs:RunTask"]
+        Effect   = "Allow"
+        Resource = [aws_ecs_task_definition.ztmf_events_jobs.arn_without_revision]
+        Condition = {
+          ArnEquals = { "ecs:cluster" = aws_ecs_cluster.ztmf.arn }
+        }
+      },
+      {
+        Action = ["iam:PassRole"]
+        Effect = "Allow"
+        Resource = [
+          module.events_jobs_task_execution.role_arn,
+          module.events_jobs_task.role_arn,
+        ]
+      },
+    ]
+  })
+}
+
+# Daily at 05:30 UTC, ahead of the Kion rotation and well clear of the
+# month boundary the partitions turn over at.
+resource "aws_cloudwatch_event_rule" "ztmf_events_retention_schedule" {
+  name                = "ztmf-events-retention-schedule-${var.environment}"
+  description         = "Daily run of ztmfeventsretention: create partitions ahead, archive expired months"
+  schedule_expression = "cron(30 5 * * ? *)"
+
+  tags = {
+    Name        = "ZTMF Events Retention Schedule"
+    Environment = var.environment
+  }
+}
+
+resource "aws_cloudwatch_event_target" "ztmf_events_retention_target" {
+  rule      = aws_cloudwatch_event_rule.ztmf_events_retention_schedule.name
+  target_id = "ZtmfEventsRetentionTask"
+  arn       = aws_ecs_cluster.ztmf.arn
+  role_arn  = module.events_jobs_scheduler.role_arn
+
+  ecs_target {
+    task_definition_arn = aws_ecs_task_definition.ztmf_events_jobs.arn_without_revision
+    launch_type         = "FARGATE"
+
+    network_configuration {
+      assign_public_ip = false
+      subnets          = data.aws_subnets.private.ids
+      security_groups  = [aws_security_group.ztmf_api_task.id]
+    }
+  }
+
+  input = jsonencode({
+    containerOverrides = [
+      { name = "ztmfeventsjobs", command = ["/usr/local/bin/ztmfeventsretention"] },
+    ]
+  })
+}
+
+# Hourly. A head that has not moved since the last checkpoint is not signed
+# again, so quiet hours publish nothing.
+resource "aws_cloudwatch_event_rule" "ztmf_events_checkpoint_schedule" {
+  name                = "ztmf-events-checkpoint-schedule-${var.environment}"
+  description         = "Hourly run of ztmfeventchain -checkpoint: verify the events chain and publish a signed checkpoint"
+  state               = var.events_checkpoint_enabled ? "ENABLED" : "DISABLED"
+  schedule_expression = "rate(1 hour)"
+
+  tags = {
+    Name        = "ZTMF Events Checkpoint Schedule"
+    Environment = var.environment
+  }
+}
+
+resource "aws_cloudwatch_event_target" "ztmf_events_checkpoint_target" {
+  rule      = aws_cloudwatch_event_rule.ztmf_events_checkpoint_schedule.name
+  target_id = "ZtmfEventsCheckpointTask"
+  arn       = aws_ecs_cluster.ztmf.arn
+  role_arn  = module.events_jobs_scheduler.role_arn
+
+  ecs_target {
+    task_definition_arn = aws_ecs_task_definition.ztmf_events_jobs.arn_without_revision
+    launch_type         = "FARGATE"
+
+    network_configuration {
+      assign_public_ip = false
+      subnets          = data.aws_subnets.private.ids
+      security_groups  = [aws_security_group.ztmf_api_task.id]
+    }
+  }
+
+  input = jsonencode({
+    containerOverrides = [
+      { name = "ztmfeventsjobs", command = ["/usr/local/bin/ztmfeventchain", "-checkpoint"] },
+    ]
+  })
+}
+
+# A retention run that failed a month, or a chain that no longer verifies.
+# Both commands exit 1 after logging these tokens.
+resource "aws_cloudwatch_log_metric_filter" "ztmf_events_jobs_failed" {
+  name           = "ztmf-events-jobs-failed-${var.environment}"
+  log_group_name = aws_cloudwatch_log_group.ztmf_events_jobs.name
+  pattern        = "?\"retention failed\" ?\"verified=false\""
+
+  metric_transformation {
+    name          = "EventsJobsFailed"
+    namespace     = "ZTMF/Events"
+    value         = "1"
+    default_value = "0"
+    unit          = "Count"
+  }
+}
+
+resource "aws_cloudwatch_metric_alarm" "ztmf_events_jobs_failed" {
+  alarm_name          = "ztmf-events-jobs-failed-${var.environment}"
+  comparison_operator = "GreaterThanThreshold"
+  evaluation_periods  = "1"
+  metric_name         = "EventsJobsFailed"
+  namespace           = "ZTMF/Events"
+  period              = "300"
+  statistic           = "Sum"
+  threshold           = "0"
+  treat_missing_data  = "notBreaching"
+  alarm_description   = "ZTMF events retention failed a month, or the events hash chain no longer verifies; see the ztmf_events_jobs log group"
+  alarm_actions       = [aws_sns_topic.ztmf_alarms.arn]
+  ok_actions          = [aws_sns_topic.ztmf_alarms.arn]
+
+  tags = {
+    Name        = "ZTMF Events Jobs Failed Alarm"
+    Environment = var.environment
+  }
+}
+
+# Every retention run ends with an EVENTS_RETENTION line; a day without one
+# means the schedule did not launch the task or it died before finishing.
+resource "aws_cloudwatch_log_metric_filter" "ztmf_events_retention_ran" {
+  name           = "ztmf-events-retention-ran-${var.environment}"
+  log_group_name = aws_cloudwatch_log_group.ztmf_events_jobs.name
+  pattern        = "EVENTS_RETENTION"
+
+  metric_transformation {
+    name      = "EventsRetentionRan"
+    namespace = "ZTMF/Events"
+    value     = "1"
+    unit      = "Count"
+  }
+}
+
+resource "aws_cloudwatch_metric_alarm" "ztmf_events_retention_missed" {
+  alarm_name          = "ztmf-events-retention-missed-${var.environment}"
+  comparison_operator = "LessThanThreshold"
+  evaluation_periods  = "1"
+  metric_name         = "EventsRetentionRan"
+  namespace           = "ZTMF/Events"
+  period              = "86400"
+  statistic           = "Sum"
+  threshold           = "1"
+  treat_missing_data  = "breaching"
+  alarm_description   = "ZTMF events retention has not completed a run in the last day"
+  alarm_actions       = [aws_sns_topic.ztmf_alarms.arn]
+  ok_actions          = [aws_sns_topic.ztmf_alarms.arn]
+
+  tags = {
+    Name        = "ZTMF Events Retention Missed Alarm"
+    Environment = var.environment
+  }
+}
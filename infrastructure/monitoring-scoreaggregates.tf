@@ -0,0 +1,42 @@
+# CloudWatch alerting for the materialized score aggregates (backend
+# scoreaggregates.go). A write that moves a score refreshes the aggregate rows
+# after it commits; a refresh that fails does not fail the write, so its only
+# runtime signal is the SCORE_AGGREGATES_STALE log token. The write's dirty
+# mark keeps reads on the live query until the API's sweep refreshes it, so
+# a sustained alarm means refreshes keep failing, not that reads are wrong.
+
+# Count of SCORE_AGGREGATES_STALE lines in the API log group.
+resource "aws_cloudwatch_log_metric_filter" "ztmf_api_score_aggregates_stale" {
+  name           = "ztmf-api-score-aggregates-stale-${var.environment}"
+  log_group_name = aws_cloudwatch_log_group.ztmf_api.name
+  pattern        = "SCORE_AGGREGATES_STALE"
+
+  metric_transformation {
+    name          = "ScoreAggregatesStale"
+    namespace     = "ZTMF/API"
+    value         = "1"
+    default_value = "0"
+    unit          = "Count"
+  }
+}
+
+# Alarm on any failed refresh, routed like the rollover alarm.
+resource "aws_cloudwatch_metric_alarm" "ztmf_api_score_aggregates_stale" {
+  alarm_name          = "ztmf-api-score-aggregates-stale-${var.environment}"
+  comparison_operator = "GreaterThanThreshold"
+  evaluation_periods  = "1"
+  metric_name         = "ScoreAggregatesStale"
+  namespace           = "ZTMF/API"
+  period              = "300"
+  statistic           = "Sum"
+  threshold           = "0"
+  treat_missing_data  = "notBreaching"
+  alarm_description   = "ZTMF score aggregate refresh failed (SCORE_AGGREGATES_STALE); run ztmfscoreaggregates -repair"
+  alarm_actions       = [aws_sns_topic.ztmf_alarms.arn]
+  ok_actions          = [aws_sns_topic.ztmf_alarms.arn]
+
+  tags = {
+    Name        = "ZTMF API Score Aggregates Stale Alarm"
+    Environment = var.environment
+  }
+}
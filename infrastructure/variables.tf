@@ -29,3 +29,15 @@ variable "kion_rotate_schedule_enabled" {
   type        = bool
   default     = true
 }
+
+variable "events_checkpoint_enabled" {
+  description = "Enable the hourly ztmfeventchain -checkpoint schedule and hand its task the checkpoint signing key. Defaults to false so ztmf_events_checkpoint_signing_key can be created and seeded first; ECS cannot start the task while the secret is empty. The daily retention schedule runs regardless."
+  type        = bool
+  default     = false
+}
+
+variable "events_checkpoint_public_key" {
+  description = "Base64 Ed25519 public key matching ztmf_events_checkpoint_signing_key, set as EVENTS_CHECKPOINT_PUBLIC_KEY on the API and the events jobs so checkpoint signatures are verified. Empty (default) leaves checkpoints matched on their hash only."
+  type        = string
+  default     = ""
+}